@@ -33,6 +33,9 @@ func (api *API) handleStartupProbe() http.HandlerFunc {
 	type response struct {
 		IsHTTPOk  bool `json:"isHttpOk"`
 		IsKafkaOk bool `json:"isKafkaOk"`
+		// BrokenSchemaSubjects is the number of schema subjects that failed to load at
+		// startup. They don't fail the probe; the subjects and their errors are logged.
+		BrokenSchemaSubjects int `json:"brokenSchemaSubjects,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -44,8 +47,9 @@ func (api *API) handleStartupProbe() http.HandlerFunc {
 		}
 
 		res := &response{
-			IsHTTPOk:  true,
-			IsKafkaOk: isKafkaOK,
+			IsHTTPOk:             true,
+			IsKafkaOk:            isKafkaOK,
+			BrokenSchemaSubjects: len(api.ConsoleSvc.BrokenSchemaSubjects()),
 		}
 
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
@@ -277,8 +277,7 @@ func (api *API) handlePutSchemaRegistrySubjectConfig() http.HandlerFunc {
 		// 2. Set subject compatibility level
 		res, err := api.ConsoleSvc.PutSchemaRegistrySubjectConfig(r.Context(), subjectName, req.Compatibility)
 		if err != nil {
-			var schemaError *schema.RestError
-			if errors.As(err, &schemaError) && schemaError.ErrorCode == schema.CodeSubjectNotFound {
+			if errors.Is(err, schema.ErrSubjectNotFound) {
 				rest.SendRESTError(w, r, api.Logger, &rest.Error{
 					Err:      err,
 					Status:   http.StatusNotFound,
@@ -328,8 +327,7 @@ func (api *API) handleDeleteSchemaRegistrySubjectConfig() http.HandlerFunc {
 		// 2. Set subject compatibility level
 		res, err := api.ConsoleSvc.DeleteSchemaRegistrySubjectConfig(r.Context(), subjectName)
 		if err != nil {
-			var schemaError *schema.RestError
-			if errors.As(err, &schemaError) && schemaError.ErrorCode == schema.CodeSubjectNotFound {
+			if errors.Is(err, schema.ErrSubjectNotFound) {
 				rest.SendRESTError(w, r, api.Logger, &rest.Error{
 					Err:      err,
 					Status:   http.StatusNotFound,
@@ -434,8 +432,7 @@ func (api *API) handleGetSchemaSubjectDetails() http.HandlerFunc {
 		// 3. Get all subjects' details
 		res, err := api.ConsoleSvc.GetSchemaRegistrySubjectDetails(r.Context(), subjectName, version)
 		if err != nil {
-			var schemaError *schema.RestError
-			if errors.As(err, &schemaError) && schemaError.ErrorCode == schema.CodeSubjectNotFound {
+			if errors.Is(err, schema.ErrSubjectNotFound) {
 				rest.SendRESTError(w, r, api.Logger, &rest.Error{
 					Err:      err,
 					Status:   http.StatusNotFound,
@@ -504,8 +501,7 @@ func (api *API) handleGetSchemaReferencedBy() http.HandlerFunc {
 		// 3. Get all subjects' details
 		res, err := api.ConsoleSvc.GetSchemaRegistrySchemaReferencedBy(r.Context(), subjectName, version)
 		if err != nil {
-			var schemaError *schema.RestError
-			if errors.As(err, &schemaError) && schemaError.ErrorCode == schema.CodeSubjectNotFound {
+			if errors.Is(err, schema.ErrSubjectNotFound) {
 				rest.SendRESTError(w, r, api.Logger, &rest.Error{
 					Err:      err,
 					Status:   http.StatusNotFound,
@@ -527,6 +523,61 @@ func (api *API) handleGetSchemaReferencedBy() http.HandlerFunc {
 	}
 }
 
+func (api *API) handleInvalidateSchemaRegistryCache() http.HandlerFunc {
+	if !api.Cfg.Kafka.Schema.Enabled {
+		return api.handleSchemaRegistryNotConfigured()
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		canManage, restErr := api.Hooks.Authorization.CanManageSchemaRegistry(r.Context())
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		if !canManage {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to invalidate the schema registry cache"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to invalidate the schema registry cache.",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// 1. Parse request parameters, purge all cached schemas if none is set
+		subject := rest.GetQueryParam(r, "subject")
+		schemaID := 0
+		if schemaIDStr := rest.GetQueryParam(r, "schemaId"); schemaIDStr != "" {
+			id, err := strconv.Atoi(schemaIDStr)
+			if err != nil || id <= 0 {
+				descriptiveErr := fmt.Errorf("schema id %q is not valid. Must be a positive integer", schemaIDStr)
+				rest.SendRESTError(w, r, api.Logger, &rest.Error{
+					Err:      descriptiveErr,
+					Status:   http.StatusBadRequest,
+					Message:  descriptiveErr.Error(),
+					IsSilent: false,
+				})
+				return
+			}
+			schemaID = id
+		}
+		if subject != "" && schemaID != 0 {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      fmt.Errorf("subject and schemaId must not be set both"),
+				Status:   http.StatusBadRequest,
+				Message:  "Either the 'subject' or the 'schemaId' query param may be set, but not both.",
+				IsSilent: false,
+			})
+			return
+		}
+
+		// 2. Purge cache
+		res := api.ConsoleSvc.InvalidateSchemaRegistryCache(r.Context(), subject, schemaID)
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
 func (api *API) handleDeleteSubject() http.HandlerFunc {
 	if !api.Cfg.Kafka.Schema.Enabled {
 		return api.handleSchemaRegistryNotConfigured()
@@ -570,8 +621,7 @@ func (api *API) handleDeleteSubject() http.HandlerFunc {
 		// 2. Send delete request
 		res, err := api.ConsoleSvc.DeleteSchemaRegistrySubject(r.Context(), subjectName, deletePermanently)
 		if err != nil {
-			var schemaError *schema.RestError
-			if errors.As(err, &schemaError) && schemaError.ErrorCode == schema.CodeSubjectNotFound {
+			if errors.Is(err, schema.ErrSubjectNotFound) {
 				rest.SendRESTError(w, r, api.Logger, &rest.Error{
 					Err:      err,
 					Status:   http.StatusNotFound,
@@ -656,8 +706,7 @@ func (api *API) handleDeleteSubjectVersion() http.HandlerFunc {
 		// 2. Send delete request
 		res, err := api.ConsoleSvc.DeleteSchemaRegistrySubjectVersion(r.Context(), subjectName, version, deletePermanently)
 		if err != nil {
-			var schemaError *schema.RestError
-			if errors.As(err, &schemaError) && schemaError.ErrorCode == schema.CodeSubjectNotFound {
+			if errors.Is(err, schema.ErrSubjectNotFound) {
 				rest.SendRESTError(w, r, api.Logger, &rest.Error{
 					Err:      err,
 					Status:   http.StatusNotFound,
@@ -666,7 +715,7 @@ func (api *API) handleDeleteSubjectVersion() http.HandlerFunc {
 				})
 				return
 			}
-			if errors.As(err, &schemaError) && schemaError.ErrorCode == schema.CodeVersionNotFound {
+			if errors.Is(err, schema.ErrVersionNotFound) {
 				rest.SendRESTError(w, r, api.Logger, &rest.Error{
 					Err:      err,
 					Status:   http.StatusNotFound,
@@ -718,6 +767,22 @@ func (api *API) handleCreateSchema() http.HandlerFunc {
 		// 1. Parse request parameters
 		subjectName := rest.GetURLParam(r, "subject")
 
+		// Schemas are normalized unless the caller wants them to be stored verbatim
+		normalizeStr := rest.GetQueryParam(r, "normalize")
+		if normalizeStr == "" {
+			normalizeStr = "true"
+		}
+		normalize, err := strconv.ParseBool(normalizeStr)
+		if err != nil {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      fmt.Errorf("failed to parse normalize query param %q: %w", normalizeStr, err),
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse 'normalize' query param with value %q: %v", normalizeStr, err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
 		var payload schema.Schema
 		restErr = rest.Decode(w, r, &payload)
 		if restErr != nil {
@@ -736,7 +801,7 @@ func (api *API) handleCreateSchema() http.HandlerFunc {
 		}
 
 		// 2. Send create request
-		res, err := api.ConsoleSvc.CreateSchemaRegistrySchema(r.Context(), subjectName, payload)
+		res, err := api.ConsoleSvc.CreateSchemaRegistrySchema(r.Context(), subjectName, payload, normalize)
 		if err != nil {
 			rest.SendRESTError(w, r, api.Logger, &rest.Error{
 				Err:          err,
@@ -0,0 +1,92 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudhut/common/rest"
+	"go.uber.org/zap"
+
+	"github.com/redpanda-data/console/backend/pkg/console"
+)
+
+const (
+	defaultEncodingSampleSize = 100
+	maxEncodingSampleSize     = 1000
+)
+
+// parseEncodingSampleSize parses the optional sampleSize query parameter of the encoding detection.
+func parseEncodingSampleSize(sampleSizeStr string) (int, error) {
+	if sampleSizeStr == "" {
+		return defaultEncodingSampleSize, nil
+	}
+	sampleSize, err := strconv.Atoi(sampleSizeStr)
+	if err != nil || sampleSize <= 0 || sampleSize > maxEncodingSampleSize {
+		return 0, fmt.Errorf("sampleSize must be an int between 1 and %d", maxEncodingSampleSize)
+	}
+	return sampleSize, nil
+}
+
+// handleDetectTopicEncodings samples the records of a topic and returns the most likely key and
+// value encodings, so that they can be preselected before listing messages.
+func (api *API) handleDetectTopicEncodings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := rest.GetURLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		sampleSize, err := parseEncodingSampleSize(rest.GetQueryParam(r, "sampleSize"))
+		if err != nil {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  err.Error(),
+				IsSilent: false,
+			})
+			return
+		}
+
+		// Sampling reads the topic's messages, so that the same permissions are required
+		canViewMessages, restErr := api.Hooks.Authorization.CanViewTopicMessages(r.Context(), &ListMessagesRequest{
+			TopicName:   topicName,
+			StartOffset: console.StartOffsetOldest,
+			PartitionID: -1,
+			MaxResults:  sampleSize,
+		})
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canViewMessages {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view messages in the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view messages in this topic",
+				IsSilent: false,
+			})
+			return
+		}
+
+		detection, err := api.ConsoleSvc.DetectTopicEncodings(r.Context(), topicName, sampleSize)
+		if err != nil {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not detect encodings of topic: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, detection)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEncodingSampleSize(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected int
+		err      bool
+	}{
+		{input: "", expected: defaultEncodingSampleSize},
+		{input: "20", expected: 20},
+		{input: "1000", expected: maxEncodingSampleSize},
+		{input: "0", err: true},
+		{input: "-5", err: true},
+		{input: "1001", err: true},
+		{input: "many", err: true},
+	} {
+		sampleSize, err := parseEncodingSampleSize(tc.input)
+		if tc.err {
+			assert.Error(t, err, "input %q", tc.input)
+			continue
+		}
+		assert.NoError(t, err, "input %q", tc.input)
+		assert.Equal(t, tc.expected, sampleSize, "input %q", tc.input)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/redpanda-data/console/backend/pkg/console"
+	"github.com/redpanda-data/console/backend/pkg/kafka"
 )
 
 // GetTopicMessagesResponse is a wrapper for an array of TopicMessage
@@ -28,6 +29,9 @@ type GetTopicMessagesResponse struct {
 	KafkaMessages *console.ListMessageResponse `json:"kafkaMessages"`
 }
 
+// maxMergeTopics is the max number of topics that can be merged with the requested topic.
+const maxMergeTopics = 10
+
 // ListMessagesRequest represents a search message request with all search parameter. This must be public as it's
 // used in Console Enterprise to implement the hooks.
 type ListMessagesRequest struct {
@@ -38,6 +42,22 @@ type ListMessagesRequest struct {
 	MaxResults            int    `json:"maxResults"`
 	FilterInterpreterCode string `json:"filterInterpreterCode"` // Base64 encoded code
 
+	// LatestPerKey only returns the most recent message of each key within the consumed
+	// offsets, which is useful for browsing compacted topics. It can't be used for live tail.
+	LatestPerKey bool `json:"latestPerKey"`
+
+	// MergeTopicNames are further topics whose messages are merged with the topic's messages
+	// into a single stream that is ordered by timestamp. All partitions are consumed. It can't
+	// be used for live tail.
+	MergeTopicNames []string `json:"mergeTopicNames,omitempty"`
+
+	// DeserializationOptions tweak how record keys, values and headers are deserialized.
+	DeserializationOptions kafka.DeserializationOptions `json:"deserializationOptions"`
+
+	// SupportsMessageChunks is set by clients that reassemble "messageChunk" messages. Large
+	// messages are only split into chunks for these clients.
+	SupportsMessageChunks bool `json:"supportsMessageChunks"`
+
 	// Enterprise may only be set in the Enterprise mode. The JSON deserialization is deferred
 	// to the enterprise backend.
 	Enterprise json.RawMessage `json:"enterprise,omitempty"`
@@ -61,10 +81,53 @@ func (l *ListMessagesRequest) OK() error {
 		return fmt.Errorf("max results must be between 1 and 500")
 	}
 
+	if l.LatestPerKey && l.StartOffset == console.StartOffsetNewest {
+		return fmt.Errorf("latest per key can't be used with the newest start offset")
+	}
+
+	if err := l.validateMergeTopicNames(); err != nil {
+		return err
+	}
+
 	if _, err := l.DecodeInterpreterCode(); err != nil {
 		return fmt.Errorf("failed to decode interpreter code %w", err)
 	}
 
+	if err := l.DeserializationOptions.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateMergeTopicNames checks that the merge topics can be merged with the topic.
+func (l *ListMessagesRequest) validateMergeTopicNames() error {
+	if len(l.MergeTopicNames) == 0 {
+		return nil
+	}
+	if len(l.MergeTopicNames) > maxMergeTopics {
+		return fmt.Errorf("at most %d topics can be merged", maxMergeTopics)
+	}
+	if l.StartOffset == console.StartOffsetNewest {
+		return fmt.Errorf("merging topics can't be used with the newest start offset")
+	}
+	if l.LatestPerKey {
+		return fmt.Errorf("merging topics can't be used with latest per key")
+	}
+	if l.PartitionID != -1 {
+		return fmt.Errorf("merging topics requires all partitions to be consumed")
+	}
+
+	topicNames := map[string]bool{l.TopicName: true}
+	for _, topicName := range l.MergeTopicNames {
+		if topicName == "" {
+			return fmt.Errorf("merge topic names must not be empty")
+		}
+		if topicNames[topicName] {
+			return fmt.Errorf("topic %q is merged more than once", topicName)
+		}
+		topicNames[topicName] = true
+	}
 	return nil
 }
 
@@ -144,6 +207,30 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 			sendError("You don't have permissions to view messages in this topic")
 			return
 		}
+		for _, topicName := range req.MergeTopicNames {
+			mergeReq := req
+			mergeReq.TopicName = topicName
+			canViewMessages, restErr := api.Hooks.Authorization.CanViewTopicMessages(ctx, &mergeReq)
+			if restErr != nil {
+				wsClient.writeJSON(restErr)
+				return
+			}
+			if !canViewMessages {
+				sendError(fmt.Sprintf("You don't have permissions to view messages in topic %q", topicName))
+				return
+			}
+			if len(req.FilterInterpreterCode) > 0 {
+				canUseMessageSearchFilters, restErr := api.Hooks.Authorization.CanUseMessageSearchFilters(ctx, &mergeReq)
+				if restErr != nil {
+					sendError(restErr.Message)
+					return
+				}
+				if !canUseMessageSearchFilters {
+					sendError(fmt.Sprintf("You don't have permissions to use message filters in topic %q", topicName))
+					return
+				}
+			}
+		}
 
 		if len(req.FilterInterpreterCode) > 0 {
 			canUseMessageSearchFilters, restErr := api.Hooks.Authorization.CanUseMessageSearchFilters(ctx, &req)
@@ -161,24 +248,33 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 
 		// Request messages from kafka and return them once we got all the messages or the context is done
 		listReq := console.ListMessageRequest{
-			TopicName:             req.TopicName,
-			PartitionID:           req.PartitionID,
-			StartOffset:           req.StartOffset,
-			StartTimestamp:        req.StartTimestamp,
-			MessageCount:          req.MaxResults,
-			FilterInterpreterCode: interpreterCode,
+			TopicName:              req.TopicName,
+			PartitionID:            req.PartitionID,
+			StartOffset:            req.StartOffset,
+			StartTimestamp:         req.StartTimestamp,
+			MessageCount:           req.MaxResults,
+			FilterInterpreterCode:  interpreterCode,
+			LatestPerKey:           req.LatestPerKey,
+			MergeTopicNames:        req.MergeTopicNames,
+			DeserializationOptions: req.DeserializationOptions,
 		}
 		api.Hooks.Authorization.PrintListMessagesAuditLog(r, &listReq)
 
-		// Use 30min duration if we want to search a whole topic or forward messages as they arrive
+		// Use 30min duration if we want to search or scan a whole topic or forward messages as they arrive
 		duration := 45 * time.Second
-		if listReq.FilterInterpreterCode != "" || listReq.StartOffset == console.StartOffsetNewest {
+		if listReq.FilterInterpreterCode != "" || listReq.LatestPerKey || listReq.StartOffset == console.StartOffsetNewest {
 			duration = 30 * time.Minute
 		}
 
 		childCtx, cancel := context.WithTimeout(ctx, duration)
 		defer cancel()
 
+		// Chunks are only sent to clients that can reassemble them
+		chunkSize := 0
+		if req.SupportsMessageChunks {
+			chunkSize = api.Cfg.Console.ListMessagesChunkSize
+		}
+
 		progress := &progressReporter{
 			ctx:              childCtx,
 			logger:           api.Logger,
@@ -187,6 +283,9 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 			statsMutex:       &sync.RWMutex{},
 			messagesConsumed: 0,
 			bytesConsumed:    0,
+			idleTimeout:      api.Cfg.Console.ListMessagesIdleTimeout,
+			chunkSize:        chunkSize,
+			cancel:           cancel,
 		}
 		progress.Start()
 
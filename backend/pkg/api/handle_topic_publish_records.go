@@ -10,11 +10,15 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/cloudhut/common/rest"
 	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/redpanda-data/console/backend/pkg/console"
+	"github.com/redpanda-data/console/backend/pkg/kafka"
 )
 
 type recordsRequest struct {
@@ -24,6 +28,52 @@ type recordsRequest struct {
 
 	// PartitionID into which the record(s) shall be produced to. May be -1 for auto partitioning.
 	PartitionID int32 `json:"partitionId"`
+
+	// KeySerialization and ValueSerialization serialize the key and value with the given
+	// encoding rather than producing them as is. The key or value then carries the JSON text
+	// (avro, cbor and json) or text that shall be serialized.
+	KeySerialization   *recordSerialization `json:"keySerialization,omitempty"`
+	ValueSerialization *recordSerialization `json:"valueSerialization,omitempty"`
+}
+
+// recordSerialization selects how a record key or value shall be serialized.
+type recordSerialization struct {
+	// Encoding is one of none, avro, cbor, json, text and binary.
+	Encoding string `json:"encoding"`
+
+	// SchemaID, or Subject and optionally Version, select the schema of Avro payloads.
+	SchemaID uint32 `json:"schemaId,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+	Version  string `json:"version,omitempty"`
+}
+
+// payloadInput returns the serializer input of a record key or value. Payloads without
+// serialization are serialized as binary, which retains them as is.
+func (s *recordSerialization) payloadInput(payload []byte) kafka.RecordPayloadInput {
+	if s == nil {
+		return kafka.RecordPayloadInput{Value: payload, Encoding: "binary"}
+	}
+	var opts []kafka.SerializeOption
+	if s.SchemaID != 0 {
+		opts = append(opts, kafka.WithSchemaID(s.SchemaID))
+	}
+	if s.Subject != "" {
+		opts = append(opts, kafka.WithSubjectVersion(s.Subject, s.Version))
+	}
+	return kafka.RecordPayloadInput{Value: payload, Encoding: s.Encoding, Options: opts}
+}
+
+// SerializeInput returns the serializer input of the record's key and value, and false if
+// neither the key nor the value shall be serialized.
+func (r *recordsRequest) SerializeInput(includeInfo bool) (kafka.SerializeInput, bool) {
+	if r.KeySerialization == nil && r.ValueSerialization == nil {
+		return kafka.SerializeInput{}, false
+	}
+	return kafka.SerializeInput{
+		Key:         r.KeySerialization.payloadInput(r.Key),
+		Value:       r.ValueSerialization.payloadInput(r.Value),
+		IncludeInfo: includeInfo,
+	}, true
 }
 
 // KgoRecordHeaders return the headers request as part of the to be produced Kafka record.
@@ -76,6 +126,10 @@ type publishRecordsRequest struct {
 
 	// Records contains one or more records (key, value, headers) that shall be produced.
 	Records []recordsRequest `json:"records"`
+
+	// IncludeSerializationInfo returns a troubleshooting entry for each serialized key and value
+	// that summarizes how it has been serialized, even if serializing succeeded.
+	IncludeSerializationInfo bool `json:"includeSerializationInfo"`
 }
 
 // OK validates the request struct and checks for any potential error prior that can be checked without
@@ -91,6 +145,35 @@ func (p *publishRecordsRequest) OK() error {
 	return nil
 }
 
+// SerializeRecords serializes the keys and values of all records that request it and replaces
+// them with the serialized payloads. The returned outputs are in the order of the records and
+// nil for records that are produced as is. If no record requests serialization, nil is returned.
+// Serializing stops at the first record that fails, whose partially populated output is
+// returned along with the error, so that the successful side and the failure are reported.
+func (p *publishRecordsRequest) SerializeRecords(
+	ctx context.Context,
+	serialize func(context.Context, kafka.SerializeInput) (*kafka.SerializeOutput, error),
+) ([]*kafka.SerializeOutput, error) {
+	var outputs []*kafka.SerializeOutput
+	for i := range p.Records {
+		input, ok := p.Records[i].SerializeInput(p.IncludeSerializationInfo)
+		if !ok {
+			continue
+		}
+		if outputs == nil {
+			outputs = make([]*kafka.SerializeOutput, len(p.Records))
+		}
+		out, err := serialize(ctx, input)
+		outputs[i] = out
+		if err != nil {
+			return outputs, fmt.Errorf("record at index %d: %w", i, err)
+		}
+		p.Records[i].Key = out.Key.Payload
+		p.Records[i].Value = out.Value.Payload
+	}
+	return outputs, nil
+}
+
 // KgoRecords returns all kgo.Record that shall be produced.
 func (p *publishRecordsRequest) KgoRecords() []*kgo.Record {
 	kgoRecords := make([]*kgo.Record, 0, len(p.Records)*len(p.TopicNames))
@@ -133,8 +216,19 @@ func (api *API) handlePublishTopicsRecords() http.HandlerFunc {
 			}
 		}
 
-		// 3. Submit publish topic records request
+		// 3. Serialize keys and values that shall not be produced as is
+		serialized, err := req.SerializeRecords(r.Context(), api.ConsoleSvc.SerializeRecord)
+		if err != nil {
+			rest.SendResponse(w, r, api.Logger, http.StatusOK, console.ProduceRecordsResponse{
+				Error:         fmt.Sprintf("Failed to serialize records: %v", err.Error()),
+				Serialization: serialized,
+			})
+			return
+		}
+
+		// 4. Submit publish topic records request
 		publishRes := api.ConsoleSvc.ProduceRecords(r.Context(), req.KgoRecords(), req.UseTransactions, req.CompressionType)
+		publishRes.Serialization = serialized
 
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, publishRes)
 	}
@@ -0,0 +1,73 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/console/backend/pkg/kafka"
+)
+
+func TestPublishRecordsRequest_SerializeRecords(t *testing.T) {
+	kafkaSvc := &kafka.Service{}
+
+	t.Run("records without serialization are produced as is", func(t *testing.T) {
+		req := publishRecordsRequest{Records: []recordsRequest{{Key: []byte("k"), Value: []byte("{ }")}}}
+		outputs, err := req.SerializeRecords(context.Background(), kafkaSvc.SerializeRecord)
+		require.NoError(t, err)
+		assert.Nil(t, outputs)
+		assert.Equal(t, []byte("{ }"), req.Records[0].Value)
+	})
+
+	t.Run("serialized records with info", func(t *testing.T) {
+		req := publishRecordsRequest{
+			Records: []recordsRequest{
+				{Key: []byte("raw"), Value: []byte("raw")},
+				{
+					Key:                []byte("o-1"),
+					Value:              []byte(`{ "id": "o-1" }`),
+					KeySerialization:   &recordSerialization{Encoding: "text"},
+					ValueSerialization: &recordSerialization{Encoding: "json"},
+				},
+			},
+			IncludeSerializationInfo: true,
+		}
+		outputs, err := req.SerializeRecords(context.Background(), kafkaSvc.SerializeRecord)
+		require.NoError(t, err)
+		require.Len(t, outputs, 2)
+		assert.Nil(t, outputs[0])
+
+		assert.Equal(t, []byte("o-1"), req.Records[1].Key)
+		assert.Equal(t, `{"id":"o-1"}`, string(req.Records[1].Value))
+		require.Len(t, outputs[1].Value.Troubleshooting, 1)
+		assert.Contains(t, outputs[1].Value.Troubleshooting[0].Message, "whitespaces have been removed")
+	})
+
+	t.Run("failed value is reported along with the key", func(t *testing.T) {
+		req := publishRecordsRequest{
+			Records: []recordsRequest{{
+				Key:                []byte("o-1"),
+				Value:              []byte(`{"id": `),
+				KeySerialization:   &recordSerialization{Encoding: "text"},
+				ValueSerialization: &recordSerialization{Encoding: "json"},
+			}},
+		}
+		outputs, err := req.SerializeRecords(context.Background(), kafkaSvc.SerializeRecord)
+		require.ErrorContains(t, err, "record at index 0: failed to serialize record value")
+		require.Len(t, outputs, 1)
+		assert.Equal(t, []byte("o-1"), outputs[0].Key.Payload)
+		require.Len(t, outputs[0].Value.Troubleshooting, 1)
+		assert.Contains(t, outputs[0].Value.Troubleshooting[0].Message, "invalid json")
+	})
+}
@@ -200,6 +200,7 @@ func (api *API) routes() *chi.Mux {
 				r.Patch("/topics/{topicName}/configuration", api.handleEditTopicConfig())
 				r.Get("/topics/{topicName}/consumers", api.handleGetTopicConsumers())
 				r.Get("/topics/{topicName}/documentation", api.handleGetTopicDocumentation())
+				r.Get("/topics/{topicName}/encodings", api.handleDetectTopicEncodings())
 
 				// Quotas
 				r.Get("/quotas", api.handleGetQuotas())
@@ -223,6 +224,7 @@ func (api *API) routes() *chi.Mux {
 				r.Put("/schema-registry/config", api.handlePutSchemaRegistryConfig())
 				r.Put("/schema-registry/config/{subject}", api.handlePutSchemaRegistrySubjectConfig())
 				r.Delete("/schema-registry/config/{subject}", api.handleDeleteSchemaRegistrySubjectConfig())
+				r.Delete("/schema-registry/cache", api.handleInvalidateSchemaRegistryCache())
 				r.Get("/schema-registry/subjects", api.handleGetSchemaSubjects())
 				r.Get("/schema-registry/schemas/types", api.handleGetSchemaRegistrySchemaTypes())
 				r.Get("/schema-registry/schemas/ids/{id}/versions", api.handleGetSchemaUsagesByID())
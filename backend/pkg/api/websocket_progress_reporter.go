@@ -11,9 +11,14 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 
 	"github.com/redpanda-data/console/backend/pkg/console"
@@ -30,9 +35,25 @@ type progressReporter struct {
 	statsMutex       *sync.RWMutex
 	messagesConsumed int64
 	bytesConsumed    int64
+
+	// idleTimeout is the max duration without any progress before the stream is cancelled
+	// using cancel. The watchdog is disabled if idleTimeout is zero.
+	idleTimeout        time.Duration
+	cancel             context.CancelFunc
+	lastProgressUnixNs atomic.Int64
+
+	// chunkSize is the max size of a message that is sent in one piece. Larger messages are
+	// sent as sequential chunks, followed by a final marker. Chunking is disabled if zero,
+	// which it must be unless the client has announced that it reassembles chunks.
+	chunkSize int
 }
 
 func (p *progressReporter) Start() {
+	p.touch()
+	if p.idleTimeout > 0 && p.request.StartOffset != console.StartOffsetNewest {
+		go p.watchIdleness()
+	}
+
 	// If search is disabled do not report progress regularly as each consumed message will be sent through the socket
 	// anyways
 	if p.request.FilterInterpreterCode == "" {
@@ -55,6 +76,45 @@ func (p *progressReporter) Start() {
 	}()
 }
 
+// touch records that the stream has made progress.
+func (p *progressReporter) touch() {
+	p.lastProgressUnixNs.Store(time.Now().UnixNano())
+}
+
+// minIdleCheckInterval is the lower bound of the interval at which the idle timeout is
+// checked, as tiny idle timeouts would otherwise result in a zero ticker interval.
+const minIdleCheckInterval = 10 * time.Millisecond
+
+// watchIdleness cancels the stream with an error if there hasn't been any progress within the
+// idle timeout, so that stalls (e.g. a hanging broker) are surfaced to the user.
+func (p *progressReporter) watchIdleness() {
+	checkInterval := p.idleTimeout / 10
+	if checkInterval < minIdleCheckInterval {
+		checkInterval = minIdleCheckInterval
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			idleFor := time.Since(time.Unix(0, p.lastProgressUnixNs.Load()))
+			if idleFor < p.idleTimeout {
+				continue
+			}
+			p.logger.Warn("cancelling stalled message stream",
+				zap.String("topic_name", p.request.TopicName),
+				zap.Duration("idle_timeout", p.idleTimeout))
+			p.OnError(fmt.Sprintf("No progress has been made for %v, the stream has been cancelled. "+
+				"The Kafka cluster may be unavailable or overloaded.", p.idleTimeout))
+			p.cancel()
+			return
+		}
+	}
+}
+
 func (p *progressReporter) reportProgress() {
 	p.statsMutex.RLock()
 	defer p.statsMutex.RUnlock()
@@ -67,6 +127,7 @@ func (p *progressReporter) reportProgress() {
 }
 
 func (p *progressReporter) OnPhase(name string) {
+	p.touch()
 	_ = p.websocket.writeJSON(struct {
 		Type  string `json:"type"`
 		Phase string `json:"phase"`
@@ -74,6 +135,7 @@ func (p *progressReporter) OnPhase(name string) {
 }
 
 func (p *progressReporter) OnMessageConsumed(size int64) {
+	p.touch()
 	p.statsMutex.Lock()
 	defer p.statsMutex.Unlock()
 
@@ -82,15 +144,86 @@ func (p *progressReporter) OnMessageConsumed(size int64) {
 }
 
 func (p *progressReporter) OnMessage(message *kafka.TopicMessage) {
-	err := p.websocket.writeJSON(struct {
-		Type    string              `json:"type"`
-		Message *kafka.TopicMessage `json:"message"`
-	}{"message", message})
+	p.touch()
+	serialized, err := json.Marshal(message)
+	if err != nil {
+		p.logger.Warn("failed to serialize message", zap.Error(err))
+		return
+	}
+	if p.chunkSize > 0 && len(serialized) > p.chunkSize {
+		p.sendMessageChunks(message, serialized)
+		return
+	}
+
+	// The message has been serialized already, so that it's embedded as is rather than
+	// serializing it once more along with its envelope
+	envelope := make([]byte, 0, len(serialized)+32)
+	envelope = append(envelope, `{"type":"message","message":`...)
+	envelope = append(envelope, serialized...)
+	envelope = append(envelope, '}')
+	err = p.websocket.writeMessage(websocket.TextMessage, envelope)
 	if err != nil {
 		p.logger.Warn("failed to write message to websocket connection", zap.Error(err))
 	}
 }
 
+// sendMessageChunks sends the serialized message in sequential chunks of at most chunkSize
+// bytes, followed by a final marker. The frontend concatenates the data of all chunks of the
+// same topic, partition and offset and parses the result like the message of a "message" type.
+// The topic name tells apart messages of merged topics that share a partition and offset.
+func (p *progressReporter) sendMessageChunks(message *kafka.TopicMessage, serialized []byte) {
+	chunks := splitMessageChunks(serialized, p.chunkSize)
+	for i, chunk := range chunks {
+		err := p.websocket.writeJSON(struct {
+			Type        string `json:"type"`
+			TopicName   string `json:"topicName,omitempty"`
+			PartitionID int32  `json:"partitionID"`
+			Offset      int64  `json:"offset"`
+			ChunkIndex  int    `json:"chunkIndex"`
+			Data        string `json:"data"`
+		}{"messageChunk", message.TopicName, message.PartitionID, message.Offset, i, string(chunk)})
+		if err != nil {
+			p.logger.Warn("failed to write message chunk to websocket connection", zap.Error(err))
+			return
+		}
+		p.touch()
+	}
+
+	err := p.websocket.writeJSON(struct {
+		Type        string `json:"type"`
+		TopicName   string `json:"topicName,omitempty"`
+		PartitionID int32  `json:"partitionID"`
+		Offset      int64  `json:"offset"`
+		TotalChunks int    `json:"totalChunks"`
+	}{"messageChunkEnd", message.TopicName, message.PartitionID, message.Offset, len(chunks)})
+	if err != nil {
+		p.logger.Warn("failed to write message chunk end to websocket connection", zap.Error(err))
+	}
+}
+
+// splitMessageChunks splits the serialized message into chunks of at most chunkSize bytes.
+// Chunks never end within a multibyte UTF-8 sequence, so that each chunk is a valid string.
+// A chunk exceeds chunkSize only if the chunk size is smaller than a single rune.
+func splitMessageChunks(serialized []byte, chunkSize int) [][]byte {
+	var chunks [][]byte
+	for len(serialized) > 0 {
+		end := chunkSize
+		if end >= len(serialized) {
+			end = len(serialized)
+		} else {
+			for end > 0 && !utf8.RuneStart(serialized[end]) {
+				end--
+			}
+			if end == 0 {
+				_, end = utf8.DecodeRune(serialized)
+			}
+		}
+		chunks = append(chunks, serialized[:end])
+		serialized = serialized[end:]
+	}
+	return chunks
+}
+
 func (p *progressReporter) OnComplete(elapsedMs int64, isCancelled bool) {
 	p.statsMutex.RLock()
 	defer p.statsMutex.RUnlock()
@@ -0,0 +1,190 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/redpanda-data/console/backend/pkg/console"
+	"github.com/redpanda-data/console/backend/pkg/kafka"
+)
+
+func TestProgressReporter_IdleTimeout(t *testing.T) {
+	tests := []struct {
+		name            string
+		idleTimeout     time.Duration
+		expectedMessage string
+	}{
+		{"regular timeout", 100 * time.Millisecond, "No progress has been made for 100ms"},
+		// A tenth of the timeout would be a zero ticker interval, which must not panic
+		{"tiny timeout", 5 * time.Nanosecond, "No progress has been made for 5ns"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamCancelled := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upgrader := websocket.Upgrader{}
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				progress := &progressReporter{
+					ctx:         ctx,
+					logger:      zap.NewNop(),
+					request:     &console.ListMessageRequest{TopicName: "orders", StartOffset: console.StartOffsetOldest},
+					websocket:   &websocketClient{Connection: conn, Mutex: &sync.RWMutex{}},
+					statsMutex:  &sync.RWMutex{},
+					idleTimeout: tt.idleTimeout,
+					cancel:      cancel,
+				}
+				progress.Start()
+
+				// Simulate a consumer that doesn't make any progress
+				<-ctx.Done()
+				if ctx.Err() == context.Canceled {
+					close(streamCancelled)
+				}
+			}))
+			defer srv.Close()
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			var msg struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			}
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+			require.NoError(t, conn.ReadJSON(&msg))
+			assert.Equal(t, "error", msg.Type)
+			assert.Contains(t, msg.Message, tt.expectedMessage)
+
+			select {
+			case <-streamCancelled:
+			case <-time.After(3 * time.Second):
+				t.Fatal("expected stalled stream to be cancelled")
+			}
+		})
+	}
+}
+
+func TestProgressReporter_MessageChunks(t *testing.T) {
+	// A large message with multibyte characters, so that chunk boundaries may fall into them
+	message := &kafka.TopicMessage{
+		TopicName:   "payments",
+		PartitionID: 2,
+		Offset:      42,
+		Headers: []kafka.MessageHeader{
+			{Key: strings.Repeat("größe-", 50)},
+			{Key: strings.Repeat("🚀", 30)},
+		},
+	}
+	expected, err := json.Marshal(message)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		progress := &progressReporter{
+			ctx:        context.Background(),
+			logger:     zap.NewNop(),
+			request:    &console.ListMessageRequest{TopicName: "orders"},
+			websocket:  &websocketClient{Connection: conn, Mutex: &sync.RWMutex{}},
+			statsMutex: &sync.RWMutex{},
+			chunkSize:  128,
+		}
+		progress.OnMessage(message)
+		progress.OnMessage(&kafka.TopicMessage{PartitionID: 2, Offset: 43})
+		progress.OnComplete(1, false)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+
+	var reconstructed strings.Builder
+	chunks := 0
+	for {
+		var msg struct {
+			Type        string `json:"type"`
+			TopicName   string `json:"topicName"`
+			PartitionID int32  `json:"partitionID"`
+			Offset      int64  `json:"offset"`
+			ChunkIndex  int    `json:"chunkIndex"`
+			Data        string `json:"data"`
+			TotalChunks int    `json:"totalChunks"`
+		}
+		require.NoError(t, conn.ReadJSON(&msg))
+		assert.Equal(t, "payments", msg.TopicName)
+		assert.Equal(t, int32(2), msg.PartitionID)
+		assert.Equal(t, int64(42), msg.Offset)
+		if msg.Type == "messageChunkEnd" {
+			assert.Equal(t, chunks, msg.TotalChunks)
+			break
+		}
+		require.Equal(t, "messageChunk", msg.Type)
+		assert.Equal(t, chunks, msg.ChunkIndex)
+		assert.LessOrEqual(t, len(msg.Data), 128)
+		reconstructed.WriteString(msg.Data)
+		chunks++
+	}
+	assert.Greater(t, chunks, 1)
+	assert.JSONEq(t, string(expected), reconstructed.String())
+
+	// Small messages are still sent in one piece
+	var msg struct {
+		Type    string              `json:"type"`
+		Message *kafka.TopicMessage `json:"message"`
+	}
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, "message", msg.Type)
+	assert.Equal(t, int64(43), msg.Message.Offset)
+}
+
+func TestSplitMessageChunks(t *testing.T) {
+	serialized := []byte(`"a🚀b"`)
+
+	chunks := splitMessageChunks(serialized, 2)
+	var reconstructed []byte
+	for _, chunk := range chunks {
+		assert.True(t, utf8.Valid(chunk), "chunk %q splits a rune", chunk)
+		reconstructed = append(reconstructed, chunk...)
+	}
+	assert.Equal(t, serialized, reconstructed)
+	// The rune is larger than the chunk size, so that it's sent in a chunk of its own
+	assert.Contains(t, chunks, []byte("🚀"))
+}
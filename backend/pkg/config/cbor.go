@@ -0,0 +1,46 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "fmt"
+
+// CBOR represents the CBOR config. Payloads that start with the self-described CBOR tag are
+// always decoded, untagged CBOR maps and arrays only if enabled for the topic, because
+// almost any short byte sequence that starts with such a head is valid CBOR.
+type CBOR struct {
+	Enabled bool `yaml:"enabled"`
+
+	// TopicNames is a list of topic names that shall be considered for untagged CBOR decoding.
+	// These names can be provided as regex string (e. g. "/.*/" or "/prefix-.*/") or as plain topic name
+	// such as "frontend-activities".
+	// This defaults to `/.*/`
+	TopicNames []string `yaml:"topicNames"`
+}
+
+// Validate if provided TopicNames are valid.
+func (c *CBOR) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	for _, topic := range c.TopicNames {
+		_, err := CompileRegex(topic)
+		if err != nil {
+			return fmt.Errorf("allowed topic string '%v' is not valid regex", topic)
+		}
+	}
+
+	return nil
+}
+
+// SetDefaults for the CBOR configuration.
+func (c *CBOR) SetDefaults() {
+	c.TopicNames = []string{"/.*/"}
+}
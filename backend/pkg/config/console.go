@@ -12,6 +12,7 @@ package config
 import (
 	"flag"
 	"fmt"
+	"time"
 )
 
 // Console contains all configuration options for features that are generic,
@@ -21,12 +22,25 @@ type Console struct {
 	// implementation that satisfies the Console interface.
 	Enabled            bool                      `yaml:"enabled"`
 	TopicDocumentation ConsoleTopicDocumentation `yaml:"topicDocumentation"`
+
+	// ListMessagesIdleTimeout is the max duration a message stream may go without any
+	// progress before it's considered stalled and cancelled with an error. Live tailing
+	// streams are exempt as they may legitimately wait for new messages. It must be at least
+	// 1s, zero disables it.
+	ListMessagesIdleTimeout time.Duration `yaml:"listMessagesIdleTimeout"`
+
+	// ListMessagesChunkSize is the max size in bytes of a single message that is sent to the
+	// frontend in one piece. Larger messages are streamed in sequential chunks, so that they
+	// don't stall the browser. Chunks are only sent to clients that announce support for
+	// them in the list messages request. Zero disables chunking.
+	ListMessagesChunkSize int `yaml:"listMessagesChunkSize"`
 }
 
 // SetDefaults for Console configs.
 func (c *Console) SetDefaults() {
 	c.Enabled = true
 	c.TopicDocumentation.SetDefaults()
+	c.ListMessagesIdleTimeout = time.Minute
 }
 
 // RegisterFlags for sensitive Console configurations.
@@ -36,6 +50,13 @@ func (c *Console) RegisterFlags(f *flag.FlagSet) {
 
 // Validate Console configurations.
 func (c *Console) Validate() error {
+	if c.ListMessagesIdleTimeout != 0 && c.ListMessagesIdleTimeout < time.Second {
+		return fmt.Errorf("list messages idle timeout must be at least 1s or 0 to disable it, but got %v", c.ListMessagesIdleTimeout)
+	}
+	if c.ListMessagesChunkSize < 0 {
+		return fmt.Errorf("list messages chunk size must not be negative")
+	}
+
 	err := c.TopicDocumentation.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate topic documentation config: %w", err)
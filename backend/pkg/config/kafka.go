@@ -25,6 +25,24 @@ type Kafka struct {
 	Schema      Schema  `yaml:"schemaRegistry"`
 	Protobuf    Proto   `yaml:"protobuf"`
 	MessagePack Msgpack `yaml:"messagePack"`
+	CBOR        CBOR    `yaml:"cbor"`
+
+	// TopicDecoders force decoders for matching topics rather than auto-detecting the
+	// encoding. It defaults to the internal topics with known encodings.
+	TopicDecoders []KafkaTopicDecoder `yaml:"topicDecoders"`
+
+	// Decoders configure which decoders are tried, and in which order, when the encoding of
+	// a record is auto-detected. It defaults to all decoders in their built-in order.
+	Decoders KafkaDecoderChain `yaml:"decoders"`
+
+	// CompositeKeys declare the layouts of record keys that are concatenations of multiple
+	// fixed-width fields, so that they are decoded field by field.
+	CompositeKeys []KafkaCompositeKey `yaml:"compositeKeys"`
+
+	// SerdeMetrics exposes the number of decode attempts and the time spent in each serde
+	// of the decoder chain as Prometheus metrics. It's disabled by default, as it's recorded
+	// for every record of every message stream.
+	SerdeMetrics bool `yaml:"serdeMetrics"`
 
 	TLS  KafkaTLS  `yaml:"tls"`
 	SASL KafkaSASL `yaml:"sasl"`
@@ -68,6 +86,27 @@ func (c *Kafka) Validate() error {
 		return fmt.Errorf("failed to validate msgpack config: %w", err)
 	}
 
+	err = c.CBOR.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate cbor config: %w", err)
+	}
+
+	for i, topicDecoder := range c.TopicDecoders {
+		if err := topicDecoder.Validate(); err != nil {
+			return fmt.Errorf("failed to validate topic decoder at index %d: %w", i, err)
+		}
+	}
+
+	if err := c.Decoders.Validate(); err != nil {
+		return fmt.Errorf("failed to validate decoders config: %w", err)
+	}
+
+	for i, compositeKey := range c.CompositeKeys {
+		if err := compositeKey.Validate(); err != nil {
+			return fmt.Errorf("failed to validate composite key at index %d: %w", i, err)
+		}
+	}
+
 	err = c.Startup.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate startup config: %w", err)
@@ -81,8 +120,11 @@ func (c *Kafka) SetDefaults() {
 	c.ClientID = "redpanda-console"
 
 	c.SASL.SetDefaults()
+	c.Schema.SetDefaults()
 	c.Protobuf.SetDefaults()
 	c.MessagePack.SetDefaults()
+	c.CBOR.SetDefaults()
+	c.TopicDecoders = defaultKafkaTopicDecoders()
 	c.Startup.SetDefaults()
 }
 
@@ -0,0 +1,102 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "fmt"
+
+// compositeKeyFieldWidths are the widths in bytes of the fixed-width composite key field types.
+var compositeKeyFieldWidths = map[string]int{
+	"int8":   1,
+	"int16":  2,
+	"int32":  4,
+	"int64":  8,
+	"uint8":  1,
+	"uint16": 2,
+	"uint32": 4,
+	"uint64": 8,
+	"uuid":   16,
+}
+
+// KafkaCompositeKey declares the layout of record keys that are built by concatenating
+// multiple fixed-width fields (e.g. an 8 byte tenant ID followed by an 8 byte entity ID),
+// so that such keys are decoded into an object with one property per field.
+type KafkaCompositeKey struct {
+	// TopicNames is a list of topic names whose keys use the layout. These names can be
+	// provided as regex string (e. g. "/orders-.*/") or as plain topic name.
+	TopicNames []string `yaml:"topicNames"`
+
+	// Fields in the order in which they are concatenated.
+	Fields []KafkaCompositeKeyField `yaml:"fields"`
+}
+
+// KafkaCompositeKeyField is a single field of a composite key.
+type KafkaCompositeKeyField struct {
+	Name string `yaml:"name"`
+
+	// Type is one of int8, int16, int32, int64, uint8, uint16, uint32, uint64 (all big
+	// endian), uuid, string (UTF-8 with trailing NUL padding removed) or bytes (hex).
+	Type string `yaml:"type"`
+
+	// Width is the size of the field in bytes. It's implied by the type for numbers and
+	// UUIDs. A width of zero for the last field of type string or bytes takes the remaining
+	// bytes of the key.
+	Width int `yaml:"width"`
+}
+
+// Validate the composite key layout.
+func (c *KafkaCompositeKey) Validate() error {
+	if len(c.TopicNames) == 0 {
+		return fmt.Errorf("at least one topic name must be set")
+	}
+	for _, topic := range c.TopicNames {
+		if _, err := CompileRegex(topic); err != nil {
+			return fmt.Errorf("topic string '%v' is not valid regex", topic)
+		}
+	}
+	if len(c.Fields) == 0 {
+		return fmt.Errorf("at least one field must be set")
+	}
+
+	names := make(map[string]bool, len(c.Fields))
+	for i, field := range c.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("name of field at index %d must be set", i)
+		}
+		if names[field.Name] {
+			return fmt.Errorf("field name %q is used more than once", field.Name)
+		}
+		names[field.Name] = true
+
+		if width, isFixed := compositeKeyFieldWidths[field.Type]; isFixed {
+			if field.Width != 0 && field.Width != width {
+				return fmt.Errorf("width of field %q must be %d for type %q", field.Name, width, field.Type)
+			}
+			continue
+		}
+		switch field.Type {
+		case "string", "bytes":
+			if field.Width < 0 || (field.Width == 0 && i != len(c.Fields)-1) {
+				return fmt.Errorf("width of field %q must be positive unless it's the last field", field.Name)
+			}
+		default:
+			return fmt.Errorf("unknown type %q of field %q", field.Type, field.Name)
+		}
+	}
+	return nil
+}
+
+// FieldWidth returns the width in bytes of the given field, which is zero for a last field
+// that takes the remaining bytes of the key.
+func (c *KafkaCompositeKeyField) FieldWidth() int {
+	if width, isFixed := compositeKeyFieldWidths[c.Type]; isFixed {
+		return width
+	}
+	return c.Width
+}
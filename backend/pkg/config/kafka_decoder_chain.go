@@ -0,0 +1,54 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "fmt"
+
+// KafkaDecoderChain configures which decoders are tried when the encoding of a record is
+// auto-detected, and in which order. The first decoder that succeeds wins, so that greedy
+// decoders can be moved back or disabled for payloads that they misdetect.
+type KafkaDecoderChain struct {
+	// Order lists the names of the decoders (e.g. "avro" or "json") that are tried first,
+	// in the given order. All other decoders are tried afterwards in their default order.
+	Order []string `yaml:"order"`
+
+	// Disabled lists the names of the decoders that are not tried during auto-detection.
+	// Topic decoders may still force a disabled decoder for their topics.
+	Disabled []string `yaml:"disabled"`
+}
+
+// Validate the decoder chain.
+func (c *KafkaDecoderChain) Validate() error {
+	ordered := make(map[string]bool, len(c.Order))
+	for _, name := range c.Order {
+		if name == "" {
+			return fmt.Errorf("order must not contain empty decoder names")
+		}
+		if ordered[name] {
+			return fmt.Errorf("decoder %q is listed more than once in order", name)
+		}
+		ordered[name] = true
+	}
+
+	disabled := make(map[string]bool, len(c.Disabled))
+	for _, name := range c.Disabled {
+		if name == "" {
+			return fmt.Errorf("disabled must not contain empty decoder names")
+		}
+		if ordered[name] {
+			return fmt.Errorf("decoder %q cannot be both ordered and disabled", name)
+		}
+		if disabled[name] {
+			return fmt.Errorf("decoder %q is listed more than once in disabled", name)
+		}
+		disabled[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "fmt"
+
+// KafkaTopicDecoder forces a decoder for the records of all matching topics, so that the
+// encoding is not auto-detected. This is useful for internal topics with a known encoding.
+type KafkaTopicDecoder struct {
+	// TopicNames is a list of topic names that use the decoder. These names can be provided
+	// as regex string (e. g. "/__redpanda\..*/") or as plain topic name such as "_schemas".
+	TopicNames []string `yaml:"topicNames"`
+
+	// Decoder is the name of the decoder (e.g. "json", "avro" or "utf8"). Use "binary" to
+	// skip decoding altogether.
+	Decoder string `yaml:"decoder"`
+}
+
+// Validate the topic decoder.
+func (c *KafkaTopicDecoder) Validate() error {
+	if c.Decoder == "" {
+		return fmt.Errorf("decoder must be set")
+	}
+	for _, topic := range c.TopicNames {
+		if _, err := CompileRegex(topic); err != nil {
+			return fmt.Errorf("topic string '%v' is not valid regex", topic)
+		}
+	}
+	return nil
+}
+
+// defaultKafkaTopicDecoders are the decoders of internal topics with known encodings.
+func defaultKafkaTopicDecoders() []KafkaTopicDecoder {
+	return []KafkaTopicDecoder{
+		{TopicNames: []string{"_schemas"}, Decoder: "json"},
+		{TopicNames: []string{"__transaction_state", `/^__redpanda\..*/`}, Decoder: "binary"},
+	}
+}
@@ -23,6 +23,10 @@ type Proto struct {
 	Git            Git                 `json:"git"`
 	FileSystem     Filesystem          `json:"fileSystem"`
 
+	// FileDescriptorSets provide compiled proto types, which are shipped out-of-band rather
+	// than via a schema registry.
+	FileDescriptorSets []ProtoFileDescriptorSet `json:"fileDescriptorSets"`
+
 	// Mappings define what proto types shall be used for each Kafka topic. If SchemaRegistry is used, no mappings are required.
 	Mappings []ProtoTopicMapping `json:"mappings"`
 
@@ -43,11 +47,18 @@ func (c *Proto) Validate() error {
 		return nil
 	}
 
-	if !c.Git.Enabled && !c.FileSystem.Enabled && !c.SchemaRegistry.Enabled {
+	if !c.Git.Enabled && !c.FileSystem.Enabled && !c.SchemaRegistry.Enabled && len(c.FileDescriptorSets) == 0 {
 		return fmt.Errorf("protobuf deserializer is enabled, at least one source provider for proto files must be configured")
 	}
 
-	if len(c.Mappings) == 0 && !c.SchemaRegistry.Enabled {
+	for i, set := range c.FileDescriptorSets {
+		if err := set.Validate(); err != nil {
+			return fmt.Errorf("failed to validate file descriptor set at index %d: %w", i, err)
+		}
+	}
+
+	// Types from FileDescriptorSets can be selected per request rather than via mappings
+	if len(c.Mappings) == 0 && !c.SchemaRegistry.Enabled && len(c.FileDescriptorSets) == 0 {
 		return fmt.Errorf("protobuf deserializer is enabled, but no topic mappings have been configured")
 	}
 
@@ -0,0 +1,41 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ProtoFileDescriptorSet is a compiled FileDescriptorSet (e.g. created via `protoc
+// --include_imports --descriptor_set_out=types.pb`) that provides proto types without a
+// schema registry. The types are referenced by the topic mappings, or selected along with
+// the protobuf key or value encoding when messages are listed.
+type ProtoFileDescriptorSet struct {
+	// Path to the binary encoded FileDescriptorSet.
+	Path string `yaml:"path"`
+
+	// Inline is the base64 encoded FileDescriptorSet, as an alternative to Path.
+	Inline string `yaml:"inline"`
+}
+
+// Validate the FileDescriptorSet configuration.
+func (c *ProtoFileDescriptorSet) Validate() error {
+	if (c.Path == "") == (c.Inline == "") {
+		return errors.New("either path or inline must be set")
+	}
+	if c.Inline != "" {
+		if _, err := base64.StdEncoding.DecodeString(c.Inline); err != nil {
+			return fmt.Errorf("inline file descriptor set is not base64 encoded: %w", err)
+		}
+	}
+	return nil
+}
@@ -13,6 +13,8 @@ import (
 	"flag"
 	"fmt"
 	"net/url"
+	"strings"
+	"time"
 )
 
 // Schema Config for using a (Confluent) Schema Registry
@@ -24,15 +26,72 @@ type Schema struct {
 	Username    string `yaml:"username"`
 	Password    string `yaml:"password"`
 	BearerToken string `yaml:"bearerToken"`
+	// OAuth acquires bearer tokens via the client credentials grant. It takes precedence over
+	// the static bearer token.
+	OAuth SchemaOAuth `yaml:"oauth"`
 
 	// TLS / Custom CA
 	TLS SchemaTLS `yaml:"tls"`
+
+	// ProxyURL is the URL of an HTTP proxy (e.g. "http://proxy.corp:3128") that all requests
+	// to the registry are sent through. If empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables are honored.
+	ProxyURL string `yaml:"proxyUrl"`
+
+	// FollowSubjectAliases resolves subject aliases when looking up schemas by subject, so
+	// that subjects which only exist as alias can be looked up as well.
+	FollowSubjectAliases bool `yaml:"followSubjectAliases"`
+
+	// MaxConcurrentFetches limits the number of schemas that are fetched from the registry
+	// concurrently while decoding records, so that cache misses don't cause bursts of
+	// requests. Zero means no limit.
+	MaxConcurrentFetches int `yaml:"maxConcurrentFetches"`
+
+	// RequestTimeout is the timeout of a single request to the registry.
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+	// RetryAttempts is the number of times a failed GET request is retried. Requests that
+	// modify the registry are never retried.
+	RetryAttempts int `yaml:"retryAttempts"`
+	// RetryBackoff is the wait time before the first retry, which is doubled for each
+	// further retry. Defaults to 100ms.
+	RetryBackoff time.Duration `yaml:"retryBackoff"`
+
+	// SchemaIDCacheSize is the max number of schemas that are cached by ID. Schemas of an ID
+	// are immutable, so that the least recently used ones are only evicted to bound memory
+	// usage. Zero disables the cache.
+	SchemaIDCacheSize int `yaml:"schemaIdCacheSize"`
+	// SchemaIDCacheTTL is the time after which cached schemas are fetched again, so that
+	// permanently deleted schemas disappear eventually. Zero means they never expire.
+	SchemaIDCacheTTL time.Duration `yaml:"schemaIdCacheTtl"`
+
+	// Context is the name of the schema context (e.g. "tenant-a") that subjects are looked up
+	// and registered in. Subject names are prefixed with the context (":.tenant-a:") unless
+	// they are qualified already, and schema IDs are resolved within the context. Empty means
+	// the default context.
+	Context string `yaml:"context"`
+
+	// ScanSubjectsOnStartup loads the latest schema of all subjects once at startup and
+	// reports the subjects that fail to load via logs and metrics. It can be disabled for
+	// registries with many subjects. ScanSubjectsTimeout bounds the duration of the scan.
+	ScanSubjectsOnStartup bool          `yaml:"scanSubjectsOnStartup"`
+	ScanSubjectsTimeout   time.Duration `yaml:"scanSubjectsTimeout"`
+}
+
+// SetDefaults for the schema registry configuration.
+func (c *Schema) SetDefaults() {
+	c.MaxConcurrentFetches = 10
+	c.RequestTimeout = 5 * time.Second
+	c.SchemaIDCacheSize = 1000
+	c.SchemaIDCacheTTL = time.Hour
+	c.ScanSubjectsOnStartup = true
+	c.ScanSubjectsTimeout = 5 * time.Minute
 }
 
 // RegisterFlags registers all nested config flags.
 func (c *Schema) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&c.Password, "schema.registry.password", "", "Password for authenticating against the schema registry (optional)")
 	f.StringVar(&c.BearerToken, "schema.registry.token", "", "Bearer token for authenticating against the schema registry (optional)")
+	c.OAuth.RegisterFlags(f)
 }
 
 // Validate the schema registry configurations.
@@ -45,6 +104,44 @@ func (c *Schema) Validate() error {
 		return fmt.Errorf("schema registry is enabled but no URL is configured")
 	}
 
+	if c.MaxConcurrentFetches < 0 {
+		return fmt.Errorf("max concurrent fetches must not be negative")
+	}
+
+	if c.RequestTimeout < 0 {
+		return fmt.Errorf("request timeout must not be negative")
+	}
+
+	if c.RetryAttempts < 0 || c.RetryBackoff < 0 {
+		return fmt.Errorf("retry attempts and retry backoff must not be negative")
+	}
+
+	if c.SchemaIDCacheSize < 0 || c.SchemaIDCacheTTL < 0 {
+		return fmt.Errorf("schema id cache size and ttl must not be negative")
+	}
+
+	if c.ScanSubjectsOnStartup && c.ScanSubjectsTimeout <= 0 {
+		return fmt.Errorf("scan subjects timeout must be positive when scanning subjects on startup")
+	}
+
+	if err := c.OAuth.Validate(); err != nil {
+		return fmt.Errorf("failed to validate oauth config: %w", err)
+	}
+
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("failed to parse schema registry proxy url %q: %w", c.ProxyURL, err)
+		}
+		if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5" {
+			return fmt.Errorf("proxy URL scheme must either be http, https or socks5, but got %q in url: %q", proxyURL.Scheme, c.ProxyURL)
+		}
+	}
+
+	if strings.Contains(c.Context, ":") {
+		return fmt.Errorf("schema context %q must not contain colons", c.Context)
+	}
+
 	for _, u := range c.URLs {
 		urlParsed, err := url.Parse(u)
 		if err != nil {
@@ -0,0 +1,50 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+)
+
+// SchemaOAuth configures authentication against the schema registry with access tokens that
+// are acquired from an OAuth2 provider via the client credentials grant. The provider is
+// requested with the TLS, proxy and timeout settings of the schema registry.
+type SchemaOAuth struct {
+	TokenURL     string   `yaml:"tokenUrl"`
+	ClientID     string   `yaml:"clientId"`
+	ClientSecret string   `yaml:"clientSecret"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+// RegisterFlags registers all sensitive OAuth settings as flag.
+func (c *SchemaOAuth) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ClientSecret, "schema.registry.oauth.clientSecret", "", "OAuth client secret for authenticating against the schema registry (optional)")
+}
+
+// Enabled returns true if a token URL is configured.
+func (c *SchemaOAuth) Enabled() bool {
+	return c.TokenURL != ""
+}
+
+// Validate the schema registry OAuth configuration.
+func (c *SchemaOAuth) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if _, err := url.ParseRequestURI(c.TokenURL); err != nil {
+		return fmt.Errorf("failed to parse token url %q: %w", c.TokenURL, err)
+	}
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("client id and client secret must be set if a token url is configured")
+	}
+	return nil
+}
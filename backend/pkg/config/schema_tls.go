@@ -16,4 +16,10 @@ type SchemaTLS struct {
 	CertFilepath          string `yaml:"certFilepath"`
 	KeyFilepath           string `yaml:"keyFilepath"`
 	InsecureSkipTLSVerify bool   `yaml:"insecureSkipTlsVerify"`
+
+	// PinnedCertFingerprints is a list of hex encoded SHA-256 fingerprints (colons are
+	// allowed as separators). If set, the registry's certificate is accepted if and only if
+	// its leaf certificate matches one of these fingerprints. This allows using self-signed
+	// certificates without disabling verification altogether.
+	PinnedCertFingerprints []string `yaml:"pinnedCertFingerprints"`
 }
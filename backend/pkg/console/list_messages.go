@@ -45,6 +45,12 @@ type ListMessageRequest struct {
 	StartTimestamp        int64 // Start offset by unix timestamp in ms
 	MessageCount          int
 	FilterInterpreterCode string
+	LatestPerKey          bool // Only return the most recent message of each key within the consumed offsets
+	// MergeTopicNames are further topics whose messages are merged with the topic's messages
+	// into a single stream that is ordered by timestamp.
+	MergeTopicNames []string
+
+	DeserializationOptions kafka.DeserializationOptions
 }
 
 // ListMessageResponse returns the requested kafka messages along with some metadata about the operation
@@ -66,11 +72,105 @@ type ListMessageResponse struct {
 func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest, progress kafka.IListMessagesProgress) error {
 	start := time.Now()
 
+	if len(listReq.MergeTopicNames) > 0 {
+		return s.listMergedMessages(ctx, listReq, progress, start)
+	}
+
+	consumeRequests, err := s.topicConsumeRequests(ctx, &listReq, progress)
+	if err != nil {
+		return err
+	}
+	if len(consumeRequests) == 0 {
+		// No partitions/messages to consume, we can quit early.
+		progress.OnComplete(time.Since(start).Milliseconds(), false)
+		return nil
+	}
+	topicConsumeRequest := kafka.TopicConsumeRequest{
+		TopicName:              listReq.TopicName,
+		MaxMessageCount:        listReq.MessageCount,
+		Partitions:             consumeRequests,
+		FilterInterpreterCode:  listReq.FilterInterpreterCode,
+		LatestPerKey:           listReq.LatestPerKey,
+		DeserializationOptions: listReq.DeserializationOptions,
+	}
+
+	progress.OnPhase("Consuming messages")
+	err = s.kafkaSvc.FetchMessages(ctx, progress, topicConsumeRequest)
+	if err != nil {
+		progress.OnError(err.Error())
+		return nil
+	}
+
+	isCancelled := ctx.Err() != nil
+	progress.OnComplete(time.Since(start).Milliseconds(), isCancelled)
+	if isCancelled {
+		return fmt.Errorf("request was cancelled while waiting for messages")
+	}
+
+	return nil
+}
+
+// listMergedMessages lists the messages of the request's topic and all merge topics as a
+// single stream that is ordered by timestamp. The consume requests are calculated for each
+// topic separately as if its messages were listed on their own. If the most recent messages
+// are requested, the message count applies to each topic, so that the most recent messages
+// of all topics are included.
+func (s *Service) listMergedMessages(ctx context.Context, listReq ListMessageRequest, progress kafka.IListMessagesProgress, start time.Time) error {
+	mergeReq := kafka.MergedConsumeRequest{
+		MaxMessageCount:        listReq.MessageCount,
+		FilterInterpreterCode:  listReq.FilterInterpreterCode,
+		DeserializationOptions: listReq.DeserializationOptions,
+	}
+	topicNames := append([]string{listReq.TopicName}, listReq.MergeTopicNames...)
+	for _, topicName := range topicNames {
+		topicListReq := listReq
+		topicListReq.TopicName = topicName
+		topicListReq.MergeTopicNames = nil
+		consumeRequests, err := s.topicConsumeRequests(ctx, &topicListReq, progress)
+		if err != nil {
+			return fmt.Errorf("topic %q: %w", topicName, err)
+		}
+		if len(consumeRequests) == 0 {
+			continue
+		}
+		mergeReq.Topics = append(mergeReq.Topics, kafka.TopicConsumeRequest{
+			TopicName:  topicName,
+			Partitions: consumeRequests,
+		})
+	}
+	if len(mergeReq.Topics) == 0 {
+		// No partitions/messages to consume, we can quit early.
+		progress.OnComplete(time.Since(start).Milliseconds(), false)
+		return nil
+	}
+	if listReq.StartOffset == StartOffsetRecent {
+		mergeReq.MaxMessageCount = listReq.MessageCount * len(topicNames)
+	}
+
+	progress.OnPhase("Consuming and merging messages")
+	err := s.kafkaSvc.FetchMergedMessages(ctx, progress, mergeReq)
+	if err != nil {
+		progress.OnError(err.Error())
+		return nil
+	}
+
+	isCancelled := ctx.Err() != nil
+	progress.OnComplete(time.Since(start).Milliseconds(), isCancelled)
+	if isCancelled {
+		return fmt.Errorf("request was cancelled while waiting for messages")
+	}
+
+	return nil
+}
+
+// topicConsumeRequests looks up the requested partitions of the request's topic along with
+// their watermarks and calculates the consume request of each partition.
+func (s *Service) topicConsumeRequests(ctx context.Context, listReq *ListMessageRequest, progress kafka.IListMessagesProgress) (map[int32]*kafka.PartitionConsumeRequest, error) {
 	progress.OnPhase("Get Partitions")
 	// Create array of partitionIDs which shall be consumed (always do that to ensure the requested topic exists at all)
 	metadata, restErr := s.kafkaSvc.GetSingleMetadata(ctx, listReq.TopicName)
 	if restErr != nil {
-		return fmt.Errorf("failed to get partitions: %w", restErr.Err)
+		return nil, fmt.Errorf("failed to get partitions: %w", restErr.Err)
 	}
 
 	partitionByID := make(map[int32]kmsg.MetadataResponseTopicPartition)
@@ -100,12 +200,12 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 		// Check if requested partitionID exists
 		pInfo, exists := partitionByID[listReq.PartitionID]
 		if !exists {
-			return fmt.Errorf("requested partitionID (%v) does not exist in topic (%v)", listReq.PartitionID, listReq.TopicName)
+			return nil, fmt.Errorf("requested partitionID (%v) does not exist in topic (%v)", listReq.PartitionID, listReq.TopicName)
 		}
 
 		// Check if the requested partitionID is available
 		if err := kerr.ErrorForCode(pInfo.ErrorCode); err != nil {
-			return fmt.Errorf("requested partitionID (%v) is not available: %w", listReq.PartitionID, err)
+			return nil, fmt.Errorf("requested partitionID (%v) is not available: %w", listReq.PartitionID, err)
 		}
 		partitionIDs = []int32{listReq.PartitionID}
 	}
@@ -113,40 +213,15 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 	progress.OnPhase("Get Watermarks and calculate consuming requests")
 	marks, err := s.kafkaSvc.GetPartitionMarks(ctx, listReq.TopicName, partitionIDs)
 	if err != nil {
-		return fmt.Errorf("failed to get watermarks: %w", err)
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
 	}
 
 	// Get partition consume request by calculating start and end offsets for each partition
-	consumeRequests, err := s.calculateConsumeRequests(ctx, &listReq, marks)
-	if err != nil {
-		return fmt.Errorf("failed to calculate consume request: %w", err)
-	}
-	if len(consumeRequests) == 0 {
-		// No partitions/messages to consume, we can quit early.
-		progress.OnComplete(time.Since(start).Milliseconds(), false)
-		return nil
-	}
-	topicConsumeRequest := kafka.TopicConsumeRequest{
-		TopicName:             listReq.TopicName,
-		MaxMessageCount:       listReq.MessageCount,
-		Partitions:            consumeRequests,
-		FilterInterpreterCode: listReq.FilterInterpreterCode,
-	}
-
-	progress.OnPhase("Consuming messages")
-	err = s.kafkaSvc.FetchMessages(ctx, progress, topicConsumeRequest)
+	consumeRequests, err := s.calculateConsumeRequests(ctx, listReq, marks)
 	if err != nil {
-		progress.OnError(err.Error())
-		return nil
+		return nil, fmt.Errorf("failed to calculate consume request: %w", err)
 	}
-
-	isCancelled := ctx.Err() != nil
-	progress.OnComplete(time.Since(start).Milliseconds(), isCancelled)
-	if isCancelled {
-		return fmt.Errorf("request was cancelled while waiting for messages")
-	}
-
-	return nil
+	return consumeRequests, nil
 }
 
 // calculateConsumeRequests is supposed to calculate the start and end offsets for each partition consumer, so that
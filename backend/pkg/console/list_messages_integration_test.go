@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
 	"go.uber.org/zap"
 
@@ -494,6 +495,147 @@ func (s *ConsoleIntegrationTestSuite) TestListMessages() {
 		err = svc.ListMessages(ctx, input, mockProgress)
 		assert.NoError(err)
 	})
+
+	t.Run("latest message per key fake", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProgress := mocks.NewMockIListMessagesProgress(mockCtrl)
+
+		fakeCluster, err := kfake.NewCluster(kfake.NumBrokers(1))
+		require.NoError(err)
+
+		defer fakeCluster.Close()
+
+		fakeClient, fakeAdminClient := testutil.CreateClients(t, fakeCluster.ListenAddrs())
+
+		compactedTopicName := testutil.TopicNameForTest("list_messages_latest_per_key")
+		_, err = fakeAdminClient.CreateTopic(ctx, 1, 1, nil, compactedTopicName)
+		require.NoError(err)
+
+		// Updates of customer-1 and customer-3, customer-2 is deleted by a tombstone
+		records := []*kgo.Record{
+			{Key: []byte("customer-1"), Value: []byte(`{"name": "jane", "version": 1}`)},
+			{Key: []byte("customer-2"), Value: []byte(`{"name": "john", "version": 1}`)},
+			{Key: []byte("customer-1"), Value: []byte(`{"name": "jane", "version": 2}`)},
+			{Key: []byte("customer-3"), Value: []byte(`{"name": "alex", "version": 1}`)},
+			{Key: []byte("customer-2"), Value: nil},
+			{Key: []byte("customer-3"), Value: []byte(`{"name": "alex", "version": 2}`)},
+		}
+		for _, record := range records {
+			record.Topic = compactedTopicName
+		}
+		require.NoError(fakeClient.ProduceSync(ctx, records...).FirstErr())
+
+		svc := createNewTestService(t, log, t.Name(), fakeCluster.ListenAddrs()[0])
+
+		var int64Type int64
+		var messages []*kafka.TopicMessage
+
+		mockProgress.EXPECT().OnPhase("Get Partitions")
+		mockProgress.EXPECT().OnPhase("Get Watermarks and calculate consuming requests")
+		mockProgress.EXPECT().OnPhase("Consuming messages")
+		mockProgress.EXPECT().OnPhase("Decoding latest messages per key")
+		mockProgress.EXPECT().OnMessageConsumed(gomock.AssignableToTypeOf(int64Type)).Times(len(records))
+		mockProgress.EXPECT().OnMessage(gomock.Any()).Do(func(msg *kafka.TopicMessage) {
+			messages = append(messages, msg)
+		}).Times(2)
+		mockProgress.EXPECT().OnComplete(gomock.AssignableToTypeOf(int64Type), false)
+
+		input := ListMessageRequest{
+			TopicName:    compactedTopicName,
+			PartitionID:  -1,
+			StartOffset:  StartOffsetOldest,
+			MessageCount: 100,
+			LatestPerKey: true,
+		}
+
+		err = svc.ListMessages(ctx, input, mockProgress)
+		assert.NoError(err)
+
+		require.Len(messages, 2)
+		assert.Equal(int64(2), messages[0].Offset)
+		assert.Equal("customer-1", string(messages[0].Key.Payload.Payload))
+		assert.JSONEq(`{"name": "jane", "version": 2}`, string(messages[0].Value.Payload.Payload))
+		assert.Equal(int64(5), messages[1].Offset)
+		assert.Equal("customer-3", string(messages[1].Key.Payload.Payload))
+		assert.JSONEq(`{"name": "alex", "version": 2}`, string(messages[1].Value.Payload.Payload))
+	})
+
+	t.Run("merge topics by timestamp fake", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockProgress := mocks.NewMockIListMessagesProgress(mockCtrl)
+
+		fakeCluster, err := kfake.NewCluster(kfake.NumBrokers(1))
+		require.NoError(err)
+
+		defer fakeCluster.Close()
+
+		_, fakeAdminClient := testutil.CreateClients(t, fakeCluster.ListenAddrs())
+
+		ordersTopicName := testutil.TopicNameForTest("list_messages_merge_orders")
+		paymentsTopicName := testutil.TopicNameForTest("list_messages_merge_payments")
+		_, err = fakeAdminClient.CreateTopic(ctx, 2, 1, nil, ordersTopicName)
+		require.NoError(err)
+		_, err = fakeAdminClient.CreateTopic(ctx, 1, 1, nil, paymentsTopicName)
+		require.NoError(err)
+
+		base := time.Now().Add(-time.Hour)
+		records := []*kgo.Record{
+			{Topic: ordersTopicName, Partition: 0, Timestamp: base.Add(1 * time.Second), Value: []byte(`"order-1"`)},
+			{Topic: ordersTopicName, Partition: 1, Timestamp: base.Add(2 * time.Second), Value: []byte(`"order-2"`)},
+			{Topic: ordersTopicName, Partition: 0, Timestamp: base.Add(5 * time.Second), Value: []byte(`"order-3"`)},
+			{Topic: paymentsTopicName, Partition: 0, Timestamp: base.Add(3 * time.Second), Value: []byte(`"payment-1"`)},
+			{Topic: paymentsTopicName, Partition: 0, Timestamp: base.Add(4 * time.Second), Value: []byte(`"payment-2"`)},
+			{Topic: paymentsTopicName, Partition: 0, Timestamp: base.Add(6 * time.Second), Value: []byte(`"payment-3"`)},
+		}
+		manualClient, err := kgo.NewClient(
+			kgo.SeedBrokers(fakeCluster.ListenAddrs()...),
+			kgo.RecordPartitioner(kgo.ManualPartitioner()),
+		)
+		require.NoError(err)
+		defer manualClient.Close()
+		require.NoError(manualClient.ProduceSync(ctx, records...).FirstErr())
+
+		svc := createNewTestService(t, log, t.Name(), fakeCluster.ListenAddrs()[0])
+
+		var int64Type int64
+		var messages []*kafka.TopicMessage
+
+		mockProgress.EXPECT().OnPhase("Get Partitions").Times(2)
+		mockProgress.EXPECT().OnPhase("Get Watermarks and calculate consuming requests").Times(2)
+		mockProgress.EXPECT().OnPhase("Consuming and merging messages")
+		mockProgress.EXPECT().OnMessageConsumed(gomock.AssignableToTypeOf(int64Type)).Times(5)
+		mockProgress.EXPECT().OnMessage(gomock.Any()).Do(func(msg *kafka.TopicMessage) {
+			messages = append(messages, msg)
+		}).Times(5)
+		mockProgress.EXPECT().OnComplete(gomock.AssignableToTypeOf(int64Type), false)
+
+		input := ListMessageRequest{
+			TopicName:       ordersTopicName,
+			MergeTopicNames: []string{paymentsTopicName},
+			PartitionID:     -1,
+			StartOffset:     StartOffsetOldest,
+			MessageCount:    5,
+		}
+
+		err = svc.ListMessages(ctx, input, mockProgress)
+		assert.NoError(err)
+
+		var actual []string
+		for _, msg := range messages {
+			actual = append(actual, msg.TopicName+"="+string(msg.Value.Payload.Payload))
+		}
+		assert.Equal([]string{
+			ordersTopicName + `="order-1"`,
+			ordersTopicName + `="order-2"`,
+			paymentsTopicName + `="payment-1"`,
+			paymentsTopicName + `="payment-2"`,
+			ordersTopicName + `="order-3"`,
+		}, actual)
+	})
 }
 
 func createNewTestService(t *testing.T, log *zap.Logger,
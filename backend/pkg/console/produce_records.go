@@ -14,6 +14,8 @@ import (
 	"fmt"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/redpanda-data/console/backend/pkg/kafka"
 )
 
 // ProduceRecordsResponse is the responses to producing multiple Kafka RecordBatches.
@@ -24,6 +26,11 @@ type ProduceRecordsResponse struct {
 	// when transactions were enabled. Another option could be that the Kafka client creation has failed because
 	// brokers are temporarily offline.
 	Error string `json:"error,omitempty"`
+
+	// Serialization contains the serialized key and value of each requested record, in the
+	// order of the request, if any record has been serialized. The entries of records that
+	// are produced as is are null.
+	Serialization []*kafka.SerializeOutput `json:"serialization,omitempty"`
 }
 
 // ProduceRecordResponse is the response to producing a Kafka RecordBatch.
@@ -65,3 +72,8 @@ func (s *Service) ProduceRecords(ctx context.Context, records []*kgo.Record, use
 		Error:   "", // Will be omitted
 	}
 }
+
+// SerializeRecord serializes the key and value of a record, so that it can be produced.
+func (s *Service) SerializeRecord(ctx context.Context, input kafka.SerializeInput) (*kafka.SerializeOutput, error) {
+	return s.kafkaSvc.SerializeRecord(ctx, input)
+}
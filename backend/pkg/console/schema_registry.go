@@ -144,6 +144,11 @@ type SchemaRegistrySubjectDetails struct {
 	RegisteredVersions  []SchemaRegistrySubjectDetailsVersion `json:"versions"`
 	LatestActiveVersion int                                   `json:"latestActiveVersion"`
 	Schemas             []*SchemaRegistryVersionedSchema      `json:"schemas"`
+
+	// EffectiveCompatibility is the compatibility level that applies to the subject, which is
+	// inherited from the global config if the subject has none of its own.
+	EffectiveCompatibility   schema.CompatibilityLevel `json:"effectiveCompatibility,omitempty"`
+	IsCompatibilityInherited bool                      `json:"isCompatibilityInherited"`
 }
 
 const (
@@ -176,19 +181,22 @@ func (s *Service) GetSchemaRegistrySubjectDetails(ctx context.Context, subjectNa
 	}
 
 	// 2. Retrieve schemas and compat level concurrently
-	var compatLevel schema.CompatibilityLevel
+	var compatLevel, effectiveCompatLevel schema.CompatibilityLevel
+	var isCompatInherited bool
 
 	grp, grpCtx := errgroup.WithContext(ctx)
 	grp.SetLimit(10)
 
 	grp.Go(func() error {
 		// 2. Retrieve subject config (subject compatibility level)
-		configRes, err := s.kafkaSvc.SchemaService.GetSubjectConfig(grpCtx, subjectName)
+		configRes, err := s.kafkaSvc.SchemaService.GetEffectiveSubjectConfig(grpCtx, subjectName)
 		if err != nil {
 			s.logger.Warn("failed to get subject config", zap.String("subject", subjectName), zap.Error(err))
 			return nil
 		}
 		compatLevel = configRes.Compatibility
+		effectiveCompatLevel = configRes.EffectiveCompatibility
+		isCompatInherited = configRes.IsInherited
 		return nil
 	})
 
@@ -241,6 +249,9 @@ func (s *Service) GetSchemaRegistrySubjectDetails(ctx context.Context, subjectNa
 		RegisteredVersions:  versions,
 		LatestActiveVersion: latestActiveVersion,
 		Schemas:             schemas,
+
+		EffectiveCompatibility:   effectiveCompatLevel,
+		IsCompatibilityInherited: isCompatInherited,
 	}, nil
 }
 
@@ -268,8 +279,7 @@ func (s *Service) getSchemaRegistrySchemaVersions(ctx context.Context, subjectNa
 	g.Go(func() error {
 		versionsRes, err := s.kafkaSvc.SchemaService.GetSubjectVersions(ctx, subjectName, false)
 		if err != nil {
-			var schemaError *schema.RestError
-			if errors.As(err, &schemaError) && schemaError.ErrorCode == 40401 {
+			if errors.Is(err, schema.ErrSubjectNotFound) {
 				// It's expected to get an error here if the targeted subject
 				// is soft-deleted (Subject not found / errcode 40401).
 				return nil
@@ -487,8 +497,9 @@ type CreateSchemaResponse struct {
 }
 
 // CreateSchemaRegistrySchema registers a new schema for the given subject in the schema registry.
-func (s *Service) CreateSchemaRegistrySchema(ctx context.Context, subjectName string, schema schema.Schema) (*CreateSchemaResponse, error) {
-	res, err := s.kafkaSvc.SchemaService.CreateSchema(ctx, subjectName, schema)
+// If normalize is set, the registry stores the schema in its normalized form.
+func (s *Service) CreateSchemaRegistrySchema(ctx context.Context, subjectName string, schema schema.Schema, normalize bool) (*CreateSchemaResponse, error) {
+	res, err := s.kafkaSvc.SchemaService.CreateSchema(ctx, subjectName, schema, normalize)
 	if err != nil {
 		return nil, err
 	}
@@ -506,8 +517,9 @@ type SchemaRegistrySchemaValidation struct {
 // SchemaRegistrySchemaValidationCompatibility is the response to the compatibility check
 // performed by the schema registry.
 type SchemaRegistrySchemaValidationCompatibility struct {
-	IsCompatible bool   `json:"isCompatible"`
-	Error        string `json:"error,omitempty"`
+	IsCompatible bool     `json:"isCompatible"`
+	Error        string   `json:"error,omitempty"`
+	Messages     []string `json:"messages,omitempty"`
 }
 
 // ValidateSchemaRegistrySchema validates a given schema by checking:
@@ -521,6 +533,7 @@ func (s *Service) ValidateSchemaRegistrySchema(
 ) *SchemaRegistrySchemaValidation {
 	// Compatibility check from schema registry
 	var compatErr string
+	var compatMessages []string
 	var isCompatible bool
 	compatRes, err := s.kafkaSvc.SchemaService.CheckCompatibility(ctx, subjectName, version, sch)
 	if err != nil {
@@ -528,15 +541,13 @@ func (s *Service) ValidateSchemaRegistrySchema(
 
 		// If subject doesn't exist, we will reset the error, because new subject schemas
 		// don't have any existing schema and therefore can't be incompatible.
-		var schemaErr *schema.RestError
-		if errors.As(err, &schemaErr) {
-			if schemaErr.ErrorCode == schema.CodeSubjectNotFound {
-				compatErr = ""
-				isCompatible = true
-			}
+		if errors.Is(err, schema.ErrSubjectNotFound) {
+			compatErr = ""
+			isCompatible = true
 		}
 	} else {
 		isCompatible = compatRes.IsCompatible
+		compatMessages = compatRes.Messages
 	}
 
 	var parsingErr string
@@ -559,6 +570,7 @@ func (s *Service) ValidateSchemaRegistrySchema(
 		Compatibility: SchemaRegistrySchemaValidationCompatibility{
 			IsCompatible: isCompatible,
 			Error:        compatErr,
+			Messages:     compatMessages,
 		},
 		ParsingError: parsingErr,
 		IsValid:      parsingErr == "" && isCompatible,
@@ -588,3 +600,24 @@ func (s *Service) GetSchemaUsagesByID(ctx context.Context, schemaID int) ([]Sche
 
 	return schemaVersions, nil
 }
+
+// SchemaRegistryCacheInvalidation describes which cached schemas have been purged.
+type SchemaRegistryCacheInvalidation struct {
+	Subject  string `json:"subject,omitempty"`
+	SchemaID int    `json:"schemaId,omitempty"`
+}
+
+// InvalidateSchemaRegistryCache purges the cached schemas of the given subject or schema ID,
+// so that they are fetched from the schema registry again. All cached schemas are purged if
+// neither is set.
+func (s *Service) InvalidateSchemaRegistryCache(_ context.Context, subject string, schemaID int) *SchemaRegistryCacheInvalidation {
+	switch {
+	case subject != "":
+		s.kafkaSvc.SchemaService.InvalidateSubject(subject)
+	case schemaID > 0:
+		s.kafkaSvc.SchemaService.InvalidateSchemaID(uint32(schemaID))
+	default:
+		s.kafkaSvc.SchemaService.InvalidateCache()
+	}
+	return &SchemaRegistryCacheInvalidation{Subject: subject, SchemaID: schemaID}
+}
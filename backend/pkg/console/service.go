@@ -20,6 +20,7 @@ import (
 	"github.com/redpanda-data/console/backend/pkg/git"
 	"github.com/redpanda-data/console/backend/pkg/kafka"
 	"github.com/redpanda-data/console/backend/pkg/redpanda"
+	"github.com/redpanda-data/console/backend/pkg/schema"
 )
 
 // Service offers all methods to serve the responses for the REST API. This usually only involves fetching
@@ -102,3 +103,13 @@ func (s *Service) Stop() {
 func (s *Service) IsHealthy(ctx context.Context) error {
 	return s.kafkaSvc.IsHealthy(ctx)
 }
+
+// BrokenSchemaSubjects returns the schema subjects that failed to load during the subject
+// scan at startup. It's empty if the schema registry is not configured or the scan has
+// not completed yet.
+func (s *Service) BrokenSchemaSubjects() []schema.SubjectLoadStatus {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil
+	}
+	return s.kafkaSvc.SchemaService.LastSubjectScan().BrokenSubjects()
+}
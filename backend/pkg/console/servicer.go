@@ -40,13 +40,16 @@ type Servicer interface {
 	ListPartitionReassignments(ctx context.Context) ([]PartitionReassignments, error)
 	AlterPartitionAssignments(ctx context.Context, topics []kmsg.AlterPartitionAssignmentsRequestTopic) ([]AlterPartitionReassignmentsResponse, error)
 	ProduceRecords(ctx context.Context, records []*kgo.Record, useTransactions bool, compressionType int8) ProduceRecordsResponse
+	SerializeRecord(ctx context.Context, input kafka.SerializeInput) (*kafka.SerializeOutput, error)
 	Start() error
 	Stop()
 	IsHealthy(ctx context.Context) error
+	BrokenSchemaSubjects() []schema.SubjectLoadStatus
 	GetTopicConfigs(ctx context.Context, topicName string, configNames []string) (*TopicConfig, *rest.Error)
 	GetTopicsConfigs(ctx context.Context, topicNames []string, configNames []string) (map[string]*TopicConfig, error)
 	ListTopicConsumers(ctx context.Context, topicName string) ([]*TopicConsumerGroup, error)
 	GetTopicDocumentation(topicName string) *TopicDocumentation
+	DetectTopicEncodings(ctx context.Context, topicName string, sampleSize int) (*kafka.TopicEncodingDetection, error)
 	GetTopicsOverview(ctx context.Context) ([]*TopicSummary, error)
 	GetAllTopicNames(ctx context.Context, metadata *kmsg.MetadataResponse) ([]string, error)
 	GetTopicDetails(ctx context.Context, topicNames []string) ([]TopicDetails, *rest.Error)
@@ -62,7 +65,8 @@ type Servicer interface {
 	DeleteSchemaRegistrySubject(ctx context.Context, subjectName string, deletePermanently bool) (*SchemaRegistryDeleteSubjectResponse, error)
 	DeleteSchemaRegistrySubjectVersion(ctx context.Context, subject, version string, deletePermanently bool) (*SchemaRegistryDeleteSubjectVersionResponse, error)
 	GetSchemaRegistrySchemaTypes(ctx context.Context) (*SchemaRegistrySchemaTypes, error)
-	CreateSchemaRegistrySchema(ctx context.Context, subjectName string, schema schema.Schema) (*CreateSchemaResponse, error)
+	CreateSchemaRegistrySchema(ctx context.Context, subjectName string, schema schema.Schema, normalize bool) (*CreateSchemaResponse, error)
 	ValidateSchemaRegistrySchema(ctx context.Context, subjectName string, version string, schema schema.Schema) *SchemaRegistrySchemaValidation
 	GetSchemaUsagesByID(ctx context.Context, schemaID int) ([]SchemaVersion, error)
+	InvalidateSchemaRegistryCache(ctx context.Context, subject string, schemaID int) *SchemaRegistryCacheInvalidation
 }
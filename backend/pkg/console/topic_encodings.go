@@ -0,0 +1,28 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package console
+
+import (
+	"context"
+	"time"
+
+	"github.com/redpanda-data/console/backend/pkg/kafka"
+)
+
+// DetectTopicEncodings samples up to sampleSize records of the given topic and returns the
+// most likely key and value encodings, so that the frontend can preselect them before
+// listing messages. Sampling stops after a few seconds, in which case the encodings are
+// detected based on the records that have been sampled so far.
+func (s *Service) DetectTopicEncodings(ctx context.Context, topicName string, sampleSize int) (*kafka.TopicEncodingDetection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.kafkaSvc.DetectTopicEncodings(ctx, topicName, sampleSize)
+}
@@ -36,9 +36,11 @@ type IListMessagesProgress interface {
 
 // TopicMessage represents a single message from a given Kafka topic/partition
 type TopicMessage struct {
-	PartitionID int32 `json:"partitionID"`
-	Offset      int64 `json:"offset"`
-	Timestamp   int64 `json:"timestamp"`
+	// TopicName tells the messages of merged topics apart.
+	TopicName   string `json:"topicName,omitempty"`
+	PartitionID int32  `json:"partitionID"`
+	Offset      int64  `json:"offset"`
+	Timestamp   int64  `json:"timestamp"`
 
 	Compression     string `json:"compression"`
 	IsTransactional bool   `json:"isTransactional"`
@@ -47,6 +49,13 @@ type TopicMessage struct {
 	Key     *deserializedPayload `json:"key"`
 	Value   *deserializedPayload `json:"value"`
 
+	// Tags of the tag rules of the deserialization options that match the message.
+	Tags []string `json:"tags,omitempty"`
+
+	// DecodeDurationMs is the time in milliseconds spent on deserializing the message. It's
+	// only set if requested by the deserialization options.
+	DecodeDurationMs *float64 `json:"decodeDurationMs,omitempty"`
+
 	// Below properties are used for the internal communication via Go channels
 	IsMessageOk  bool   `json:"-"`
 	ErrorMessage string `json:"-"`
@@ -79,6 +88,32 @@ type TopicConsumeRequest struct {
 	MaxMessageCount       int
 	Partitions            map[int32]*PartitionConsumeRequest
 	FilterInterpreterCode string
+
+	// LatestPerKey only returns the most recent record of each key within the consumed offset
+	// ranges, like a compacted topic would retain them. Keys whose most recent record is a
+	// tombstone are omitted. Records are decoded only after all partitions have been scanned.
+	LatestPerKey bool
+
+	DeserializationOptions DeserializationOptions
+}
+
+// partitionRequest returns the consume request of the given topic partition, or nil if
+// the partition has not been requested.
+func (r TopicConsumeRequest) partitionRequest(topic string, partitionID int32) *PartitionConsumeRequest {
+	if topic != r.TopicName {
+		return nil
+	}
+	return r.Partitions[partitionID]
+}
+
+// messageWorkerCount returns the number of workers that decode the consumed records.
+func (r TopicConsumeRequest) messageWorkerCount() int {
+	// If we use more than one worker the order of messages in each partition gets lost. Hence we only use it where
+	// multiple workers are actually beneficial - for potentially high throughput stream requests.
+	if r.FilterInterpreterCode != "" {
+		return 6
+	}
+	return 1
 }
 
 type interpreterArguments struct {
@@ -94,55 +129,30 @@ type interpreterArguments struct {
 // in many cases, often due to the fact that we can't consume backwards, but we offer
 // users to consume the most recent messages.
 func (s *Service) FetchMessages(ctx context.Context, progress IListMessagesProgress, consumeReq TopicConsumeRequest) error {
-	// 1. Assign partitions with right start offsets and create client
-	partitionOffsets := make(map[string]map[int32]kgo.Offset)
-	partitionOffsets[consumeReq.TopicName] = make(map[int32]kgo.Offset)
-	for _, req := range consumeReq.Partitions {
-		offset := kgo.NewOffset().At(req.StartOffset)
-		partitionOffsets[consumeReq.TopicName][req.PartitionID] = offset
+	if consumeReq.LatestPerKey {
+		return s.fetchLatestMessagesPerKey(ctx, progress, consumeReq)
 	}
 
-	client, err := s.NewKgoClient(kgo.ConsumePartitions(partitionOffsets))
+	// 1. Assign partitions with right start offsets and create client
+	client, err := s.newConsumeClient(consumeReq)
 	if err != nil {
-		return fmt.Errorf("failed to create new kafka client: %w", err)
+		return err
 	}
 	defer client.Close()
 
 	// 2. Create consumer workers
 	jobs := make(chan *kgo.Record, 100)
-	resultsCh := make(chan *TopicMessage, 100)
 	workerCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	wg := sync.WaitGroup{}
-
-	// If we use more than one worker the order of messages in each partition gets lost. Hence we only use it where
-	// multiple workers are actually beneficial - for potentially high throughput stream requests.
-	workerCount := 1
-	if consumeReq.FilterInterpreterCode != "" {
-		workerCount = 6
+	resultsCh, err := s.startMessageWorkers(workerCtx, progress, consumeReq, consumeReq.messageWorkerCount(), jobs)
+	if err != nil {
+		return err
 	}
-	for i := 0; i < workerCount; i++ {
-		// Setup JavaScript interpreter
-		isMessageOK, err := s.setupInterpreter(consumeReq.FilterInterpreterCode)
-		if err != nil {
-			s.Logger.Error("failed to setup interpreter", zap.Error(err))
-			progress.OnError(fmt.Sprintf("failed to setup interpreter: %v", err.Error()))
-			return err
-		}
-
-		wg.Add(1)
-		go s.startMessageWorker(workerCtx, &wg, isMessageOK, jobs, resultsCh)
-	}
-	// Close the results channel once all workers have finished processing jobs and therefore no senders are left anymore
-	go func() {
-		wg.Wait()
-		close(resultsCh)
-	}()
 
 	// 3. Start go routine that consumes messages from Kafka and produces these records on the jobs channel so that these
 	// can be decoded by our workers.
-	go s.consumeKafkaMessages(workerCtx, client, consumeReq, jobs)
+	go s.consumeKafkaMessages(workerCtx, client, consumeReq.partitionRequest, jobs)
 
 	// 4. Receive decoded messages until our request is satisfied. Once that's the case we will cancel the context
 	// that propagate to all the launched go routines.
@@ -174,10 +184,63 @@ func (s *Service) FetchMessages(ctx context.Context, progress IListMessagesProgr
 	return nil
 }
 
-// consumeKafkaMessages consumes messages for the consume request and sends responses to the jobs channel.
+// newConsumeClient creates a client that consumes the requested partitions from their start offsets.
+// The caller is responsible for closing the client.
+func (s *Service) newConsumeClient(consumeReq TopicConsumeRequest) (*kgo.Client, error) {
+	partitionOffsets := make(map[string]map[int32]kgo.Offset)
+	partitionOffsets[consumeReq.TopicName] = make(map[int32]kgo.Offset)
+	for _, req := range consumeReq.Partitions {
+		offset := kgo.NewOffset().At(req.StartOffset)
+		partitionOffsets[consumeReq.TopicName][req.PartitionID] = offset
+	}
+
+	client, err := s.NewKgoClient(kgo.ConsumePartitions(partitionOffsets))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new kafka client: %w", err)
+	}
+	return client, nil
+}
+
+// startMessageWorkers starts the workers that decode and filter the records from the jobs channel.
+// The returned results channel is closed once the jobs channel is closed and all workers are done.
+func (s *Service) startMessageWorkers(ctx context.Context, progress IListMessagesProgress, consumeReq TopicConsumeRequest, workerCount int, jobs <-chan *kgo.Record) (<-chan *TopicMessage, error) {
+	// Forced encodings are validated here, as custom serdes are only known to the deserializer
+	if err := s.Deserializer.validateForcedEncodings(consumeReq.DeserializationOptions); err != nil {
+		progress.OnError(err.Error())
+		return nil, err
+	}
+
+	// Patterns and rules are parsed once rather than for each record
+	deserializationOpts := consumeReq.DeserializationOptions.withParsedPatterns()
+
+	resultsCh := make(chan *TopicMessage, 100)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < workerCount; i++ {
+		// Setup JavaScript interpreter
+		isMessageOK, err := s.setupInterpreter(consumeReq.FilterInterpreterCode)
+		if err != nil {
+			s.Logger.Error("failed to setup interpreter", zap.Error(err))
+			progress.OnError(fmt.Sprintf("failed to setup interpreter: %v", err.Error()))
+			return nil, err
+		}
+
+		wg.Add(1)
+		go s.startMessageWorker(ctx, &wg, isMessageOK, deserializationOpts, jobs, resultsCh)
+	}
+	// Close the results channel once all workers have finished processing jobs and therefore no senders are left anymore
+	go func() {
+		wg.Wait()
+		close(resultsCh)
+	}()
+
+	return resultsCh, nil
+}
+
+// consumeKafkaMessages consumes messages for the requested partitions and sends responses to the jobs channel.
 // This function will close the channel.
 // The caller is responsible for closing the client if desired.
-func (s *Service) consumeKafkaMessages(ctx context.Context, client *kgo.Client, consumeReq TopicConsumeRequest, jobs chan<- *kgo.Record) {
+func (s *Service) consumeKafkaMessages(ctx context.Context, client *kgo.Client, partitionRequest func(topic string, partitionID int32) *PartitionConsumeRequest, jobs chan<- *kgo.Record) {
 	defer close(jobs)
 
 	for {
@@ -202,9 +265,9 @@ func (s *Service) consumeKafkaMessages(ctx context.Context, client *kgo.Client,
 			// Iterate on all messages from this poll
 			for !iter.Done() {
 				record := iter.Next()
-				partitionReq := consumeReq.Partitions[record.Partition]
+				partitionReq := partitionRequest(record.Topic, record.Partition)
 
-				if record.Offset > partitionReq.EndOffset {
+				if partitionReq == nil || record.Offset > partitionReq.EndOffset {
 					// reached end offset within this partition, we strive to fulfil the consume request so that we achieve
 					// equal distribution across the partitions
 					continue
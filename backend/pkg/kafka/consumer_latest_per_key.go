@@ -0,0 +1,191 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// latestPerKeyMaxKeys limits the number of distinct keys that are buffered while scanning
+// for the latest record per key, so that the memory usage of a single request is bounded.
+const latestPerKeyMaxKeys = 100_000
+
+// latestPerKeyMaxBytes limits the total size of the keys, values and headers of the buffered
+// records, because a few large records can exhaust the memory long before the key limit is
+// reached.
+const latestPerKeyMaxBytes = 256 << 20
+
+var (
+	errLatestPerKeyTooManyKeys = fmt.Errorf("the consumed offset ranges contain more than %d distinct keys", latestPerKeyMaxKeys)
+	errLatestPerKeyTooLarge    = fmt.Errorf("the latest records per key within the consumed offset ranges exceed %d MiB", latestPerKeyMaxBytes>>20)
+)
+
+// latestPerKeyBufferKey identifies a key within a partition. Null keys are told apart from
+// empty keys.
+type latestPerKeyBufferKey struct {
+	partitionID int32
+	isNull      bool
+	key         string
+}
+
+// latestPerKeyBuffer retains the most recent record of each key, like log compaction does.
+type latestPerKeyBuffer struct {
+	recordsByKey map[latestPerKeyBufferKey]*kgo.Record
+	// size is the total size of all buffered records
+	size int
+	// maxBytes is the limit of size
+	maxBytes int
+}
+
+func newLatestPerKeyBuffer() *latestPerKeyBuffer {
+	return &latestPerKeyBuffer{
+		recordsByKey: make(map[latestPerKeyBufferKey]*kgo.Record),
+		maxBytes:     latestPerKeyMaxBytes,
+	}
+}
+
+// bufferedRecordSize returns the number of bytes a buffered record holds on to.
+func bufferedRecordSize(record *kgo.Record) int {
+	size := len(record.Key) + len(record.Value)
+	for _, header := range record.Headers {
+		size += len(header.Key) + len(header.Value)
+	}
+	return size
+}
+
+// add replaces the buffered record with the same key if the given record is more recent. It
+// returns an error if the record has a new key but the buffer already holds
+// latestPerKeyMaxKeys, or if buffering the record would exceed the byte limit.
+func (b *latestPerKeyBuffer) add(record *kgo.Record) error {
+	key := latestPerKeyBufferKey{
+		partitionID: record.Partition,
+		isNull:      record.Key == nil,
+		key:         string(record.Key),
+	}
+	existing, exists := b.recordsByKey[key]
+	if !exists && len(b.recordsByKey) >= latestPerKeyMaxKeys {
+		return errLatestPerKeyTooManyKeys
+	}
+	if exists && record.Offset <= existing.Offset {
+		return nil
+	}
+
+	size := b.size + bufferedRecordSize(record)
+	if exists {
+		size -= bufferedRecordSize(existing)
+	}
+	if size > b.maxBytes {
+		return errLatestPerKeyTooLarge
+	}
+	b.recordsByKey[key] = record
+	b.size = size
+	return nil
+}
+
+// records returns the buffered records ordered by partition and offset. Tombstones are
+// omitted, as their keys have been deleted.
+func (b *latestPerKeyBuffer) records() []*kgo.Record {
+	records := make([]*kgo.Record, 0, len(b.recordsByKey))
+	for _, record := range b.recordsByKey {
+		if record.Value == nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Partition != records[j].Partition {
+			return records[i].Partition < records[j].Partition
+		}
+		return records[i].Offset < records[j].Offset
+	})
+	return records
+}
+
+// fetchLatestMessagesPerKey scans the requested offset ranges of all partitions and buffers the
+// most recent record of each key. Once all partitions have been scanned, the buffered records
+// are decoded and filtered like in FetchMessages. The consumed records are reported while
+// scanning, so that the progress is visible for large ranges.
+func (s *Service) fetchLatestMessagesPerKey(ctx context.Context, progress IListMessagesProgress, consumeReq TopicConsumeRequest) error {
+	// 1. Scan all partitions until their end offset is reached
+	client, err := s.newConsumeClient(consumeReq)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	scanCtx, cancelScan := context.WithCancel(ctx)
+	defer cancelScan()
+	scanned := make(chan *kgo.Record, 100)
+	go s.consumeKafkaMessages(scanCtx, client, consumeReq.partitionRequest, scanned)
+
+	buffer := newLatestPerKeyBuffer()
+	remainingPartitionRequests := len(consumeReq.Partitions)
+	for record := range scanned {
+		progress.OnMessageConsumed(int64(len(record.Key) + len(record.Value)))
+
+		// Control records are consumed as they may be the last record of a partition, but
+		// they are not returned
+		if !record.Attrs.IsControl() {
+			if err := buffer.add(record); err != nil {
+				return err
+			}
+		}
+
+		if record.Offset >= consumeReq.Partitions[record.Partition].EndOffset {
+			remainingPartitionRequests--
+		}
+		if remainingPartitionRequests == 0 {
+			break
+		}
+	}
+	cancelScan()
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	// 2. Decode and filter the latest records until the requested number of messages is reached
+	progress.OnPhase("Decoding latest messages per key")
+	jobs := make(chan *kgo.Record, 100)
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resultsCh, err := s.startMessageWorkers(workerCtx, progress, consumeReq, consumeReq.messageWorkerCount(), jobs)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer close(jobs)
+		for _, record := range buffer.records() {
+			select {
+			case <-workerCtx.Done():
+				return
+			case jobs <- record:
+			}
+		}
+	}()
+
+	messageCount := 0
+	for msg := range resultsCh {
+		if !msg.IsMessageOk {
+			continue
+		}
+		messageCount++
+		progress.OnMessage(msg)
+		if messageCount == consumeReq.MaxMessageCount {
+			return nil
+		}
+	}
+
+	return nil
+}
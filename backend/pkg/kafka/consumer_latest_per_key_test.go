@@ -0,0 +1,79 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestLatestPerKeyBuffer(t *testing.T) {
+	newRecord := func(partition int32, offset int64, key, value []byte) *kgo.Record {
+		return &kgo.Record{Partition: partition, Offset: offset, Key: key, Value: value}
+	}
+
+	buffer := newLatestPerKeyBuffer()
+	records := []*kgo.Record{
+		newRecord(0, 0, []byte("a"), []byte("a1")),
+		newRecord(0, 1, []byte("b"), []byte("b1")),
+		newRecord(0, 2, []byte("a"), []byte("a2")),
+		newRecord(0, 3, []byte("b"), nil), // Tombstone deletes b
+		newRecord(0, 4, []byte("c"), []byte("c1")),
+		newRecord(0, 5, []byte(""), []byte("empty key")),
+		newRecord(0, 6, nil, []byte("null key")),
+		newRecord(1, 0, []byte("a"), []byte("a1 in partition 1")),
+		newRecord(0, 7, []byte("c"), nil),
+		newRecord(0, 8, []byte("c"), []byte("c2")), // Recreated after deletion
+	}
+	for _, record := range records {
+		require.NoError(t, buffer.add(record))
+	}
+	// Records that are older than the buffered record are ignored
+	require.NoError(t, buffer.add(newRecord(0, 1, []byte("a"), []byte("a0"))))
+
+	var actual []string
+	for _, record := range buffer.records() {
+		actual = append(actual, strconv.Itoa(int(record.Partition))+"/"+strconv.FormatInt(record.Offset, 10)+"="+string(record.Value))
+	}
+	assert.Equal(t, []string{
+		"0/2=a2",
+		"0/5=empty key",
+		"0/6=null key",
+		"0/8=c2",
+		"1/0=a1 in partition 1",
+	}, actual)
+
+	t.Run("number of keys is limited", func(t *testing.T) {
+		buffer := newLatestPerKeyBuffer()
+		for i := 0; i < latestPerKeyMaxKeys; i++ {
+			require.NoError(t, buffer.add(newRecord(0, int64(i), []byte(strconv.Itoa(i)), []byte("v"))))
+		}
+		assert.ErrorIs(t, buffer.add(newRecord(0, latestPerKeyMaxKeys, []byte("new"), []byte("v"))), errLatestPerKeyTooManyKeys)
+		assert.NoError(t, buffer.add(newRecord(0, latestPerKeyMaxKeys, []byte("0"), []byte("v2"))), "known keys can still be updated")
+	})
+
+	t.Run("size of the buffered records is limited", func(t *testing.T) {
+		buffer := newLatestPerKeyBuffer()
+		buffer.maxBytes = 10
+
+		require.NoError(t, buffer.add(newRecord(0, 0, []byte("a"), []byte("1234"))))
+		require.NoError(t, buffer.add(newRecord(0, 1, []byte("b"), []byte("1234"))))
+		assert.ErrorIs(t, buffer.add(newRecord(0, 2, []byte("c"), []byte("1"))), errLatestPerKeyTooLarge)
+
+		// Replaced records no longer count towards the limit
+		require.NoError(t, buffer.add(newRecord(0, 3, []byte("a"), nil)))
+		assert.NoError(t, buffer.add(newRecord(0, 4, []byte("c"), []byte("1"))))
+		assert.Equal(t, 8, buffer.size)
+	})
+}
@@ -0,0 +1,265 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// mergeMaxBufferedRecords is the number of records that are buffered per partition while
+// waiting for records of other partitions. Fetching from a partition is paused once its
+// buffer is full and resumed once half of it has been merged.
+const mergeMaxBufferedRecords = 1000
+
+// MergedConsumeRequest defines the request parameters for consuming the messages of multiple
+// topics as a single stream that is ordered by timestamp.
+type MergedConsumeRequest struct {
+	// Topics are the topics along with their partitions to consume. Their MaxMessageCount
+	// and FilterInterpreterCode are ignored in favor of the merged request's.
+	Topics                []TopicConsumeRequest
+	MaxMessageCount       int
+	FilterInterpreterCode string
+
+	DeserializationOptions DeserializationOptions
+}
+
+// partitionRequest returns the consume request of the given topic partition, or nil if
+// the partition has not been requested.
+func (r MergedConsumeRequest) partitionRequest(topic string, partitionID int32) *PartitionConsumeRequest {
+	for _, topicReq := range r.Topics {
+		if topicReq.TopicName == topic {
+			return topicReq.Partitions[partitionID]
+		}
+	}
+	return nil
+}
+
+// mergePartition is the merge state of a single topic partition.
+type mergePartition struct {
+	topic string
+	req   *PartitionConsumeRequest
+	// buffered are the consumed records that have not been merged yet, in offset order.
+	buffered []*kgo.Record
+	// consumed is the number of records that have been buffered in total.
+	consumed int64
+	isDone   bool
+	isPaused bool
+}
+
+// recordMerger merges the records of multiple partitions by timestamp. A record can only
+// be merged once all partitions that may still yield records have buffered at least one,
+// as there's no way to know whether a partition's next record is older otherwise.
+type recordMerger struct {
+	partitions []*mergePartition
+	// limitPartitionRecords stops consuming a partition once it yielded the partition
+	// request's MaxMessageCount. Records that will be filtered must not be counted.
+	limitPartitionRecords bool
+	maxBufferedRecords    int
+}
+
+func newRecordMerger(req MergedConsumeRequest, maxBufferedRecords int) *recordMerger {
+	m := &recordMerger{
+		limitPartitionRecords: req.FilterInterpreterCode == "",
+		maxBufferedRecords:    maxBufferedRecords,
+	}
+	for _, topicReq := range req.Topics {
+		for _, partitionReq := range topicReq.Partitions {
+			m.partitions = append(m.partitions, &mergePartition{
+				topic: topicReq.TopicName,
+				req:   partitionReq,
+				// Empty partitions won't yield any record that would complete them
+				isDone: partitionReq.StartOffset > partitionReq.EndOffset,
+			})
+		}
+	}
+	return m
+}
+
+func (m *recordMerger) partition(topic string, partitionID int32) *mergePartition {
+	for _, p := range m.partitions {
+		if p.topic == topic && p.req.PartitionID == partitionID {
+			return p
+		}
+	}
+	return nil
+}
+
+// add buffers the consumed record. It returns true if fetching from the record's partition
+// shall be paused, because its buffer is full.
+func (m *recordMerger) add(record *kgo.Record) (pause bool) {
+	p := m.partition(record.Topic, record.Partition)
+	if p == nil || p.isDone {
+		return false
+	}
+
+	// Control records are consumed as they may be the last record of a partition, but
+	// they are not returned
+	if !record.Attrs.IsControl() {
+		p.buffered = append(p.buffered, record)
+		p.consumed++
+	}
+	if record.Offset >= p.req.EndOffset || (m.limitPartitionRecords && p.consumed >= p.req.MaxMessageCount) {
+		p.isDone = true
+	}
+
+	if !p.isDone && !p.isPaused && len(p.buffered) >= m.maxBufferedRecords {
+		p.isPaused = true
+		return true
+	}
+	return false
+}
+
+// next returns the oldest buffered record, if it can be merged already. The returned
+// partition is not nil if fetching from it shall be resumed.
+func (m *recordMerger) next() (record *kgo.Record, resume *mergePartition) {
+	var oldest *mergePartition
+	for _, p := range m.partitions {
+		if len(p.buffered) == 0 {
+			if !p.isDone {
+				return nil, nil
+			}
+			continue
+		}
+		if oldest == nil || isMergedBefore(p.buffered[0], oldest.buffered[0]) {
+			oldest = p
+		}
+	}
+	if oldest == nil {
+		return nil, nil
+	}
+
+	record = oldest.buffered[0]
+	oldest.buffered[0] = nil
+	oldest.buffered = oldest.buffered[1:]
+	if oldest.isPaused && len(oldest.buffered) <= m.maxBufferedRecords/2 {
+		oldest.isPaused = false
+		resume = oldest
+	}
+	return record, resume
+}
+
+// isDone returns true if all partitions are done and all their records have been merged.
+func (m *recordMerger) isDone() bool {
+	for _, p := range m.partitions {
+		if !p.isDone || len(p.buffered) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isMergedBefore orders records by timestamp. Records with the same timestamp are ordered
+// by topic, partition and offset, so that the merged order is deterministic.
+func isMergedBefore(a, b *kgo.Record) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	if a.Topic != b.Topic {
+		return a.Topic < b.Topic
+	}
+	if a.Partition != b.Partition {
+		return a.Partition < b.Partition
+	}
+	return a.Offset < b.Offset
+}
+
+// FetchMergedMessages consumes the requested partitions of multiple topics and streams their
+// messages as a single stream that is ordered by timestamp. Records are decoded by a single
+// worker, so that the merged order is retained. Live tail is not supported, because a
+// partition without new records would hold back the records of all other partitions.
+func (s *Service) FetchMergedMessages(ctx context.Context, progress IListMessagesProgress, mergeReq MergedConsumeRequest) error {
+	// 1. Assign the partitions of all topics with their start offsets and create client
+	partitionOffsets := make(map[string]map[int32]kgo.Offset)
+	for _, topicReq := range mergeReq.Topics {
+		partitionOffsets[topicReq.TopicName] = make(map[int32]kgo.Offset)
+		for _, req := range topicReq.Partitions {
+			partitionOffsets[topicReq.TopicName][req.PartitionID] = kgo.NewOffset().At(req.StartOffset)
+		}
+	}
+	client, err := s.NewKgoClient(kgo.ConsumePartitions(partitionOffsets))
+	if err != nil {
+		return fmt.Errorf("failed to create new kafka client: %w", err)
+	}
+	defer client.Close()
+
+	// 2. Create a single consumer worker, as multiple workers would lose the merged order
+	jobs := make(chan *kgo.Record, 100)
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workerReq := TopicConsumeRequest{
+		FilterInterpreterCode:  mergeReq.FilterInterpreterCode,
+		DeserializationOptions: mergeReq.DeserializationOptions,
+	}
+	resultsCh, err := s.startMessageWorkers(workerCtx, progress, workerReq, 1, jobs)
+	if err != nil {
+		return err
+	}
+
+	// 3. Consume the records of all partitions and merge them by timestamp into the jobs channel
+	consumed := make(chan *kgo.Record, 100)
+	go s.consumeKafkaMessages(workerCtx, client, mergeReq.partitionRequest, consumed)
+	go s.mergeKafkaMessages(workerCtx, client, newRecordMerger(mergeReq, mergeMaxBufferedRecords), consumed, jobs)
+
+	// 4. Receive decoded messages until the requested number of messages is reached
+	messageCount := 0
+	for msg := range resultsCh {
+		progress.OnMessageConsumed(msg.MessageSize)
+		if !msg.IsMessageOk {
+			continue
+		}
+		messageCount++
+		progress.OnMessage(msg)
+		if messageCount == mergeReq.MaxMessageCount {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// mergeKafkaMessages buffers the consumed records and sends them to the jobs channel in merged
+// order. Fetching from partitions with full buffers is paused until their records have been
+// merged. This function closes the jobs channel once all partitions are done.
+func (*Service) mergeKafkaMessages(ctx context.Context, client *kgo.Client, merger *recordMerger, consumed <-chan *kgo.Record, jobs chan<- *kgo.Record) {
+	defer close(jobs)
+
+	for !merger.isDone() {
+		select {
+		case <-ctx.Done():
+			return
+		case record, ok := <-consumed:
+			if !ok {
+				return
+			}
+			if merger.add(record) {
+				client.PauseFetchPartitions(map[string][]int32{record.Topic: {record.Partition}})
+			}
+		}
+
+		for {
+			record, resume := merger.next()
+			if record == nil {
+				break
+			}
+			if resume != nil {
+				client.ResumeFetchPartitions(map[string][]int32{resume.topic: {resume.req.PartitionID}})
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- record:
+			}
+		}
+	}
+}
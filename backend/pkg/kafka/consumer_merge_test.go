@@ -0,0 +1,118 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestRecordMerger(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	newRecord := func(topic string, partition int32, offset int64, tsSeconds int) *kgo.Record {
+		return &kgo.Record{Topic: topic, Partition: partition, Offset: offset, Timestamp: base.Add(time.Duration(tsSeconds) * time.Second)}
+	}
+	recordID := func(record *kgo.Record) string {
+		return record.Topic + "/" + strconv.Itoa(int(record.Partition)) + "/" + strconv.FormatInt(record.Offset, 10)
+	}
+	mergeReq := func(filterCode string) MergedConsumeRequest {
+		return MergedConsumeRequest{
+			FilterInterpreterCode: filterCode,
+			Topics: []TopicConsumeRequest{
+				{TopicName: "orders", Partitions: map[int32]*PartitionConsumeRequest{
+					0: {PartitionID: 0, StartOffset: 0, EndOffset: 2, MaxMessageCount: 3},
+				}},
+				{TopicName: "payments", Partitions: map[int32]*PartitionConsumeRequest{
+					0: {PartitionID: 0, StartOffset: 0, EndOffset: 1, MaxMessageCount: 2},
+					1: {PartitionID: 1, StartOffset: 5, EndOffset: 4, MaxMessageCount: 0}, // empty
+				}},
+			},
+		}
+	}
+	drain := func(m *recordMerger) []string {
+		var merged []string
+		for {
+			record, _ := m.next()
+			if record == nil {
+				return merged
+			}
+			merged = append(merged, recordID(record))
+		}
+	}
+
+	t.Run("records are merged by timestamp", func(t *testing.T) {
+		m := newRecordMerger(mergeReq(""), 10)
+
+		m.add(newRecord("orders", 0, 0, 1))
+		m.add(newRecord("orders", 0, 1, 4))
+		assert.Empty(t, drain(m), "nothing can be merged before all partitions have records")
+
+		m.add(newRecord("payments", 0, 0, 2))
+		assert.Equal(t, []string{"orders/0/0", "payments/0/0"}, drain(m))
+
+		m.add(newRecord("payments", 0, 1, 4)) // Same timestamp as orders/0/1
+		assert.Equal(t, []string{"orders/0/1"}, drain(m))
+		assert.False(t, m.isDone())
+
+		m.add(newRecord("orders", 0, 2, 3)) // Timestamps are not necessarily increasing
+		assert.Equal(t, []string{"orders/0/2", "payments/0/1"}, drain(m))
+		assert.True(t, m.isDone())
+	})
+
+	t.Run("partitions are done after max message count", func(t *testing.T) {
+		req := mergeReq("")
+		req.Topics[0].Partitions[0].MaxMessageCount = 1
+		m := newRecordMerger(req, 10)
+		m.add(newRecord("orders", 0, 0, 1))
+		m.add(newRecord("payments", 0, 0, 2))
+		m.add(newRecord("payments", 0, 1, 3))
+		assert.Equal(t, []string{"orders/0/0", "payments/0/0", "payments/0/1"}, drain(m))
+		assert.True(t, m.isDone())
+
+		// Records are filtered after merging, so that all records up to the end offset are merged
+		m = newRecordMerger(mergeReq("return true"), 10)
+		m.add(newRecord("orders", 0, 0, 1))
+		m.add(newRecord("payments", 0, 0, 2))
+		m.add(newRecord("payments", 0, 1, 3))
+		assert.Equal(t, []string{"orders/0/0"}, drain(m))
+		assert.False(t, m.isDone())
+	})
+
+	t.Run("full partitions are paused", func(t *testing.T) {
+		req := mergeReq("")
+		req.Topics[0].Partitions[0].EndOffset = 100
+		req.Topics[0].Partitions[0].MaxMessageCount = 100
+		m := newRecordMerger(req, 4)
+
+		for i := 0; i < 3; i++ {
+			require.False(t, m.add(newRecord("orders", 0, int64(i), i)))
+		}
+		require.True(t, m.add(newRecord("orders", 0, 3, 3)), "the buffer is full")
+		require.False(t, m.add(newRecord("orders", 0, 4, 4)), "records that are already fetched are still buffered")
+
+		m.add(newRecord("payments", 0, 0, 10))
+		var resumed *mergePartition
+		for i := 0; i < 3; i++ {
+			record, resume := m.next()
+			require.NotNil(t, record)
+			if resume != nil {
+				resumed = resume
+				assert.Equal(t, 2, i, "resumed once half of the buffer has been merged")
+			}
+		}
+		require.NotNil(t, resumed)
+		assert.Equal(t, "orders", resumed.topic)
+	})
+}
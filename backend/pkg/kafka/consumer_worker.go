@@ -19,7 +19,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, isMessageOK isMessageOkFunc, jobs <-chan *kgo.Record, resultsCh chan<- *TopicMessage) {
+func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, isMessageOK isMessageOkFunc, deserializationOpts DeserializationOptions, jobs <-chan *kgo.Record, resultsCh chan<- *TopicMessage) {
 	defer wg.Done()
 	defer func() {
 		if r := recover(); r != nil {
@@ -34,6 +34,7 @@ func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, is
 		isControlRecord := record.Attrs.IsControl()
 		if isControlRecord {
 			topicMessage := &TopicMessage{
+				TopicName:   record.Topic,
 				PartitionID: record.Partition,
 				Offset:      record.Offset,
 				Timestamp:   record.Timestamp.UnixNano() / int64(time.Millisecond),
@@ -50,7 +51,7 @@ func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, is
 		}
 
 		// Run Interpreter filter and check if message passes the filter
-		deserializedRec := s.Deserializer.DeserializeRecord(record)
+		deserializedRec := s.Deserializer.DeserializeRecord(record, deserializationOpts)
 
 		headersByKey := make(map[string]interface{}, len(deserializedRec.Headers))
 		headers := make([]MessageHeader, 0)
@@ -80,6 +81,7 @@ func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, is
 		}
 
 		topicMessage := &TopicMessage{
+			TopicName:       record.Topic,
 			PartitionID:     record.Partition,
 			Offset:          record.Offset,
 			Timestamp:       record.Timestamp.UnixNano() / int64(time.Millisecond),
@@ -88,10 +90,15 @@ func (s *Service) startMessageWorker(ctx context.Context, wg *sync.WaitGroup, is
 			IsTransactional: record.Attrs.IsTransactional(),
 			Key:             deserializedRec.Key,
 			Value:           deserializedRec.Value,
+			Tags:            deserializedRec.Tags,
 			IsMessageOk:     isOK,
 			ErrorMessage:    errMessage,
 			MessageSize:     int64(len(record.Key) + len(record.Value)),
 		}
+		if deserializationOpts.IncludeDecodeDuration {
+			decodeDurationMs := float64(deserializedRec.DecodeDuration) / float64(time.Millisecond)
+			topicMessage.DecodeDurationMs = &decodeDurationMs
+		}
 
 		select {
 		case <-ctx.Done():
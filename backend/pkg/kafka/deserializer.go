@@ -16,8 +16,10 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	xj "github.com/basgys/goxml2json"
@@ -38,6 +40,28 @@ type deserializer struct {
 	SchemaService  *schema.Service
 	ProtoService   *proto.Service
 	MsgPackService *kmsgpack.Service
+
+	// cborTopics are the topics whose payloads are decoded as CBOR without the self-described
+	// CBOR tag. Untagged CBOR isn't decoded for any topic if nil.
+	cborTopics []*regexp.Regexp
+
+	// metrics records the decode attempts of each serde. It's a no-op if nil.
+	metrics *serdeMetrics
+
+	// decoders overrides the default chain of payload decoders if set.
+	decoders []payloadDecoder
+
+	// decoderChain reorders and filters the decoders that are tried during auto-detection.
+	decoderChain decoderChain
+
+	// topicDecoders force a single decoder for matching topics, see payloadDecodersForTopic.
+	topicDecoders []topicDecoder
+
+	// compositeKeys are the layouts of keys that are decoded by decodeCompositeKey.
+	compositeKeys []compositeKeyLayout
+
+	// customSerdes are inserted into the default chain of payload decoders.
+	customSerdes []SerdeRegistration
 }
 
 type messageEncoding string
@@ -51,10 +75,14 @@ const (
 	messageEncodingText                 messageEncoding = "text"
 	messageEncodingUtf8WithControlChars messageEncoding = "utf8WithControlChars"
 	messageEncodingConsumerOffsets      messageEncoding = "consumerOffsets"
+	messageEncodingAuditLog             messageEncoding = "auditLog"
 	messageEncodingBinary               messageEncoding = "binary"
 	messageEncodingMsgP                 messageEncoding = "msgpack"
 	messageEncodingSmile                messageEncoding = "smile"
+	messageEncodingCBOR                 messageEncoding = "cbor"
 	messageEncodingUint                 messageEncoding = "uint"
+	messageEncodingSkipped              messageEncoding = "skipped"
+	messageEncodingCompositeKey         messageEncoding = "compositeKey"
 )
 
 // normalizedPayload is a wrapper of the original message with the purpose of having a custom JSON marshal method
@@ -68,7 +96,7 @@ type normalizedPayload struct {
 // We do this because we want to pass the deserialized payload as JavaScript object (regardless of the encoding) to the frontend.
 func (d *normalizedPayload) MarshalJSON() ([]byte, error) {
 	switch d.RecognizedEncoding {
-	case messageEncodingNone:
+	case messageEncodingNone, messageEncodingSkipped:
 		return []byte("{}"), nil
 	case messageEncodingText:
 		return json.Marshal(string(d.Payload))
@@ -80,6 +108,20 @@ func (d *normalizedPayload) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// isStructuredEncoding returns true if the normalized payload of the encoding is a JSON
+// document that has been decoded from the record, e.g. from Avro, CBOR or a composite key.
+// Post-processing steps that operate on decoded fields must skip all other encodings, as
+// their normalized payload is empty, the raw text or the raw bytes.
+func isStructuredEncoding(encoding messageEncoding) bool {
+	switch encoding {
+	case messageEncodingNone, messageEncodingSkipped, messageEncodingText, messageEncodingBinary,
+		messageEncodingUtf8WithControlChars, messageEncodingUint:
+		return false
+	default:
+		return true
+	}
+}
+
 type deserializedPayload struct {
 	Payload       normalizedPayload `json:"payload"`
 	IsPayloadNull bool              `json:"isPayloadNull"`
@@ -89,12 +131,60 @@ type deserializedPayload struct {
 	RecognizedEncoding messageEncoding `json:"encoding"`
 	SchemaID           uint32          `json:"schemaId"`
 	Size               int             `json:"size"` // number of 'raw' bytes
+	// NormalizedSize is the number of bytes of the serialized normalized payload, e.g. of the
+	// JSON that an Avro record has been decoded into. It's 0 if there is no normalized payload.
+	NormalizedSize int `json:"normalizedSize"`
+
+	// SchemaSubject and SchemaVersion identify the subject version of the schema, if requested
+	// via the deserialization options.
+	SchemaSubject string `json:"schemaSubject,omitempty"`
+	SchemaVersion int    `json:"schemaVersion,omitempty"`
+
+	// Compression is the compression of the payload (e.g. snappy), if it had to be decompressed
+	// before it could be decoded with the recognized encoding. UncompressedSize is the size of
+	// the decompressed payload, whereas Size remains the size of the compressed payload.
+	Compression      string `json:"compression,omitempty"`
+	UncompressedSize int    `json:"uncompressedSize,omitempty"`
+
+	// InvalidUTF8Replaced is set if invalid UTF-8 sequences in a text payload have been
+	// replaced with U+FFFD, which is only done if requested via the deserialization options.
+	InvalidUTF8Replaced bool `json:"invalidUtf8Replaced,omitempty"`
+
+	// Truncated is set if the payload exceeds the preview limit of the deserialization options.
+	// Either only a prefix of the payload has been decoded, or nothing but its metadata.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Debezium is the normalized view of a Debezium change event. It's only set if requested
+	// via the deserialization options and the payload is a Debezium envelope.
+	Debezium *debeziumChange `json:"debezium,omitempty"`
+
+	// FieldDocs are the doc strings of the Avro schema, keyed by the path of the documented
+	// field in the decoded payload. They are only set if requested via the deserialization
+	// options.
+	FieldDocs map[string]string `json:"fieldDocs,omitempty"`
+
+	// Troubleshooting explains why decoders that may have been expected to decode the
+	// payload did not succeed.
+	Troubleshooting []troubleshootingReport `json:"troubleshooting,omitempty"`
+}
+
+// troubleshootingReport is a user-facing note about a decoder that could not decode a payload.
+type troubleshootingReport struct {
+	SerdeName string `json:"serdeName"`
+	Message   string `json:"message"`
 }
 
 type deserializedRecord struct {
 	Key     *deserializedPayload
 	Value   *deserializedPayload
 	Headers map[string]*deserializedPayload
+
+	// Tags of all tag rules of the deserialization options that match the record.
+	Tags []string
+
+	// DecodeDuration is the time spent on deserializing the record, including all
+	// post-processing steps. It's only measured if requested by the options.
+	DecodeDuration time.Duration
 }
 
 // DeserializeRecord tries to deserialize a whole record.
@@ -104,24 +194,118 @@ type deserializedRecord struct {
 //   - Binary content
 //
 // Idea: Add encoding hint where user can suggest the backend to test this encoding first.
-func (d *deserializer) DeserializeRecord(record *kgo.Record) *deserializedRecord {
+func (d *deserializer) DeserializeRecord(record *kgo.Record, opts DeserializationOptions) *deserializedRecord {
+	var start time.Time
+	if opts.IncludeDecodeDuration {
+		start = time.Now()
+	}
+	if opts.DecodeBudgetMs > 0 {
+		opts.deadline = time.Now().Add(time.Duration(opts.DecodeBudgetMs) * time.Millisecond)
+	}
+
+	rec := d.deserializeRecord(record, opts)
+	patterns := opts.parsedPatterns()
+	if opts.AvroDocs {
+		// Docs are collected before any post-processing step modifies the decoded objects
+		d.addAvroDocsRecord(rec)
+	}
+	if len(patterns.nestedBytesFields) > 0 {
+		d.decodeNestedBytesRecord(rec, record, patterns.nestedBytesFields, opts)
+	}
+	if opts.CoerceNumericStrings {
+		coerceNumericStringsRecord(rec)
+	}
+	if opts.PreciseJSONIntegers {
+		preciseIntegersRecord(rec)
+	}
+	if len(patterns.redact) > 0 {
+		redactDeserializedRecord(rec, patterns.redact)
+	}
+	if len(patterns.tagRules) > 0 {
+		// Rules are evaluated after redaction, so that they can't be used to probe redacted values
+		rec.Tags = tagDeserializedRecord(rec, patterns.tagRules)
+	}
+	if opts.Debezium {
+		debeziumDeserializedRecord(rec)
+	}
+	if opts.FlattenPayload {
+		flattenDeserializedRecord(rec)
+	}
+
+	// The post-processing steps may have modified the normalized payloads
+	rec.Key.setNormalizedSize()
+	rec.Value.setNormalizedSize()
+	for _, header := range rec.Headers {
+		header.setNormalizedSize()
+	}
+	if opts.IncludeDecodeDuration {
+		rec.DecodeDuration = time.Since(start)
+	}
+	return rec
+}
+
+func (d *deserializer) deserializeRecord(record *kgo.Record, opts DeserializationOptions) *deserializedRecord {
+	if opts.HeadersOnly {
+		return &deserializedRecord{
+			Key:     newSkippedPayload(record.Key),
+			Value:   newSkippedPayload(record.Value),
+			Headers: d.deserializeHeaders(record, opts),
+		}
+	}
+
 	// 1. Test if it's a known binary Format
 	if record.Topic == "__consumer_offsets" {
-		rec, err := d.deserializeConsumerOffset(record)
+		rec, err := d.deserializeConsumerOffset(record, opts)
+		if err == nil {
+			return rec
+		}
+	}
+	if record.Topic == auditLogTopicName {
+		rec, err := d.deserializeAuditLogRecord(record, opts)
 		if err == nil {
 			return rec
 		}
 	}
 
-	headers := make(map[string]*deserializedPayload)
-	for _, header := range record.Headers {
-		headers[header.Key] = d.deserializePayload(header.Value, record.Topic, proto.RecordValue)
+	// Key and value are deserialized before the headers, so that they are preferred if the
+	// decode budget is limited.
+	key := d.decodeSchemaIDHeader(record, proto.RecordKey, opts)
+	if key == nil {
+		key = d.deserializePayload(record.Key, record.Topic, proto.RecordKey, opts)
+	}
+	value := d.decodeAvroFingerprintHeader(record, opts)
+	if value == nil {
+		value = d.decodeSchemaIDHeader(record, proto.RecordValue, opts)
+	}
+	if value == nil {
+		value = d.deserializePayload(record.Value, record.Topic, proto.RecordValue, opts)
 	}
 	return &deserializedRecord{
-		Key:     d.deserializePayload(record.Key, record.Topic, proto.RecordKey),
-		Value:   d.deserializePayload(record.Value, record.Topic, proto.RecordValue),
-		Headers: headers,
+		Key:     key,
+		Value:   value,
+		Headers: d.deserializeHeaders(record, opts),
+	}
+}
+
+// deserializeHeaders deserializes all record headers. Headers are decoded with all decoders,
+// unless they shall be returned as text.
+func (d *deserializer) deserializeHeaders(record *kgo.Record, opts DeserializationOptions) map[string]*deserializedPayload {
+	// Forced encodings apply to keys and values only
+	opts.KeyEncoding, opts.ValueEncoding = "", ""
+	headers := make(map[string]*deserializedPayload)
+	for _, header := range record.Headers {
+		if opts.HeadersAsText {
+			in := payloadDecoderInput{Payload: header.Value, TopicName: record.Topic, RecordType: proto.RecordValue, Opts: opts}
+			if dp := d.decodeUTF8(in); dp != nil {
+				headers[header.Key] = dp
+				continue
+			}
+			headers[header.Key] = newBinaryPayload(header.Value)
+			continue
+		}
+		headers[header.Key] = d.deserializePayload(header.Value, record.Topic, proto.RecordValue, opts)
 	}
+	return headers
 }
 
 // deserializePayload tries to deserialize a binary payload into a human-readable format,
@@ -130,10 +314,147 @@ func (d *deserializer) DeserializeRecord(record *kgo.Record) *deserializedRecord
 // type (JSON, Text, XML, Protobuf, Avro etc) by trying to decode each message into
 // the respective type. If none matches, we return the binary content as is and it
 // will be displayed as hex string in the frontend.
-//
-//nolint:gocognit,cyclop,gocyclo // This function should be refactored and broken into multiple functions
-func (d *deserializer) deserializePayload(payload []byte, topicName string, recordType proto.RecordPropertyType) *deserializedPayload {
+func (d *deserializer) deserializePayload(payload []byte, topicName string, recordType proto.RecordPropertyType, opts DeserializationOptions) *deserializedPayload {
+	if opts.PreviewBytes > 0 && len(payload) > opts.PreviewBytes {
+		dp := d.decodePreview(payload, topicName, recordType, opts)
+		dp.setNormalizedSize()
+		return dp
+	}
+
+	// Payloads that are forced to be binary haven't been tried by any decoder
+	forcedBinary := opts.forcedEncoding(recordType) == topicDecoderBinary
+	var troubleshooting []troubleshootingReport
+	if !forcedBinary {
+		troubleshooting = d.schemaTypeTroubleshooting(payload)
+	}
+
+	dp := d.decodePayload(payload, topicName, recordType, opts)
+	if dp.SchemaID == 0 {
+		// A missing schema registry is only worth reporting if no other decoder could make
+		// sense of the payload either, so that it's shown as raw bytes
+		fellBack := dp.Payload.RecognizedEncoding == messageEncodingBinary ||
+			dp.Payload.RecognizedEncoding == messageEncodingUtf8WithControlChars
+		if !forcedBinary && fellBack {
+			dp.Troubleshooting = append(dp.Troubleshooting, d.schemaRegistryTroubleshooting(payload)...)
+		}
+		dp.Troubleshooting = append(dp.Troubleshooting, troubleshooting...)
+	}
+	if opts.IncludeSchemaVersion && dp.SchemaID != 0 {
+		d.addSchemaSubjectVersion(dp, topicName, recordType)
+	}
+	dp.setNormalizedSize()
+	return dp
+}
+
+// setNormalizedSize sets the size of the serialized normalized payload. It must be called
+// again whenever the normalized payload is modified.
+func (dp *deserializedPayload) setNormalizedSize() {
+	if dp == nil {
+		return
+	}
+	switch dp.Payload.RecognizedEncoding {
+	case messageEncodingNone, messageEncodingSkipped:
+		dp.NormalizedSize = 0
+		return
+	}
+	serialized, err := dp.Payload.MarshalJSON()
+	if err != nil {
+		dp.NormalizedSize = 0
+		return
+	}
+	dp.NormalizedSize = len(serialized)
+}
+
+// addSchemaSubjectVersion adds the subject version of the payload's schema. If the schema is
+// used by multiple subjects, the subject of the topic name strategy is preferred.
+func (d *deserializer) addSchemaSubjectVersion(dp *deserializedPayload, topicName string, recordType proto.RecordPropertyType) {
+	if d.SchemaService == nil {
+		return
+	}
+	usages, err := d.SchemaService.GetCachedSchemaUsagesByID(context.Background(), dp.SchemaID)
+	if err != nil || len(usages) == 0 {
+		return
+	}
+
+	topicSubject, _ := schema.TopicNameStrategy.SubjectName(topicName, recordType == proto.RecordKey, "")
+	usage := usages[0]
+	for _, candidate := range usages {
+		if candidate.Subject == topicSubject {
+			usage = candidate
+			break
+		}
+	}
+	dp.SchemaSubject = usage.Subject
+	dp.SchemaVersion = usage.Version
+}
+
+// payloadDecoderInput is passed to each payloadDecoder.
+type payloadDecoderInput struct {
+	Payload []byte
+	// Trimmed is the payload without leading whitespaces. It's never empty.
+	Trimmed    []byte
+	TopicName  string
+	RecordType proto.RecordPropertyType
+	Opts       DeserializationOptions
+}
+
+// payloadDecoder is a single step in the chain of decoders that are tried one after
+// another. Decode returns nil if the payload could not be decoded.
+type payloadDecoder struct {
+	Name   string
+	Decode func(in payloadDecoderInput) *deserializedPayload
+}
+
+// payloadDecoders returns the chain of decoders in the order they are tried.
+func (d *deserializer) payloadDecoders() []payloadDecoder {
+	return d.decoderChain.apply(d.allPayloadDecoders())
+}
+
+// allPayloadDecoders returns all decoders in their default order, including the ones that
+// the decoder chain disables.
+func (d *deserializer) allPayloadDecoders() []payloadDecoder {
+	if d.decoders != nil {
+		return d.decoders
+	}
+	return d.withCustomSerdes([]payloadDecoder{
+		{Name: "compositeKey", Decode: d.decodeCompositeKey},
+		{Name: "schemaless", Decode: d.decodeSchemaless},
+		{Name: "json", Decode: d.decodeJSON},
+		{Name: "varintSchemaId", Decode: d.decodeVarintSchemaID},
+		{Name: "trailingSchemaId", Decode: d.decodeTrailingSchemaID},
+		{Name: "gzipNdjson", Decode: d.decodeGzipNDJSON},
+		{Name: "compressed", Decode: d.decodeCompressed},
+		{Name: "jsonSchema", Decode: d.decodeJSONSchema},
+		{Name: "xml", Decode: d.decodeXML},
+		{Name: "avroContainerFile", Decode: d.decodeAvroOCF},
+		{Name: "avroSingleObject", Decode: d.decodeAvroSingleObject},
+		{Name: "avro", Decode: d.decodeAvro},
+		{Name: "protobuf", Decode: d.decodeProtobuf},
+		{Name: "msgpack", Decode: d.decodeMsgPack},
+		{Name: "cbor", Decode: d.decodeCBOR},
+		{Name: "smile", Decode: d.decodeSmile},
+		{Name: "utf8", Decode: d.decodeUTF8},
+		{Name: "uint", Decode: d.decodeUint},
+	})
+}
+
+// decodePayload tries all decoders one after another and returns the first successful result.
+// If no decoder succeeds the payload is returned as binary.
+func (d *deserializer) decodePayload(payload []byte, topicName string, recordType proto.RecordPropertyType, opts DeserializationOptions) *deserializedPayload {
 	// 0. Check if payload is empty / whitespace only
+	if len(payload) == 0 && payload != nil && opts.EmptyPayloadAsText {
+		return &deserializedPayload{
+			Payload: normalizedPayload{
+				Payload:            payload,
+				RecognizedEncoding: messageEncodingText,
+			},
+			IsPayloadNull:      false,
+			Object:             "",
+			RecognizedEncoding: messageEncodingText,
+			Size:               0,
+		}
+	}
+
 	if len(payload) == 0 {
 		return &deserializedPayload{
 			Payload: normalizedPayload{
@@ -161,197 +482,435 @@ func (d *deserializer) deserializePayload(payload []byte, topicName string, reco
 		}
 	}
 
-	// 1. Test for valid JSON
-	startsWithJSON := trimmed[0] == '[' || trimmed[0] == '{'
-	if startsWithJSON {
-		var obj interface{}
-		err := json.Unmarshal(payload, &obj)
-		if err == nil {
-			return &deserializedPayload{
-				Payload: normalizedPayload{
-					Payload:            trimmed,
-					RecognizedEncoding: messageEncodingJSON,
-				},
-				IsPayloadNull:      payload == nil,
-				Object:             obj,
-				RecognizedEncoding: messageEncodingJSON,
-				Size:               len(payload),
-			}
-		}
+	in := payloadDecoderInput{
+		Payload:    payload,
+		Trimmed:    trimmed,
+		TopicName:  topicName,
+		RecordType: recordType,
+		Opts:       opts,
+	}
+	decoders := d.payloadDecodersForTopic(topicName)
+	forcedEncoding := opts.forcedEncoding(recordType)
+	if forcedEncoding != "" {
+		decoders = d.forcedPayloadDecoders(forcedEncoding)
 	}
 
-	// 2. Test for json schema
-	if d.SchemaService != nil && len(payload) > 5 && payload[0] == byte(0) {
-		// TODO: For more confidence we could just ask the schema service for the given
-		// schema and based on the response we can check the schema type (avro, json, ..)
-		schemaID := binary.BigEndian.Uint32(payload[1:5])
-		trimmed := payload[5:]
-		startsWithJSON := trimmed[0] == '[' || trimmed[0] == '{'
-		if startsWithJSON {
-			var obj interface{}
-			err := json.Unmarshal(payload[5:], &obj)
-			if err == nil {
-				return &deserializedPayload{
-					Payload: normalizedPayload{
-						Payload:            trimmed,
-						RecognizedEncoding: messageEncodingJSON,
-					},
-					IsPayloadNull:      payload == nil,
-					Object:             obj,
-					RecognizedEncoding: messageEncodingJSON,
-					SchemaID:           schemaID,
-					Size:               len(payload),
-				}
+	var skippedDecoders []string
+	for _, decoder := range decoders {
+		if d.skipForLargePayload(decoder.Name, in) {
+			skippedDecoders = append(skippedDecoders, decoder.Name)
+			fallback, ok := d.largePayloadFallback(decoder.Name)
+			if !ok {
+				continue
 			}
+			decoder = fallback
+		}
+		if opts.decodeBudgetExceeded() {
+			dp := newBinaryPayload(payload)
+			dp.Troubleshooting = []troubleshootingReport{{
+				SerdeName: decoder.Name,
+				Message: fmt.Sprintf("decode budget of %dms for this record has been exceeded, "+
+					"remaining decoders have been skipped", opts.DecodeBudgetMs),
+			}}
+			return dp
+		}
+		start := time.Now()
+		dp := decoder.Decode(in)
+		d.metrics.observe(decoder.Name, dp != nil, time.Since(start))
+		if dp != nil {
+			return dp
 		}
 	}
 
-	// 3. Test for valid XML
-	startsWithXML := trimmed[0] == '<'
-	if startsWithXML {
-		r := strings.NewReader(string(trimmed))
-		jsonPayload, err := xj.Convert(r)
-		if err == nil {
-			var obj interface{}
-			_ = json.Unmarshal(jsonPayload.Bytes(), &obj) // no err possible unless the xml2json package is buggy
-			return &deserializedPayload{
-				Payload: normalizedPayload{
-					Payload:            jsonPayload.Bytes(),
-					RecognizedEncoding: messageEncodingXML,
-				},
-				IsPayloadNull:      payload == nil,
-				Object:             obj,
-				RecognizedEncoding: messageEncodingXML,
-				Size:               len(payload),
-			}
-		}
+	// Anything else is considered as binary content
+	dp := newBinaryPayload(payload)
+	switch {
+	case len(skippedDecoders) > 0:
+		dp.Troubleshooting = []troubleshootingReport{largePayloadTroubleshooting(len(payload), skippedDecoders, opts)}
+	case forcedEncoding != "" && forcedEncoding != topicDecoderBinary:
+		dp.Troubleshooting = []troubleshootingReport{{
+			SerdeName: forcedEncoding,
+			Message:   fmt.Sprintf("payload could not be decoded as %s, which has been requested as encoding", forcedEncoding),
+		}}
 	}
+	return dp
+}
 
-	// 4. Test for Avro (reference: https://docs.confluent.io/current/schema-registry/serdes-develop/index.html#wire-format)
-	if d.SchemaService != nil && len(payload) > 5 {
-		// Check if magic byte is set
-		if payload[0] == byte(0) {
-			schemaID := binary.BigEndian.Uint32(payload[1:5])
-
-			schema, err := d.SchemaService.GetAvroSchemaByID(context.Background(), schemaID)
-			if err == nil {
-				var obj interface{}
-				if err := avro.Unmarshal(schema, payload[5:], &obj); err == nil {
-					jsonBytes, _ := json.Marshal(obj)
-					return &deserializedPayload{
-						Payload: normalizedPayload{
-							Payload:            jsonBytes,
-							RecognizedEncoding: messageEncodingAvro,
-						},
-						IsPayloadNull:      payload == nil,
-						Object:             obj,
-						RecognizedEncoding: messageEncodingAvro,
-						SchemaID:           schemaID,
-						Size:               len(payload),
-					}
-				}
-			}
-		}
+// newSkippedPayload returns a placeholder for a payload that has not been decoded.
+func newSkippedPayload(payload []byte) *deserializedPayload {
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			RecognizedEncoding: messageEncodingSkipped,
+		},
+		IsPayloadNull:      payload == nil,
+		RecognizedEncoding: messageEncodingSkipped,
+		Size:               len(payload),
 	}
+}
 
-	// 5. Test for Protobuf
-	if d.ProtoService != nil {
-		jsonBytes, schemaID, err := d.ProtoService.UnmarshalPayload(payload, topicName, recordType)
-		if err == nil {
-			var native interface{}
-			err := json.Unmarshal(jsonBytes, &native)
-			if err == nil {
-				return &deserializedPayload{
-					Payload: normalizedPayload{
-						Payload:            jsonBytes,
-						RecognizedEncoding: messageEncodingProtobuf,
-					},
-					IsPayloadNull:      payload == nil,
-					Object:             native,
-					RecognizedEncoding: messageEncodingProtobuf,
-					SchemaID:           uint32(schemaID),
-					Size:               len(payload),
-				}
-			}
-		}
+func newBinaryPayload(payload []byte) *deserializedPayload {
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            payload,
+			RecognizedEncoding: messageEncodingBinary,
+		},
+		IsPayloadNull:      payload == nil,
+		Object:             payload,
+		RecognizedEncoding: messageEncodingBinary,
+		Size:               len(payload),
 	}
+}
 
-	// 6. Test for MessagePack (only if enabled and topic allowed)
-	if d.MsgPackService != nil && d.MsgPackService.IsTopicAllowed(topicName) {
-		var obj interface{}
-		err := msgpack.Unmarshal(payload, &obj)
-		if err == nil {
-			data, err := json.Marshal(obj)
-			if err == nil {
-				return &deserializedPayload{
-					Payload: normalizedPayload{
-						Payload:            data,
-						RecognizedEncoding: messageEncodingMsgP,
-					},
-					IsPayloadNull:      payload == nil,
-					Object:             string(payload),
-					RecognizedEncoding: messageEncodingMsgP,
-					Size:               len(payload),
-				}
-			}
-		}
+// decodeJSON tests for valid JSON.
+func (*deserializer) decodeJSON(in payloadDecoderInput) *deserializedPayload {
+	startsWithJSON := in.Trimmed[0] == '[' || in.Trimmed[0] == '{'
+	if !startsWithJSON {
+		return nil
 	}
 
-	// 7. Test for valid Smile
+	var obj interface{}
+	if err := json.Unmarshal(in.Payload, &obj); err != nil {
+		return nil
+	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            in.Trimmed,
+			RecognizedEncoding: messageEncodingJSON,
+		},
+		IsPayloadNull:      in.Payload == nil,
+		Object:             obj,
+		RecognizedEncoding: messageEncodingJSON,
+		Size:               len(in.Payload),
+	}
+}
+
+// decodeJSONSchema tests for JSON that is prefixed with the schema registry wire format header.
+func (d *deserializer) decodeJSONSchema(in payloadDecoderInput) *deserializedPayload {
+	payload := in.Payload
+	if d.SchemaService == nil || len(payload) <= 5 || payload[0] != byte(0) {
+		return nil
+	}
+
+	return d.decodeJSONWithSchemaID(payload, binary.BigEndian.Uint32(payload[1:5]), payload[5:])
+}
+
+// decodeJSONWithSchemaID decodes the JSON body of a payload that has been validated with the
+// JSON schema of the given ID.
+func (d *deserializer) decodeJSONWithSchemaID(payload []byte, schemaID uint32, body []byte) *deserializedPayload {
+	startsWithJSON := len(body) > 0 && (body[0] == '[' || body[0] == '{')
+	if !startsWithJSON || d.schemaTypeMismatches(schemaID, schema.TypeJSON) {
+		return nil
+	}
+
+	var obj interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil
+	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            body,
+			RecognizedEncoding: messageEncodingJSON,
+		},
+		IsPayloadNull:      payload == nil,
+		Object:             obj,
+		RecognizedEncoding: messageEncodingJSON,
+		SchemaID:           schemaID,
+		Size:               len(payload),
+	}
+}
+
+// decodeXML tests for valid XML.
+func (*deserializer) decodeXML(in payloadDecoderInput) *deserializedPayload {
+	startsWithXML := in.Trimmed[0] == '<'
+	if !startsWithXML {
+		return nil
+	}
+
+	r := strings.NewReader(string(in.Trimmed))
+	jsonPayload, err := xj.Convert(r)
+	if err != nil {
+		return nil
+	}
+	var obj interface{}
+	_ = json.Unmarshal(jsonPayload.Bytes(), &obj) // no err possible unless the xml2json package is buggy
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            jsonPayload.Bytes(),
+			RecognizedEncoding: messageEncodingXML,
+		},
+		IsPayloadNull:      in.Payload == nil,
+		Object:             obj,
+		RecognizedEncoding: messageEncodingXML,
+		Size:               len(in.Payload),
+	}
+}
+
+// decodeAvroOCF tests for Avro Object Container Files, which carry their writer schema in the file header.
+func (d *deserializer) decodeAvroOCF(in payloadDecoderInput) *deserializedPayload {
+	if !bytes.HasPrefix(in.Payload, avroOCFMagic) {
+		return nil
+	}
+
+	// The magic bytes identify the payload as container file, so that it's shown as binary
+	// along with the reason why it couldn't be decoded (e.g. an unsupported codec)
+	records, err := d.deserializeAvroOCF(in.Payload)
+	if err != nil {
+		dp := newBinaryPayload(in.Payload)
+		dp.Troubleshooting = []troubleshootingReport{{SerdeName: "avroContainerFile", Message: err.Error()}}
+		return dp
+	}
+	jsonBytes, err := json.Marshal(records)
+	if err != nil {
+		return nil
+	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingAvro,
+		},
+		IsPayloadNull:      in.Payload == nil,
+		Object:             records,
+		RecognizedEncoding: messageEncodingAvro,
+		Size:               len(in.Payload),
+	}
+}
+
+// decodeAvro tests for Avro (reference: https://docs.confluent.io/current/schema-registry/serdes-develop/index.html#wire-format)
+func (d *deserializer) decodeAvro(in payloadDecoderInput) *deserializedPayload {
+	payload := in.Payload
+	// Check if magic byte is set
+	if d.SchemaService == nil || len(payload) <= 5 || payload[0] != byte(0) {
+		return nil
+	}
+
+	schemaID := binary.BigEndian.Uint32(payload[1:5])
+	return d.decodeAvroWithSchemaID(payload, schemaID, payload[5:], in.Opts)
+}
+
+// decodeAvroWithSchemaID decodes the Avro encoded body of the given payload with the
+// registry schema of the given ID.
+func (d *deserializer) decodeAvroWithSchemaID(payload []byte, schemaID uint32, body []byte, opts DeserializationOptions) *deserializedPayload {
+	schema, err := d.SchemaService.GetAvroSchemaByID(context.Background(), schemaID)
+	if err != nil {
+		return nil
+	}
+
+	obj, err := unmarshalAvroExact(schema, body)
+	if err != nil {
+		return nil
+	}
+	encryptedFields, err := d.SchemaService.GetAvroEncryptedFieldsByID(context.Background(), schemaID)
+	if err == nil && len(encryptedFields) > 0 {
+		obj = labelEncryptedAvroFields(schema, obj, encryptedFields)
+	}
+	obj = formatAvroValues(schema, obj, opts.avroValueFormat())
+	jsonBytes, _ := json.Marshal(obj)
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingAvro,
+		},
+		IsPayloadNull:      payload == nil,
+		Object:             obj,
+		RecognizedEncoding: messageEncodingAvro,
+		SchemaID:           schemaID,
+		Size:               len(payload),
+	}
+}
+
+// unmarshalAvroExact decodes the body with the given schema and requires it to be consumed
+// exactly. Unlike avro.Unmarshal, it rejects truncated bodies and bodies with leftover bytes,
+// which are signs that the body has been encoded with a different schema.
+func unmarshalAvroExact(sch avro.Schema, body []byte) (interface{}, error) {
+	r := avro.NewReader(nil, 0).Reset(body)
+	var obj interface{}
+	r.ReadVal(sch, &obj)
+	if r.Error != nil {
+		// Reading past the end of the body reports io.EOF
+		return nil, fmt.Errorf("failed to decode avro body: %w", r.Error)
+	}
+	r.Read(make([]byte, 1))
+	if r.Error == nil {
+		return nil, fmt.Errorf("avro body has leftover bytes")
+	}
+	return obj, nil
+}
+
+// decodeProtobuf tests for Protobuf.
+func (d *deserializer) decodeProtobuf(in payloadDecoderInput) *deserializedPayload {
+	if d.ProtoService == nil {
+		return nil
+	}
+	// Payloads in the schema registry wire format are skipped if their schema is no Protobuf schema
+	payload := in.Payload
+	if d.SchemaService != nil && len(payload) > 5 && payload[0] == byte(0) &&
+		d.schemaTypeMismatches(binary.BigEndian.Uint32(payload[1:5]), schema.TypeProtobuf) {
+		return nil
+	}
+
+	var jsonBytes []byte
+	var schemaID int
+	var err error
+	if messageType := in.Opts.protobufType(in.RecordType); messageType != "" {
+		jsonBytes, err = d.ProtoService.UnmarshalPayloadWithMessageType(in.Payload, messageType, in.Opts.protoUnmarshalOptions())
+	} else {
+		jsonBytes, schemaID, err = d.ProtoService.UnmarshalPayload(in.Payload, in.TopicName, in.RecordType, in.Opts.protoUnmarshalOptions())
+	}
+	if err != nil {
+		return nil
+	}
+	var native interface{}
+	if err := json.Unmarshal(jsonBytes, &native); err != nil {
+		return nil
+	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingProtobuf,
+		},
+		IsPayloadNull:      in.Payload == nil,
+		Object:             native,
+		RecognizedEncoding: messageEncodingProtobuf,
+		SchemaID:           uint32(schemaID),
+		Size:               len(in.Payload),
+	}
+}
+
+// decodeMsgPack tests for MessagePack (only if enabled and topic allowed).
+func (d *deserializer) decodeMsgPack(in payloadDecoderInput) *deserializedPayload {
+	if d.MsgPackService == nil || !d.MsgPackService.IsTopicAllowed(in.TopicName) {
+		return nil
+	}
+
+	var obj interface{}
+	if err := msgpack.Unmarshal(in.Payload, &obj); err != nil {
+		return nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            data,
+			RecognizedEncoding: messageEncodingMsgP,
+		},
+		IsPayloadNull:      in.Payload == nil,
+		Object:             string(in.Payload),
+		RecognizedEncoding: messageEncodingMsgP,
+		Size:               len(in.Payload),
+	}
+}
+
+// decodeSmile tests for valid Smile.
+func (*deserializer) decodeSmile(in payloadDecoderInput) *deserializedPayload {
+	payload := in.Payload
 	startsWithSmile := len(payload) > 3 && payload[0] == ':' && payload[1] == ')' && payload[2] == '\n'
-	if startsWithSmile {
-		obj, err := smile.DecodeToObject(payload)
-		if err == nil {
-			jsonBytes, err := json.Marshal(obj)
-			if err == nil {
-				return &deserializedPayload{
-					Payload: normalizedPayload{
-						Payload:            jsonBytes,
-						RecognizedEncoding: messageEncodingSmile,
-					},
-					IsPayloadNull:      payload == nil,
-					Object:             obj,
-					RecognizedEncoding: messageEncodingSmile,
-					Size:               len(payload),
-				}
-			}
+	if !startsWithSmile {
+		return nil
+	}
+
+	obj, err := smile.DecodeToObject(payload)
+	if err != nil {
+		return nil
+	}
+	jsonBytes, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingSmile,
+		},
+		IsPayloadNull:      payload == nil,
+		Object:             obj,
+		RecognizedEncoding: messageEncodingSmile,
+		Size:               len(payload),
+	}
+}
+
+// decodeUTF8 tests for UTF-8 validity.
+func (d *deserializer) decodeUTF8(in payloadDecoderInput) *deserializedPayload {
+	payload := in.Payload
+	replaced := false
+	if !utf8.Valid(payload) {
+		if !in.Opts.LenientUTF8 {
+			return nil
 		}
+		var ok bool
+		if payload, ok = replaceInvalidUTF8(payload); !ok {
+			return nil
+		}
+		replaced = true
 	}
+	dp := d.decodeValidUTF8(payload)
+	dp.IsPayloadNull = in.Payload == nil
+	dp.Size = len(in.Payload)
+	dp.InvalidUTF8Replaced = replaced
+	return dp
+}
 
-	// 8. Test for UTF-8 validity
-	isUTF8 := utf8.Valid(payload)
-	if isUTF8 {
-		// If we have an UTF8 string with control chars (e.g. byte array with 0x00) we want to
-		// render all control chars as pills and the rest as a human-readable string.
-		// Thus, if the utf8 string contains any control chars we will send it as binary data.
-		if d.containsControlChars(payload) {
-			return &deserializedPayload{
-				Payload: normalizedPayload{
-					Payload:            payload,
-					RecognizedEncoding: messageEncodingUtf8WithControlChars,
-				},
-				IsPayloadNull:      payload == nil,
-				Object:             payload,
-				RecognizedEncoding: messageEncodingUtf8WithControlChars,
-				Size:               len(payload),
-			}
+// maxInvalidUTF8Ratio is the maximum share of invalid bytes in a payload that is decoded
+// leniently. Payloads with more invalid bytes are considered binary.
+const maxInvalidUTF8Ratio = 0.1
+
+// replaceInvalidUTF8 replaces each invalid byte in the payload with the replacement
+// character U+FFFD. It returns false if too many bytes are invalid for the payload to be text.
+func replaceInvalidUTF8(payload []byte) ([]byte, bool) {
+	replacement := []byte(string(utf8.RuneError))
+	result := make([]byte, 0, len(payload)+len(replacement))
+	invalidBytes := 0
+	for i := 0; i < len(payload); {
+		r, size := utf8.DecodeRune(payload[i:])
+		if r == utf8.RuneError && size == 1 {
+			invalidBytes++
+			result = append(result, replacement...)
+		} else {
+			result = append(result, payload[i:i+size]...)
 		}
+		i += size
+	}
+	if float64(invalidBytes) > maxInvalidUTF8Ratio*float64(len(payload)) {
+		return nil, false
+	}
+	return result, true
+}
+
+// decodeValidUTF8 returns the valid UTF-8 payload as text.
+func (d *deserializer) decodeValidUTF8(payload []byte) *deserializedPayload {
+	// If we have an UTF8 string with control chars (e.g. byte array with 0x00) we want to
+	// render all control chars as pills and the rest as a human-readable string.
+	// Thus, if the utf8 string contains any control chars we will send it as binary data.
+	if d.containsControlChars(payload) {
 		return &deserializedPayload{
 			Payload: normalizedPayload{
 				Payload:            payload,
-				RecognizedEncoding: messageEncodingText,
+				RecognizedEncoding: messageEncodingUtf8WithControlChars,
 			},
 			IsPayloadNull:      payload == nil,
-			Object:             string(payload),
-			RecognizedEncoding: messageEncodingText,
+			Object:             payload,
+			RecognizedEncoding: messageEncodingUtf8WithControlChars,
 			Size:               len(payload),
 		}
 	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            payload,
+			RecognizedEncoding: messageEncodingText,
+		},
+		IsPayloadNull:      payload == nil,
+		Object:             string(payload),
+		RecognizedEncoding: messageEncodingText,
+		Size:               len(payload),
+	}
+}
 
-	// 9. Numeric values are tricky.
-	// If the payload is of specific length we can try to convert to a numeric value.
-	// We are going to assume and support only uints.
-	// We have to do this before UTF8 as some numeric values can also be "valid" UTF8 values.
+// decodeUint tests for numeric values. Numeric values are tricky.
+// If the payload is of specific length we can try to convert to a numeric value.
+// We are going to assume and support only uints.
+func (*deserializer) decodeUint(in payloadDecoderInput) *deserializedPayload {
+	payload := in.Payload
 	isNumeric := false
 	var numericPayload []byte
 	var numericObject interface{}
@@ -394,34 +953,25 @@ func (d *deserializer) deserializePayload(payload []byte, topicName string, reco
 		}
 	}
 
-	if isNumeric {
-		return &deserializedPayload{
-			Payload: normalizedPayload{
-				Payload:            numericPayload,
-				RecognizedEncoding: messageEncodingUint,
-			},
-			IsPayloadNull:      payload == nil,
-			Object:             numericObject,
-			RecognizedEncoding: messageEncodingUint,
-			Size:               len(payload),
-		}
+	if !isNumeric {
+		return nil
 	}
-
-	// Anything else is considered as binary content
 	return &deserializedPayload{
 		Payload: normalizedPayload{
-			Payload:            payload,
-			RecognizedEncoding: messageEncodingBinary,
+			Payload:            numericPayload,
+			RecognizedEncoding: messageEncodingUint,
 		},
 		IsPayloadNull:      payload == nil,
-		Object:             payload,
-		RecognizedEncoding: messageEncodingBinary,
+		Object:             numericObject,
+		RecognizedEncoding: messageEncodingUint,
 		Size:               len(payload),
 	}
 }
 
-// deserializeConsumerOffset deserializes the binary messages in the __consumer_offsets topic
-func (*deserializer) deserializeConsumerOffset(record *kgo.Record) (*deserializedRecord, error) {
+// deserializeConsumerOffset deserializes the binary messages in the __consumer_offsets topic.
+// An error is returned if the key can't be deserialized, so that the record is decoded with
+// the decoder chain instead.
+func (d *deserializer) deserializeConsumerOffset(record *kgo.Record, opts DeserializationOptions) (*deserializedRecord, error) {
 	if len(record.Key) < 2 {
 		return nil, fmt.Errorf("offset commit key is supposed to be at least 2 bytes long")
 	}
@@ -462,7 +1012,7 @@ func (*deserializer) deserializeConsumerOffset(record *kgo.Record) (*deserialize
 					Payload:            val,
 					RecognizedEncoding: messageEncodingConsumerOffsets,
 				},
-				Object:             val,
+				Object:             offsetCommitValue,
 				RecognizedEncoding: messageEncodingConsumerOffsets,
 				Size:               len(record.Value),
 			}
@@ -506,17 +1056,25 @@ func (*deserializer) deserializeConsumerOffset(record *kgo.Record) (*deserialize
 		return nil, fmt.Errorf("unknown message version '%d' detected", messageVer)
 	}
 
+	if deserializedKey == nil {
+		return nil, fmt.Errorf("failed to deserialize key of message version '%d'", messageVer)
+	}
 	if deserializedVal == nil {
-		// Tombstone
-		deserializedVal = &deserializedPayload{Payload: normalizedPayload{
-			Payload:            record.Value,
-			RecognizedEncoding: messageEncodingNone,
-		}, Object: "", RecognizedEncoding: messageEncodingNone, Size: len(record.Value)}
+		if record.Value != nil {
+			// The key is known, but the value is not, hence it's decoded like in any other topic
+			deserializedVal = d.deserializePayload(record.Value, record.Topic, proto.RecordValue, opts)
+		} else {
+			// Tombstone
+			deserializedVal = &deserializedPayload{Payload: normalizedPayload{
+				Payload:            record.Value,
+				RecognizedEncoding: messageEncodingNone,
+			}, IsPayloadNull: true, Object: "", RecognizedEncoding: messageEncodingNone, Size: len(record.Value)}
+		}
 	}
 	return &deserializedRecord{
 		Key:     deserializedKey,
 		Value:   deserializedVal,
-		Headers: nil,
+		Headers: d.deserializeHeaders(record, opts),
 	}, nil
 }
 
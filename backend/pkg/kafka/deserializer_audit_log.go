@@ -0,0 +1,133 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+// auditLogTopicName is the internal topic Redpanda writes its audit events to.
+const auditLogTopicName = "_redpanda.audit_log"
+
+// auditLogEvent is the subset of an OCSF (Open Cybersecurity Schema Framework) event, as
+// written by Redpanda, that is required to label the event.
+type auditLogEvent struct {
+	CategoryUID int   `json:"category_uid"`
+	ClassUID    int   `json:"class_uid"`
+	ActivityID  int   `json:"activity_id"`
+	SeverityID  int   `json:"severity_id"`
+	Time        int64 `json:"time"`
+	Metadata    struct {
+		Version string `json:"version"`
+	} `json:"metadata"`
+}
+
+// auditLogRecord is the labeled representation of an audit event that is sent to the frontend.
+type auditLogRecord struct {
+	Time         string          `json:"time"`
+	CategoryName string          `json:"categoryName"`
+	ClassName    string          `json:"className"`
+	ActivityName string          `json:"activityName"`
+	SeverityName string          `json:"severityName"`
+	Event        json.RawMessage `json:"event"`
+}
+
+var auditLogCategoryNames = map[int]string{
+	3: "Identity & Access Management",
+	6: "Application Activity",
+}
+
+var auditLogClassNames = map[int]string{
+	3002: "Authentication",
+	6002: "Application Lifecycle",
+	6003: "API Activity",
+}
+
+var auditLogActivityNamesByClass = map[int]map[int]string{
+	3002: {1: "Logon", 2: "Logoff", 3: "Authentication Ticket", 4: "Service Ticket"},
+	6002: {1: "Install", 2: "Remove", 3: "Start", 4: "Stop"},
+	6003: {1: "Create", 2: "Read", 3: "Update", 4: "Delete"},
+}
+
+var auditLogSeverityNames = map[int]string{
+	0:  "Unknown",
+	1:  "Informational",
+	2:  "Low",
+	3:  "Medium",
+	4:  "High",
+	5:  "Critical",
+	6:  "Fatal",
+	99: "Other",
+}
+
+// deserializeAuditLogRecord decodes a record of Redpanda's audit log topic. The value is
+// labeled with human-readable names for the OCSF class, category, activity and severity.
+// An error is returned for schema versions or event classes that are not known, so that
+// the caller can fall back to the regular deserialization.
+func (d *deserializer) deserializeAuditLogRecord(record *kgo.Record, opts DeserializationOptions) (*deserializedRecord, error) {
+	var event auditLogEvent
+	if err := json.Unmarshal(record.Value, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode audit log event: %w", err)
+	}
+	if !strings.HasPrefix(event.Metadata.Version, "1.") {
+		return nil, fmt.Errorf("unsupported audit log schema version %q", event.Metadata.Version)
+	}
+	className, exists := auditLogClassNames[event.ClassUID]
+	if !exists {
+		return nil, fmt.Errorf("unknown audit log event class %d", event.ClassUID)
+	}
+
+	activityName, exists := auditLogActivityNamesByClass[event.ClassUID][event.ActivityID]
+	if !exists {
+		activityName = "Other"
+	}
+	severityName, exists := auditLogSeverityNames[event.SeverityID]
+	if !exists {
+		severityName = "Other"
+	}
+
+	labeled := auditLogRecord{
+		Time:         time.UnixMilli(event.Time).UTC().Format(time.RFC3339Nano),
+		CategoryName: auditLogCategoryNames[event.CategoryUID],
+		ClassName:    className,
+		ActivityName: activityName,
+		SeverityName: severityName,
+		Event:        record.Value,
+	}
+	jsonBytes, err := json.Marshal(labeled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize labeled audit log event: %w", err)
+	}
+	var obj interface{}
+	if err := json.Unmarshal(jsonBytes, &obj); err != nil {
+		return nil, fmt.Errorf("failed to deserialize labeled audit log event: %w", err)
+	}
+
+	return &deserializedRecord{
+		Key: d.deserializePayload(record.Key, record.Topic, proto.RecordKey, opts),
+		Value: &deserializedPayload{
+			Payload: normalizedPayload{
+				Payload:            jsonBytes,
+				RecognizedEncoding: messageEncodingAuditLog,
+			},
+			Object:             obj,
+			RecognizedEncoding: messageEncodingAuditLog,
+			Size:               len(record.Value),
+		},
+		Headers: d.deserializeHeaders(record, opts),
+	}, nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hamba/avro/v2"
+)
+
+// maxAvroFieldDocs limits the number of doc strings that are attached to a single payload,
+// as fields in large arrays or maps would otherwise repeat the same doc for each element.
+const maxAvroFieldDocs = 1000
+
+// addAvroDocsRecord attaches the doc strings of the Avro schemas to the key, value and all
+// headers of the given record, if they have been decoded as Avro.
+func (d *deserializer) addAvroDocsRecord(rec *deserializedRecord) {
+	d.addAvroDocsPayload(rec.Key)
+	d.addAvroDocsPayload(rec.Value)
+	for _, header := range rec.Headers {
+		d.addAvroDocsPayload(header)
+	}
+}
+
+// addAvroDocsPayload walks the decoded Avro value along with its schema and collects the doc
+// strings of all fields that are present in the value.
+func (d *deserializer) addAvroDocsPayload(dp *deserializedPayload) {
+	if dp == nil || d.SchemaService == nil || dp.RecognizedEncoding != messageEncodingAvro || dp.SchemaID == 0 {
+		return
+	}
+	sch, err := d.SchemaService.GetAvroSchemaByID(context.Background(), dp.SchemaID)
+	if err != nil {
+		return
+	}
+
+	docs := make(map[string]string)
+	collectAvroDocs(sch, "", dp.Object, docs)
+	if len(docs) > 0 {
+		dp.FieldDocs = docs
+	}
+}
+
+// collectAvroDocs adds the doc strings of the given schema and its nested fields to docs. The
+// keys are the paths of the fields in the decoded value, which are built like the keys of
+// flattened payloads (e.g. `customer.email` or `items[0].sku`). Doc strings of fields take
+// precedence over those of the field's type.
+func collectAvroDocs(sch avro.Schema, path string, value interface{}, docs map[string]string) {
+	if len(docs) >= maxAvroFieldDocs {
+		return
+	}
+
+	switch s := sch.(type) {
+	case *avro.RecordSchema:
+		if _, exists := docs[path]; !exists && s.Doc() != "" {
+			docs[path] = s.Doc()
+		}
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, field := range s.Fields() {
+			fieldValue, exists := record[field.Name()]
+			if !exists {
+				continue
+			}
+			fieldPath := joinAvroDocPath(path, field.Name())
+			if field.Doc() != "" && len(docs) < maxAvroFieldDocs {
+				docs[fieldPath] = field.Doc()
+			}
+			collectAvroDocs(field.Type(), fieldPath, fieldValue, docs)
+		}
+	case *avro.EnumSchema:
+		if _, exists := docs[path]; !exists && s.Doc() != "" {
+			docs[path] = s.Doc()
+		}
+	case *avro.RefSchema:
+		collectAvroDocs(s.Schema(), path, value, docs)
+	case *avro.ArraySchema:
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			collectAvroDocs(s.Items(), path+"["+strconv.Itoa(i)+"]", item, docs)
+		}
+	case *avro.MapSchema:
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, v := range values {
+			collectAvroDocs(s.Values(), joinAvroDocPath(path, key), v, docs)
+		}
+	case *avro.UnionSchema:
+		if value == nil {
+			return
+		}
+		// Unions of named types are decoded as a map with the type name as single key
+		if wrapped, ok := value.(map[string]interface{}); ok && len(wrapped) == 1 {
+			for _, t := range s.Types() {
+				named, isNamed := t.(avro.NamedSchema)
+				if !isNamed {
+					continue
+				}
+				if v, exists := wrapped[named.FullName()]; exists {
+					collectAvroDocs(t, joinAvroDocPath(path, named.FullName()), v, docs)
+					return
+				}
+			}
+		}
+		for _, t := range s.Types() {
+			if t.Type() == avro.Record || t.Type() == avro.Ref || t.Type() == avro.Enum {
+				collectAvroDocs(t, path, value, docs)
+			}
+		}
+	}
+}
+
+// joinAvroDocPath appends the key to the path of the parent.
+func joinAvroDocPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
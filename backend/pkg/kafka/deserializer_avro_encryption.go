@@ -0,0 +1,91 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"github.com/hamba/avro/v2"
+
+	"github.com/redpanda-data/console/backend/pkg/schema"
+)
+
+// encryptedAvroField replaces the value of an Avro field that has been encrypted using
+// client-side field level encryption, so that the ciphertext is not mistaken for data.
+type encryptedAvroField struct {
+	Encrypted  bool        `json:"encrypted"`
+	Ciphertext interface{} `json:"ciphertext"`
+}
+
+// labelEncryptedAvroFields walks the decoded Avro value along with its schema and replaces
+// the values of all encrypted fields with an encryptedAvroField.
+func labelEncryptedAvroFields(sch avro.Schema, value interface{}, encryptedFields map[string]struct{}) interface{} {
+	switch s := sch.(type) {
+	case *avro.RecordSchema:
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for _, field := range s.Fields() {
+			fieldValue, exists := record[field.Name()]
+			if !exists {
+				continue
+			}
+			if _, isEncrypted := encryptedFields[schema.EncryptedAvroFieldName(s, field)]; isEncrypted {
+				if fieldValue != nil {
+					record[field.Name()] = encryptedAvroField{Encrypted: true, Ciphertext: fieldValue}
+				}
+				continue
+			}
+			record[field.Name()] = labelEncryptedAvroFields(field.Type(), fieldValue, encryptedFields)
+		}
+		return record
+	case *avro.RefSchema:
+		return labelEncryptedAvroFields(s.Schema(), value, encryptedFields)
+	case *avro.ArraySchema:
+		items, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		for i, item := range items {
+			items[i] = labelEncryptedAvroFields(s.Items(), item, encryptedFields)
+		}
+		return items
+	case *avro.MapSchema:
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for key, v := range values {
+			values[key] = labelEncryptedAvroFields(s.Values(), v, encryptedFields)
+		}
+		return values
+	case *avro.UnionSchema:
+		// Unions of named types are decoded as a map with the type name as single key
+		if wrapped, ok := value.(map[string]interface{}); ok && len(wrapped) == 1 {
+			for _, t := range s.Types() {
+				named, isNamed := t.(avro.NamedSchema)
+				if !isNamed {
+					continue
+				}
+				if v, exists := wrapped[named.FullName()]; exists {
+					wrapped[named.FullName()] = labelEncryptedAvroFields(t, v, encryptedFields)
+					return wrapped
+				}
+			}
+		}
+		for _, t := range s.Types() {
+			if t.Type() == avro.Record || t.Type() == avro.Ref {
+				value = labelEncryptedAvroFields(t, value, encryptedFields)
+			}
+		}
+		return value
+	default:
+		return value
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+// avroSingleObjectMarker are the first two bytes of each payload in Avro's single object
+// encoding. They are followed by the 8 byte little endian CRC-64-AVRO fingerprint of the
+// writer schema and the Avro encoded body.
+var avroSingleObjectMarker = []byte{0xc3, 0x01}
+
+// decodeAvroSingleObject decodes payloads in Avro's single object encoding, as produced by
+// non-Confluent Avro libraries, with the registered schema of the payload's fingerprint.
+func (d *deserializer) decodeAvroSingleObject(in payloadDecoderInput) *deserializedPayload {
+	payload := in.Payload
+	if d.SchemaService == nil || len(payload) <= 10 || !bytes.HasPrefix(payload, avroSingleObjectMarker) {
+		return nil
+	}
+
+	fingerprint := binary.LittleEndian.Uint64(payload[2:10])
+	schemaID, err := d.SchemaService.GetAvroSchemaIDByFingerprint(context.Background(), fingerprint)
+	if err != nil {
+		return nil
+	}
+	return d.decodeAvroWithSchemaID(payload, schemaID, payload[10:], in.Opts)
+}
+
+// decodeAvroFingerprintHeader decodes the record value as raw Avro body with the registered
+// schema whose CRC-64-AVRO fingerprint is carried in the configured header. The fingerprint
+// is expected as 8 byte little endian integer, as in Avro's single object encoding. Nil is
+// returned if the record has no such header or the value can't be decoded with the schema.
+func (d *deserializer) decodeAvroFingerprintHeader(record *kgo.Record, opts DeserializationOptions) *deserializedPayload {
+	if opts.AvroFingerprintHeader == "" || opts.ValueEncoding != "" || d.SchemaService == nil || len(record.Value) == 0 || opts.decodeBudgetExceeded() {
+		return nil
+	}
+
+	for _, header := range record.Headers {
+		if header.Key != opts.AvroFingerprintHeader {
+			continue
+		}
+		if len(header.Value) != 8 {
+			return nil
+		}
+		fingerprint := binary.LittleEndian.Uint64(header.Value)
+		schemaID, err := d.SchemaService.GetAvroSchemaIDByFingerprint(context.Background(), fingerprint)
+		if err != nil {
+			return nil
+		}
+		dp := d.decodeAvroWithSchemaID(record.Value, schemaID, record.Value, opts)
+		if dp == nil {
+			return nil
+		}
+		if opts.IncludeSchemaVersion {
+			d.addSchemaSubjectVersion(dp, record.Topic, proto.RecordValue)
+		}
+		dp.setNormalizedSize()
+		return dp
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"time"
+
+	"github.com/hamba/avro/v2"
+)
+
+// avroDuration is the decoded form of an Avro duration, which is a fixed of 12 bytes that
+// holds three little endian unsigned integers.
+type avroDuration struct {
+	Months       uint32 `json:"months"`
+	Days         uint32 `json:"days"`
+	Milliseconds uint32 `json:"milliseconds"`
+}
+
+const (
+	avroDateLayout       = "2006-01-02"
+	avroTimeMillisLayout = "15:04:05.000"
+	avroTimeMicrosLayout = "15:04:05.000000"
+)
+
+// avroValueFormat configures how decoded Avro values are formatted.
+type avroValueFormat struct {
+	// fixedAsHex returns fixed values without logical type as hex string rather than bytes.
+	fixedAsHex bool
+	// rawLogicalTypes returns logical types as values of their underlying type.
+	rawLogicalTypes bool
+	// jsonEncoding returns values as in the Avro JSON encoding, which requires raw logical
+	// types. Bytes are returned as string with one code point per byte and union values are
+	// wrapped in an object with the name of the type (without logical type) as key.
+	jsonEncoding bool
+}
+
+// encodeBytes returns bytes as in the Avro JSON encoding if jsonEncoding is set.
+func (f avroValueFormat) encodeBytes(value interface{}) interface{} {
+	b, ok := value.([]byte)
+	if !ok || !f.jsonEncoding {
+		return value
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// formatAvroValues converts decoded Avro values of logical types and fixed types to the forms
+// that are returned to the frontend. By default, logical types are returned readable:
+// timestamps as RFC 3339 strings, dates as "2006-01-02", times of day as "15:04:05.000",
+// decimals as decimal strings (e.g. "12.34") and durations as their three components. With
+// rawLogicalTypes, they are returned as their underlying Avro values instead, e.g. timestamps
+// as milliseconds since epoch and decimals as unscaled two's complement bytes. Fixed values
+// are returned as hex string if fixedAsHex is set, and as bytes (which are base64 encoded in
+// JSON) otherwise.
+func formatAvroValues(sch avro.Schema, value interface{}, format avroValueFormat) interface{} {
+	switch s := sch.(type) {
+	case *avro.PrimitiveSchema:
+		return format.encodeBytes(formatAvroPrimitive(s, value, format))
+	case *avro.FixedSchema:
+		return format.encodeBytes(formatAvroFixed(s, value, format))
+	case *avro.RecordSchema:
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for _, field := range s.Fields() {
+			if fieldValue, exists := record[field.Name()]; exists {
+				record[field.Name()] = formatAvroValues(field.Type(), fieldValue, format)
+			}
+		}
+		return record
+	case *avro.RefSchema:
+		return formatAvroValues(s.Schema(), value, format)
+	case *avro.ArraySchema:
+		items, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		for i, item := range items {
+			items[i] = formatAvroValues(s.Items(), item, format)
+		}
+		return items
+	case *avro.MapSchema:
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for key, v := range values {
+			values[key] = formatAvroValues(s.Values(), v, format)
+		}
+		return values
+	case *avro.UnionSchema:
+		// Non-null union values are decoded as a map with the type name as single key
+		wrapped, ok := value.(map[string]interface{})
+		if !ok || len(wrapped) != 1 {
+			return value
+		}
+		for _, t := range s.Types() {
+			name := avroUnionTypeName(t)
+			v, exists := wrapped[name]
+			if !exists {
+				continue
+			}
+			if format.jsonEncoding {
+				// The Avro JSON encoding names primitive types without their logical type
+				if _, isNamed := t.(avro.NamedSchema); !isNamed {
+					return map[string]interface{}{string(t.Type()): formatAvroValues(t, v, format)}
+				}
+			}
+			wrapped[name] = formatAvroValues(t, v, format)
+			return wrapped
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// formatAvroPrimitive converts a single value of a primitive schema with logical type.
+func formatAvroPrimitive(s *avro.PrimitiveSchema, value interface{}, format avroValueFormat) interface{} {
+	logical := s.Logical()
+	if logical == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		switch logical.Type() {
+		case avro.Date:
+			if format.rawLogicalTypes {
+				return int32(v.Unix() / int64(24*time.Hour/time.Second))
+			}
+			return v.UTC().Format(avroDateLayout)
+		case avro.TimestampMillis:
+			if format.rawLogicalTypes {
+				return v.UnixMilli()
+			}
+		case avro.TimestampMicros:
+			if format.rawLogicalTypes {
+				return v.UnixMicro()
+			}
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case time.Duration:
+		// Times of day are decoded as duration since midnight
+		switch logical.Type() {
+		case avro.TimeMillis:
+			if format.rawLogicalTypes {
+				return int32(v.Milliseconds())
+			}
+			return time.Time{}.Add(v).Format(avroTimeMillisLayout)
+		case avro.TimeMicros:
+			if format.rawLogicalTypes {
+				return v.Microseconds()
+			}
+			return time.Time{}.Add(v).Format(avroTimeMicrosLayout)
+		}
+	case *big.Rat:
+		decimal, ok := logical.(*avro.DecimalLogicalSchema)
+		if !ok || v == nil {
+			return value
+		}
+		if format.rawLogicalTypes {
+			return avroDecimalUnscaledBytes(v, decimal.Scale(), 0)
+		}
+		return v.FloatString(decimal.Scale())
+	}
+	return value
+}
+
+// formatAvroFixed converts a single value of the given fixed schema.
+func formatAvroFixed(s *avro.FixedSchema, value interface{}, format avroValueFormat) interface{} {
+	var logicalType avro.LogicalType
+	if logical := s.Logical(); logical != nil {
+		logicalType = logical.Type()
+	}
+
+	switch logicalType {
+	case avro.Decimal:
+		rat, ok := value.(*big.Rat)
+		if !ok || rat == nil {
+			return value
+		}
+		decimal, ok := s.Logical().(*avro.DecimalLogicalSchema)
+		if !ok {
+			return value
+		}
+		if !format.rawLogicalTypes {
+			return rat.FloatString(decimal.Scale())
+		}
+		value = avroDecimalUnscaledBytes(rat, decimal.Scale(), s.Size())
+	case avro.Duration:
+		b, ok := value.([]byte)
+		if !ok || len(b) != 12 || format.rawLogicalTypes {
+			break
+		}
+		return avroDuration{
+			Months:       binary.LittleEndian.Uint32(b[0:4]),
+			Days:         binary.LittleEndian.Uint32(b[4:8]),
+			Milliseconds: binary.LittleEndian.Uint32(b[8:12]),
+		}
+	}
+
+	if b, ok := value.([]byte); ok && format.fixedAsHex {
+		return hex.EncodeToString(b)
+	}
+	return value
+}
+
+// avroDecimalUnscaledBytes returns the unscaled value of the decimal as big endian two's
+// complement bytes, which is how Avro encodes decimals. The bytes are sign extended to size,
+// or as short as possible if size is 0.
+func avroDecimalUnscaledBytes(rat *big.Rat, scale int, size int) []byte {
+	unscaled := new(big.Int).Mul(rat.Num(), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+	unscaled.Quo(unscaled, rat.Denom())
+
+	// Negative numbers are represented by 2^(8*n) + x, with n large enough for the sign bit
+	n := unscaled.BitLen()/8 + 1
+	if size > n {
+		n = size
+	}
+	if unscaled.Sign() < 0 {
+		unscaled.Add(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*n)))
+	}
+	b := unscaled.FillBytes(make([]byte, n))
+	if size == 0 {
+		// Strip redundant sign bytes
+		for len(b) > 1 && ((b[0] == 0 && b[1]&0x80 == 0) || (b[0] == 0xff && b[1]&0x80 != 0)) {
+			b = b[1:]
+		}
+	}
+	return b
+}
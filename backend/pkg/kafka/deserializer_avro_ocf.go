@@ -0,0 +1,60 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/ocf"
+)
+
+// avroOCFMagic are the first four bytes of every Avro Object Container File.
+var avroOCFMagic = []byte{'O', 'b', 'j', 1}
+
+// deserializeAvroOCF decodes all records of an Avro Object Container File. The writer schema
+// and the block compression codec are both read from the file header, so that no schema
+// registry is involved. Supported codecs are null, deflate and snappy.
+func (*deserializer) deserializeAvroOCF(payload []byte) ([]interface{}, error) {
+	// We decode the header ourselves first so that we can return a descriptive error for
+	// codecs that are valid in Avro but not supported by the ocf decoder (e.g. bzip2).
+	var header ocf.Header
+	reader := avro.NewReader(bytes.NewReader(payload), 1024)
+	reader.ReadVal(ocf.HeaderSchema, &header)
+	if reader.Error != nil {
+		return nil, fmt.Errorf("failed to read avro container file header: %w", reader.Error)
+	}
+	codec := ocf.CodecName(header.Meta["avro.codec"])
+	switch codec {
+	case "", ocf.Null, ocf.Deflate, ocf.Snappy:
+	default:
+		return nil, fmt.Errorf("unsupported avro container file codec %q, supported codecs are null, deflate and snappy", codec)
+	}
+
+	decoder, err := ocf.NewDecoder(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create avro container file decoder: %w", err)
+	}
+
+	records := make([]interface{}, 0)
+	for decoder.HasNext() {
+		var record interface{}
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("failed to decode avro container file record: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := decoder.Error(); err != nil {
+		return nil, fmt.Errorf("failed to read avro container file blocks: %w", err)
+	}
+
+	return records, nil
+}
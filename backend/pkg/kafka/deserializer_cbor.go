@@ -0,0 +1,121 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// cborSelfDescribeTag is the tag number (55799) that marks a payload as CBOR. It is encoded
+// as the three bytes 0xd9 0xd9 0xf7.
+const cborSelfDescribeTag = 55799
+
+var cborDecMode = func() cbor.DecMode {
+	dm, err := cbor.DecOptions{
+		DupMapKey:        cbor.DupMapKeyEnforcedAPF,
+		MapKeyByteString: cbor.MapKeyByteStringAllowed,
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return dm
+}()
+
+// decodeCBOR tests for CBOR. Almost any short byte sequence is a valid CBOR data item (e.g.
+// 0x81 0x05 is the array [5]), so that only payloads that start with the self-described CBOR
+// tag are accepted on all topics. Payloads with a map or array at the top level are accepted
+// on the configured CBOR topics, like MessagePack. Neither can be mistaken for UTF-8 text,
+// because their first byte is a UTF-8 continuation byte.
+func (d *deserializer) decodeCBOR(in payloadDecoderInput) *deserializedPayload {
+	if !hasCBORSelfDescribeTag(in.Payload) && !(isCBORContainer(in.Payload) && d.isCBORTopic(in.TopicName)) {
+		return nil
+	}
+
+	var obj interface{}
+	if err := cborDecMode.Unmarshal(in.Payload, &obj); err != nil {
+		return nil
+	}
+	obj = jsonCompatibleCBOR(obj)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            data,
+			RecognizedEncoding: messageEncodingCBOR,
+		},
+		IsPayloadNull:      in.Payload == nil,
+		Object:             obj,
+		RecognizedEncoding: messageEncodingCBOR,
+		Size:               len(in.Payload),
+	}
+}
+
+// hasCBORSelfDescribeTag returns true if the payload starts with the self-described CBOR tag.
+func hasCBORSelfDescribeTag(payload []byte) bool {
+	return len(payload) >= 3 && payload[0] == 0xd9 && payload[1] == 0xd9 && payload[2] == 0xf7
+}
+
+// isCBORContainer returns true if the payload starts with the head of a CBOR map or array
+// (major types 4 and 5).
+func isCBORContainer(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+	majorType := payload[0] >> 5
+	return majorType == 4 || majorType == 5
+}
+
+// isCBORTopic returns true if untagged CBOR payloads of the topic shall be decoded.
+func (d *deserializer) isCBORTopic(topicName string) bool {
+	for _, regex := range d.cborTopics {
+		if regex.MatchString(topicName) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonCompatibleCBOR converts decoded CBOR values into values that can be marshalled to
+// JSON. Map keys are formatted as strings and tags are returned as object with the tag
+// number and its content.
+func jsonCompatibleCBOR(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			switch k := key.(type) {
+			case string:
+				obj[k] = jsonCompatibleCBOR(val)
+			case cbor.ByteString:
+				obj[fmt.Sprintf("%x", string(k))] = jsonCompatibleCBOR(val)
+			default:
+				obj[fmt.Sprint(k)] = jsonCompatibleCBOR(val)
+			}
+		}
+		return obj
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonCompatibleCBOR(item)
+		}
+		return v
+	case cbor.Tag:
+		return map[string]interface{}{
+			"tag":   v.Number,
+			"value": jsonCompatibleCBOR(v.Content),
+		}
+	default:
+		return v
+	}
+}
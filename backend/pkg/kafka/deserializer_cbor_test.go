@@ -0,0 +1,141 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+func TestDeserializer_CBOR(t *testing.T) {
+	d := deserializer{cborTopics: []*regexp.Regexp{regexp.MustCompile(`^orders$`)}}
+
+	t.Run("valid map", func(t *testing.T) {
+		payload, err := cbor.Marshal(map[string]interface{}{
+			"id":      "o-1",
+			"items":   []interface{}{1, -2, 3.5},
+			"paid":    true,
+			"note":    nil,
+			"payload": []byte{0xde, 0xad},
+		})
+		require.NoError(t, err)
+
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingCBOR, dp.RecognizedEncoding)
+		assert.Equal(t, len(payload), dp.Size)
+		assert.JSONEq(t, `{"id": "o-1", "items": [1, -2, 3.5], "paid": true, "note": null, "payload": "3q0="}`,
+			string(dp.Payload.Payload))
+	})
+
+	t.Run("non-string map keys and tags", func(t *testing.T) {
+		payload, err := cbor.Marshal(map[interface{}]interface{}{
+			1:   "one",
+			"t": cbor.Tag{Number: 1001, Content: "tagged"},
+		})
+		require.NoError(t, err)
+
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingCBOR, dp.RecognizedEncoding)
+		assert.JSONEq(t, `{"1": "one", "t": {"tag": 1001, "value": "tagged"}}`, string(dp.Payload.Payload))
+	})
+
+	t.Run("self-described scalar", func(t *testing.T) {
+		payload := []byte{0xd9, 0xd9, 0xf7, 0x18, 0x2a}
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingCBOR, dp.RecognizedEncoding)
+		assert.Equal(t, "42", string(dp.Payload.Payload))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		// Map header with two pairs, but only one pair follows
+		dp := d.deserializePayload([]byte{0xa2, 0x61, 0x61, 0x01}, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingCBOR, dp.RecognizedEncoding)
+
+		// Text that happens to be a valid CBOR data item is not decoded as CBOR
+		dp = d.deserializePayload([]byte("abc"), "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+
+		// Trailing bytes after the data item
+		dp = d.deserializePayload([]byte{0x81, 0x01, 0x02}, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingCBOR, dp.RecognizedEncoding)
+	})
+
+	t.Run("untagged on other topics", func(t *testing.T) {
+		payload, err := cbor.Marshal(map[string]interface{}{"name": "jane"})
+		require.NoError(t, err)
+		dp := d.deserializePayload(payload, "customers", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingCBOR, dp.RecognizedEncoding)
+
+		// Short binary keys are often valid CBOR arrays, e.g. [5]
+		dp = d.deserializePayload([]byte{0x81, 0x05}, "customers", proto.RecordKey, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingCBOR, dp.RecognizedEncoding)
+
+		tagged, err := serializeCBOR(map[string]interface{}{"name": "jane"})
+		require.NoError(t, err)
+		dp = d.deserializePayload(tagged, "customers", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingCBOR, dp.RecognizedEncoding)
+		assert.JSONEq(t, `{"name": "jane"}`, string(dp.Payload.Payload))
+	})
+
+	t.Run("key and value", func(t *testing.T) {
+		key, err := cbor.Marshal([]interface{}{"tenant-a", 7})
+		require.NoError(t, err)
+		value, err := cbor.Marshal(map[string]interface{}{"name": "jane"})
+		require.NoError(t, err)
+
+		keyDp := d.deserializePayload(key, "orders", proto.RecordKey, DeserializationOptions{})
+		require.Equal(t, messageEncodingCBOR, keyDp.RecognizedEncoding)
+		assert.JSONEq(t, `["tenant-a", 7]`, string(keyDp.Payload.Payload))
+
+		valueDp := d.deserializePayload(value, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingCBOR, valueDp.RecognizedEncoding)
+		assert.JSONEq(t, `{"name": "jane"}`, string(valueDp.Payload.Payload))
+	})
+}
+
+func TestSerializeCBOR(t *testing.T) {
+	d := deserializer{}
+
+	t.Run("json text round trip", func(t *testing.T) {
+		input := `{"id": "o-1", "total": 18446744073709551615, "weight": 1.5, "tags": ["gift"], "note": null}`
+		payload, err := serializeCBOR(json.RawMessage(input))
+		require.NoError(t, err)
+
+		var decoded map[string]interface{}
+		require.NoError(t, cbor.Unmarshal(payload, &decoded))
+		assert.Equal(t, uint64(18446744073709551615), decoded["total"])
+
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingCBOR, dp.RecognizedEncoding)
+		assert.JSONEq(t, input, string(dp.Payload.Payload))
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		payload, err := serializeCBOR("hello")
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xd9, 0xd9, 0xf7}, payload[:3])
+
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingCBOR, dp.RecognizedEncoding)
+		assert.Equal(t, `"hello"`, string(dp.Payload.Payload))
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := serializeCBOR([]byte(`{"id":`))
+		assert.ErrorContains(t, err, "failed to decode json value")
+	})
+}
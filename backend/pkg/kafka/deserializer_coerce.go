@@ -0,0 +1,94 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// numericStringRegex matches strings that are a JSON number as a whole. Numbers with leading
+// zeros (e.g. zip codes such as "02134"), a plus sign or surrounding whitespace don't match,
+// as they are unlikely to be meant as number.
+var numericStringRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// coerceNumericStringsRecord coerces numeric strings in the key, value and all headers of
+// the given record.
+func coerceNumericStringsRecord(rec *deserializedRecord) {
+	coerceNumericStringsPayload(rec.Key)
+	coerceNumericStringsPayload(rec.Value)
+	for _, header := range rec.Headers {
+		coerceNumericStringsPayload(header)
+	}
+}
+
+// coerceNumericStringsPayload replaces all string values that are a number as a whole with
+// the number. Only schemaless encodings are coerced, as the types of encodings such as Avro
+// or Protobuf are defined by the schema.
+func coerceNumericStringsPayload(dp *deserializedPayload) {
+	if dp == nil {
+		return
+	}
+	switch dp.Payload.RecognizedEncoding {
+	case messageEncodingJSON, messageEncodingXML, messageEncodingMsgP, messageEncodingSmile:
+	default:
+		return
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(dp.Payload.Payload))
+	dec.UseNumber()
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return
+	}
+
+	coerced, changed := coerceNumericStrings(obj)
+	if !changed {
+		return
+	}
+	jsonBytes, err := json.Marshal(coerced)
+	if err != nil {
+		return
+	}
+
+	dp.Payload.Payload = jsonBytes
+	dp.Object = coerced
+}
+
+// coerceNumericStrings walks the given value and replaces numeric strings with json.Number,
+// so that the number is kept as is rather than being rounded to a float64.
+func coerceNumericStrings(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		changed := false
+		for key, child := range v {
+			coerced, childChanged := coerceNumericStrings(child)
+			v[key] = coerced
+			changed = changed || childChanged
+		}
+		return v, changed
+	case []interface{}:
+		changed := false
+		for i, child := range v {
+			coerced, childChanged := coerceNumericStrings(child)
+			v[i] = coerced
+			changed = changed || childChanged
+		}
+		return v, changed
+	case string:
+		if numericStringRegex.MatchString(v) {
+			return json.Number(v), true
+		}
+		return v, false
+	default:
+		return v, false
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/redpanda-data/console/backend/pkg/config"
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+// compositeKeyLayout is the field layout of the keys of all topics matching one of the
+// topic names.
+type compositeKeyLayout struct {
+	topicNames []*regexp.Regexp
+	fields     []config.KafkaCompositeKeyField
+}
+
+// newCompositeKeyLayouts compiles the topic names of the configured composite keys.
+func newCompositeKeyLayouts(cfgs []config.KafkaCompositeKey) ([]compositeKeyLayout, error) {
+	layouts := make([]compositeKeyLayout, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		topicNames, err := config.CompileRegexes(cfg.TopicNames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile topic names for composite key: %w", err)
+		}
+		layouts = append(layouts, compositeKeyLayout{topicNames: topicNames, fields: cfg.Fields})
+	}
+	return layouts, nil
+}
+
+// decodeCompositeKey decodes record keys of topics with a composite key layout into an
+// object with one property per field. Keys whose length doesn't match the layout are left
+// to the other decoders.
+func (d *deserializer) decodeCompositeKey(in payloadDecoderInput) *deserializedPayload {
+	if in.RecordType != proto.RecordKey {
+		return nil
+	}
+	for _, layout := range d.compositeKeys {
+		if !matchesAnyRegex(layout.topicNames, in.TopicName) {
+			continue
+		}
+		obj, err := decodeCompositeKeyFields(in.Payload, layout.fields)
+		if err != nil {
+			return nil
+		}
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return nil
+		}
+		return &deserializedPayload{
+			Payload: normalizedPayload{
+				Payload:            jsonBytes,
+				RecognizedEncoding: messageEncodingCompositeKey,
+			},
+			IsPayloadNull:      in.Payload == nil,
+			Object:             obj,
+			RecognizedEncoding: messageEncodingCompositeKey,
+			Size:               len(in.Payload),
+		}
+	}
+	return nil
+}
+
+// decodeCompositeKeyFields splits the key into the given fields and decodes each of them.
+func decodeCompositeKeyFields(key []byte, fields []config.KafkaCompositeKeyField) (map[string]interface{}, error) {
+	obj := make(map[string]interface{}, len(fields))
+	offset := 0
+	for _, field := range fields {
+		width := field.FieldWidth()
+		if width == 0 {
+			width = len(key) - offset
+		}
+		if offset+width > len(key) {
+			return nil, fmt.Errorf("key with %d bytes is too short for field %q", len(key), field.Name)
+		}
+		value, err := decodeCompositeKeyField(key[offset:offset+width], field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode field %q: %w", field.Name, err)
+		}
+		obj[field.Name] = value
+		offset += width
+	}
+	if offset != len(key) {
+		return nil, fmt.Errorf("key has %d bytes, but the layout has %d bytes", len(key), offset)
+	}
+	return obj, nil
+}
+
+func decodeCompositeKeyField(b []byte, fieldType string) (interface{}, error) {
+	switch fieldType {
+	case "int8":
+		return int8(b[0]), nil
+	case "int16":
+		return int16(binary.BigEndian.Uint16(b)), nil
+	case "int32":
+		return int32(binary.BigEndian.Uint32(b)), nil
+	case "int64":
+		return int64(binary.BigEndian.Uint64(b)), nil
+	case "uint8":
+		return b[0], nil
+	case "uint16":
+		return binary.BigEndian.Uint16(b), nil
+	case "uint32":
+		return binary.BigEndian.Uint32(b), nil
+	case "uint64":
+		return binary.BigEndian.Uint64(b), nil
+	case "uuid":
+		id, err := uuid.FromBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	case "string":
+		s := bytes.TrimRight(b, "\x00")
+		if !utf8.Valid(s) {
+			return nil, fmt.Errorf("string is not valid UTF-8")
+		}
+		return string(s), nil
+	case "bytes":
+		return hex.EncodeToString(b), nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", fieldType)
+	}
+}
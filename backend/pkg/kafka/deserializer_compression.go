@@ -0,0 +1,180 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// maxDecompressedPayloadSize limits the size of decompressed payloads, so that small
+// payloads can't be used to exhaust the memory.
+const maxDecompressedPayloadSize = 16 * 1024 * 1024
+
+const (
+	compressionSnappy       = "snappy"
+	compressionSnappyXerial = "snappyXerial"
+	compressionSnappyFramed = "snappyFramed"
+	compressionGzip         = "gzip"
+	compressionLz4          = "lz4"
+	compressionZstd         = "zstd"
+)
+
+var (
+	// snappyXerialMagic is the header of the xerial (Hadoop) snappy framing, which is followed
+	// by the version and the minimum compatible version as 4 byte integers each.
+	snappyXerialMagic = []byte{0x82, 'S', 'N', 'A', 'P', 'P', 'Y', 0x00}
+	// snappyFramedMagic is the stream identifier chunk of the snappy framing format.
+	snappyFramedMagic = []byte{0xff, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y'}
+	// gzipMagic is the gzip header's ID followed by the deflate compression method.
+	gzipMagic = []byte{0x1f, 0x8b, 0x08}
+	// lz4FrameMagic is the magic number of the lz4 frame format.
+	lz4FrameMagic = []byte{0x04, 0x22, 0x4d, 0x18}
+	// zstdFrameMagic is the magic number of zstd frames.
+	zstdFrameMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decodeCompressed decompresses payloads that have been compressed by the producer and
+// decodes the result with the decoder chain. The compression is reported along with the
+// inner encoding. Compressed payloads within compressed payloads are not decompressed.
+func (d *deserializer) decodeCompressed(in payloadDecoderInput) *deserializedPayload {
+	if in.Opts.decompressed {
+		return nil
+	}
+
+	compression, decompressed, err := decompressPayload(in.Payload, in.Opts)
+	if err != nil || compression == "" {
+		return nil
+	}
+
+	opts := in.Opts
+	opts.decompressed = true
+	dp := d.decodePayload(decompressed, in.TopicName, in.RecordType, opts)
+
+	// Raw snappy has no header, hence only an inner decoder that succeeds confirms that the
+	// payload actually was compressed. Decoders that accept almost any input don't count.
+	if compression == compressionSnappy && !isStructuredEncoding(dp.RecognizedEncoding) {
+		return nil
+	}
+
+	dp.Compression = compression
+	dp.UncompressedSize = len(decompressed)
+	dp.IsPayloadNull = in.Payload == nil
+	dp.Size = len(in.Payload)
+	return dp
+}
+
+// decompressPayload detects the compression of the payload and decompresses it. An empty
+// compression is returned if the payload is not compressed.
+func decompressPayload(payload []byte, opts DeserializationOptions) (string, []byte, error) {
+	switch {
+	case bytes.HasPrefix(payload, snappyXerialMagic):
+		decompressed, err := decompressSnappyXerial(payload)
+		return compressionSnappyXerial, decompressed, err
+	case bytes.HasPrefix(payload, snappyFramedMagic):
+		decompressed, err := readAllLimited(snappy.NewReader(bytes.NewReader(payload)))
+		return compressionSnappyFramed, decompressed, err
+	case bytes.HasPrefix(payload, gzipMagic):
+		decompressed, err := decompressGzip(payload)
+		return compressionGzip, decompressed, err
+	case bytes.HasPrefix(payload, lz4FrameMagic):
+		decompressed, err := readAllLimited(lz4.NewReader(bytes.NewReader(payload)))
+		return compressionLz4, decompressed, err
+	case bytes.HasPrefix(payload, zstdFrameMagic):
+		decompressed, err := decompressZstd(payload)
+		return compressionZstd, decompressed, err
+	case opts.RawSnappy:
+		decompressed, err := decompressSnappyBlock(payload)
+		return compressionSnappy, decompressed, err
+	}
+	return "", nil, nil
+}
+
+// decompressSnappyXerial decompresses the xerial framing, which consists of the header and
+// blocks of raw snappy that are prefixed by their length as 4 byte big endian integer.
+func decompressSnappyXerial(payload []byte) ([]byte, error) {
+	const headerLength = 16
+	if len(payload) < headerLength {
+		return nil, errors.New("xerial snappy header is truncated")
+	}
+
+	var decompressed []byte
+	remaining := payload[headerLength:]
+	for len(remaining) > 0 {
+		if len(remaining) < 4 {
+			return nil, errors.New("xerial snappy block length is truncated")
+		}
+		blockLength := binary.BigEndian.Uint32(remaining)
+		remaining = remaining[4:]
+		if uint64(blockLength) > uint64(len(remaining)) {
+			return nil, errors.New("xerial snappy block is truncated")
+		}
+
+		block, err := decompressSnappyBlock(remaining[:blockLength])
+		if err != nil {
+			return nil, err
+		}
+		if len(decompressed)+len(block) > maxDecompressedPayloadSize {
+			return nil, errors.New("decompressed payload exceeds size limit")
+		}
+		decompressed = append(decompressed, block...)
+		remaining = remaining[blockLength:]
+	}
+	return decompressed, nil
+}
+
+func decompressSnappyBlock(block []byte) ([]byte, error) {
+	decodedLength, err := snappy.DecodedLen(block)
+	if err != nil {
+		return nil, err
+	}
+	if decodedLength > maxDecompressedPayloadSize {
+		return nil, errors.New("decompressed payload exceeds size limit")
+	}
+	return snappy.Decode(nil, block)
+}
+
+func decompressGzip(payload []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzip header: %w", err)
+	}
+	defer r.Close()
+	return readAllLimited(r)
+}
+
+func decompressZstd(payload []byte) ([]byte, error) {
+	r, err := zstd.NewReader(bytes.NewReader(payload), zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxDecompressedPayloadSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+	}
+	defer r.Close()
+	return readAllLimited(r)
+}
+
+// readAllLimited reads the decompressed payload from r, up to maxDecompressedPayloadSize.
+func readAllLimited(r io.Reader) ([]byte, error) {
+	decompressed, err := io.ReadAll(io.LimitReader(r, maxDecompressedPayloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress payload: %w", err)
+	}
+	if len(decompressed) > maxDecompressedPayloadSize {
+		return nil, errors.New("decompressed payload exceeds size limit")
+	}
+	return decompressed, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// debeziumOperations maps the op codes of Debezium change events to readable names.
+var debeziumOperations = map[string]string{
+	"c": "create",
+	"u": "update",
+	"d": "delete",
+	"r": "read",
+	"t": "truncate",
+}
+
+// debeziumChange is the normalized view of a Debezium change event envelope.
+type debeziumChange struct {
+	Op     string                 `json:"op"`
+	Before map[string]interface{} `json:"before"`
+	After  map[string]interface{} `json:"after"`
+	Source map[string]interface{} `json:"source"`
+	TsMs   json.Number            `json:"tsMs,omitempty"`
+
+	// ChangedFields are the fields whose values differ between before and after. It's only
+	// set for updates.
+	ChangedFields []string `json:"changedFields,omitempty"`
+}
+
+// debeziumDeserializedRecord adds the normalized view of Debezium change events to the
+// record's value. The decoded payload itself is not changed.
+func debeziumDeserializedRecord(rec *deserializedRecord) {
+	dp := rec.Value
+	if dp == nil {
+		return
+	}
+	switch dp.Payload.RecognizedEncoding {
+	case messageEncodingJSON, messageEncodingAvro, messageEncodingProtobuf:
+	default:
+		return
+	}
+	dp.Debezium = parseDebeziumEnvelope(dp.Payload.Payload)
+}
+
+// parseDebeziumEnvelope returns the normalized view of the Debezium envelope in the given
+// JSON payload, or nil if the payload is not a Debezium envelope. Envelopes that are wrapped
+// along with their schema, as done by the JSON converter with schemas enabled, are supported.
+func parseDebeziumEnvelope(payload []byte) *debeziumChange {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var envelope map[string]interface{}
+	if err := dec.Decode(&envelope); err != nil {
+		return nil
+	}
+	if wrapped, ok := envelope["payload"].(map[string]interface{}); ok {
+		if _, hasSchema := envelope["schema"]; hasSchema {
+			envelope = wrapped
+		}
+	}
+
+	op, _ := envelope["op"].(string)
+	opName, isKnownOp := debeziumOperations[op]
+	source, hasSource := envelope["source"].(map[string]interface{})
+	_, hasBefore := envelope["before"]
+	_, hasAfter := envelope["after"]
+	if !isKnownOp || !hasSource || (!hasBefore && !hasAfter) {
+		return nil
+	}
+
+	change := &debeziumChange{Op: opName, Source: source}
+	change.Before, _ = unwrapDebeziumRow(envelope["before"]).(map[string]interface{})
+	change.After, _ = unwrapDebeziumRow(envelope["after"]).(map[string]interface{})
+	change.TsMs, _ = envelope["ts_ms"].(json.Number)
+
+	if op == "u" && change.Before != nil && change.After != nil {
+		for field, after := range change.After {
+			if before, exists := change.Before[field]; !exists || !reflect.DeepEqual(before, after) {
+				change.ChangedFields = append(change.ChangedFields, field)
+			}
+		}
+		for field := range change.Before {
+			if _, exists := change.After[field]; !exists {
+				change.ChangedFields = append(change.ChangedFields, field)
+			}
+		}
+		sort.Strings(change.ChangedFields)
+	}
+	return change
+}
+
+// unwrapDebeziumRow unwraps rows that have been decoded from an Avro union of null and the
+// row record, which are represented as single-key maps with the record name as key.
+func unwrapDebeziumRow(row interface{}) interface{} {
+	wrapped, ok := row.(map[string]interface{})
+	if !ok || len(wrapped) != 1 {
+		return row
+	}
+	for key, inner := range wrapped {
+		if innerRow, isRow := inner.(map[string]interface{}); isRow && strings.Contains(key, ".") {
+			return innerRow
+		}
+	}
+	return row
+}
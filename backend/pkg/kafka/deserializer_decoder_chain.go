@@ -0,0 +1,71 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/redpanda-data/console/backend/pkg/config"
+)
+
+// decoderChain reorders and filters the decoders that are tried during auto-detection.
+// The zero value keeps the default chain.
+type decoderChain struct {
+	order    []string
+	disabled map[string]bool
+}
+
+// newDecoderChain ensures that the configured decoder chain refers to known decoders only.
+func newDecoderChain(cfg config.KafkaDecoderChain) (decoderChain, error) {
+	known := make(map[string]bool)
+	for _, decoder := range (&deserializer{}).allPayloadDecoders() {
+		known[decoder.Name] = true
+	}
+
+	for _, name := range cfg.Order {
+		if !known[name] {
+			return decoderChain{}, fmt.Errorf("unknown decoder %q in order", name)
+		}
+	}
+	disabled := make(map[string]bool, len(cfg.Disabled))
+	for _, name := range cfg.Disabled {
+		if !known[name] {
+			return decoderChain{}, fmt.Errorf("unknown decoder %q in disabled", name)
+		}
+		disabled[name] = true
+	}
+	return decoderChain{order: cfg.Order, disabled: disabled}, nil
+}
+
+// apply returns the ordered decoders first, followed by the remaining decoders in their
+// default order. Disabled decoders are dropped.
+func (c decoderChain) apply(decoders []payloadDecoder) []payloadDecoder {
+	if len(c.order) == 0 && len(c.disabled) == 0 {
+		return decoders
+	}
+
+	chain := make([]payloadDecoder, 0, len(decoders))
+	ordered := make(map[string]bool, len(c.order))
+	for _, name := range c.order {
+		for _, decoder := range decoders {
+			if decoder.Name == name {
+				chain = append(chain, decoder)
+				ordered[name] = true
+				break
+			}
+		}
+	}
+	for _, decoder := range decoders {
+		if !ordered[decoder.Name] && !c.disabled[decoder.Name] {
+			chain = append(chain, decoder)
+		}
+	}
+	return chain
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// flattenDeserializedRecord flattens the key, value and all headers of the given record.
+func flattenDeserializedRecord(rec *deserializedRecord) {
+	flattenDeserializedPayload(rec.Key)
+	flattenDeserializedPayload(rec.Value)
+	for _, header := range rec.Headers {
+		flattenDeserializedPayload(header)
+	}
+}
+
+// flattenDeserializedPayload replaces the payload and object of a deserialized payload with
+// a single-level map, if the payload has been decoded into a JSON object or array. This is a
+// post-processing step and therefore works the same for all encodings.
+func flattenDeserializedPayload(dp *deserializedPayload) {
+	if dp == nil {
+		return
+	}
+	if !isStructuredEncoding(dp.Payload.RecognizedEncoding) {
+		return
+	}
+
+	// We decode the normalized JSON payload rather than the object, so that the flattened
+	// payload is the same regardless of the native Go types each decoder returns.
+	dec := json.NewDecoder(bytes.NewReader(dp.Payload.Payload))
+	dec.UseNumber()
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return
+	}
+	switch obj.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return
+	}
+
+	flattened := make(map[string]interface{})
+	flattenValue("", obj, flattened)
+	jsonBytes, err := json.Marshal(flattened)
+	if err != nil {
+		return
+	}
+
+	dp.Payload.Payload = jsonBytes
+	dp.Object = flattened
+}
+
+// flattenValue adds all leaves of the given value to the flattened map. Nested object keys are
+// joined with a dot and array indices are appended in square brackets. Empty objects and arrays
+// are kept as leaves so that they are not lost.
+func flattenValue(prefix string, value interface{}, flattened map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && prefix != "" {
+			flattened[prefix] = v
+			return
+		}
+		for key, child := range v {
+			childKey := key
+			if prefix != "" {
+				childKey = prefix + "." + key
+			}
+			flattenValue(childKey, child, flattened)
+		}
+	case []interface{}:
+		if len(v) == 0 && prefix != "" {
+			flattened[prefix] = v
+			return
+		}
+		for i, child := range v {
+			flattenValue(prefix+"["+strconv.Itoa(i)+"]", child, flattened)
+		}
+	default:
+		flattened[prefix] = v
+	}
+}
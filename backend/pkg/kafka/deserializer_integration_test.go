@@ -213,7 +213,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingJSON, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -301,7 +301,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -455,7 +455,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -624,7 +624,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -764,7 +764,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -915,7 +915,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -1060,7 +1060,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -1283,7 +1283,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -1452,7 +1452,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 		assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -1546,7 +1546,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 			cr := cr
 
 			if string(cr.Key) == msg.Id {
-				dr := svc2.Deserializer.DeserializeRecord(cr)
+				dr := svc2.Deserializer.DeserializeRecord(cr, DeserializationOptions{})
 				require.NotNil(dr)
 				assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 				assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -1564,7 +1564,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 				assert.Equal("222", o2.Id)
 				assert.Equal(timestamppb.New(orderCreatedAt).GetSeconds(), o2.GetCreatedAt().GetSeconds())
 			} else if string(cr.Key) == msg2ID {
-				dr := svc2.Deserializer.DeserializeRecord(cr)
+				dr := svc2.Deserializer.DeserializeRecord(cr, DeserializationOptions{})
 				require.NotNil(dr)
 				assert.Equal(messageEncodingProtobuf, dr.Value.Payload.RecognizedEncoding)
 				assert.IsType(map[string]interface{}{}, dr.Value.Object)
@@ -1660,7 +1660,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingText, dr.Value.Payload.RecognizedEncoding)
 		assert.Equal("my text value", dr.Value.Object)
@@ -1730,7 +1730,7 @@ func (s *KafkaIntegrationTestSuite) TestDeserializeRecord() {
 
 		require.NotEmpty(record)
 
-		dr := svc.Deserializer.DeserializeRecord(record)
+		dr := svc.Deserializer.DeserializeRecord(record, DeserializationOptions{})
 		require.NotNil(dr)
 		assert.Equal(messageEncodingText, dr.Value.Payload.RecognizedEncoding)
 		assert.Equal("my text value", dr.Value.Object)
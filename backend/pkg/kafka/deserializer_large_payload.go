@@ -0,0 +1,114 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// largePayloadSerdeName is the name that troubleshooting reports of large payloads refer to.
+const largePayloadSerdeName = "largePayload"
+
+// largePayloadJSONPrefixBytes is the number of leading bytes of a large payload that are
+// tokenized to tell whether it is JSON.
+const largePayloadJSONPrefixBytes = 4096
+
+// lightweightDecoders are the decoders that are tried for large payloads. They check
+// for magic bytes before they decode anything. JSON is only checked by its prefix, see
+// decodeLargeJSON.
+var lightweightDecoders = map[string]bool{
+	"varintSchemaId":   true,
+	"trailingSchemaId": true,
+	"avroSingleObject": true,
+	"smile":            true,
+	"utf8":             true,
+	"uint":             true,
+}
+
+// skipForLargePayload returns true if the decoder shall not be tried, because the payload
+// exceeds the large payload threshold. Schema registry backed decoders are still tried if
+// the payload refers to a registered schema, all other heavy decoders are skipped.
+func (d *deserializer) skipForLargePayload(decoderName string, in payloadDecoderInput) bool {
+	if in.Opts.LargePayloadBytes <= 0 || len(in.Payload) <= in.Opts.LargePayloadBytes {
+		return false
+	}
+	if lightweightDecoders[decoderName] {
+		return false
+	}
+	for _, serdeName := range schemaRegistryBackedSerdes {
+		if serdeName == decoderName {
+			return d.registeredSchemaID(in.Payload) == 0
+		}
+	}
+	return true
+}
+
+// largePayloadFallback returns the cheap decoder that is tried instead of the given decoder,
+// if it's skipped for a large payload.
+func (d *deserializer) largePayloadFallback(decoderName string) (payloadDecoder, bool) {
+	if decoderName == "json" {
+		return payloadDecoder{Name: decoderName, Decode: d.decodeLargeJSON}, true
+	}
+	return payloadDecoder{}, false
+}
+
+// decodeLargeJSON is tried instead of the json decoder for large payloads. It doesn't parse
+// the payload, but only checks a bounded prefix, so that large JSON payloads are shown as
+// text along with a troubleshooting report.
+func (d *deserializer) decodeLargeJSON(in payloadDecoderInput) *deserializedPayload {
+	if !hasJSONPrefix(in.Trimmed) {
+		return nil
+	}
+	dp := d.decodeUTF8(in)
+	if dp == nil {
+		return nil
+	}
+	dp.Troubleshooting = append(dp.Troubleshooting, troubleshootingReport{
+		SerdeName: "json",
+		Message: fmt.Sprintf("payload of %d bytes starts like JSON, but exceeds the large payload threshold of %d bytes, "+
+			"hence it has not been parsed and is shown as text", len(in.Payload), in.Opts.LargePayloadBytes),
+	})
+	return dp
+}
+
+// hasJSONPrefix returns true if the payload starts with a JSON object or array whose first
+// largePayloadJSONPrefixBytes are syntactically valid.
+func hasJSONPrefix(trimmed []byte) bool {
+	if trimmed[0] != '{' && trimmed[0] != '[' {
+		return false
+	}
+	prefix := trimmed
+	if len(prefix) > largePayloadJSONPrefixBytes {
+		prefix = prefix[:largePayloadJSONPrefixBytes]
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(prefix))
+	for {
+		if _, err := dec.Token(); err != nil {
+			// The prefix ends in the middle of the document
+			var syntaxErr *json.SyntaxError
+			return !errors.As(err, &syntaxErr)
+		}
+	}
+}
+
+// largePayloadTroubleshooting explains that a payload may be binary only because
+// heavy decoders have been skipped.
+func largePayloadTroubleshooting(size int, skippedDecoders []string, opts DeserializationOptions) troubleshootingReport {
+	return troubleshootingReport{
+		SerdeName: largePayloadSerdeName,
+		Message: fmt.Sprintf("payload of %d bytes exceeds the large payload threshold of %d bytes, "+
+			"hence these decoders have been skipped: %s", size, opts.LargePayloadBytes, strings.Join(skippedDecoders, ", ")),
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/redpanda-data/console/backend/pkg/promutil"
+)
+
+// serdeMetrics counts the decode attempts of each serde in the decoder chain, including the
+// attempts of serdes that failed before another serde succeeded. A nil *serdeMetrics is valid
+// and does not record anything.
+type serdeMetrics struct {
+	attempts *prometheus.CounterVec
+	duration *prometheus.CounterVec
+}
+
+// newSerdeMetrics creates the serde metrics and registers them with the given registerer. If
+// the registerer is nil, nil is returned so that no metrics are recorded. Metrics that have
+// already been registered by another service instance are reused.
+func newSerdeMetrics(reg prometheus.Registerer, metricsNamespace string) (*serdeMetrics, error) {
+	if reg == nil {
+		return nil, nil
+	}
+
+	attempts := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "serde",
+		Name:      "decode_attempts_total",
+		Help:      "Number of decode attempts per serde and result (success or failure)",
+	}, []string{"serde", "result"})
+	duration := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "serde",
+		Name:      "decode_duration_seconds_total",
+		Help:      "Time spent in decode attempts per serde and result (success or failure)",
+	}, []string{"serde", "result"})
+
+	var err error
+	if attempts, err = promutil.RegisterOrReuse(reg, attempts); err != nil {
+		return nil, err
+	}
+	if duration, err = promutil.RegisterOrReuse(reg, duration); err != nil {
+		return nil, err
+	}
+	return &serdeMetrics{attempts: attempts, duration: duration}, nil
+}
+
+// observe records a single decode attempt of the given serde.
+func (m *serdeMetrics) observe(serdeName string, success bool, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	m.attempts.WithLabelValues(serdeName, result).Inc()
+	m.duration.WithLabelValues(serdeName, result).Add(elapsed.Seconds())
+}
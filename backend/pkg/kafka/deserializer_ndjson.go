@@ -0,0 +1,72 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// decodeGzipNDJSON decodes gzip compressed newline-delimited JSON, as written by log
+// forwarding agents that batch several log lines into a single record. The lines are
+// returned as a JSON array. Payloads that are not gzip compressed or where any line is
+// not a JSON object are left to the other decoders.
+func (*deserializer) decodeGzipNDJSON(in payloadDecoderInput) *deserializedPayload {
+	if !in.Opts.GzipNDJSON || in.Opts.decompressed || !bytes.HasPrefix(in.Payload, gzipMagic) {
+		return nil
+	}
+
+	decompressed, err := decompressGzip(in.Payload)
+	if err != nil {
+		return nil
+	}
+	objects, ok := parseNDJSONObjects(decompressed)
+	if !ok {
+		return nil
+	}
+	normalized, err := json.Marshal(objects)
+	if err != nil {
+		return nil
+	}
+
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            normalized,
+			RecognizedEncoding: messageEncodingJSON,
+		},
+		IsPayloadNull:      in.Payload == nil,
+		Object:             objects,
+		RecognizedEncoding: messageEncodingJSON,
+		Compression:        compressionGzip,
+		UncompressedSize:   len(decompressed),
+		Size:               len(in.Payload),
+	}
+}
+
+// parseNDJSONObjects parses each non-empty line of the payload as JSON object. It returns
+// false if the payload contains no objects or any line is not a JSON object.
+func parseNDJSONObjects(payload []byte) ([]interface{}, bool) {
+	var objects []interface{}
+	for _, line := range bytes.Split(payload, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '{' {
+			return nil, false
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(line, &obj); err != nil {
+			return nil, false
+		}
+		objects = append(objects, obj)
+	}
+	return objects, len(objects) > 0
+}
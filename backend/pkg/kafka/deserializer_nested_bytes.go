@@ -0,0 +1,89 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+// decodeNestedBytesRecord decodes the nested payloads of the record's key and value.
+func (d *deserializer) decodeNestedBytesRecord(rec *deserializedRecord, record *kgo.Record, patterns []redactPattern, opts DeserializationOptions) {
+	d.decodeNestedBytesPayload(rec.Key, record.Topic, proto.RecordKey, patterns, opts)
+	d.decodeNestedBytesPayload(rec.Value, record.Topic, proto.RecordValue, patterns, opts)
+}
+
+// decodeNestedBytesPayload decodes the values of Avro bytes fields that match any of the
+// patterns with the decoder chain, and inlines the decoded values. Fields whose values can't
+// be decoded into anything but binary are left as is.
+func (d *deserializer) decodeNestedBytesPayload(dp *deserializedPayload, topicName string, recordType proto.RecordPropertyType, patterns []redactPattern, opts DeserializationOptions) {
+	if dp == nil || dp.Payload.RecognizedEncoding != messageEncodingAvro {
+		return
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(dp.Payload.Payload))
+	dec.UseNumber()
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return
+	}
+
+	decodeNested := func(value interface{}) interface{} {
+		// Bytes are encoded as base64 strings in the normalized payload. Nullable bytes are
+		// wrapped in an object with the union's type name as key.
+		if union, isUnion := value.(map[string]interface{}); isUnion && len(union) == 1 {
+			if inner, isBytes := union["bytes"].(string); isBytes {
+				value = inner
+			}
+		}
+		encoded, ok := value.(string)
+		if !ok {
+			return value
+		}
+		nested, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(nested) == 0 {
+			return value
+		}
+		return d.decodeNestedBytes(nested, topicName, recordType, opts, value)
+	}
+
+	decoded := walkMatchingValues(obj, nil, patterns, decodeNested)
+	jsonBytes, err := json.Marshal(decoded)
+	if err != nil {
+		return
+	}
+	dp.Payload.Payload = jsonBytes
+	dp.Object = decoded
+}
+
+// decodeNestedBytes decodes the nested payload and returns the value that is inlined, or the
+// fallback if the payload can only be decoded as binary.
+func (d *deserializer) decodeNestedBytes(nested []byte, topicName string, recordType proto.RecordPropertyType, opts DeserializationOptions, fallback interface{}) interface{} {
+	nestedDp := d.decodePayload(nested, topicName, recordType, opts)
+	if nestedDp.Payload.RecognizedEncoding == messageEncodingText {
+		return string(nestedDp.Payload.Payload)
+	}
+	if !isStructuredEncoding(nestedDp.Payload.RecognizedEncoding) {
+		return fallback
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(nestedDp.Payload.Payload))
+	dec.UseNumber()
+	var inlined interface{}
+	if err := dec.Decode(&inlined); err != nil {
+		return fallback
+	}
+	return inlined
+}
@@ -0,0 +1,319 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+// DeserializationOptions are sent along with a list messages request and tweak how
+// the record payloads are deserialized. The zero value retains the default behaviour.
+type DeserializationOptions struct {
+	// EmptyPayloadAsText classifies non-null payloads of length zero as empty text rather
+	// than as none. Null payloads are still reported as none, so that both can be told apart.
+	EmptyPayloadAsText bool `json:"emptyPayloadAsText"`
+
+	// FlattenPayload flattens decoded keys, values and headers into a single-level object
+	// with dotted keys (e.g. `a.b.c` or `a.items[0]`), so that they can be shown in a table.
+	FlattenPayload bool `json:"flattenPayload"`
+
+	// DecodeBudgetMs limits the time in milliseconds that may be spent on decoding a single
+	// record (key, value and headers). Once exceeded, all remaining payloads are returned as
+	// binary. Zero means no limit.
+	DecodeBudgetMs int `json:"decodeBudgetMs"`
+
+	// KeyEncoding and ValueEncoding force the decoder (e.g. "avro", "json" or "uint") of keys
+	// and values rather than auto-detecting their encoding. No other decoder is tried, and
+	// payloads that the decoder fails on are returned as binary with a troubleshooting
+	// report. Use "binary" to skip decoding, or the name of a registered custom serde to force
+	// it. Headers are still auto-detected.
+	KeyEncoding   string `json:"keyEncoding,omitempty"`
+	ValueEncoding string `json:"valueEncoding,omitempty"`
+
+	// KeyProtobufType and ValueProtobufType are the fully qualified message types (e.g.
+	// "shop.Order") that plain Protobuf keys and values without schema registry framing are
+	// decoded with, such as types from configured FileDescriptorSets. They require the key or
+	// value encoding to be forced to "protobuf" and take precedence over the topic mappings.
+	KeyProtobufType   string `json:"keyProtobufType,omitempty"`
+	ValueProtobufType string `json:"valueProtobufType,omitempty"`
+
+	// VarintSchemaID enables decoding payloads that are framed with a magic byte, followed
+	// by the schema ID as varint rather than as 4 byte big endian integer.
+	VarintSchemaID VarintSchemaIDOptions `json:"varintSchemaId"`
+
+	// TrailingSchemaID enables decoding Avro payloads that carry the schema ID at the end rather
+	// than at the start. This conflicts with the regular framing, which it takes precedence over.
+	TrailingSchemaID TrailingSchemaIDOptions `json:"trailingSchemaId"`
+
+	// AvroFingerprintHeader is the name of a record header that carries the CRC-64-AVRO
+	// fingerprint of the schema that the value has been encoded with, as 8 byte little endian
+	// integer. Values of such records are decoded as raw Avro body with the registered schema
+	// of that fingerprint.
+	AvroFingerprintHeader string `json:"avroFingerprintHeader,omitempty"`
+
+	// SchemaIDHeaders name the record headers that carry the schema registry IDs of keys and
+	// values, for producers that don't prefix the payload with the schema ID. Keys or values
+	// of records with such a header are decoded as unframed Avro, Protobuf or JSON body with
+	// the registered schema of that ID.
+	SchemaIDHeaders SchemaIDHeaderOptions `json:"schemaIdHeaders"`
+
+	// HeadersOnly skips decoding record keys and values, which are returned as placeholders
+	// along with their size, so that scanning a topic for header values is cheap.
+	HeadersOnly bool `json:"headersOnly"`
+
+	// HeadersAsText returns headers as UTF-8 text (or binary if they are not valid UTF-8)
+	// rather than trying all decoders on them.
+	HeadersAsText bool `json:"headersAsText"`
+
+	// RawSnappy enables decompressing payloads that are compressed with the raw snappy block
+	// format. This format has no header and is therefore only reported if the decompressed
+	// payload can be decoded. Snappy framings with header are always decompressed.
+	RawSnappy bool `json:"rawSnappy"`
+
+	// GzipNDJSON decodes gzip compressed newline-delimited JSON (e.g. batched log lines of
+	// log forwarding agents) as array of the JSON objects. Without it, such payloads are
+	// decompressed and shown as text.
+	GzipNDJSON bool `json:"gzipNdjson"`
+
+	// LenientUTF8 decodes text payloads with a few invalid UTF-8 sequences as text, where
+	// each invalid byte is replaced with U+FFFD. By default, such payloads are decoded as
+	// binary, so that binary payloads are never masked as text.
+	LenientUTF8 bool `json:"lenientUtf8"`
+
+	// IncludeDecodeDuration reports the time spent on deserializing each record, so that
+	// records or schemas that are slow to decode can be spotted.
+	IncludeDecodeDuration bool `json:"includeDecodeDuration"`
+
+	// IncludeSchemaVersion looks up the subject and version of the schema that has been used
+	// to decode a payload. This requires an additional (cached) request per schema ID.
+	IncludeSchemaVersion bool `json:"includeSchemaVersion"`
+
+	// AvroDocs attaches the doc strings of Avro schemas to decoded Avro payloads, keyed by the
+	// path of each documented field in the decoded payload (e.g. `customer.email`), so that
+	// fields can be shown with their description.
+	AvroDocs bool `json:"avroDocs"`
+
+	// AvroFixedAsHex returns the values of Avro fixed types without logical type as hex
+	// string rather than as base64 encoded bytes, which is easier to read for identifiers
+	// such as 16 byte UUIDs. Fixed decimals and durations are returned readable, unless
+	// AvroRawLogicalTypes is set.
+	AvroFixedAsHex bool `json:"avroFixedAsHex"`
+
+	// AvroRawLogicalTypes returns the values of Avro logical types as their underlying Avro
+	// type, e.g. timestamps as milliseconds since epoch and decimals as unscaled bytes. By
+	// default, timestamps are returned as RFC 3339 strings, dates as "2006-01-02", times of
+	// day as "15:04:05.000" and decimals as decimal strings.
+	AvroRawLogicalTypes bool `json:"avroRawLogicalTypes"`
+
+	// AvroJSONEncoding returns decoded Avro payloads as specified by the Avro JSON encoding,
+	// so that they can be produced again with Avro tooling such as kafka-avro-console-producer.
+	// Non-null union values are wrapped in an object with the type name as key, bytes and fixed
+	// values are strings with one code point per byte and logical types are returned raw. It
+	// takes precedence over AvroFixedAsHex and AvroRawLogicalTypes.
+	AvroJSONEncoding bool `json:"avroJsonEncoding"`
+
+	// ProtobufEnumsAsNameAndNumber renders enum values of Protobuf payloads as object with
+	// both the number and the name (e.g. `{"value": 2, "name": "ACTIVE"}`), so that the
+	// number is not lost. Numbers unknown to the schema are returned with an empty name.
+	ProtobufEnumsAsNameAndNumber bool `json:"protobufEnumsAsNameAndNumber"`
+
+	// Debezium adds a normalized view (operation, before, after and source) to values that
+	// are Debezium change event envelopes. The decoded value itself is not changed.
+	Debezium bool `json:"debezium"`
+
+	// CoerceNumericStrings converts string values that are a number as a whole (e.g. "30" but
+	// not "02134") to numbers in JSON, XML, MessagePack and Smile payloads. Payloads with a
+	// schema such as Avro or Protobuf keep their types.
+	CoerceNumericStrings bool `json:"coerceNumericStrings"`
+
+	// PreciseJSONIntegers returns integers in JSON, XML, MessagePack and Smile payloads that
+	// exceed the range that a float64 represents exactly (2^53) as decimal strings, so that
+	// 64 bit IDs are not rounded. Smaller integers and all other numbers are kept as numbers.
+	PreciseJSONIntegers bool `json:"preciseJsonIntegers"`
+
+	// Redact replaces the values of matching fields in decoded keys, values and headers with
+	// a placeholder. Patterns starting with "$" are JSONPaths (e.g. `$.items[*].ssn`), all
+	// others are glob patterns for field names at any depth (e.g. `email` or `*_ssn`).
+	Redact []string `json:"redact,omitempty"`
+
+	// TagRules tag records whose key or value matches a simple comparison, such as a status
+	// field being "ERROR", so that they can be highlighted. The tags of matching records are
+	// returned along with the record.
+	TagRules []TagRule `json:"tagRules,omitempty"`
+
+	// Schemaless decodes Avro and Protobuf payloads that are not framed with a schema ID with
+	// the latest schema of the subject that is selected by the subject name strategy. It
+	// takes precedence over all other decoders.
+	Schemaless SchemalessOptions `json:"schemaless"`
+
+	// NestedBytesFields selects Avro bytes fields that carry nested encoded payloads (e.g.
+	// JSON). Their values are decoded with the decoder chain and inlined, unless they can
+	// only be decoded as binary. The patterns have the same syntax as the Redact patterns.
+	NestedBytesFields []string `json:"nestedBytesFields,omitempty"`
+
+	// PreviewBytes limits decoding of payloads that are larger than the given number of bytes.
+	// Text payloads (e.g. JSON lines) are decoded up to the limit, other payloads such as Avro
+	// or Protobuf can't be decoded partially and only their metadata is returned. Both are
+	// flagged as truncated. 0 decodes all payloads entirely.
+	PreviewBytes int `json:"previewBytes"`
+
+	// LargePayloadBytes is the size above which payloads are only tried with decoders that
+	// detect their format cheaply, such as by magic bytes. JSON is only detected by its
+	// prefix and shown as text. Decoders that require a schema are only tried if the payload
+	// refers to a registered schema. This caps the memory and CPU that is spent on large
+	// records. 0 tries all decoders.
+	LargePayloadBytes int `json:"largePayloadBytes"`
+
+	// deadline is derived from DecodeBudgetMs when a record is deserialized.
+	deadline time.Time
+	// decompressed is set while decoding a decompressed payload.
+	decompressed bool
+	// patterns are the parsed patterns and rules, so that they are not parsed for each record.
+	patterns *parsedPatterns
+}
+
+// parsedPatterns are the parsed redact patterns, nested bytes fields and tag rules of the
+// options.
+type parsedPatterns struct {
+	redact            []redactPattern
+	nestedBytesFields []redactPattern
+	tagRules          []tagRule
+}
+
+// parsePatterns parses the redact patterns, nested bytes fields and tag rules. The returned
+// patterns are usable even if an error is returned: Invalid redact patterns redact all fields
+// rather than leaking them, while invalid nested bytes fields and tag rules are ignored.
+func (o DeserializationOptions) parsePatterns() (*parsedPatterns, error) {
+	var errs []error
+	patterns := &parsedPatterns{}
+
+	redact, err := parseRedactPatterns(o.Redact)
+	if err != nil {
+		redact = []redactPattern{{fieldName: "*"}}
+		errs = append(errs, fmt.Errorf("invalid redact option: %w", err))
+	}
+	patterns.redact = redact
+
+	nestedBytesFields, err := parseRedactPatterns(o.NestedBytesFields)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid nested bytes fields option: %w", err))
+	} else {
+		patterns.nestedBytesFields = nestedBytesFields
+	}
+
+	tagRules, err := parseTagRules(o.TagRules)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid tag rules option: %w", err))
+	} else {
+		patterns.tagRules = tagRules
+	}
+
+	return patterns, errors.Join(errs...)
+}
+
+// withParsedPatterns returns the options along with their parsed patterns, so that records
+// that are deserialized with them don't parse the patterns again.
+func (o DeserializationOptions) withParsedPatterns() DeserializationOptions {
+	o.patterns, _ = o.parsePatterns()
+	return o
+}
+
+// parsedPatterns returns the parsed patterns of the options, which are parsed now if the
+// options haven't been prepared with withParsedPatterns.
+func (o DeserializationOptions) parsedPatterns() *parsedPatterns {
+	if o.patterns != nil {
+		return o.patterns
+	}
+	patterns, _ := o.parsePatterns()
+	return patterns
+}
+
+// VarintSchemaIDOptions configure the decoding of payloads with varint encoded schema IDs.
+type VarintSchemaIDOptions struct {
+	Enabled bool `json:"enabled"`
+	// MagicByte is the first byte of each payload that uses this framing.
+	MagicByte byte `json:"magicByte"`
+}
+
+// TrailingSchemaIDOptions configure the decoding of payloads with a trailing schema ID.
+type TrailingSchemaIDOptions struct {
+	Enabled bool `json:"enabled"`
+	// MagicByte is the last byte of each payload that uses this framing, following the
+	// schema ID. If nil, the schema ID is expected in the last 4 bytes.
+	MagicByte *byte `json:"magicByte,omitempty"`
+}
+
+// SchemaIDHeaderOptions name the headers that carry schema IDs. The IDs are expected as 4 or
+// 8 byte big endian integer, or as decimal text.
+type SchemaIDHeaderOptions struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// forcedEncoding returns the decoder that is forced for keys or values, if any.
+func (o DeserializationOptions) forcedEncoding(recordType proto.RecordPropertyType) string {
+	if recordType == proto.RecordKey {
+		return o.KeyEncoding
+	}
+	return o.ValueEncoding
+}
+
+// protobufType returns the message type that keys or values are decoded with, if any.
+func (o DeserializationOptions) protobufType(recordType proto.RecordPropertyType) string {
+	if recordType == proto.RecordKey {
+		return o.KeyProtobufType
+	}
+	return o.ValueProtobufType
+}
+
+// decodeBudgetExceeded returns true if the record's decode budget has been used up.
+func (o DeserializationOptions) decodeBudgetExceeded() bool {
+	return !o.deadline.IsZero() && time.Now().After(o.deadline)
+}
+
+// avroValueFormat returns the format of decoded Avro values.
+func (o DeserializationOptions) avroValueFormat() avroValueFormat {
+	if o.AvroJSONEncoding {
+		return avroValueFormat{rawLogicalTypes: true, jsonEncoding: true}
+	}
+	return avroValueFormat{fixedAsHex: o.AvroFixedAsHex, rawLogicalTypes: o.AvroRawLogicalTypes}
+}
+
+// protoUnmarshalOptions returns the options for rendering Protobuf payloads as JSON.
+func (o DeserializationOptions) protoUnmarshalOptions() proto.UnmarshalOptions {
+	return proto.UnmarshalOptions{EnumsAsNameAndNumber: o.ProtobufEnumsAsNameAndNumber}
+}
+
+// Validate checks the options for errors that can be detected before any record is decoded.
+func (o DeserializationOptions) Validate() error {
+	if _, err := o.parsePatterns(); err != nil {
+		return err
+	}
+	if err := o.Schemaless.validate(); err != nil {
+		return fmt.Errorf("invalid schemaless option: %w", err)
+	}
+	if o.KeyProtobufType != "" && o.KeyEncoding != string(messageEncodingProtobuf) {
+		return fmt.Errorf("key protobuf type requires the key encoding %q", messageEncodingProtobuf)
+	}
+	if o.ValueProtobufType != "" && o.ValueEncoding != string(messageEncodingProtobuf) {
+		return fmt.Errorf("value protobuf type requires the value encoding %q", messageEncodingProtobuf)
+	}
+	if o.PreviewBytes < 0 {
+		return fmt.Errorf("preview bytes must not be negative")
+	}
+	if o.LargePayloadBytes < 0 {
+		return fmt.Errorf("large payload bytes must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// maxSafeInteger is the largest integer that can be represented exactly as float64, which is
+// what JSON numbers are decoded to both by encoding/json and by JavaScript.
+const maxSafeInteger = 1 << 53
+
+// preciseIntegersRecord preserves large integers in the key, value and all headers of the
+// given record.
+func preciseIntegersRecord(rec *deserializedRecord) {
+	preciseIntegersPayload(rec.Key)
+	preciseIntegersPayload(rec.Value)
+	for _, header := range rec.Headers {
+		preciseIntegersPayload(header)
+	}
+}
+
+// preciseIntegersPayload replaces all integers that can't be represented as float64 without
+// losing precision with their decimal string, both in the normalized payload and in the
+// object that is passed to the filter interpreter. Other numbers are kept as float64.
+func preciseIntegersPayload(dp *deserializedPayload) {
+	if dp == nil {
+		return
+	}
+	switch dp.Payload.RecognizedEncoding {
+	case messageEncodingJSON, messageEncodingXML, messageEncodingMsgP, messageEncodingSmile:
+	default:
+		return
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(dp.Payload.Payload))
+	dec.UseNumber()
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return
+	}
+
+	precise, changed := preciseIntegers(obj)
+	if !changed {
+		return
+	}
+	jsonBytes, err := json.Marshal(precise)
+	if err != nil {
+		return
+	}
+
+	dp.Payload.Payload = jsonBytes
+	dp.Object = precise
+}
+
+// preciseIntegers walks the given value that has been decoded with json.Number and replaces
+// large integers with strings and all other numbers with float64.
+func preciseIntegers(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		changed := false
+		for key, child := range v {
+			precise, childChanged := preciseIntegers(child)
+			v[key] = precise
+			changed = changed || childChanged
+		}
+		return v, changed
+	case []interface{}:
+		changed := false
+		for i, child := range v {
+			precise, childChanged := preciseIntegers(child)
+			v[i] = precise
+			changed = changed || childChanged
+		}
+		return v, changed
+	case json.Number:
+		if isLargeInteger(v) {
+			return v.String(), true
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return v.String(), true
+		}
+		return f, false
+	default:
+		return v, false
+	}
+}
+
+// isLargeInteger returns true if the number is an integer literal whose absolute value
+// exceeds the range of integers that float64 represents exactly.
+func isLargeInteger(n json.Number) bool {
+	s := n.String()
+	if strings.ContainsAny(s, ".eE") {
+		return false
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		// Out of the int64 range, which is beyond the safe range as well
+		return true
+	}
+	return i > maxSafeInteger || i < -maxSafeInteger
+}
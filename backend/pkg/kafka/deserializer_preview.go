@@ -0,0 +1,128 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+// previewSerdeName is the name that troubleshooting reports of truncated payloads refer to.
+const previewSerdeName = "preview"
+
+// previewTextDecoder is the name of the decoder that decodes the prefix of text payloads.
+const previewTextDecoder = "utf8"
+
+// decodePreview decodes a payload that exceeds the preview limit. Text payloads are decoded
+// up to the limit, whereas only the metadata (size and schema ID) of all other payloads is
+// returned, because formats such as Avro or Protobuf can't be decoded partially. Payloads are
+// only considered text if they neither refer to a registered schema nor start with the
+// signature of a binary format, and if their prefix is printable. If an encoding is forced,
+// the prefix is only decoded if the forced encoding is text.
+func (d *deserializer) decodePreview(payload []byte, topicName string, recordType proto.RecordPropertyType, opts DeserializationOptions) *deserializedPayload {
+	schemaID := d.registeredSchemaID(payload)
+	prefix := previewPrefix(payload, opts.PreviewBytes)
+	forcedEncoding := opts.forcedEncoding(recordType)
+	isText := forcedEncoding == previewTextDecoder ||
+		(forcedEncoding == "" && schemaID == 0 && !hasBinarySignature(payload) && isPrintableText(prefix, opts.LenientUTF8))
+	if isText {
+		in := payloadDecoderInput{Payload: prefix, TopicName: topicName, RecordType: recordType, Opts: opts}
+		if dp := d.decodeUTF8(in); dp != nil {
+			dp.Size = len(payload)
+			dp.Truncated = true
+			return dp
+		}
+	}
+
+	dp := newSkippedPayload(payload)
+	dp.Truncated = true
+	dp.SchemaID = schemaID
+	dp.Troubleshooting = []troubleshootingReport{{
+		SerdeName: previewSerdeName,
+		Message: fmt.Sprintf("payload of %d bytes exceeds the preview limit of %d bytes and can't be "+
+			"decoded partially, hence only its metadata is returned", len(payload), opts.PreviewBytes),
+	}}
+	return dp
+}
+
+// binarySignatures are the leading bytes of binary formats that the deserializer knows.
+var binarySignatures = [][]byte{
+	avroOCFMagic, avroSingleObjectMarker,
+	gzipMagic, zstdFrameMagic, lz4FrameMagic, snappyXerialMagic, snappyFramedMagic,
+}
+
+// hasBinarySignature returns true if the payload starts with the magic byte of the schema
+// registry wire format or with the signature of another binary format.
+func hasBinarySignature(payload []byte) bool {
+	if len(payload) > 0 && payload[0] == 0 {
+		return true
+	}
+	for _, signature := range binarySignatures {
+		if bytes.HasPrefix(payload, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrintableText returns true if the prefix contains non-whitespace text and no control
+// characters other than line breaks and tabs. Invalid UTF-8 is only accepted if lenient.
+func isPrintableText(prefix []byte, lenient bool) bool {
+	if len(bytes.TrimLeft(prefix, " \t\r\n")) == 0 {
+		return false
+	}
+	for i := 0; i < len(prefix); {
+		r, size := utf8.DecodeRune(prefix[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			if !lenient {
+				return false
+			}
+		case r == '\t' || r == '\r' || r == '\n':
+		case unicode.IsControl(r):
+			return false
+		}
+	}
+	return true
+}
+
+// previewPrefix returns the first bytes of the payload up to the given limit. A rune that
+// is cut off at the limit is dropped. If the prefix contains multiple lines, such as JSON
+// lines, it ends with the last complete line.
+func previewPrefix(payload []byte, limit int) []byte {
+	prefix := payload[:limit]
+	for i := 0; i < utf8.UTFMax-1 && len(prefix) > 0 && !utf8.Valid(prefix); i++ {
+		prefix = prefix[:len(prefix)-1]
+	}
+	if idx := bytes.LastIndexByte(prefix, '\n'); idx > 0 {
+		prefix = prefix[:idx+1]
+	}
+	return prefix
+}
+
+// registeredSchemaID returns the ID of the registered schema that the payload refers to in
+// the schema registry wire format, or 0 if there's none.
+func (d *deserializer) registeredSchemaID(payload []byte) uint32 {
+	if d.SchemaService == nil || len(payload) < 5 || payload[0] != 0 {
+		return 0
+	}
+	schemaID := binary.BigEndian.Uint32(payload[1:5])
+	if _, err := d.SchemaService.GetSchemaTypeByID(context.Background(), schemaID); err != nil {
+		return 0
+	}
+	return schemaID
+}
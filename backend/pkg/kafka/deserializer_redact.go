@@ -0,0 +1,190 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// redactedPlaceholder replaces the values of redacted fields.
+const redactedPlaceholder = "[REDACTED]"
+
+// redactPattern is a parsed pattern of DeserializationOptions.Redact.
+type redactPattern struct {
+	// fieldName is a glob pattern that is matched against field names at any depth.
+	fieldName string
+	// jsonPath are the segments of a JSONPath pattern that is matched against the full
+	// path of a value. A segment of "*" matches any field name or array index.
+	jsonPath []string
+}
+
+// parseRedactPatterns parses the given patterns. Patterns that start with "$" are JSONPaths
+// such as `$.customer.address.*` or `$.items[*].ssn`, all others are field name glob patterns
+// such as `email` or `*_ssn`.
+func parseRedactPatterns(patterns []string) ([]redactPattern, error) {
+	parsed := make([]redactPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if !strings.HasPrefix(pattern, "$") {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+			}
+			parsed = append(parsed, redactPattern{fieldName: pattern})
+			continue
+		}
+
+		// Normalize the bracket notation so that `$.a[*].b` and `$.a.*.b` are equivalent
+		normalized := strings.NewReplacer("[", ".", "]", "").Replace(strings.TrimPrefix(pattern, "$"))
+		segments := strings.Split(strings.TrimPrefix(normalized, "."), ".")
+		for _, segment := range segments {
+			if segment == "" {
+				return nil, fmt.Errorf("invalid redact pattern %q: empty path segment", pattern)
+			}
+		}
+		parsed = append(parsed, redactPattern{jsonPath: segments})
+	}
+	return parsed, nil
+}
+
+// matches returns true if the value with the given field name and path shall be redacted.
+func (p redactPattern) matches(fieldName string, valuePath []string) bool {
+	if p.jsonPath == nil {
+		matched, _ := path.Match(p.fieldName, fieldName)
+		return matched
+	}
+	if len(p.jsonPath) != len(valuePath) {
+		return false
+	}
+	for i, segment := range p.jsonPath {
+		if segment != "*" && segment != valuePath[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// redactDeserializedRecord redacts the key, value and all headers of the given record.
+func redactDeserializedRecord(rec *deserializedRecord, patterns []redactPattern) {
+	redactDeserializedPayload(rec.Key, patterns)
+	redactDeserializedPayload(rec.Value, patterns)
+	for _, header := range rec.Headers {
+		redactDeserializedPayload(header, patterns)
+	}
+}
+
+// redactDeserializedPayload replaces the values of all fields that match any of the patterns
+// with a placeholder. Both the object and the normalized payload are replaced, so that the
+// original values are not sent to the frontend. Like flattening, this is a post-processing
+// step and therefore works the same for all encodings that are decoded into JSON.
+//
+// Redaction fails closed: payloads that are not a JSON object or array, such as text, binary
+// or payloads that fell back to binary because decoding was cut short, are replaced with the
+// placeholder altogether, as their fields can't be told apart.
+func redactDeserializedPayload(dp *deserializedPayload, patterns []redactPattern) {
+	if dp == nil || len(patterns) == 0 {
+		return
+	}
+	switch {
+	case dp.Payload.RecognizedEncoding == messageEncodingNone, dp.Payload.RecognizedEncoding == messageEncodingSkipped:
+		// There is no payload that could be leaked
+		return
+	case !isStructuredEncoding(dp.Payload.RecognizedEncoding):
+		redactWholePayload(dp)
+		return
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(dp.Payload.Payload))
+	dec.UseNumber()
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		redactWholePayload(dp)
+		return
+	}
+	switch obj.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		redactWholePayload(dp)
+		return
+	}
+
+	redacted := redactValue(obj, nil, patterns)
+	jsonBytes, err := json.Marshal(redacted)
+	if err != nil {
+		redactWholePayload(dp)
+		return
+	}
+
+	dp.Payload.Payload = jsonBytes
+	dp.Object = redacted
+}
+
+// redactWholePayload replaces the payload with the placeholder text. The recognized encoding
+// of the payload is kept, so that it's still shown what it has been.
+func redactWholePayload(dp *deserializedPayload) {
+	dp.Payload = normalizedPayload{
+		Payload:            []byte(redactedPlaceholder),
+		RecognizedEncoding: messageEncodingText,
+	}
+	dp.Object = redactedPlaceholder
+}
+
+// redactValue walks the given value and replaces all values whose field name or path match
+// any of the patterns. Array items are matched by path only, with their index as segment.
+func redactValue(value interface{}, valuePath []string, patterns []redactPattern) interface{} {
+	return walkMatchingValues(value, valuePath, patterns, func(interface{}) interface{} {
+		return redactedPlaceholder
+	})
+}
+
+// walkMatchingValues walks the given value and replaces all values whose field name or path
+// match any of the patterns with the result of replace. It's also used to select the fields
+// of other post-processing steps, which share the syntax of redact patterns.
+func walkMatchingValues(value interface{}, valuePath []string, patterns []redactPattern, replace func(interface{}) interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := append(valuePath[:len(valuePath):len(valuePath)], key)
+			if matchesAnyRedactPattern(key, childPath, patterns) {
+				v[key] = replace(child)
+				continue
+			}
+			v[key] = walkMatchingValues(child, childPath, patterns, replace)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			childPath := append(valuePath[:len(valuePath):len(valuePath)], strconv.Itoa(i))
+			if matchesAnyRedactPattern("", childPath, patterns) {
+				v[i] = replace(child)
+				continue
+			}
+			v[i] = walkMatchingValues(child, childPath, patterns, replace)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func matchesAnyRedactPattern(fieldName string, valuePath []string, patterns []redactPattern) bool {
+	for _, pattern := range patterns {
+		if pattern.jsonPath == nil && fieldName == "" {
+			continue
+		}
+		if pattern.matches(fieldName, valuePath) {
+			return true
+		}
+	}
+	return false
+}
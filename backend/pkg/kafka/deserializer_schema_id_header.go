@@ -0,0 +1,89 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+	"github.com/redpanda-data/console/backend/pkg/schema"
+)
+
+// decodeSchemaIDHeader decodes the record key or value as unframed body with the registered
+// schema whose ID is carried in the configured header. Nil is returned if the record has no
+// such header or the payload can't be decoded with the schema, so that it's auto-detected.
+func (d *deserializer) decodeSchemaIDHeader(record *kgo.Record, recordType proto.RecordPropertyType, opts DeserializationOptions) *deserializedPayload {
+	headerKey, payload := opts.SchemaIDHeaders.Value, record.Value
+	if recordType == proto.RecordKey {
+		headerKey, payload = opts.SchemaIDHeaders.Key, record.Key
+	}
+	if headerKey == "" || opts.forcedEncoding(recordType) != "" || d.SchemaService == nil || len(payload) == 0 || opts.decodeBudgetExceeded() {
+		return nil
+	}
+
+	for _, header := range record.Headers {
+		if header.Key != headerKey {
+			continue
+		}
+		schemaID, ok := parseSchemaIDHeader(header.Value)
+		if !ok {
+			return nil
+		}
+		schemaType, err := d.SchemaService.GetSchemaTypeByID(context.Background(), schemaID)
+		if err != nil {
+			return nil
+		}
+
+		var dp *deserializedPayload
+		switch schemaType {
+		case schema.TypeAvro:
+			dp = d.decodeAvroWithSchemaID(payload, schemaID, payload, opts)
+		case schema.TypeProtobuf:
+			dp = d.decodeProtobufWithSchemaID(payload, schemaID, "", opts)
+		case schema.TypeJSON:
+			dp = d.decodeJSONWithSchemaID(payload, schemaID, payload)
+		}
+		if dp == nil {
+			return nil
+		}
+		if opts.IncludeSchemaVersion {
+			d.addSchemaSubjectVersion(dp, record.Topic, recordType)
+		}
+		dp.setNormalizedSize()
+		return dp
+	}
+	return nil
+}
+
+// parseSchemaIDHeader parses a schema ID that is given as decimal text, or as 4 or 8 byte big
+// endian integer. Text is tried first, as binary IDs whose bytes are all ASCII digits would
+// be larger than 800 million.
+func parseSchemaIDHeader(value []byte) (uint32, bool) {
+	if id, err := strconv.ParseUint(string(value), 10, 32); err == nil {
+		return uint32(id), true
+	}
+	switch len(value) {
+	case 4:
+		return binary.BigEndian.Uint32(value), true
+	case 8:
+		id := binary.BigEndian.Uint64(value)
+		if id > math.MaxUint32 {
+			return 0, false
+		}
+		return uint32(id), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+	"github.com/redpanda-data/console/backend/pkg/schema"
+)
+
+// SchemalessOptions configure the decoding of Avro and Protobuf payloads that are not framed
+// with a schema ID. The schema is selected by the subject name strategy that the producer
+// used to register it.
+type SchemalessOptions struct {
+	// Strategy is the subject name strategy. Schemaless decoding is disabled if empty.
+	Strategy schema.SubjectNameStrategy `json:"strategy"`
+
+	// KeyRecordName and ValueRecordName are the fully qualified names of the Avro record or
+	// Protobuf message types of keys and values. They are required for the RecordName and
+	// TopicRecordName strategies. Keys or values without record name are not decoded
+	// schemaless with these strategies.
+	KeyRecordName   string `json:"keyRecordName"`
+	ValueRecordName string `json:"valueRecordName"`
+}
+
+// validate checks that the strategy is known.
+func (o SchemalessOptions) validate() error {
+	if o.Strategy == "" {
+		return nil
+	}
+	_, err := o.Strategy.SubjectName("topic", false, "record")
+	return err
+}
+
+// decodeSchemaless decodes payloads without schema ID with the latest schema of the subject
+// that is selected by the subject name strategy. It's only tried if enabled via the options,
+// and only for payloads that must be consumed exactly by the schema.
+func (d *deserializer) decodeSchemaless(in payloadDecoderInput) *deserializedPayload {
+	opts := in.Opts.Schemaless
+	if opts.Strategy == "" || d.SchemaService == nil {
+		return nil
+	}
+	// Payloads that are framed with a registered schema ID are left to the other decoders
+	if d.registeredSchemaID(in.Payload) != 0 {
+		return nil
+	}
+
+	isKey := in.RecordType == proto.RecordKey
+	recordName := opts.ValueRecordName
+	if isKey {
+		recordName = opts.KeyRecordName
+	}
+	subject, err := opts.Strategy.SubjectName(in.TopicName, isKey, recordName)
+	if err != nil {
+		return nil
+	}
+	schemaRes, err := d.SchemaService.GetSchemaBySubjectAndVersion(context.Background(), subject, "latest")
+	if err != nil {
+		return nil
+	}
+
+	switch schemaRes.Type {
+	case schema.TypeAvro:
+		return d.decodeAvroWithSchemaID(in.Payload, uint32(schemaRes.SchemaID), in.Payload, in.Opts)
+	case schema.TypeProtobuf:
+		return d.decodeProtobufWithSchemaID(in.Payload, uint32(schemaRes.SchemaID), recordName, in.Opts)
+	default:
+		return nil
+	}
+}
+
+// decodeProtobufWithSchemaID decodes a Protobuf payload that is not framed with the schema
+// ID as message of the given type, or as the schema's first message type if messageName is
+// empty. Payloads with fields that the message type doesn't define are rejected.
+func (d *deserializer) decodeProtobufWithSchemaID(payload []byte, schemaID uint32, messageName string, opts DeserializationOptions) *deserializedPayload {
+	if d.ProtoService == nil {
+		return nil
+	}
+	jsonBytes, err := d.ProtoService.UnmarshalPayloadWithSchemaID(payload, int(schemaID), messageName, opts.protoUnmarshalOptions())
+	if err != nil {
+		return nil
+	}
+	var native interface{}
+	if err := json.Unmarshal(jsonBytes, &native); err != nil {
+		return nil
+	}
+	return &deserializedPayload{
+		Payload: normalizedPayload{
+			Payload:            jsonBytes,
+			RecognizedEncoding: messageEncodingProtobuf,
+		},
+		IsPayloadNull:      payload == nil,
+		Object:             native,
+		RecognizedEncoding: messageEncodingProtobuf,
+		SchemaID:           schemaID,
+		Size:               len(payload),
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// TagRule tags records whose decoded key or value matches a simple comparison, so that they
+// can be highlighted in a scan without running the filter interpreter.
+type TagRule struct {
+	// Tag is added to the tags of matching records.
+	Tag string `json:"tag"`
+	// Payload is the payload that the rule is applied to, either "value" (default) or "key".
+	Payload string `json:"payload,omitempty"`
+	// Field is a JSONPath of the compared field, such as `$.status` or `$.items[*].state`.
+	// Wildcards match if any of the fields matches. `$` compares the whole payload, which
+	// also works for text payloads.
+	Field string `json:"field"`
+	// Operator is one of "==", "!=", "<", "<=", ">", ">=", "contains" and "exists". Numbers
+	// are compared numerically and strings lexicographically.
+	Operator string `json:"operator"`
+	// Value is compared with the field. It's ignored by the "exists" operator.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// tagRuleOperators are the supported operators of tag rules.
+var tagRuleOperators = map[string]struct{}{
+	"==": {}, "!=": {}, "<": {}, "<=": {}, ">": {}, ">=": {}, "contains": {}, "exists": {},
+}
+
+// tagRule is a parsed TagRule.
+type tagRule struct {
+	TagRule
+	// jsonPath are the segments of the field's path. A segment of "*" matches any field
+	// name or array index. It's empty for the whole payload.
+	jsonPath []string
+}
+
+// parseTagRules validates and parses the given rules.
+func parseTagRules(rules []TagRule) ([]tagRule, error) {
+	parsed := make([]tagRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Tag == "" {
+			return nil, fmt.Errorf("tag rule %d has no tag", i)
+		}
+		if rule.Payload != "" && rule.Payload != "key" && rule.Payload != "value" {
+			return nil, fmt.Errorf("tag rule %q: payload must be key or value, but got %q", rule.Tag, rule.Payload)
+		}
+		if _, ok := tagRuleOperators[rule.Operator]; !ok {
+			return nil, fmt.Errorf("tag rule %q: unknown operator %q", rule.Tag, rule.Operator)
+		}
+
+		var jsonPath []string
+		if rule.Field != "$" {
+			patterns, err := parseRedactPatterns([]string{rule.Field})
+			if err != nil || patterns[0].jsonPath == nil {
+				return nil, fmt.Errorf("tag rule %q: field must be a JSONPath starting with $, but got %q", rule.Tag, rule.Field)
+			}
+			jsonPath = patterns[0].jsonPath
+		}
+		parsed = append(parsed, tagRule{TagRule: rule, jsonPath: jsonPath})
+	}
+	return parsed, nil
+}
+
+// tagDeserializedRecord returns the tags of all rules that match the given record. Each tag
+// is returned once, in the order of the rules.
+func tagDeserializedRecord(rec *deserializedRecord, rules []tagRule) []string {
+	var key, value interface{}
+	var keyOk, valueOk bool
+	var tags []string
+	for _, rule := range rules {
+		var payload interface{}
+		if rule.Payload == "key" {
+			if !keyOk {
+				key, keyOk = tagRulePayload(rec.Key)
+			}
+			payload = key
+		} else {
+			if !valueOk {
+				value, valueOk = tagRulePayload(rec.Value)
+			}
+			payload = value
+		}
+		if payload == nil || !rule.matches(payload) || slices.Contains(tags, rule.Tag) {
+			continue
+		}
+		tags = append(tags, rule.Tag)
+	}
+	return tags
+}
+
+// tagRulePayload returns the payload that tag rules are evaluated against. Like other
+// post-processing steps, the normalized payload is decoded, so that all encodings that are
+// decoded into JSON behave the same. Text payloads are returned as string. The bool is
+// false if the payload can't be evaluated.
+func tagRulePayload(dp *deserializedPayload) (interface{}, bool) {
+	if dp == nil {
+		return nil, false
+	}
+	if dp.Payload.RecognizedEncoding == messageEncodingText {
+		return string(dp.Payload.Payload), true
+	}
+	if !isStructuredEncoding(dp.Payload.RecognizedEncoding) {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(dp.Payload.Payload))
+	dec.UseNumber()
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+// matches returns true if any of the values at the rule's path satisfies the comparison.
+func (r tagRule) matches(payload interface{}) bool {
+	for _, v := range selectJSONPathValues(payload, r.jsonPath) {
+		if r.Operator == "exists" || compareTagRuleValue(v, r.Operator, r.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectJSONPathValues returns all values at the given path. A segment of "*" selects all
+// fields of an object or all items of an array.
+func selectJSONPathValues(value interface{}, jsonPath []string) []interface{} {
+	if len(jsonPath) == 0 {
+		return []interface{}{value}
+	}
+	segment, rest := jsonPath[0], jsonPath[1:]
+
+	var selected []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if segment == "*" {
+			for _, child := range v {
+				selected = append(selected, selectJSONPathValues(child, rest)...)
+			}
+		} else if child, exists := v[segment]; exists {
+			selected = selectJSONPathValues(child, rest)
+		}
+	case []interface{}:
+		if segment == "*" {
+			for _, child := range v {
+				selected = append(selected, selectJSONPathValues(child, rest)...)
+			}
+		} else if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(v) {
+			selected = selectJSONPathValues(v[i], rest)
+		}
+	}
+	return selected
+}
+
+// compareTagRuleValue compares the payload's value with the rule's value.
+func compareTagRuleValue(actual interface{}, operator string, expected interface{}) bool {
+	if operator == "contains" {
+		switch a := actual.(type) {
+		case string:
+			s, ok := expected.(string)
+			return ok && strings.Contains(a, s)
+		case []interface{}:
+			for _, item := range a {
+				if compareTagRuleValue(item, "==", expected) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var cmp int
+	actualNumber, isActualNumber := tagRuleNumber(actual)
+	expectedNumber, isExpectedNumber := tagRuleNumber(expected)
+	actualString, isActualString := actual.(string)
+	expectedString, isExpectedString := expected.(string)
+	switch {
+	case isActualNumber && isExpectedNumber:
+		switch {
+		case actualNumber < expectedNumber:
+			cmp = -1
+		case actualNumber > expectedNumber:
+			cmp = 1
+		}
+	case isActualString && isExpectedString:
+		cmp = strings.Compare(actualString, expectedString)
+	default:
+		// Other types (e.g. bools or null) can only be compared for equality
+		equal := reflect.DeepEqual(actual, expected)
+		switch operator {
+		case "==":
+			return equal
+		case "!=":
+			return !equal
+		default:
+			return false
+		}
+	}
+
+	switch operator {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+// tagRuleNumber returns the value as float64, if it's a number.
+func tagRuleNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,2359 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/ocf"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"github.com/redpanda-data/console/backend/pkg/config"
+	"github.com/redpanda-data/console/backend/pkg/proto"
+	"github.com/redpanda-data/console/backend/pkg/schema"
+)
+
+const testAvroOCFSchema = `{"type": "record", "name": "order", "fields": [{"name": "id", "type": "string"}, {"name": "quantity", "type": "int"}]}`
+
+func encodeAvroOCF(t *testing.T, codec ocf.CodecName) []byte {
+	t.Helper()
+
+	type order struct {
+		ID       string `avro:"id"`
+		Quantity int    `avro:"quantity"`
+	}
+
+	buf := &bytes.Buffer{}
+	enc, err := ocf.NewEncoder(testAvroOCFSchema, buf, ocf.WithCodec(codec))
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(order{ID: "a", Quantity: 1}))
+	require.NoError(t, enc.Encode(order{ID: "b", Quantity: 2}))
+	require.NoError(t, enc.Close())
+
+	return buf.Bytes()
+}
+
+func TestDeserializer_AvroOCF(t *testing.T) {
+	d := deserializer{}
+
+	for _, codec := range []ocf.CodecName{ocf.Null, ocf.Deflate, ocf.Snappy} {
+		t.Run(string(codec), func(t *testing.T) {
+			payload := encodeAvroOCF(t, codec)
+
+			dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+			assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+			assert.JSONEq(t, `[{"id":"a","quantity":1},{"id":"b","quantity":2}]`, string(dp.Payload.Payload))
+			assert.Equal(t, len(payload), dp.Size)
+		})
+	}
+}
+
+func TestDeserializer_AvroOCFUnsupportedCodec(t *testing.T) {
+	header := ocf.Header{
+		Magic: [4]byte{'O', 'b', 'j', 1},
+		Meta: map[string][]byte{
+			"avro.schema": []byte(testAvroOCFSchema),
+			"avro.codec":  []byte("bzip2"),
+		},
+	}
+	payload, err := avro.Marshal(ocf.HeaderSchema, header)
+	require.NoError(t, err)
+
+	d := deserializer{}
+	_, err = d.deserializeAvroOCF(payload)
+	assert.ErrorContains(t, err, `unsupported avro container file codec "bzip2"`)
+
+	dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+	assert.Equal(t, messageEncodingBinary, dp.RecognizedEncoding)
+	require.Len(t, dp.Troubleshooting, 1)
+	assert.Equal(t, "avroContainerFile", dp.Troubleshooting[0].SerdeName)
+	assert.Contains(t, dp.Troubleshooting[0].Message, `unsupported avro container file codec "bzip2"`)
+}
+
+func TestDeserializer_EmptyPayloadAsText(t *testing.T) {
+	d := deserializer{}
+
+	tests := []struct {
+		name             string
+		payload          []byte
+		opts             DeserializationOptions
+		expectedEncoding messageEncoding
+		expectedNull     bool
+		expectedJSON     string
+	}{
+		{"null default", nil, DeserializationOptions{}, messageEncodingNone, true, `{}`},
+		{"null with option", nil, DeserializationOptions{EmptyPayloadAsText: true}, messageEncodingNone, true, `{}`},
+		{"empty default", []byte{}, DeserializationOptions{}, messageEncodingNone, false, `{}`},
+		{"empty with option", []byte{}, DeserializationOptions{EmptyPayloadAsText: true}, messageEncodingText, false, `""`},
+		{"whitespace default", []byte(" \n"), DeserializationOptions{}, messageEncodingText, false, `" \n"`},
+		{"whitespace with option", []byte(" \n"), DeserializationOptions{EmptyPayloadAsText: true}, messageEncodingText, false, `" \n"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := d.deserializePayload(tt.payload, "orders", proto.RecordValue, tt.opts)
+			assert.Equal(t, tt.expectedEncoding, dp.RecognizedEncoding)
+			assert.Equal(t, tt.expectedNull, dp.IsPayloadNull)
+
+			normalized, err := dp.Payload.MarshalJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expectedJSON, string(normalized))
+		})
+	}
+}
+
+func TestIsStructuredEncoding(t *testing.T) {
+	for _, enc := range []messageEncoding{
+		messageEncodingAvro, messageEncodingProtobuf, messageEncodingJSON, messageEncodingXML, messageEncodingMsgP,
+		messageEncodingSmile, messageEncodingCBOR, messageEncodingAuditLog, messageEncodingConsumerOffsets, messageEncodingCompositeKey,
+	} {
+		assert.True(t, isStructuredEncoding(enc), enc)
+	}
+	for _, enc := range []messageEncoding{
+		messageEncodingNone, messageEncodingSkipped, messageEncodingText, messageEncodingBinary,
+		messageEncodingUtf8WithControlChars, messageEncodingUint,
+	} {
+		assert.False(t, isStructuredEncoding(enc), enc)
+	}
+}
+
+func TestDeserializer_FlattenPayload(t *testing.T) {
+	d := deserializer{}
+	opts := DeserializationOptions{FlattenPayload: true}
+
+	t.Run("nested record", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic: "orders",
+			Key:   []byte("order-1"),
+			Value: []byte(`{"id":1,"customer":{"name":"jane","address":{"city":"Berlin"}},"meta":{}}`),
+		}
+		rec := d.DeserializeRecord(record, opts)
+		assert.JSONEq(t, `{"id":1,"customer.name":"jane","customer.address.city":"Berlin","meta":{}}`, string(rec.Value.Payload.Payload))
+		assert.Equal(t, "Berlin", rec.Value.Object.(map[string]interface{})["customer.address.city"])
+
+		// Text keys are not flattened
+		assert.Equal(t, "order-1", rec.Key.Object)
+	})
+
+	t.Run("arrays", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic: "orders",
+			Value: []byte(`{"items":[{"sku":"a","tags":["x","y"]},{"sku":"b","tags":[]}]}`),
+		}
+		rec := d.DeserializeRecord(record, opts)
+		assert.JSONEq(t, `{
+			"items[0].sku":"a","items[0].tags[0]":"x","items[0].tags[1]":"y",
+			"items[1].sku":"b","items[1].tags":[]
+		}`, string(rec.Value.Payload.Payload))
+	})
+
+	t.Run("top level array", func(t *testing.T) {
+		record := &kgo.Record{Topic: "orders", Value: []byte(`[{"id":1},{"id":2}]`)}
+		rec := d.DeserializeRecord(record, opts)
+		assert.JSONEq(t, `{"[0].id":1,"[1].id":2}`, string(rec.Value.Payload.Payload))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		record := &kgo.Record{Topic: "orders", Value: []byte(`{"a":{"b":1}}`)}
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		assert.JSONEq(t, `{"a":{"b":1}}`, string(rec.Value.Payload.Payload))
+	})
+}
+
+func TestDeserializer_AuditLog(t *testing.T) {
+	d := deserializer{}
+
+	t.Run("api activity", func(t *testing.T) {
+		event := `{"category_uid":6,"class_uid":6003,"activity_id":3,"severity_id":1,"time":1700000000000,` +
+			`"metadata":{"product":{"name":"Redpanda","vendor_name":"Redpanda Data, Inc."},"version":"1.0.0"},` +
+			`"api":{"operation":"alter_configs"},"actor":{"user":{"name":"admin"}},"type_uid":600303}`
+		record := &kgo.Record{Topic: auditLogTopicName, Key: []byte("key"), Value: []byte(event)}
+
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		assert.Equal(t, messageEncodingAuditLog, rec.Value.RecognizedEncoding)
+		assert.JSONEq(t, `{
+			"time": "2023-11-14T22:13:20Z",
+			"categoryName": "Application Activity",
+			"className": "API Activity",
+			"activityName": "Update",
+			"severityName": "Informational",
+			"event": `+event+`
+		}`, string(rec.Value.Payload.Payload))
+		assert.Equal(t, len(event), rec.Value.Size)
+		assert.Equal(t, messageEncodingText, rec.Key.RecognizedEncoding)
+	})
+
+	t.Run("authentication", func(t *testing.T) {
+		event := `{"category_uid":3,"class_uid":3002,"activity_id":1,"severity_id":1,"time":1700000000000,"metadata":{"version":"1.0.0"}}`
+		record := &kgo.Record{Topic: auditLogTopicName, Value: []byte(event)}
+
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		obj := rec.Value.Object.(map[string]interface{})
+		assert.Equal(t, "Authentication", obj["className"])
+		assert.Equal(t, "Logon", obj["activityName"])
+	})
+
+	t.Run("unsupported version falls back", func(t *testing.T) {
+		event := `{"category_uid":6,"class_uid":6003,"activity_id":3,"severity_id":1,"time":1700000000000,"metadata":{"version":"2.0.0"}}`
+		record := &kgo.Record{Topic: auditLogTopicName, Value: []byte(event)}
+
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding)
+	})
+}
+
+func TestDeserializer_NoSchemaRegistry(t *testing.T) {
+	d := deserializer{}
+
+	expectedTroubleshooting := []troubleshootingReport{
+		{SerdeName: "jsonSchema", Message: noSchemaRegistryMessage},
+		{SerdeName: "avro", Message: noSchemaRegistryMessage},
+		{SerdeName: "protobuf", Message: noSchemaRegistryMessage},
+	}
+
+	t.Run("avro framed payload", func(t *testing.T) {
+		// Magic byte, schema ID 1 and the avro encoded string "hello"
+		payload := []byte{0, 0, 0, 0, 1, 10, 'h', 'e', 'l', 'l', 'o'}
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.Equal(t, expectedTroubleshooting, dp.Troubleshooting)
+	})
+
+	t.Run("json schema framed payload", func(t *testing.T) {
+		payload := append([]byte{0, 0, 0, 0, 1}, []byte(`{"id":1}`)...)
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, expectedTroubleshooting, dp.Troubleshooting)
+	})
+
+	t.Run("unframed payload", func(t *testing.T) {
+		dp := d.deserializePayload([]byte(`{"id":1}`), "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+		assert.Empty(t, dp.Troubleshooting)
+	})
+
+	t.Run("framed payload decoded by another decoder", func(t *testing.T) {
+		textDecoder := deserializer{decoders: []payloadDecoder{{
+			Name: "text",
+			Decode: func(in payloadDecoderInput) *deserializedPayload {
+				return &deserializedPayload{
+					Payload:            normalizedPayload{Payload: in.Payload, RecognizedEncoding: messageEncodingText},
+					RecognizedEncoding: messageEncodingText,
+					Size:               len(in.Payload),
+				}
+			},
+		}}}
+		payload := []byte{0, 0, 0, 0, 1, 'h', 'e', 'l', 'l', 'o'}
+		dp := textDecoder.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+		assert.Empty(t, dp.Troubleshooting)
+	})
+}
+
+func TestDeserializer_DecodeBudget(t *testing.T) {
+	d := deserializer{}
+	slowJSONDecoder := payloadDecoder{
+		Name: "slowJSON",
+		Decode: func(in payloadDecoderInput) *deserializedPayload {
+			time.Sleep(50 * time.Millisecond)
+			return d.decodeJSON(in)
+		},
+	}
+	d.decoders = []payloadDecoder{slowJSONDecoder}
+
+	record := &kgo.Record{
+		Topic:   "orders",
+		Key:     []byte(`{"id":1}`),
+		Value:   []byte(`{"id":1,"quantity":2}`),
+		Headers: []kgo.RecordHeader{{Key: "trace", Value: []byte(`{"span":1}`)}},
+	}
+
+	t.Run("budget exceeded", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{DecodeBudgetMs: 20})
+
+		// The key was decoded before the budget was exceeded
+		assert.Equal(t, messageEncodingJSON, rec.Key.RecognizedEncoding)
+		assert.Empty(t, rec.Key.Troubleshooting)
+
+		for _, dp := range []*deserializedPayload{rec.Value, rec.Headers["trace"]} {
+			assert.Equal(t, messageEncodingBinary, dp.RecognizedEncoding)
+			require.Len(t, dp.Troubleshooting, 1)
+			assert.Equal(t, "slowJSON", dp.Troubleshooting[0].SerdeName)
+			assert.Contains(t, dp.Troubleshooting[0].Message, "decode budget of 20ms")
+		}
+	})
+
+	t.Run("no budget", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		assert.Equal(t, messageEncodingJSON, rec.Key.RecognizedEncoding)
+		assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding)
+		assert.Equal(t, messageEncodingJSON, rec.Headers["trace"].RecognizedEncoding)
+	})
+}
+
+func TestDeserializer_IncludeDecodeDuration(t *testing.T) {
+	d := deserializer{}
+	record := &kgo.Record{Topic: "orders", Key: []byte("o-1"), Value: []byte(`{"id":"o-1"}`)}
+
+	rec := d.DeserializeRecord(record, DeserializationOptions{IncludeDecodeDuration: true})
+	assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding)
+	assert.Greater(t, rec.DecodeDuration, time.Duration(0))
+
+	rec = d.DeserializeRecord(record, DeserializationOptions{})
+	assert.Zero(t, rec.DecodeDuration, "the duration is only measured if requested")
+}
+
+func TestLabelEncryptedAvroFields(t *testing.T) {
+	sch := avro.MustParse(`{"type": "record", "name": "customer", "namespace": "com.shop", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "ssn", "type": "string", "confluent:tags": ["PII"]},
+		{"name": "addresses", "type": {"type": "array", "items": {"type": "record", "name": "address", "fields": [
+			{"name": "street", "type": ["null", "string"]}
+		]}}}
+	]}`)
+	encryptedFields := map[string]struct{}{
+		"com.shop.customer.ssn":   {},
+		"com.shop.address.street": {},
+	}
+
+	payload, err := avro.Marshal(sch, map[string]interface{}{
+		"name":      "jane",
+		"ssn":       "ZW5jcnlwdGVk",
+		"addresses": []map[string]interface{}{{"street": "c3RyZWV0"}, {"street": nil}},
+	})
+	require.NoError(t, err)
+
+	var obj interface{}
+	require.NoError(t, avro.Unmarshal(sch, payload, &obj))
+	labeled := labelEncryptedAvroFields(sch, obj, encryptedFields)
+
+	jsonBytes, err := json.Marshal(labeled)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "jane",
+		"ssn": {"encrypted": true, "ciphertext": "ZW5jcnlwdGVk"},
+		"addresses": [{"street": {"encrypted": true, "ciphertext": {"string": "c3RyZWV0"}}}, {"street": null}]
+	}`, string(jsonBytes))
+}
+
+// newTestSchemaService returns a schema service backed by a fake registry that serves the
+// given schemas by ID.
+func newTestSchemaService(t *testing.T, schemasByID map[int]string) *schema.Service {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/schemas" {
+			schemas := make([]map[string]interface{}, 0, len(schemasByID))
+			for id, schemaStr := range schemasByID {
+				schemas = append(schemas, map[string]interface{}{
+					"subject": "subject-" + strconv.Itoa(id),
+					"version": 1,
+					"id":      id,
+					"schema":  schemaStr,
+				})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(schemas)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/subjects/subject-") {
+			// Each schema is the only version of the subject "subject-<id>"
+			subjectID, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/subjects/subject-"), "/")
+			id, err := strconv.Atoi(subjectID)
+			if schemaStr, exists := schemasByID[id]; err == nil && exists {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"subject": "subject-" + subjectID, "version": 1, "id": id, "schema": schemaStr,
+				})
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/schemas/ids/"))
+		schemaStr, exists := schemasByID[id]
+		if err != nil || !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": schemaStr})
+	}))
+	t.Cleanup(srv.Close)
+
+	svc, err := schema.NewService(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil, "")
+	require.NoError(t, err)
+	return svc
+}
+
+func TestDeserializer_VarintSchemaID(t *testing.T) {
+	schemaIDs := []int{1, 300, 70000, 3000000}
+	schemasByID := make(map[int]string)
+	for _, id := range schemaIDs {
+		schemasByID[id] = testAvroOCFSchema
+	}
+	d := deserializer{SchemaService: newTestSchemaService(t, schemasByID)}
+	opts := DeserializationOptions{VarintSchemaID: VarintSchemaIDOptions{Enabled: true, MagicByte: 0x7}}
+
+	body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": "a", "quantity": 1})
+	require.NoError(t, err)
+
+	for i, id := range schemaIDs {
+		payload := binary.AppendUvarint([]byte{0x7}, uint64(id))
+		require.Len(t, payload, 2+i) // IDs of 1 to 4 varint bytes
+		payload = append(payload, body...)
+
+		t.Run(strconv.Itoa(id), func(t *testing.T) {
+			dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+			assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+			assert.Equal(t, uint32(id), dp.SchemaID)
+			assert.JSONEq(t, `{"id":"a","quantity":1}`, string(dp.Payload.Payload))
+		})
+	}
+
+	t.Run("json body", func(t *testing.T) {
+		payload := append(binary.AppendUvarint([]byte{0x7}, 300), []byte(`{"id":"a"}`)...)
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(300), dp.SchemaID)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		payload := append(binary.AppendUvarint([]byte{0x7}, 300), body...)
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+}
+
+func TestDeserializer_AvroDocs(t *testing.T) {
+	documentedSchema := `{
+		"type": "record",
+		"name": "Order",
+		"namespace": "com.example",
+		"doc": "An order placed in the web shop",
+		"fields": [
+			{"name": "id", "type": "string", "doc": "Unique order ID"},
+			{"name": "quantity", "type": "int"},
+			{"name": "status", "type": {"type": "enum", "name": "Status", "doc": "Processing state", "symbols": ["OPEN", "SHIPPED"]}},
+			{"name": "customer", "type": ["null", {
+				"type": "record",
+				"name": "Customer",
+				"fields": [{"name": "email", "type": "string", "doc": "Contact email address"}]
+			}], "doc": "Buyer of the order"},
+			{"name": "items", "type": {"type": "array", "items": {
+				"type": "record",
+				"name": "Item",
+				"fields": [{"name": "sku", "type": "string", "doc": "Stock keeping unit"}]
+			}}}
+		]
+	}`
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{7: documentedSchema})}
+
+	body, err := avro.Marshal(avro.MustParse(documentedSchema), map[string]interface{}{
+		"id":       "o-1",
+		"quantity": 2,
+		"status":   "OPEN",
+		"customer": map[string]interface{}{"com.example.Customer": map[string]interface{}{"email": "jane@example.com"}},
+		"items":    []interface{}{map[string]interface{}{"sku": "a"}, map[string]interface{}{"sku": "b"}},
+	})
+	require.NoError(t, err)
+	record := &kgo.Record{Topic: "orders", Value: append([]byte{0, 0, 0, 0, 7}, body...)}
+
+	t.Run("docs are attached", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{AvroDocs: true})
+		require.Equal(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+		assert.Equal(t, map[string]string{
+			"":                                    "An order placed in the web shop",
+			"id":                                  "Unique order ID",
+			"status":                              "Processing state",
+			"customer":                            "Buyer of the order",
+			"customer.com.example.Customer.email": "Contact email address",
+			"items[0].sku":                        "Stock keeping unit",
+			"items[1].sku":                        "Stock keeping unit",
+		}, rec.Value.FieldDocs)
+
+		jsonBytes, err := json.Marshal(rec.Value)
+		require.NoError(t, err)
+		assert.Contains(t, string(jsonBytes), `"fieldDocs":{`)
+	})
+
+	t.Run("docs are opt-in", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		assert.Nil(t, rec.Value.FieldDocs)
+
+		jsonBytes, err := json.Marshal(rec.Value)
+		require.NoError(t, err)
+		assert.NotContains(t, string(jsonBytes), "fieldDocs")
+	})
+}
+
+func TestDeserializer_AvroFixed(t *testing.T) {
+	fixedSchema := `{
+		"type": "record",
+		"name": "Payment",
+		"fields": [
+			{"name": "amount", "type": {"type": "fixed", "name": "Amount", "size": 8, "logicalType": "decimal", "precision": 18, "scale": 2}},
+			{"name": "period", "type": {"type": "fixed", "name": "Period", "size": 12, "logicalType": "duration"}},
+			{"name": "id", "type": {"type": "fixed", "name": "ID", "size": 4}}
+		]
+	}`
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{8: fixedSchema})}
+
+	// Fixed fields are encoded as their raw bytes, so that the record is the concatenation of them
+	body := binary.BigEndian.AppendUint64(nil, 123456)
+	body = binary.LittleEndian.AppendUint32(body, 1)
+	body = binary.LittleEndian.AppendUint32(body, 2)
+	body = binary.LittleEndian.AppendUint32(body, 3000)
+	body = append(body, 0xde, 0xad, 0xbe, 0xef)
+	record := &kgo.Record{Topic: "payments", Value: append([]byte{0, 0, 0, 0, 8}, body...)}
+
+	t.Run("base64", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		require.Equal(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+		assert.JSONEq(t, `{
+			"amount": "1234.56",
+			"period": {"months": 1, "days": 2, "milliseconds": 3000},
+			"id": "3q2+7w=="
+		}`, string(rec.Value.Payload.Payload))
+	})
+
+	t.Run("hex", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{AvroFixedAsHex: true})
+		require.Equal(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+		assert.JSONEq(t, `{
+			"amount": "1234.56",
+			"period": {"months": 1, "days": 2, "milliseconds": 3000},
+			"id": "deadbeef"
+		}`, string(rec.Value.Payload.Payload))
+	})
+}
+
+func TestDeserializer_AvroLogicalTypes(t *testing.T) {
+	logicalSchema := `{
+		"type": "record",
+		"name": "Event",
+		"fields": [
+			{"name": "created_at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "updated_at", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+			{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+			{"name": "opens_at", "type": {"type": "int", "logicalType": "time-millis"}},
+			{"name": "closes_at", "type": {"type": "long", "logicalType": "time-micros"}},
+			{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+			{"name": "refund", "type": {"type": "fixed", "name": "Refund", "size": 4, "logicalType": "decimal", "precision": 8, "scale": 2}},
+			{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+			{"name": "deleted_at", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]}
+		]
+	}`
+	schemaSvc := newTestSchemaService(t, map[int]string{9: logicalSchema})
+	d := deserializer{SchemaService: schemaSvc}
+
+	createdAt := time.Date(2023, 6, 1, 12, 30, 0, 123000000, time.UTC)
+	body, err := avro.Marshal(avro.MustParse(logicalSchema), map[string]interface{}{
+		"created_at": createdAt,
+		"updated_at": createdAt.Add(456 * time.Microsecond),
+		"day":        time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+		"opens_at":   9*time.Hour + 30*time.Minute + 5*time.Millisecond,
+		"closes_at":  17*time.Hour + 7*time.Microsecond,
+		"price":      big.NewRat(1999, 100),
+		"refund":     big.NewRat(-250, 100),
+		"id":         "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"deleted_at": map[string]interface{}{"long.timestamp-millis": createdAt},
+	})
+	require.NoError(t, err)
+	payload := append([]byte{0, 0, 0, 0, 9}, body...)
+
+	t.Run("readable", func(t *testing.T) {
+		dp := d.deserializePayload(payload, "events", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.JSONEq(t, `{
+			"created_at": "2023-06-01T12:30:00.123Z",
+			"updated_at": "2023-06-01T12:30:00.123456Z",
+			"day": "2023-06-01",
+			"opens_at": "09:30:00.005",
+			"closes_at": "17:00:00.000007",
+			"price": "19.99",
+			"refund": "-2.50",
+			"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			"deleted_at": {"long.timestamp-millis": "2023-06-01T12:30:00.123Z"}
+		}`, string(dp.Payload.Payload))
+
+		again, err := serializeAvro(context.Background(), schemaSvc, json.RawMessage(dp.Payload.Payload), WithSchemaID(9))
+		require.NoError(t, err)
+		assert.Equal(t, payload, again)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		dp := d.deserializePayload(payload, "events", proto.RecordValue, DeserializationOptions{AvroRawLogicalTypes: true})
+		require.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.JSONEq(t, `{
+			"created_at": 1685622600123,
+			"updated_at": 1685622600123456,
+			"day": 19509,
+			"opens_at": 34200005,
+			"closes_at": 61200000007,
+			"price": "B88=",
+			"refund": "////Bg==",
+			"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			"deleted_at": {"long.timestamp-millis": 1685622600123}
+		}`, string(dp.Payload.Payload))
+
+		again, err := serializeAvro(context.Background(), schemaSvc, json.RawMessage(dp.Payload.Payload), WithSchemaID(9))
+		require.NoError(t, err)
+		assert.Equal(t, payload, again)
+	})
+}
+
+func TestDeserializer_AvroJSONEncoding(t *testing.T) {
+	jsonSchema := `{
+		"type": "record",
+		"name": "Order",
+		"namespace": "com.shop",
+		"fields": [
+			{"name": "id", "type": {"type": "fixed", "name": "ID", "size": 2}},
+			{"name": "signature", "type": "bytes"},
+			{"name": "note", "type": ["null", "string"]},
+			{"name": "customer", "type": ["null", {"type": "record", "name": "Customer", "fields": [{"name": "name", "type": "string"}]}]},
+			{"name": "shipped_at", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]},
+			{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}}
+		]
+	}`
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{10: jsonSchema})}
+
+	body, err := avro.Marshal(avro.MustParse(jsonSchema), map[string]interface{}{
+		"id":         [2]byte{0x00, 0xff},
+		"signature":  []byte{0x41, 0xe9},
+		"note":       map[string]interface{}{"string": "fragile"},
+		"customer":   map[string]interface{}{"com.shop.Customer": map[string]interface{}{"name": "jane"}},
+		"shipped_at": map[string]interface{}{"long.timestamp-millis": time.UnixMilli(1685622600123)},
+		"price":      big.NewRat(1999, 100),
+	})
+	require.NoError(t, err)
+	payload := append([]byte{0, 0, 0, 0, 10}, body...)
+
+	t.Run("json encoding", func(t *testing.T) {
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{AvroJSONEncoding: true})
+		require.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.JSONEq(t, `{
+			"id": "\u0000\u00ff",
+			"signature": "A\u00e9",
+			"note": {"string": "fragile"},
+			"customer": {"com.shop.Customer": {"name": "jane"}},
+			"shipped_at": {"long": 1685622600123},
+			"price": "\u0007\u00cf"
+		}`, string(dp.Payload.Payload))
+	})
+
+	t.Run("default", func(t *testing.T) {
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.JSONEq(t, `{
+			"id": "AP8=",
+			"signature": "Qek=",
+			"note": {"string": "fragile"},
+			"customer": {"com.shop.Customer": {"name": "jane"}},
+			"shipped_at": {"long.timestamp-millis": "2023-06-01T12:30:00.123Z"},
+			"price": "19.99"
+		}`, string(dp.Payload.Payload))
+	})
+}
+
+func TestDeserializer_TrailingSchemaID(t *testing.T) {
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{300: testAvroOCFSchema})}
+	magicByte := byte(0x7)
+
+	body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": "a", "quantity": 1})
+	require.NoError(t, err)
+	payload := binary.BigEndian.AppendUint32(append([]byte{}, body...), 300)
+
+	t.Run("schema id", func(t *testing.T) {
+		opts := DeserializationOptions{TrailingSchemaID: TrailingSchemaIDOptions{Enabled: true}}
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(300), dp.SchemaID)
+		assert.JSONEq(t, `{"id":"a","quantity":1}`, string(dp.Payload.Payload))
+	})
+
+	t.Run("schema id with magic byte", func(t *testing.T) {
+		opts := DeserializationOptions{TrailingSchemaID: TrailingSchemaIDOptions{Enabled: true, MagicByte: &magicByte}}
+		dp := d.deserializePayload(append(payload, magicByte), "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(300), dp.SchemaID)
+		assert.JSONEq(t, `{"id":"a","quantity":1}`, string(dp.Payload.Payload))
+
+		// The schema ID is not read if the magic byte is missing
+		dp = d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+
+	t.Run("unknown schema id", func(t *testing.T) {
+		opts := DeserializationOptions{TrailingSchemaID: TrailingSchemaIDOptions{Enabled: true}}
+		unknown := binary.BigEndian.AppendUint32(append([]byte{}, body...), 301)
+		dp := d.deserializePayload(unknown, "orders", proto.RecordValue, opts)
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+}
+
+func TestDeserializer_AvroFingerprintHeader(t *testing.T) {
+	otherSchema := `{"type":"record","name":"Payment","fields":[{"name":"amount","type":"int"}]}`
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{300: testAvroOCFSchema, 301: otherSchema})}
+	opts := DeserializationOptions{AvroFingerprintHeader: "avro.fingerprint"}
+
+	sch := avro.MustParse(testAvroOCFSchema)
+	body, err := avro.Marshal(sch, map[string]interface{}{"id": "a", "quantity": 1})
+	require.NoError(t, err)
+	fingerprint := binary.LittleEndian.AppendUint64(nil, schema.AvroFingerprint(sch))
+
+	t.Run("fingerprint header", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic:   "orders",
+			Value:   body,
+			Headers: []kgo.RecordHeader{{Key: "avro.fingerprint", Value: fingerprint}},
+		}
+		rec := d.DeserializeRecord(record, opts)
+		assert.Equal(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+		assert.Equal(t, uint32(300), rec.Value.SchemaID)
+		assert.JSONEq(t, `{"id":"a","quantity":1}`, string(rec.Value.Payload.Payload))
+		assert.Contains(t, rec.Headers, "avro.fingerprint", "the header is still returned")
+	})
+
+	t.Run("unknown fingerprint", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic:   "orders",
+			Value:   body,
+			Headers: []kgo.RecordHeader{{Key: "avro.fingerprint", Value: binary.LittleEndian.AppendUint64(nil, 42)}},
+		}
+		rec := d.DeserializeRecord(record, opts)
+		assert.NotEqual(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+	})
+
+	t.Run("header not configured", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic:   "orders",
+			Value:   body,
+			Headers: []kgo.RecordHeader{{Key: "avro.fingerprint", Value: fingerprint}},
+		}
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+	})
+}
+
+func TestDeserializer_AvroSingleObject(t *testing.T) {
+	otherSchema := `{"type":"record","name":"Payment","fields":[{"name":"amount","type":"int"}]}`
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{300: testAvroOCFSchema, 301: otherSchema})}
+
+	sch := avro.MustParse(testAvroOCFSchema)
+	body, err := avro.Marshal(sch, map[string]interface{}{"id": "a", "quantity": 1})
+	require.NoError(t, err)
+	singleObject := func(fingerprint uint64) []byte {
+		return append(binary.LittleEndian.AppendUint64([]byte{0xc3, 0x01}, fingerprint), body...)
+	}
+
+	t.Run("registered fingerprint", func(t *testing.T) {
+		dp := d.deserializePayload(singleObject(schema.AvroFingerprint(sch)), "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(300), dp.SchemaID)
+		assert.JSONEq(t, `{"id":"a","quantity":1}`, string(dp.Payload.Payload))
+	})
+
+	t.Run("unknown fingerprint", func(t *testing.T) {
+		dp := d.deserializePayload(singleObject(42), "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+
+	t.Run("other marker", func(t *testing.T) {
+		payload := singleObject(schema.AvroFingerprint(avro.MustParse(otherSchema)))
+		payload[1] = 0x02
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+}
+
+func TestDeserializer_SerdeMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics, err := newSerdeMetrics(reg, "test")
+	require.NoError(t, err)
+
+	d := deserializer{metrics: metrics}
+	d.deserializePayload([]byte(`{"a": 1}`), "orders", proto.RecordValue, DeserializationOptions{})
+	d.deserializePayload([]byte("hello"), "orders", proto.RecordValue, DeserializationOptions{})
+
+	attempts := func(serde, result string) float64 {
+		return promtestutil.ToFloat64(metrics.attempts.WithLabelValues(serde, result))
+	}
+	assert.Equal(t, 1.0, attempts("json", "success"))
+	assert.Equal(t, 1.0, attempts("json", "failure"))
+	// Serdes after json are only attempted for the text payload
+	assert.Equal(t, 1.0, attempts("xml", "failure"))
+	assert.Equal(t, 1.0, attempts("utf8", "success"))
+	assert.Equal(t, 0.0, attempts("utf8", "failure"))
+	assert.Equal(t, 0.0, attempts("uint", "failure"))
+
+	// Registering the metrics again reuses the existing collectors
+	again, err := newSerdeMetrics(reg, "test")
+	require.NoError(t, err)
+	assert.Same(t, metrics.attempts, again.attempts)
+
+	noop, err := newSerdeMetrics(nil, "test")
+	require.NoError(t, err)
+	assert.Nil(t, noop)
+	noop.observe("json", true, time.Millisecond)
+}
+
+func TestDeserializer_Redact(t *testing.T) {
+	d := deserializer{}
+	record := &kgo.Record{
+		Topic: "customers",
+		Key:   []byte(`{"email": "jane@example.com"}`),
+		Value: []byte(`{
+			"name": "jane",
+			"contact": {"email": "jane@example.com", "phone": "123"},
+			"orders": [{"id": 1, "card_number": "4111"}, {"id": 2, "card_number": "5500"}],
+			"address": {"city": "Berlin", "zip": "10115"}
+		}`),
+	}
+
+	rec := d.DeserializeRecord(record, DeserializationOptions{Redact: []string{"email", "*_number", "$.address.zip"}})
+	assert.JSONEq(t, `{"email": "[REDACTED]"}`, string(rec.Key.Payload.Payload))
+	assert.JSONEq(t, `{
+		"name": "jane",
+		"contact": {"email": "[REDACTED]", "phone": "123"},
+		"orders": [{"id": 1, "card_number": "[REDACTED]"}, {"id": 2, "card_number": "[REDACTED]"}],
+		"address": {"city": "Berlin", "zip": "[REDACTED]"}
+	}`, string(rec.Value.Payload.Payload))
+	contact := rec.Value.Object.(map[string]interface{})["contact"].(map[string]interface{})
+	assert.Equal(t, redactedPlaceholder, contact["email"])
+
+	t.Run("json path with array wildcard", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{Redact: []string{"$.orders[*].id", "$.contact"}})
+		assert.JSONEq(t, `{
+			"name": "jane",
+			"contact": "[REDACTED]",
+			"orders": [{"id": "[REDACTED]", "card_number": "4111"}, {"id": "[REDACTED]", "card_number": "5500"}],
+			"address": {"city": "Berlin", "zip": "10115"}
+		}`, string(rec.Value.Payload.Payload))
+	})
+
+	t.Run("redacted before flattening", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{Redact: []string{"contact"}, FlattenPayload: true})
+		obj := rec.Value.Object.(map[string]interface{})
+		assert.Equal(t, redactedPlaceholder, obj["contact"])
+		assert.NotContains(t, obj, "contact.email")
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		opts := DeserializationOptions{Redact: []string{"[email"}}
+		assert.Error(t, opts.Validate())
+
+		rec := d.DeserializeRecord(record, opts)
+		assert.JSONEq(t, `{"email": "[REDACTED]"}`, string(rec.Key.Payload.Payload))
+
+		rec = d.DeserializeRecord(record, opts.withParsedPatterns())
+		assert.JSONEq(t, `{"email": "[REDACTED]"}`, string(rec.Key.Payload.Payload))
+	})
+
+	t.Run("parsed patterns", func(t *testing.T) {
+		opts := DeserializationOptions{Redact: []string{"email"}}.withParsedPatterns()
+		require.NotNil(t, opts.patterns)
+		// Patterns that have been parsed once are used rather than the options' patterns
+		opts.Redact = []string{"name"}
+
+		rec := d.DeserializeRecord(record, opts)
+		assert.JSONEq(t, `{"email": "[REDACTED]"}`, string(rec.Key.Payload.Payload))
+	})
+
+	t.Run("root level array", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Value: []byte(`[{"email": "a@example.com"}, "b@example.com"]`)},
+			DeserializationOptions{Redact: []string{"email", "$[1]"}})
+		assert.JSONEq(t, `[{"email": "[REDACTED]"}, "[REDACTED]"]`, string(rec.Value.Payload.Payload))
+	})
+
+	assertRedacted := func(t *testing.T, dp *deserializedPayload) {
+		t.Helper()
+		assert.Equal(t, messageEncodingText, dp.Payload.RecognizedEncoding)
+		assert.Equal(t, redactedPlaceholder, string(dp.Payload.Payload))
+		assert.Equal(t, redactedPlaceholder, dp.Object)
+		normalized, err := dp.Payload.MarshalJSON()
+		require.NoError(t, err)
+		assert.NotContains(t, string(normalized), "jane")
+	}
+
+	t.Run("unstructured payloads are redacted as a whole", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{
+			Key:     []byte("jane@example.com"),
+			Value:   []byte{0x00, 0xff, 'j', 'a', 'n', 'e'},
+			Headers: []kgo.RecordHeader{{Key: "quoted", Value: []byte(`"jane"`)}},
+		}, DeserializationOptions{Redact: []string{"email"}})
+		assert.Equal(t, messageEncodingText, rec.Key.RecognizedEncoding)
+		assertRedacted(t, rec.Key)
+		assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+		assertRedacted(t, rec.Value)
+		assertRedacted(t, rec.Headers["quoted"])
+	})
+
+	t.Run("decode budget exceeded", func(t *testing.T) {
+		slow := deserializer{decoders: []payloadDecoder{{
+			Name: "slowJSON",
+			Decode: func(in payloadDecoderInput) *deserializedPayload {
+				time.Sleep(50 * time.Millisecond)
+				return d.decodeJSON(in)
+			},
+		}}}
+		rec := slow.DeserializeRecord(&kgo.Record{Key: []byte(`{"id": 1}`), Value: []byte(`{"email": "jane@example.com"}`)},
+			DeserializationOptions{Redact: []string{"email"}, DecodeBudgetMs: 20})
+		assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+		assertRedacted(t, rec.Value)
+	})
+
+	t.Run("preview prefix", func(t *testing.T) {
+		payload := []byte("name=jane\nemail=jane@example.com\n" + strings.Repeat("padding ", 16))
+		rec := d.DeserializeRecord(&kgo.Record{Value: payload}, DeserializationOptions{Redact: []string{"email"}, PreviewBytes: 32})
+		assert.True(t, rec.Value.Truncated)
+		assertRedacted(t, rec.Value)
+	})
+
+	t.Run("large xml falls back to text", func(t *testing.T) {
+		payload := []byte(`<customer><name>jane</name><email>jane@example.com</email>` + strings.Repeat("<tag/>", 256) + `</customer>`)
+		rec := d.DeserializeRecord(&kgo.Record{Value: payload}, DeserializationOptions{Redact: []string{"email"}, LargePayloadBytes: 1024})
+		assert.Equal(t, messageEncodingText, rec.Value.RecognizedEncoding)
+		assertRedacted(t, rec.Value)
+	})
+}
+
+func TestDeserializer_CoerceNumericStrings(t *testing.T) {
+	d := deserializer{}
+	record := &kgo.Record{
+		Topic: "customers",
+		Key:   []byte(`{"id": "42"}`),
+		Value: []byte(`{
+			"age": "30",
+			"balance": "-1250.75",
+			"ratio": "1e-3",
+			"accountNumber": "12345678901234567890123",
+			"zip": "02134",
+			"phone": "+49301234567",
+			"padded": " 7 ",
+			"version": "1.2.3",
+			"name": "jane",
+			"tags": ["7", "seven"],
+			"nested": {"count": "0", "score": 12}
+		}`),
+		Headers: []kgo.RecordHeader{{Key: "meta", Value: []byte(`{"retries": "3"}`)}},
+	}
+
+	t.Run("numeric strings are coerced", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{CoerceNumericStrings: true})
+		assert.JSONEq(t, `{"id": 42}`, string(rec.Key.Payload.Payload))
+		assert.JSONEq(t, `{
+			"age": 30,
+			"balance": -1250.75,
+			"ratio": 1e-3,
+			"accountNumber": 12345678901234567890123,
+			"zip": "02134",
+			"phone": "+49301234567",
+			"padded": " 7 ",
+			"version": "1.2.3",
+			"name": "jane",
+			"tags": [7, "seven"],
+			"nested": {"count": 0, "score": 12}
+		}`, string(rec.Value.Payload.Payload))
+		assert.Contains(t, string(rec.Value.Payload.Payload), "12345678901234567890123", "large numbers keep their precision")
+		assert.Equal(t, json.Number("30"), rec.Value.Object.(map[string]interface{})["age"])
+		assert.JSONEq(t, `{"retries": 3}`, string(rec.Headers["meta"].Payload.Payload))
+	})
+
+	t.Run("strict typing by default", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		assert.JSONEq(t, `{"id": "42"}`, string(rec.Key.Payload.Payload))
+		assert.Equal(t, "30", rec.Value.Object.(map[string]interface{})["age"])
+	})
+
+	t.Run("text payloads are not coerced", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Value: []byte("30 items")}, DeserializationOptions{CoerceNumericStrings: true})
+		assert.Equal(t, messageEncodingText, rec.Value.RecognizedEncoding)
+		assert.Equal(t, "30 items", string(rec.Value.Payload.Payload))
+	})
+}
+
+func TestDeserializer_PreciseJSONIntegers(t *testing.T) {
+	d := deserializer{}
+	record := &kgo.Record{
+		Topic: "orders",
+		Key:   []byte(`{"id": 1234567890123456789}`),
+		Value: []byte(`{
+			"id": 1234567890123456789,
+			"min": -9223372036854775808,
+			"huge": 123456789012345678901234567890,
+			"safe": 9007199254740992,
+			"count": 42,
+			"price": 12.5,
+			"ids": [9007199254740993, 1]
+		}`),
+	}
+
+	t.Run("large integers are preserved as strings", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{PreciseJSONIntegers: true})
+		assert.JSONEq(t, `{"id": "1234567890123456789"}`, string(rec.Key.Payload.Payload))
+		assert.JSONEq(t, `{
+			"id": "1234567890123456789",
+			"min": "-9223372036854775808",
+			"huge": "123456789012345678901234567890",
+			"safe": 9007199254740992,
+			"count": 42,
+			"price": 12.5,
+			"ids": ["9007199254740993", 1]
+		}`, string(rec.Value.Payload.Payload))
+
+		obj := rec.Value.Object.(map[string]interface{})
+		assert.Equal(t, "1234567890123456789", obj["id"])
+		assert.Equal(t, float64(42), obj["count"], "other numbers remain float64 for the filter interpreter")
+	})
+
+	t.Run("numbers are float64 by default", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		obj := rec.Value.Object.(map[string]interface{})
+		assert.Equal(t, float64(1234567890123456789), obj["id"])
+		assert.Contains(t, string(rec.Value.Payload.Payload), "1234567890123456789")
+	})
+
+	t.Run("payloads without large integers are unchanged", func(t *testing.T) {
+		payload := []byte(`{"count": 42,  "price": 12.5}`)
+		rec := d.DeserializeRecord(&kgo.Record{Value: payload}, DeserializationOptions{PreciseJSONIntegers: true})
+		assert.Equal(t, payload, rec.Value.Payload.Payload)
+	})
+}
+
+func TestDeserializer_TagRules(t *testing.T) {
+	d := deserializer{}
+	opts := DeserializationOptions{
+		Redact: []string{"ssn"},
+		TagRules: []TagRule{
+			{Tag: "error", Field: "$.status", Operator: "==", Value: "ERROR"},
+			{Tag: "slow", Field: "$.durationMs", Operator: ">=", Value: float64(1000)},
+			{Tag: "retried", Field: "$.attempts[*]", Operator: "exists"},
+			{Tag: "vip", Payload: "key", Field: "$.tier", Operator: "==", Value: "gold"},
+			{Tag: "timeout", Field: "$.message", Operator: "contains", Value: "timed out"},
+			{Tag: "error", Field: "$.level", Operator: "==", Value: "ERROR"},
+			{Tag: "probe", Field: "$.ssn", Operator: "==", Value: "123-45-6789"},
+			{Tag: "heartbeat", Field: "$", Operator: "==", Value: "ping"},
+		},
+	}
+	require.NoError(t, opts.Validate())
+
+	for _, tc := range []struct {
+		name     string
+		record   *kgo.Record
+		expected []string
+	}{
+		{
+			name: "matching value",
+			record: &kgo.Record{
+				Key:   []byte(`{"tier": "gold"}`),
+				Value: []byte(`{"status": "ERROR", "level": "ERROR", "durationMs": 1500, "attempts": [1], "message": "request timed out", "ssn": "123-45-6789"}`),
+			},
+			expected: []string{"error", "slow", "retried", "vip", "timeout"},
+		},
+		{
+			name: "non-matching value",
+			record: &kgo.Record{
+				Key:   []byte(`{"tier": "silver"}`),
+				Value: []byte(`{"status": "OK", "durationMs": 999, "attempts": [], "message": "done"}`),
+			},
+			expected: nil,
+		},
+		{
+			name:     "mismatching types",
+			record:   &kgo.Record{Value: []byte(`{"status": 500, "durationMs": "slow"}`)},
+			expected: nil,
+		},
+		{
+			// Text can't be redacted field by field and is therefore redacted as a whole
+			name:     "redacted text payload",
+			record:   &kgo.Record{Value: []byte("ping")},
+			expected: nil,
+		},
+		{
+			name:     "binary payload",
+			record:   &kgo.Record{Value: []byte{0xff, 0x00, 0xfe}},
+			expected: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := d.DeserializeRecord(tc.record, opts)
+			assert.Equal(t, tc.expected, rec.Tags)
+		})
+	}
+
+	t.Run("text payload", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Value: []byte("ping")}, DeserializationOptions{TagRules: opts.TagRules})
+		assert.Equal(t, []string{"heartbeat"}, rec.Tags)
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, rule := range []TagRule{
+			{Field: "$.status", Operator: "=="},
+			{Tag: "t", Field: "$.status", Operator: "~="},
+			{Tag: "t", Field: "status", Operator: "=="},
+			{Tag: "t", Payload: "header", Field: "$.status", Operator: "=="},
+		} {
+			err := DeserializationOptions{TagRules: []TagRule{rule}}.Validate()
+			assert.Error(t, err, "rule %+v", rule)
+		}
+	})
+}
+
+func TestDeserializer_HeadersOnly(t *testing.T) {
+	var decoded []string
+	d := deserializer{decoders: []payloadDecoder{{
+		Name: "recording",
+		Decode: func(in payloadDecoderInput) *deserializedPayload {
+			decoded = append(decoded, string(in.Payload))
+			return nil
+		},
+	}}}
+	record := &kgo.Record{
+		Key:   []byte("key"),
+		Value: []byte("large value"),
+		Headers: []kgo.RecordHeader{
+			{Key: "traceparent", Value: []byte("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")},
+		},
+	}
+
+	rec := d.DeserializeRecord(record, DeserializationOptions{HeadersOnly: true})
+	assert.Equal(t, []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, decoded)
+	assert.Equal(t, messageEncodingSkipped, rec.Value.RecognizedEncoding)
+	assert.Equal(t, len(record.Value), rec.Value.Size)
+	assert.False(t, rec.Value.IsPayloadNull)
+	jsonBytes, err := json.Marshal(rec.Value)
+	require.NoError(t, err)
+	assert.Contains(t, string(jsonBytes), `"payload":{}`)
+
+	t.Run("headers as text", func(t *testing.T) {
+		decoded = nil
+		rec := d.DeserializeRecord(record, DeserializationOptions{HeadersOnly: true, HeadersAsText: true})
+		assert.Empty(t, decoded)
+		assert.Equal(t, messageEncodingText, rec.Headers["traceparent"].RecognizedEncoding)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", rec.Headers["traceparent"].Object)
+	})
+}
+
+func TestDeserializer_Snappy(t *testing.T) {
+	d := deserializer{}
+	jsonPayload := []byte(`{"id": "a", "quantity": 1}`)
+
+	xerial := append([]byte{}, snappyXerialMagic...)
+	xerial = binary.BigEndian.AppendUint32(xerial, 1) // version
+	xerial = binary.BigEndian.AppendUint32(xerial, 1) // min compatible version
+	for _, chunk := range [][]byte{jsonPayload[:10], jsonPayload[10:]} {
+		block := snappy.Encode(nil, chunk)
+		xerial = binary.BigEndian.AppendUint32(xerial, uint32(len(block)))
+		xerial = append(xerial, block...)
+	}
+
+	var framed bytes.Buffer
+	w := snappy.NewBufferedWriter(&framed)
+	_, err := w.Write(jsonPayload)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	raw := snappy.Encode(nil, jsonPayload)
+
+	tt := []struct {
+		name        string
+		payload     []byte
+		opts        DeserializationOptions
+		compression string
+	}{
+		{"xerial", xerial, DeserializationOptions{}, compressionSnappyXerial},
+		{"framed", framed.Bytes(), DeserializationOptions{}, compressionSnappyFramed},
+		{"raw", raw, DeserializationOptions{RawSnappy: true}, compressionSnappy},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			dp := d.deserializePayload(test.payload, "orders", proto.RecordValue, test.opts)
+			assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+			assert.Equal(t, test.compression, dp.Compression)
+			assert.Equal(t, len(test.payload), dp.Size)
+			assert.Equal(t, len(jsonPayload), dp.UncompressedSize)
+			assert.JSONEq(t, string(jsonPayload), string(dp.Payload.Payload))
+		})
+	}
+
+	t.Run("raw snappy requires opt-in", func(t *testing.T) {
+		dp := d.deserializePayload(raw, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Empty(t, dp.Compression)
+	})
+
+	t.Run("raw snappy is only reported if the inner payload can be decoded", func(t *testing.T) {
+		binaryPayload := snappy.Encode(nil, []byte{0x01, 0x02, 0xff, 0xfe})
+		dp := d.deserializePayload(binaryPayload, "orders", proto.RecordValue, DeserializationOptions{RawSnappy: true})
+		assert.Empty(t, dp.Compression)
+	})
+}
+
+func TestDeserializer_Compression(t *testing.T) {
+	d := deserializer{}
+	jsonPayload := []byte(`{"id": "a", "quantity": 1, "note": "compressed payloads are decompressed before decoding"}`)
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err := gw.Write(jsonPayload)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	var lz4ed bytes.Buffer
+	lw := lz4.NewWriter(&lz4ed)
+	_, err = lw.Write(jsonPayload)
+	require.NoError(t, err)
+	require.NoError(t, lw.Close())
+
+	zw, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zstded := zw.EncodeAll(jsonPayload, nil)
+	require.NoError(t, zw.Close())
+
+	tt := []struct {
+		name        string
+		payload     []byte
+		compression string
+	}{
+		{"gzip", gzipped.Bytes(), compressionGzip},
+		{"lz4", lz4ed.Bytes(), compressionLz4},
+		{"zstd", zstded, compressionZstd},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			dp := d.deserializePayload(test.payload, "orders", proto.RecordValue, DeserializationOptions{})
+			assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+			assert.Equal(t, test.compression, dp.Compression)
+			assert.Equal(t, len(test.payload), dp.Size)
+			assert.Equal(t, len(jsonPayload), dp.UncompressedSize)
+			assert.JSONEq(t, string(jsonPayload), string(dp.Payload.Payload))
+		})
+	}
+
+	t.Run("uncompressed payloads omit compression fields", func(t *testing.T) {
+		dp := d.deserializePayload(jsonPayload, "orders", proto.RecordValue, DeserializationOptions{})
+		serialized, err := json.Marshal(dp)
+		require.NoError(t, err)
+		assert.NotContains(t, string(serialized), "compression")
+		assert.NotContains(t, string(serialized), "uncompressedSize")
+	})
+
+	t.Run("truncated payload is not decompressed", func(t *testing.T) {
+		dp := d.deserializePayload(gzipped.Bytes()[:12], "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Empty(t, dp.Compression)
+	})
+}
+
+func TestDeserializer_GzipNDJSON(t *testing.T) {
+	d := deserializer{}
+	gzipPayload := func(t *testing.T, payload string) []byte {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		_, err := gw.Write([]byte(payload))
+		require.NoError(t, err)
+		require.NoError(t, gw.Close())
+		return buf.Bytes()
+	}
+	ndjson := `{"level": "info", "msg": "started"}
+{"level": "warn", "msg": "slow request", "durationMs": 1200}
+
+{"level": "info", "msg": "stopped"}
+`
+	payload := gzipPayload(t, ndjson)
+
+	t.Run("gzip compressed NDJSON is decoded as array", func(t *testing.T) {
+		dp := d.deserializePayload(payload, "logs", proto.RecordValue, DeserializationOptions{GzipNDJSON: true})
+		assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+		assert.Equal(t, compressionGzip, dp.Compression)
+		assert.Equal(t, len(payload), dp.Size)
+		assert.Equal(t, len(ndjson), dp.UncompressedSize)
+		assert.JSONEq(t, `[
+			{"level": "info", "msg": "started"},
+			{"level": "warn", "msg": "slow request", "durationMs": 1200},
+			{"level": "info", "msg": "stopped"}
+		]`, string(dp.Payload.Payload))
+		require.IsType(t, []interface{}{}, dp.Object)
+		assert.Len(t, dp.Object, 3)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		dp := d.deserializePayload(payload, "logs", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, compressionGzip, dp.Compression)
+		assert.NotEqual(t, messageEncodingJSON, dp.RecognizedEncoding)
+	})
+
+	t.Run("single JSON document is left to the compressed decoder", func(t *testing.T) {
+		single := gzipPayload(t, `{"level": "info", "msg": "started"}`)
+		dp := d.deserializePayload(single, "logs", proto.RecordValue, DeserializationOptions{GzipNDJSON: true})
+		assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+		assert.Equal(t, compressionGzip, dp.Compression)
+		assert.JSONEq(t, `[{"level": "info", "msg": "started"}]`, string(dp.Payload.Payload))
+	})
+
+	t.Run("lines that are not JSON objects", func(t *testing.T) {
+		mixed := gzipPayload(t, "{\"level\": \"info\"}\nplain log line\n")
+		dp := d.deserializePayload(mixed, "logs", proto.RecordValue, DeserializationOptions{GzipNDJSON: true})
+		assert.Equal(t, compressionGzip, dp.Compression)
+		assert.NotEqual(t, messageEncodingJSON, dp.RecognizedEncoding)
+	})
+
+	t.Run("uncompressed NDJSON is not affected", func(t *testing.T) {
+		dp := d.deserializePayload([]byte(ndjson), "logs", proto.RecordValue, DeserializationOptions{GzipNDJSON: true})
+		assert.Empty(t, dp.Compression)
+		assert.NotEqual(t, messageEncodingJSON, dp.RecognizedEncoding)
+	})
+}
+
+func TestDeserializer_Debezium(t *testing.T) {
+	d := deserializer{}
+	source := `"source": {"connector": "postgresql", "db": "shop", "table": "customers"}`
+
+	tt := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name:     "insert",
+			value:    `{"before": null, "after": {"id": 1, "name": "jane"}, ` + source + `, "op": "c", "ts_ms": 1700000000000}`,
+			expected: `{"op": "create", "before": null, "after": {"id": 1, "name": "jane"}, "source": {"connector": "postgresql", "db": "shop", "table": "customers"}, "tsMs": 1700000000000}`,
+		},
+		{
+			name:     "update",
+			value:    `{"before": {"id": 1, "name": "jane"}, "after": {"id": 1, "name": "janet"}, ` + source + `, "op": "u"}`,
+			expected: `{"op": "update", "before": {"id": 1, "name": "jane"}, "after": {"id": 1, "name": "janet"}, "source": {"connector": "postgresql", "db": "shop", "table": "customers"}, "changedFields": ["name"]}`,
+		},
+		{
+			name:     "delete with schema",
+			value:    `{"schema": {"type": "struct"}, "payload": {"before": {"id": 1, "name": "janet"}, "after": null, ` + source + `, "op": "d"}}`,
+			expected: `{"op": "delete", "before": {"id": 1, "name": "janet"}, "after": null, "source": {"connector": "postgresql", "db": "shop", "table": "customers"}}`,
+		},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			rec := d.DeserializeRecord(&kgo.Record{Value: []byte(test.value)}, DeserializationOptions{Debezium: true})
+			require.NotNil(t, rec.Value.Debezium)
+			jsonBytes, err := json.Marshal(rec.Value.Debezium)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(jsonBytes))
+			// The decoded payload is kept as is
+			assert.JSONEq(t, test.value, string(rec.Value.Payload.Payload))
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Value: []byte(tt[0].value)}, DeserializationOptions{})
+		assert.Nil(t, rec.Value.Debezium)
+	})
+
+	t.Run("not an envelope", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Value: []byte(`{"op": "c", "after": {"id": 1}}`)}, DeserializationOptions{Debezium: true})
+		assert.Nil(t, rec.Value.Debezium)
+	})
+}
+
+func TestDeserializer_IncludeSchemaVersion(t *testing.T) {
+	var usageRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/schemas/ids/5":
+			_ = json.NewEncoder(w).Encode(map[string]string{"schema": testAvroOCFSchema})
+		case "/schemas/ids/5/versions":
+			usageRequests.Add(1)
+			_ = json.NewEncoder(w).Encode([]schema.SubjectVersion{{Subject: "orders-copy-value", Version: 1}, {Subject: "orders-value", Version: 3}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	svc, err := schema.NewService(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil, "")
+	require.NoError(t, err)
+	d := deserializer{SchemaService: svc}
+
+	body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": "a", "quantity": 1})
+	require.NoError(t, err)
+	payload := append([]byte{0, 0, 0, 0, 5}, body...)
+
+	dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+	assert.Equal(t, uint32(5), dp.SchemaID)
+	assert.Empty(t, dp.SchemaSubject)
+	assert.Equal(t, int32(0), usageRequests.Load())
+
+	for i := 0; i < 2; i++ {
+		dp = d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{IncludeSchemaVersion: true})
+		assert.Equal(t, "orders-value", dp.SchemaSubject)
+		assert.Equal(t, 3, dp.SchemaVersion)
+	}
+	assert.Equal(t, int32(1), usageRequests.Load(), "usages must be cached")
+}
+
+func TestDeserializer_SchemaTypeMismatch(t *testing.T) {
+	schemasByID := map[string]map[string]string{
+		"/schemas/ids/1": {"schemaType": "PROTOBUF", "schema": `syntax = "proto3"; message Order { string id = 1; }`},
+		"/schemas/ids/2": {"schema": testAvroOCFSchema},
+		"/schemas/ids/3": {"schemaType": "JSON", "schema": `{"type": "object"}`},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, exists := schemasByID[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+	svc, err := schema.NewService(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil, "")
+	require.NoError(t, err)
+	d := deserializer{SchemaService: svc}
+
+	t.Run("protobuf schema is not decoded as avro", func(t *testing.T) {
+		payload := []byte{0, 0, 0, 0, 1, 0, 0x0a, 0x01, 'a'}
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Zero(t, dp.SchemaID)
+		assert.Contains(t, dp.Troubleshooting, troubleshootingReport{
+			SerdeName: "avro",
+			Message:   "schema 1 is PROTOBUF, not AVRO: payload has been skipped by this decoder",
+		})
+
+		_, err := svc.GetAvroSchemaByID(context.Background(), 1)
+		assert.EqualError(t, err, "schema 1 is PROTOBUF, not AVRO")
+	})
+
+	t.Run("json payload with avro schema is not decoded as json schema", func(t *testing.T) {
+		payload := append([]byte{0, 0, 0, 0, 2}, []byte(`{"id":"a"}`)...)
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingJSON, dp.RecognizedEncoding)
+		assert.Contains(t, dp.Troubleshooting, troubleshootingReport{
+			SerdeName: "jsonSchema",
+			Message:   "schema 2 is AVRO, not JSON: payload has been skipped by this decoder",
+		})
+	})
+
+	t.Run("mixed formats decode with their serde", func(t *testing.T) {
+		body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": "a", "quantity": 1})
+		require.NoError(t, err)
+		dp := d.deserializePayload(append([]byte{0, 0, 0, 0, 2}, body...), "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(2), dp.SchemaID)
+		assert.Empty(t, dp.Troubleshooting)
+
+		dp = d.deserializePayload(append([]byte{0, 0, 0, 0, 3}, []byte(`{"id":"a"}`)...), "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(3), dp.SchemaID)
+		assert.Empty(t, dp.Troubleshooting)
+	})
+}
+
+func TestDeserializer_LenientUTF8(t *testing.T) {
+	d := deserializer{}
+	lenient := DeserializationOptions{LenientUTF8: true}
+
+	tests := []struct {
+		name             string
+		payload          []byte
+		opts             DeserializationOptions
+		expectedEncoding messageEncoding
+		expectedText     string
+		expectedReplaced bool
+	}{
+		{
+			name:             "valid utf8",
+			payload:          []byte("level=info msg=\"started\""),
+			opts:             lenient,
+			expectedEncoding: messageEncodingText,
+			expectedText:     "level=info msg=\"started\"",
+		},
+		{
+			name:             "slightly invalid utf8 is binary by default",
+			payload:          []byte("level=info msg=\"caf\xe9 opened\""),
+			expectedEncoding: messageEncodingBinary,
+		},
+		{
+			name:             "slightly invalid utf8 is replaced",
+			payload:          []byte("level=info msg=\"caf\xe9 opened\""),
+			opts:             lenient,
+			expectedEncoding: messageEncodingText,
+			expectedText:     "level=info msg=\"caf� opened\"",
+			expectedReplaced: true,
+		},
+		{
+			name:             "binary remains binary",
+			payload:          []byte{0xde, 0xad, 0xbe, 0xef, 0xff, 0xfe, 0x80, 0x81, 0xc0, 0xc1, 0xf5},
+			opts:             lenient,
+			expectedEncoding: messageEncodingBinary,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dp := d.deserializePayload(test.payload, "logs", proto.RecordValue, test.opts)
+			assert.Equal(t, test.expectedEncoding, dp.RecognizedEncoding)
+			assert.Equal(t, test.expectedReplaced, dp.InvalidUTF8Replaced)
+			assert.Equal(t, len(test.payload), dp.Size)
+			if test.expectedText != "" {
+				assert.Equal(t, test.expectedText, dp.Object)
+			}
+		})
+	}
+}
+
+func TestDeserializer_NestedBytesFields(t *testing.T) {
+	const envelopeSchema = `{
+		"type": "record",
+		"name": "Envelope",
+		"fields": [
+			{"name": "id", "type": "string"},
+			{"name": "payload", "type": "bytes"},
+			{"name": "attachment", "type": ["null", "bytes"]},
+			{"name": "checksum", "type": "bytes"}
+		]
+	}`
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{1: envelopeSchema})}
+
+	body, err := avro.Marshal(avro.MustParse(envelopeSchema), map[string]interface{}{
+		"id":         "a",
+		"payload":    []byte(`{"orderId": "o-1", "ssn": "123"}`),
+		"attachment": []byte(`{"note": "fragile"}`),
+		"checksum":   []byte(`{"not": "decoded"}`),
+	})
+	require.NoError(t, err)
+	record := &kgo.Record{Topic: "envelopes", Value: append([]byte{0, 0, 0, 0, 1}, body...)}
+
+	t.Run("configured fields are decoded", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{NestedBytesFields: []string{"$.payload", "attachment"}})
+		require.Equal(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+		checksum := base64.StdEncoding.EncodeToString([]byte(`{"not": "decoded"}`))
+		assert.JSONEq(t, `{
+			"id": "a",
+			"payload": {"orderId": "o-1", "ssn": "123"},
+			"attachment": {"note": "fragile"},
+			"checksum": "`+checksum+`"
+		}`, string(rec.Value.Payload.Payload))
+	})
+
+	t.Run("nested fields can be redacted", func(t *testing.T) {
+		rec := d.DeserializeRecord(record, DeserializationOptions{NestedBytesFields: []string{"payload"}, Redact: []string{"ssn"}})
+		assert.Contains(t, string(rec.Value.Payload.Payload), `"ssn":"[REDACTED]"`)
+	})
+
+	t.Run("binary bytes remain base64", func(t *testing.T) {
+		binaryBody, err := avro.Marshal(avro.MustParse(envelopeSchema), map[string]interface{}{
+			"id":         "b",
+			"payload":    []byte{0xff, 0xfe, 0x00, 0x01, 0x02},
+			"attachment": nil,
+			"checksum":   []byte{0x01},
+		})
+		require.NoError(t, err)
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "envelopes", Value: append([]byte{0, 0, 0, 0, 1}, binaryBody...)},
+			DeserializationOptions{NestedBytesFields: []string{"payload", "attachment"}})
+		assert.JSONEq(t, `{"id": "b", "payload": "//4AAQI=", "attachment": null, "checksum": "AQ=="}`, string(rec.Value.Payload.Payload))
+	})
+}
+
+func TestDeserializer_Schemaless(t *testing.T) {
+	const orderSchema = `{"type": "record", "name": "Order", "namespace": "shop", "fields": [{"name": "id", "type": "string"}, {"name": "quantity", "type": "int"}]}`
+	const paymentProto = `syntax = "proto3"; package shop; message Payment { string id = 1; int64 amount = 2; }`
+
+	avroSubject := schema.SchemaVersionedResponse{SchemaID: 1, Version: 1, Schema: orderSchema, Type: schema.TypeAvro}
+	protoSubject := schema.SchemaVersionedResponse{Subject: "payments-value", SchemaID: 2, Version: 1, Schema: paymentProto, Type: schema.TypeProtobuf}
+	subjects := map[string]schema.SchemaVersionedResponse{
+		"orders-value":      avroSubject,
+		"shop.Order":        avroSubject,
+		"orders-shop.Order": avroSubject,
+		"payments-value":    protoSubject,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/schemas/types":
+			_ = json.NewEncoder(w).Encode([]string{"AVRO", "PROTOBUF"})
+		case r.URL.Path == "/schemas":
+			_ = json.NewEncoder(w).Encode([]schema.SchemaVersionedResponse{protoSubject})
+		case r.URL.Path == "/schemas/ids/1":
+			_ = json.NewEncoder(w).Encode(map[string]string{"schema": orderSchema})
+		case strings.HasSuffix(r.URL.Path, "/versions/latest"):
+			subject := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions/latest")
+			res, exists := subjects[subject]
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(schema.RestError{ErrorCode: schema.CodeSubjectNotFound, Message: "Subject not found."})
+				return
+			}
+			res.Subject = subject
+			_ = json.NewEncoder(w).Encode(res)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	schemaSvc, err := schema.NewService(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil, "")
+	require.NoError(t, err)
+	protoSvc, err := proto.NewService(config.Proto{
+		Enabled:        true,
+		SchemaRegistry: config.ProtoSchemaRegistry{Enabled: true, RefreshInterval: time.Hour},
+	}, zap.NewNop(), schemaSvc)
+	require.NoError(t, err)
+	require.NoError(t, protoSvc.Start())
+	d := deserializer{SchemaService: schemaSvc, ProtoService: protoSvc}
+
+	avroBody, err := avro.Marshal(avro.MustParse(orderSchema), map[string]interface{}{"id": "o-1", "quantity": 2})
+	require.NoError(t, err)
+
+	tt := []struct {
+		name string
+		opts SchemalessOptions
+	}{
+		{"topic name strategy", SchemalessOptions{Strategy: schema.TopicNameStrategy}},
+		{"record name strategy", SchemalessOptions{Strategy: schema.RecordNameStrategy, ValueRecordName: "shop.Order"}},
+		{"topic record name strategy", SchemalessOptions{Strategy: schema.TopicRecordNameStrategy, ValueRecordName: "shop.Order"}},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			opts := DeserializationOptions{Schemaless: test.opts}
+			require.NoError(t, opts.Validate())
+			dp := d.deserializePayload(avroBody, "orders", proto.RecordValue, opts)
+			assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+			assert.Equal(t, uint32(1), dp.SchemaID)
+			assert.JSONEq(t, `{"id": "o-1", "quantity": 2}`, string(dp.Payload.Payload))
+		})
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		// Field 1 (id) = "p-1", field 2 (amount) = 150
+		protoBody := []byte{0x0a, 0x03, 'p', '-', '1', 0x10, 0x96, 0x01}
+		dp := d.deserializePayload(protoBody, "payments", proto.RecordValue, DeserializationOptions{
+			Schemaless: SchemalessOptions{Strategy: schema.TopicNameStrategy},
+		})
+		assert.Equal(t, messageEncodingProtobuf, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(2), dp.SchemaID)
+		assert.JSONEq(t, `{"id": "p-1", "amount": "150"}`, string(dp.Payload.Payload))
+	})
+
+	t.Run("framed payloads are left to the other decoders", func(t *testing.T) {
+		framed := append([]byte{0x0, 0, 0, 0, 1}, avroBody...)
+		dp := d.deserializePayload(framed, "orders", proto.RecordValue, DeserializationOptions{
+			Schemaless: SchemalessOptions{Strategy: schema.TopicNameStrategy},
+		})
+		assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.JSONEq(t, `{"id": "o-1", "quantity": 2}`, string(dp.Payload.Payload))
+	})
+
+	t.Run("avro body with leftover bytes", func(t *testing.T) {
+		dp := d.deserializePayload(append(avroBody, 0x2), "orders", proto.RecordValue, DeserializationOptions{
+			Schemaless: SchemalessOptions{Strategy: schema.TopicNameStrategy},
+		})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+
+	t.Run("truncated avro body", func(t *testing.T) {
+		dp := d.deserializePayload(avroBody[:len(avroBody)-1], "orders", proto.RecordValue, DeserializationOptions{
+			Schemaless: SchemalessOptions{Strategy: schema.TopicNameStrategy},
+		})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+
+	t.Run("protobuf with undefined fields", func(t *testing.T) {
+		// Field 1 (id) = "p-1" and the undefined field 3 = 1
+		protoBody := []byte{0x0a, 0x03, 'p', '-', '1', 0x18, 0x01}
+		dp := d.deserializePayload(protoBody, "payments", proto.RecordValue, DeserializationOptions{
+			Schemaless: SchemalessOptions{Strategy: schema.TopicNameStrategy},
+		})
+		assert.NotEqual(t, messageEncodingProtobuf, dp.RecognizedEncoding)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		dp := d.deserializePayload(avroBody, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+
+	t.Run("record name strategies require the record name", func(t *testing.T) {
+		dp := d.deserializePayload(avroBody, "orders", proto.RecordKey, DeserializationOptions{
+			Schemaless: SchemalessOptions{Strategy: schema.RecordNameStrategy, ValueRecordName: "shop.Order"},
+		})
+		assert.NotEqual(t, messageEncodingAvro, dp.RecognizedEncoding)
+	})
+
+	t.Run("unknown strategy is rejected", func(t *testing.T) {
+		opts := DeserializationOptions{Schemaless: SchemalessOptions{Strategy: "Custom"}}
+		assert.ErrorContains(t, opts.Validate(), "invalid schemaless option")
+	})
+}
+
+func TestDeserializer_SchemaIDHeaders(t *testing.T) {
+	const orderSchema = `{"type": "record", "name": "Order", "namespace": "shop", "fields": [{"name": "id", "type": "string"}, {"name": "quantity", "type": "int"}]}`
+	const paymentProto = `syntax = "proto3"; package shop; message Payment { string id = 1; int64 amount = 2; }`
+	const customerSchema = `{"type": "object", "properties": {"name": {"type": "string"}}}`
+
+	protoSchema := schema.SchemaVersionedResponse{Subject: "payments-value", SchemaID: 2, Version: 1, Schema: paymentProto, Type: schema.TypeProtobuf}
+	schemasByID := map[string]map[string]string{
+		"1": {"schema": orderSchema},
+		"2": {"schema": paymentProto, "schemaType": "PROTOBUF"},
+		"3": {"schema": customerSchema, "schemaType": "JSON"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/schemas/types":
+			_ = json.NewEncoder(w).Encode([]string{"AVRO", "PROTOBUF", "JSON"})
+			return
+		case "/schemas":
+			_ = json.NewEncoder(w).Encode([]schema.SchemaVersionedResponse{protoSchema})
+			return
+		}
+		res, exists := schemasByID[strings.TrimPrefix(r.URL.Path, "/schemas/ids/")]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	schemaSvc, err := schema.NewService(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil, "")
+	require.NoError(t, err)
+	protoSvc, err := proto.NewService(config.Proto{
+		Enabled:        true,
+		SchemaRegistry: config.ProtoSchemaRegistry{Enabled: true, RefreshInterval: time.Hour},
+	}, zap.NewNop(), schemaSvc)
+	require.NoError(t, err)
+	require.NoError(t, protoSvc.Start())
+	d := deserializer{SchemaService: schemaSvc, ProtoService: protoSvc}
+	opts := DeserializationOptions{SchemaIDHeaders: SchemaIDHeaderOptions{Key: "key.schema.id", Value: "value.schema.id"}}
+
+	avroBody, err := avro.Marshal(avro.MustParse(orderSchema), map[string]interface{}{"id": "o-1", "quantity": 2})
+	require.NoError(t, err)
+
+	t.Run("avro key and value", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic: "orders",
+			Key:   avroBody,
+			Value: avroBody,
+			Headers: []kgo.RecordHeader{
+				{Key: "key.schema.id", Value: []byte("1")},
+				{Key: "value.schema.id", Value: binary.BigEndian.AppendUint32(nil, 1)},
+			},
+		}
+		rec := d.DeserializeRecord(record, opts)
+		for _, dp := range []*deserializedPayload{rec.Key, rec.Value} {
+			assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+			assert.Equal(t, uint32(1), dp.SchemaID)
+			assert.Equal(t, len(avroBody), dp.Size)
+			assert.JSONEq(t, `{"id": "o-1", "quantity": 2}`, string(dp.Payload.Payload))
+		}
+		assert.Contains(t, rec.Headers, "value.schema.id", "the header is still returned")
+	})
+
+	t.Run("protobuf value", func(t *testing.T) {
+		// Field 1 (id) = "p-1", field 2 (amount) = 150
+		protoBody := []byte{0x0a, 0x03, 'p', '-', '1', 0x10, 0x96, 0x01}
+		record := &kgo.Record{
+			Topic:   "payments",
+			Value:   protoBody,
+			Headers: []kgo.RecordHeader{{Key: "value.schema.id", Value: binary.BigEndian.AppendUint64(nil, 2)}},
+		}
+		rec := d.DeserializeRecord(record, opts)
+		assert.Equal(t, messageEncodingProtobuf, rec.Value.RecognizedEncoding)
+		assert.Equal(t, uint32(2), rec.Value.SchemaID)
+		assert.JSONEq(t, `{"id": "p-1", "amount": "150"}`, string(rec.Value.Payload.Payload))
+	})
+
+	t.Run("json schema value", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic:   "customers",
+			Value:   []byte(`{"name": "jane"}`),
+			Headers: []kgo.RecordHeader{{Key: "value.schema.id", Value: []byte("3")}},
+		}
+		rec := d.DeserializeRecord(record, opts)
+		assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding)
+		assert.Equal(t, uint32(3), rec.Value.SchemaID)
+	})
+
+	t.Run("unknown schema id", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic:   "orders",
+			Value:   avroBody,
+			Headers: []kgo.RecordHeader{{Key: "value.schema.id", Value: []byte("42")}},
+		}
+		rec := d.DeserializeRecord(record, opts)
+		assert.NotEqual(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+		assert.Equal(t, uint32(0), rec.Value.SchemaID)
+	})
+
+	t.Run("header not configured", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic:   "orders",
+			Value:   avroBody,
+			Headers: []kgo.RecordHeader{{Key: "value.schema.id", Value: []byte("1")}},
+		}
+		rec := d.DeserializeRecord(record, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingAvro, rec.Value.RecognizedEncoding)
+	})
+
+	t.Run("invalid header values", func(t *testing.T) {
+		for _, value := range [][]byte{nil, []byte("abc"), binary.BigEndian.AppendUint64(nil, math.MaxUint32+1)} {
+			_, ok := parseSchemaIDHeader(value)
+			assert.False(t, ok)
+		}
+	})
+}
+
+func TestDeserializer_TopicDecoders(t *testing.T) {
+	topicDecoders, err := newTopicDecoders([]config.KafkaTopicDecoder{
+		{TopicNames: []string{"_schemas"}, Decoder: "json"},
+		{TopicNames: []string{"__transaction_state", `/^__redpanda\..*/`}, Decoder: "binary"},
+	})
+	require.NoError(t, err)
+	d := deserializer{topicDecoders: topicDecoders}
+
+	tests := []struct {
+		name             string
+		topicName        string
+		payload          []byte
+		expectedEncoding messageEncoding
+	}{
+		{
+			name:             "forced decoder succeeds",
+			topicName:        "_schemas",
+			payload:          []byte(`{"subject":"orders-value","version":1}`),
+			expectedEncoding: messageEncodingJSON,
+		},
+		{
+			name:             "forced decoder fails",
+			topicName:        "_schemas",
+			payload:          []byte("plain text"),
+			expectedEncoding: messageEncodingBinary,
+		},
+		{
+			name:             "binary by topic name",
+			topicName:        "__transaction_state",
+			payload:          []byte("plain text"),
+			expectedEncoding: messageEncodingBinary,
+		},
+		{
+			name:             "binary by topic regex",
+			topicName:        "__redpanda.cloud.events",
+			payload:          []byte(`{"key":"value"}`),
+			expectedEncoding: messageEncodingBinary,
+		},
+		{
+			name:             "unmatched topic is auto-detected",
+			topicName:        "orders",
+			payload:          []byte("plain text"),
+			expectedEncoding: messageEncodingText,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dp := d.deserializePayload(test.payload, test.topicName, proto.RecordValue, DeserializationOptions{})
+			assert.Equal(t, test.expectedEncoding, dp.RecognizedEncoding)
+		})
+	}
+
+	assert.NoError(t, d.validateTopicDecoders())
+	unknown, err := newTopicDecoders([]config.KafkaTopicDecoder{{TopicNames: []string{"_schemas"}, Decoder: "yaml"}})
+	require.NoError(t, err)
+	assert.ErrorContains(t, (&deserializer{topicDecoders: unknown}).validateTopicDecoders(), `unknown decoder "yaml"`)
+}
+
+func TestDeserializer_ForcedEncoding(t *testing.T) {
+	topicDecoders, err := newTopicDecoders([]config.KafkaTopicDecoder{{TopicNames: []string{"_schemas"}, Decoder: "json"}})
+	require.NoError(t, err)
+	d := deserializer{topicDecoders: topicDecoders}
+
+	t.Run("only the forced decoder is tried", func(t *testing.T) {
+		record := &kgo.Record{
+			Topic:   "orders",
+			Key:     []byte(`{"id":1}`),
+			Value:   []byte(`{"id":1}`),
+			Headers: []kgo.RecordHeader{{Key: "trace", Value: []byte(`{"span":2}`)}},
+		}
+		rec := d.DeserializeRecord(record, DeserializationOptions{KeyEncoding: "utf8", ValueEncoding: "binary"})
+		assert.Equal(t, messageEncodingText, rec.Key.RecognizedEncoding)
+		assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+		assert.Empty(t, rec.Value.Troubleshooting)
+		assert.Equal(t, messageEncodingJSON, rec.Headers["trace"].RecognizedEncoding, "headers are auto-detected")
+	})
+
+	t.Run("failing forced decoder returns binary", func(t *testing.T) {
+		dp := d.deserializePayload([]byte("plain text"), "orders", proto.RecordValue, DeserializationOptions{ValueEncoding: "json"})
+		assert.Equal(t, messageEncodingBinary, dp.RecognizedEncoding)
+		require.Len(t, dp.Troubleshooting, 1)
+		assert.Equal(t, "json", dp.Troubleshooting[0].SerdeName)
+	})
+
+	t.Run("forced encoding overrides topic decoders", func(t *testing.T) {
+		dp := d.deserializePayload([]byte(`{"subject":"orders-value"}`), "_schemas", proto.RecordValue, DeserializationOptions{ValueEncoding: "utf8"})
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+	})
+
+	t.Run("unforced payload type is auto-detected", func(t *testing.T) {
+		dp := d.deserializePayload([]byte(`{"id":1}`), "orders", proto.RecordKey, DeserializationOptions{ValueEncoding: "utf8"})
+		assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+	})
+
+	t.Run("forced binary has no schema registry notes", func(t *testing.T) {
+		payload := []byte{0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 'a'}
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{ValueEncoding: "binary"})
+		assert.Equal(t, messageEncodingBinary, dp.RecognizedEncoding)
+		assert.Empty(t, dp.Troubleshooting)
+	})
+
+	t.Run("forced encodings apply to previews", func(t *testing.T) {
+		payload := []byte(strings.Repeat("a", 64))
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{ValueEncoding: "binary", PreviewBytes: 32})
+		assert.Equal(t, messageEncodingSkipped, dp.RecognizedEncoding)
+		assert.True(t, dp.Truncated)
+
+		dp = d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{ValueEncoding: "utf8", PreviewBytes: 32})
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+		assert.Equal(t, strings.Repeat("a", 32), dp.Object)
+	})
+
+	assert.NoError(t, d.validateForcedEncodings(DeserializationOptions{KeyEncoding: "avro", ValueEncoding: "binary"}))
+	assert.ErrorContains(t, d.validateForcedEncodings(DeserializationOptions{ValueEncoding: "int16"}), `unknown encoding "int16"`)
+}
+
+func TestDeserializer_ProtobufMessageType(t *testing.T) {
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{
+			"shop/payment.proto": `syntax = "proto3";
+package shop;
+message Payment {
+  string id = 1;
+  int64 amount = 2;
+}`,
+		}),
+	}
+	fds, err := parser.ParseFiles("shop/payment.proto")
+	require.NoError(t, err)
+	serialized, err := protobuf.Marshal(desc.ToFileDescriptorSet(fds...))
+	require.NoError(t, err)
+
+	protoSvc, err := proto.NewService(config.Proto{
+		Enabled:            true,
+		FileDescriptorSets: []config.ProtoFileDescriptorSet{{Inline: base64.StdEncoding.EncodeToString(serialized)}},
+	}, zap.NewNop(), nil)
+	require.NoError(t, err)
+	require.NoError(t, protoSvc.Start())
+	d := deserializer{ProtoService: protoSvc}
+
+	// Field 1 (id) = "p-1", field 2 (amount) = 150
+	payload := []byte{0x0a, 0x03, 'p', '-', '1', 0x10, 0x96, 0x01}
+
+	t.Run("message type with protobuf encoding", func(t *testing.T) {
+		opts := DeserializationOptions{ValueEncoding: "protobuf", ValueProtobufType: "shop.Payment"}
+		require.NoError(t, opts.Validate())
+		dp := d.deserializePayload(payload, "payments", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingProtobuf, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(0), dp.SchemaID)
+		assert.JSONEq(t, `{"id": "p-1", "amount": "150"}`, string(dp.Payload.Payload))
+	})
+
+	t.Run("unknown message type returns binary", func(t *testing.T) {
+		opts := DeserializationOptions{ValueEncoding: "protobuf", ValueProtobufType: "shop.Refund"}
+		dp := d.deserializePayload(payload, "payments", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingBinary, dp.RecognizedEncoding)
+		require.Len(t, dp.Troubleshooting, 1)
+		assert.Equal(t, "protobuf", dp.Troubleshooting[0].SerdeName)
+	})
+
+	t.Run("message type requires protobuf encoding", func(t *testing.T) {
+		err := DeserializationOptions{ValueProtobufType: "shop.Payment"}.Validate()
+		assert.ErrorContains(t, err, `value protobuf type requires the value encoding "protobuf"`)
+		err = DeserializationOptions{KeyEncoding: "json", KeyProtobufType: "shop.Payment"}.Validate()
+		assert.ErrorContains(t, err, `key protobuf type requires the key encoding "protobuf"`)
+	})
+}
+
+func TestDeserializer_DecoderChain(t *testing.T) {
+	defaultNames := func(decoders []payloadDecoder) []string {
+		names := make([]string, len(decoders))
+		for i, decoder := range decoders {
+			names[i] = decoder.Name
+		}
+		return names
+	}
+	chain, err := newDecoderChain(config.KafkaDecoderChain{})
+	require.NoError(t, err)
+	d := deserializer{decoderChain: chain}
+	assert.Equal(t, defaultNames(d.allPayloadDecoders()), defaultNames(d.payloadDecoders()))
+
+	chain, err = newDecoderChain(config.KafkaDecoderChain{Order: []string{"utf8", "avro"}, Disabled: []string{"xml"}})
+	require.NoError(t, err)
+	names := defaultNames((&deserializer{decoderChain: chain}).payloadDecoders())
+	assert.Equal(t, []string{"utf8", "avro", "compositeKey"}, names[:3])
+	assert.NotContains(t, names, "xml")
+	assert.Len(t, names, len(d.allPayloadDecoders())-1)
+
+	topicDecoders, err := newTopicDecoders([]config.KafkaTopicDecoder{{TopicNames: []string{"sitemaps"}, Decoder: "xml"}})
+	require.NoError(t, err)
+	d = deserializer{decoderChain: chain, topicDecoders: topicDecoders}
+
+	t.Run("ordered decoder is tried first", func(t *testing.T) {
+		dp := d.deserializePayload([]byte(`{"id":1}`), "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+	})
+
+	t.Run("disabled decoder is skipped", func(t *testing.T) {
+		dp := d.deserializePayload([]byte("<id>1</id>"), "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+	})
+
+	t.Run("topic decoder forces a disabled decoder", func(t *testing.T) {
+		dp := d.deserializePayload([]byte("<id>1</id>"), "sitemaps", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingXML, dp.RecognizedEncoding)
+	})
+
+	_, err = newDecoderChain(config.KafkaDecoderChain{Order: []string{"yaml"}})
+	assert.ErrorContains(t, err, `unknown decoder "yaml" in order`)
+	_, err = newDecoderChain(config.KafkaDecoderChain{Disabled: []string{"yaml"}})
+	assert.ErrorContains(t, err, `unknown decoder "yaml" in disabled`)
+}
+
+func TestDeserializer_CompositeKey(t *testing.T) {
+	cfg := config.KafkaCompositeKey{
+		TopicNames: []string{"/^orders-.*/"},
+		Fields: []config.KafkaCompositeKeyField{
+			{Name: "tenantId", Type: "uint64"},
+			{Name: "entityId", Type: "int64"},
+		},
+	}
+	require.NoError(t, cfg.Validate())
+	compositeKeys, err := newCompositeKeyLayouts([]config.KafkaCompositeKey{cfg})
+	require.NoError(t, err)
+	d := deserializer{compositeKeys: compositeKeys}
+
+	key := binary.BigEndian.AppendUint64(nil, 42)
+	key = binary.BigEndian.AppendUint64(key, uint64(1<<40))
+
+	t.Run("key is decoded field by field", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "orders-eu", Key: key, Value: []byte("v")}, DeserializationOptions{})
+		require.Equal(t, messageEncodingCompositeKey, rec.Key.RecognizedEncoding)
+		assert.JSONEq(t, `{"tenantId":42,"entityId":1099511627776}`, string(rec.Key.Payload.Payload))
+		assert.Equal(t, messageEncodingText, rec.Value.RecognizedEncoding)
+	})
+
+	t.Run("values are not decoded with the layout", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "orders-eu", Value: key}, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingCompositeKey, rec.Value.RecognizedEncoding)
+	})
+
+	t.Run("other topics are auto-detected", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "payments", Key: key}, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingCompositeKey, rec.Key.RecognizedEncoding)
+	})
+
+	t.Run("keys that don't match the layout are auto-detected", func(t *testing.T) {
+		rec := d.DeserializeRecord(&kgo.Record{Topic: "orders-eu", Key: key[:12]}, DeserializationOptions{})
+		assert.NotEqual(t, messageEncodingCompositeKey, rec.Key.RecognizedEncoding)
+	})
+
+	t.Run("variable width last field", func(t *testing.T) {
+		layout := []config.KafkaCompositeKeyField{
+			{Name: "region", Type: "string", Width: 4},
+			{Name: "id", Type: "uuid"},
+			{Name: "suffix", Type: "bytes"},
+		}
+		require.NoError(t, (&config.KafkaCompositeKey{TopicNames: []string{"t"}, Fields: layout}).Validate())
+
+		key := append([]byte("eu\x00\x00"), bytes.Repeat([]byte{0xab}, 16)...)
+		key = append(key, 0x01, 0x02)
+		obj, err := decodeCompositeKeyFields(key, layout)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"region": "eu",
+			"id":     "abababab-abab-abab-abab-abababababab",
+			"suffix": "0102",
+		}, obj)
+	})
+
+	t.Run("invalid layouts", func(t *testing.T) {
+		for _, fields := range [][]config.KafkaCompositeKeyField{
+			{{Name: "a", Type: "float"}},
+			{{Name: "a", Type: "int32", Width: 8}},
+			{{Name: "a", Type: "string"}, {Name: "b", Type: "int8"}},
+			{{Name: "a", Type: "int8"}, {Name: "a", Type: "int8"}},
+		} {
+			assert.Error(t, (&config.KafkaCompositeKey{TopicNames: []string{"t"}, Fields: fields}).Validate())
+		}
+	})
+}
+
+func TestDeserializer_NormalizedSize(t *testing.T) {
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{1: testAvroOCFSchema})}
+
+	body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": "order-with-a-long-id", "quantity": 1})
+	require.NoError(t, err)
+	avroPayload := append([]byte{0x00, 0x00, 0x00, 0x00, 0x01}, body...)
+
+	t.Run("avro expands to json", func(t *testing.T) {
+		dp := d.deserializePayload(avroPayload, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.Equal(t, len(avroPayload), dp.Size)
+		assert.Equal(t, len(dp.Payload.Payload), dp.NormalizedSize)
+		assert.Greater(t, dp.NormalizedSize, dp.Size)
+	})
+
+	t.Run("text and binary are measured serialized", func(t *testing.T) {
+		dp := d.deserializePayload([]byte(`say "hi"`), "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+		assert.Equal(t, 8, dp.Size)
+		assert.Equal(t, len(`"say \"hi\""`), dp.NormalizedSize)
+
+		dp = d.deserializePayload([]byte{0xff, 0xfe, 0x00, 0x01, 0x02}, "orders", proto.RecordValue, DeserializationOptions{})
+		require.Equal(t, messageEncodingBinary, dp.RecognizedEncoding)
+		assert.Equal(t, len(`"//4AAQI="`), dp.NormalizedSize)
+	})
+
+	t.Run("no normalized payload", func(t *testing.T) {
+		dp := d.deserializePayload(nil, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Zero(t, dp.NormalizedSize)
+	})
+
+	t.Run("size after post-processing", func(t *testing.T) {
+		record := &kgo.Record{Topic: "orders", Key: []byte(`{"id":"1"}`), Value: avroPayload}
+		rec := d.DeserializeRecord(record, DeserializationOptions{Redact: []string{"id"}})
+		assert.Equal(t, len(`{"id":"[REDACTED]"}`), rec.Key.NormalizedSize)
+		assert.Equal(t, len(rec.Value.Payload.Payload), rec.Value.NormalizedSize)
+
+		serialized, err := json.Marshal(rec.Value)
+		require.NoError(t, err)
+		assert.Contains(t, string(serialized), `"normalizedSize":`+strconv.Itoa(rec.Value.NormalizedSize))
+	})
+}
+
+func TestDeserializer_PerSideEncodings(t *testing.T) {
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{1: testAvroOCFSchema})}
+
+	body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": "a", "quantity": 1})
+	require.NoError(t, err)
+	avroPayload := append([]byte{0x00, 0x00, 0x00, 0x00, 0x01}, body...)
+
+	tests := []struct {
+		name          string
+		record        *kgo.Record
+		keyEncoding   messageEncoding
+		keySchemaID   uint32
+		valueEncoding messageEncoding
+		valueSchemaID uint32
+	}{
+		{
+			name:          "text key and avro value",
+			record:        &kgo.Record{Topic: "orders", Key: []byte("order-1"), Value: avroPayload},
+			keyEncoding:   messageEncodingText,
+			valueEncoding: messageEncodingAvro,
+			valueSchemaID: 1,
+		},
+		{
+			name:          "avro key and json value",
+			record:        &kgo.Record{Topic: "orders", Key: avroPayload, Value: []byte(`{"status":"shipped"}`)},
+			keyEncoding:   messageEncodingAvro,
+			keySchemaID:   1,
+			valueEncoding: messageEncodingJSON,
+		},
+		{
+			name:          "null key and avro value",
+			record:        &kgo.Record{Topic: "orders", Value: avroPayload},
+			keyEncoding:   messageEncodingNone,
+			valueEncoding: messageEncodingAvro,
+			valueSchemaID: 1,
+		},
+		{
+			name:          "consumer offsets with unknown key",
+			record:        &kgo.Record{Topic: "__consumer_offsets", Key: []byte{0x00, 0x02, 0xff}, Value: []byte("unknown")},
+			keyEncoding:   messageEncodingBinary,
+			valueEncoding: messageEncodingText,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := d.DeserializeRecord(test.record, DeserializationOptions{})
+			require.NotNil(t, rec.Key)
+			require.NotNil(t, rec.Value)
+			assert.NotNil(t, rec.Headers)
+
+			assert.Equal(t, test.keyEncoding, rec.Key.RecognizedEncoding)
+			assert.Equal(t, test.keyEncoding, rec.Key.Payload.RecognizedEncoding)
+			assert.Equal(t, test.keySchemaID, rec.Key.SchemaID)
+			assert.Equal(t, test.record.Key == nil, rec.Key.IsPayloadNull)
+			assert.Equal(t, test.valueEncoding, rec.Value.RecognizedEncoding)
+			assert.Equal(t, test.valueEncoding, rec.Value.Payload.RecognizedEncoding)
+			assert.Equal(t, test.valueSchemaID, rec.Value.SchemaID)
+
+			msg, err := json.Marshal(TopicMessage{Key: rec.Key, Value: rec.Value})
+			require.NoError(t, err)
+			var sides struct {
+				Key   struct{ Encoding messageEncoding }
+				Value struct{ Encoding messageEncoding }
+			}
+			require.NoError(t, json.Unmarshal(msg, &sides))
+			assert.Equal(t, test.keyEncoding, sides.Key.Encoding)
+			assert.Equal(t, test.valueEncoding, sides.Value.Encoding)
+		})
+	}
+}
+
+func TestDeserializer_PreviewBytes(t *testing.T) {
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{1: testAvroOCFSchema})}
+	opts := DeserializationOptions{PreviewBytes: 32}
+
+	t.Run("json lines are truncated", func(t *testing.T) {
+		payload := []byte("{\"id\":\"a\",\"quantity\":1}\n{\"id\":\"b\",\"quantity\":2}\n")
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		// Like any other text, line breaks are rendered as control chars
+		assert.Equal(t, messageEncodingUtf8WithControlChars, dp.RecognizedEncoding)
+		assert.True(t, dp.Truncated)
+		assert.Equal(t, "{\"id\":\"a\",\"quantity\":1}\n", string(dp.Payload.Payload))
+		assert.Equal(t, len(payload), dp.Size)
+	})
+
+	t.Run("text is truncated on rune boundary", func(t *testing.T) {
+		payload := []byte(strings.Repeat("ä", 20))
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+		assert.True(t, dp.Truncated)
+		assert.Equal(t, strings.Repeat("ä", 16), dp.Object)
+	})
+
+	t.Run("avro returns metadata only", func(t *testing.T) {
+		body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": strings.Repeat("a", 64), "quantity": 1})
+		require.NoError(t, err)
+		payload := append([]byte{0x00, 0x00, 0x00, 0x00, 0x01}, body...)
+
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingSkipped, dp.RecognizedEncoding)
+		assert.True(t, dp.Truncated)
+		assert.Equal(t, uint32(1), dp.SchemaID)
+		assert.Equal(t, len(payload), dp.Size)
+		require.Len(t, dp.Troubleshooting, 1)
+		assert.Equal(t, previewSerdeName, dp.Troubleshooting[0].SerdeName)
+	})
+
+	t.Run("avro with short string returns metadata only", func(t *testing.T) {
+		// The prefix of this payload is valid UTF-8 and must not be mistaken for text
+		body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": "aaaaaaaaaaaaaaaaaaaa", "quantity": 1})
+		require.NoError(t, err)
+		payload := append([]byte{0x00, 0x00, 0x00, 0x00, 0x01}, body...)
+
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{PreviewBytes: 16})
+		assert.Equal(t, messageEncodingSkipped, dp.RecognizedEncoding)
+		assert.True(t, dp.Truncated)
+		assert.Equal(t, uint32(1), dp.SchemaID)
+	})
+
+	t.Run("binary with control chars returns metadata only", func(t *testing.T) {
+		payload := append([]byte{0x01, 0x02, 0x03}, strings.Repeat("a", 64)...)
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingSkipped, dp.RecognizedEncoding)
+		assert.True(t, dp.Truncated)
+		assert.Zero(t, dp.SchemaID)
+	})
+
+	t.Run("small payloads are decoded entirely", func(t *testing.T) {
+		dp := d.deserializePayload([]byte(`{"id":"a"}`), "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+		assert.False(t, dp.Truncated)
+	})
+}
+
+func TestDeserializer_LargePayloadBytes(t *testing.T) {
+	d := deserializer{SchemaService: newTestSchemaService(t, map[int]string{1: testAvroOCFSchema})}
+	opts := DeserializationOptions{LargePayloadBytes: 1024}
+
+	t.Run("large json is not parsed", func(t *testing.T) {
+		payload := []byte(`{"items":["` + strings.Repeat("a", 2048) + `"]}`)
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+		require.Len(t, dp.Troubleshooting, 1)
+		assert.Equal(t, "json", dp.Troubleshooting[0].SerdeName)
+
+		dp = d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingJSON, dp.RecognizedEncoding)
+	})
+
+	t.Run("large text with a json like start", func(t *testing.T) {
+		payload := []byte(`{not json} ` + strings.Repeat("a", 2048))
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+		assert.Empty(t, dp.Troubleshooting)
+	})
+
+	t.Run("large xml is not decoded structurally", func(t *testing.T) {
+		payload := []byte(`<order><note>` + strings.Repeat("a", 2048) + `</note></order>`)
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingText, dp.RecognizedEncoding)
+
+		dp = d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+		assert.Equal(t, messageEncodingXML, dp.RecognizedEncoding)
+	})
+
+	t.Run("large avro with registered schema", func(t *testing.T) {
+		body, err := avro.Marshal(avro.MustParse(testAvroOCFSchema), map[string]interface{}{"id": strings.Repeat("a", 2048), "quantity": 1})
+		require.NoError(t, err)
+		payload := append([]byte{0x00, 0x00, 0x00, 0x00, 0x01}, body...)
+
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+		assert.Equal(t, uint32(1), dp.SchemaID)
+	})
+
+	t.Run("large binary", func(t *testing.T) {
+		payload := bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 512)
+		dp := d.deserializePayload(payload, "orders", proto.RecordValue, opts)
+		assert.Equal(t, messageEncodingBinary, dp.RecognizedEncoding)
+
+		var report *troubleshootingReport
+		for i := range dp.Troubleshooting {
+			if dp.Troubleshooting[i].SerdeName == largePayloadSerdeName {
+				report = &dp.Troubleshooting[i]
+			}
+		}
+		require.NotNil(t, report)
+		assert.Contains(t, report.Message, "xml")
+		assert.Contains(t, report.Message, "avro")
+		assert.NotContains(t, report.Message, "utf8")
+	})
+}
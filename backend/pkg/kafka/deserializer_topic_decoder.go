@@ -0,0 +1,112 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/redpanda-data/console/backend/pkg/config"
+)
+
+// topicDecoderBinary is the decoder name that skips decoding altogether.
+const topicDecoderBinary = "binary"
+
+// topicDecoder forces a single payload decoder for all topics matching one of the topic names.
+type topicDecoder struct {
+	topicNames []*regexp.Regexp
+	decoder    string
+}
+
+// newTopicDecoders compiles the configured topic decoders. The decoder names are checked by
+// validateTopicDecoders once all custom serdes have been registered.
+func newTopicDecoders(cfgs []config.KafkaTopicDecoder) ([]topicDecoder, error) {
+	topicDecoders := make([]topicDecoder, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		topicNames, err := config.CompileRegexes(cfg.TopicNames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile topic names for decoder %q: %w", cfg.Decoder, err)
+		}
+		topicDecoders = append(topicDecoders, topicDecoder{topicNames: topicNames, decoder: cfg.Decoder})
+	}
+	return topicDecoders, nil
+}
+
+// validateTopicDecoders ensures that each topic decoder refers to a known decoder.
+func (d *deserializer) validateTopicDecoders() error {
+	for _, td := range d.topicDecoders {
+		if !d.isKnownDecoder(td.decoder) {
+			topicNames := make([]string, len(td.topicNames))
+			for i, expr := range td.topicNames {
+				topicNames[i] = expr.String()
+			}
+			return fmt.Errorf("unknown decoder %q for topics %v", td.decoder, topicNames)
+		}
+	}
+	return nil
+}
+
+// validateForcedEncodings ensures that the key and value encodings of the options refer to
+// known decoders.
+func (d *deserializer) validateForcedEncodings(opts DeserializationOptions) error {
+	for _, encoding := range []string{opts.KeyEncoding, opts.ValueEncoding} {
+		if encoding != "" && !d.isKnownDecoder(encoding) {
+			return fmt.Errorf("unknown encoding %q", encoding)
+		}
+	}
+	return nil
+}
+
+// isKnownDecoder returns true if name is the name of a built-in decoder, a registered custom
+// serde or "binary".
+func (d *deserializer) isKnownDecoder(name string) bool {
+	if name == topicDecoderBinary {
+		return true
+	}
+	for _, decoder := range d.allPayloadDecoders() {
+		if decoder.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// forcedPayloadDecoders returns the decoder with the given name, or none at all if the
+// payload shall be returned as binary.
+func (d *deserializer) forcedPayloadDecoders(name string) []payloadDecoder {
+	for _, decoder := range d.allPayloadDecoders() {
+		if decoder.Name == name {
+			return []payloadDecoder{decoder}
+		}
+	}
+	return nil
+}
+
+// payloadDecodersForTopic returns the decoders to try for the given topic. Topics
+// matching a topic decoder skip auto-detection and only try the forced decoder,
+// or none at all if the payload shall be returned as binary. Forced decoders are
+// used even if the decoder chain disables them.
+func (d *deserializer) payloadDecodersForTopic(topicName string) []payloadDecoder {
+	for _, td := range d.topicDecoders {
+		if matchesAnyRegex(td.topicNames, topicName) {
+			return d.forcedPayloadDecoders(td.decoder)
+		}
+	}
+	return d.payloadDecoders()
+}
+
+func matchesAnyRegex(exprs []*regexp.Regexp, s string) bool {
+	for _, expr := range exprs {
+		if expr.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
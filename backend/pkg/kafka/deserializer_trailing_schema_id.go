@@ -0,0 +1,40 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"encoding/binary"
+)
+
+// decodeTrailingSchemaID decodes payloads that end with the schema ID as 4 byte big endian
+// integer, optionally followed by the configured magic byte. The body in front of the schema
+// ID is decoded as Avro using the registry schema.
+func (d *deserializer) decodeTrailingSchemaID(in payloadDecoderInput) *deserializedPayload {
+	payload := in.Payload
+	opts := in.Opts.TrailingSchemaID
+	if !opts.Enabled || d.SchemaService == nil {
+		return nil
+	}
+
+	end := len(payload)
+	if opts.MagicByte != nil {
+		if end == 0 || payload[end-1] != *opts.MagicByte {
+			return nil
+		}
+		end--
+	}
+	if end <= 4 {
+		return nil
+	}
+	schemaID := binary.BigEndian.Uint32(payload[end-4 : end])
+	body := payload[:end-4]
+
+	return d.decodeAvroWithSchemaID(payload, schemaID, body, in.Opts)
+}
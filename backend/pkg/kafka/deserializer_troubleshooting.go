@@ -0,0 +1,83 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/redpanda-data/console/backend/pkg/schema"
+)
+
+// noSchemaRegistryMessage is reported by all schema registry backed decoders if a payload
+// uses the schema registry wire format, but no schema registry has been configured.
+const noSchemaRegistryMessage = "no schema registry configured: payload starts with the schema registry magic byte, " +
+	"but the schema can't be looked up. Configure a schema registry to decode this payload"
+
+// schemaRegistryBackedSerdes are the names of all decoders that require a schema registry
+// in order to decode payloads in the schema registry wire format.
+var schemaRegistryBackedSerdes = []string{"jsonSchema", "avro", "protobuf"}
+
+// schemaTypeBySerde is the schema type that each schema registry backed decoder expects.
+var schemaTypeBySerde = map[string]schema.SchemaType{
+	"jsonSchema": schema.TypeJSON,
+	"avro":       schema.TypeAvro,
+	"protobuf":   schema.TypeProtobuf,
+}
+
+// schemaRegistryTroubleshooting returns a uniform troubleshooting report for each schema
+// registry backed decoder, if the payload is in the schema registry wire format (magic byte
+// followed by a 4 byte schema ID) but no schema service is configured. It's only attached to
+// payloads that fell back to binary or to text with control characters.
+func (d *deserializer) schemaRegistryTroubleshooting(payload []byte) []troubleshootingReport {
+	if d.SchemaService != nil || len(payload) <= 5 || payload[0] != byte(0) {
+		return nil
+	}
+
+	reports := make([]troubleshootingReport, len(schemaRegistryBackedSerdes))
+	for i, serdeName := range schemaRegistryBackedSerdes {
+		reports[i] = troubleshootingReport{SerdeName: serdeName, Message: noSchemaRegistryMessage}
+	}
+	return reports
+}
+
+// schemaTypeTroubleshooting returns a troubleshooting report for each schema registry backed
+// decoder whose type doesn't match the type of the schema that is referenced by the payload.
+// These decoders skip the payload, so that the decoder of the schema's type can decode it.
+func (d *deserializer) schemaTypeTroubleshooting(payload []byte) []troubleshootingReport {
+	if d.SchemaService == nil || len(payload) <= 5 || payload[0] != byte(0) {
+		return nil
+	}
+	schemaID := binary.BigEndian.Uint32(payload[1:5])
+	schemaType, err := d.SchemaService.GetSchemaTypeByID(context.Background(), schemaID)
+	if err != nil {
+		return nil
+	}
+
+	var reports []troubleshootingReport
+	for _, serdeName := range schemaRegistryBackedSerdes {
+		if expected := schemaTypeBySerde[serdeName]; expected != schemaType {
+			reports = append(reports, troubleshootingReport{
+				SerdeName: serdeName,
+				Message:   fmt.Sprintf("schema %d is %s, not %s: payload has been skipped by this decoder", schemaID, schemaType, expected),
+			})
+		}
+	}
+	return reports
+}
+
+// schemaTypeMismatches returns true if the schema with the given ID is known to be of
+// another type than expected. If the type can't be looked up, the decoder should try to
+// decode the payload anyway and report its own error.
+func (d *deserializer) schemaTypeMismatches(schemaID uint32, expected schema.SchemaType) bool {
+	schemaType, err := d.SchemaService.GetSchemaTypeByID(context.Background(), schemaID)
+	return err == nil && schemaType != expected
+}
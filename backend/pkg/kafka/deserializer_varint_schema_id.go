@@ -0,0 +1,53 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+)
+
+// decodeVarintSchemaID decodes payloads that start with the configured magic byte, followed
+// by the schema ID as protobuf-style (unsigned) varint and the body. The body is decoded as
+// Avro using the registry schema or, if it is JSON, as JSON schema encoded payload.
+func (d *deserializer) decodeVarintSchemaID(in payloadDecoderInput) *deserializedPayload {
+	payload := in.Payload
+	opts := in.Opts.VarintSchemaID
+	if !opts.Enabled || d.SchemaService == nil || len(payload) < 3 || payload[0] != opts.MagicByte {
+		return nil
+	}
+
+	id, n := binary.Uvarint(payload[1:])
+	if n <= 0 || id > math.MaxUint32 || 1+n >= len(payload) {
+		return nil
+	}
+	schemaID := uint32(id)
+	body := payload[1+n:]
+
+	if body[0] == '{' || body[0] == '[' {
+		var obj interface{}
+		if err := json.Unmarshal(body, &obj); err == nil {
+			return &deserializedPayload{
+				Payload: normalizedPayload{
+					Payload:            body,
+					RecognizedEncoding: messageEncodingJSON,
+				},
+				IsPayloadNull:      payload == nil,
+				Object:             obj,
+				RecognizedEncoding: messageEncodingJSON,
+				SchemaID:           schemaID,
+				Size:               len(payload),
+			}
+		}
+	}
+
+	return d.decodeAvroWithSchemaID(payload, schemaID, body, in.Opts)
+}
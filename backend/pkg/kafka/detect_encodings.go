@@ -0,0 +1,189 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// PayloadEncodingDetection describes the detected encodings for either the keys
+// or the values of a sampled set of records.
+type PayloadEncodingDetection struct {
+	// Encoding is the most frequently detected encoding in the sample.
+	Encoding string `json:"encoding"`
+	// Confidence is the share (0-1) of sampled records that were detected with Encoding.
+	Confidence float64 `json:"confidence"`
+	// Distribution counts the sampled records by their detected encoding. If a topic carries
+	// mixed encodings this reports all of them rather than just the most likely one.
+	Distribution map[string]int `json:"distribution"`
+}
+
+// TopicEncodingDetection is the result of sampling a topic's records in order to guess
+// the key and value encodings used for the whole topic.
+type TopicEncodingDetection struct {
+	TopicName      string                   `json:"topicName"`
+	SampledRecords int                      `json:"sampledRecords"`
+	Key            PayloadEncodingDetection `json:"key"`
+	Value          PayloadEncodingDetection `json:"value"`
+}
+
+// encodingDetectionKey is the cache key of a topic's detected encodings.
+type encodingDetectionKey struct {
+	topicName  string
+	sampleSize int
+}
+
+// DetectTopicEncodings consumes up to sampleSize records from the beginning of the given topic,
+// deserializes each of them and returns the most likely key and value encodings for the topic.
+// Results are cached per topic and sample size, so that the frontend can call this once to
+// preselect encodings before listing messages.
+func (s *Service) DetectTopicEncodings(ctx context.Context, topicName string, sampleSize int) (*TopicEncodingDetection, error) {
+	if sampleSize <= 0 {
+		return nil, fmt.Errorf("sample size must be greater than 0")
+	}
+
+	cacheKey := encodingDetectionKey{topicName: topicName, sampleSize: sampleSize}
+	detection, err, _ := s.encodingDetectionByTopic.Get(cacheKey, func() (*TopicEncodingDetection, error) {
+		records, err := s.sampleRecords(ctx, topicName, sampleSize)
+		if err != nil {
+			return nil, err
+		}
+
+		deserializedRecords := make([]*deserializedRecord, len(records))
+		for i, record := range records {
+			deserializedRecords[i] = s.Deserializer.DeserializeRecord(record, DeserializationOptions{})
+		}
+
+		detection := summarizeEncodings(deserializedRecords)
+		detection.TopicName = topicName
+		return detection, nil
+	})
+
+	return detection, err
+}
+
+// sampleRecords consumes up to sampleSize records starting at the low watermark of each
+// partition. It returns early if all partitions have been consumed up to their high watermark.
+func (s *Service) sampleRecords(ctx context.Context, topicName string, sampleSize int) ([]*kgo.Record, error) {
+	metadata, restErr := s.GetSingleMetadata(ctx, topicName)
+	if restErr != nil {
+		return nil, fmt.Errorf("failed to get partitions: %w", restErr.Err)
+	}
+
+	partitionIDs := make([]int32, 0, len(metadata.Partitions))
+	for _, partition := range metadata.Partitions {
+		if err := kerr.ErrorForCode(partition.ErrorCode); err != nil {
+			continue
+		}
+		partitionIDs = append(partitionIDs, partition.Partition)
+	}
+
+	marks, err := s.GetPartitionMarks(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+
+	offsets := make(map[int32]kgo.Offset)
+	endOffsets := make(map[int32]int64)
+	for _, mark := range marks {
+		if mark.Error != nil || mark.High <= mark.Low {
+			continue
+		}
+		offsets[mark.PartitionID] = kgo.NewOffset().At(mark.Low)
+		endOffsets[mark.PartitionID] = mark.High - 1
+	}
+	if len(offsets) == 0 {
+		return []*kgo.Record{}, nil
+	}
+
+	client, err := s.NewKgoClient(kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topicName: offsets}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new kafka client: %w", err)
+	}
+	defer client.Close()
+
+	records := make([]*kgo.Record, 0, sampleSize)
+	for len(records) < sampleSize && len(endOffsets) > 0 {
+		fetches := client.PollFetches(ctx)
+		if ctx.Err() != nil {
+			// Return the partial sample if the caller gave up waiting for the remaining records
+			if len(records) > 0 {
+				break
+			}
+			return nil, ctx.Err()
+		}
+		fetches.EachRecord(func(record *kgo.Record) {
+			endOffset, exists := endOffsets[record.Partition]
+			if !exists || len(records) >= sampleSize {
+				return
+			}
+			if record.Offset >= endOffset {
+				delete(endOffsets, record.Partition)
+			}
+			if !record.Attrs.IsControl() {
+				records = append(records, record)
+			}
+		})
+	}
+
+	return records, nil
+}
+
+// summarizeEncodings tallies the recognized key and value encodings of the given records.
+func summarizeEncodings(records []*deserializedRecord) *TopicEncodingDetection {
+	keyDistribution := make(map[string]int)
+	valueDistribution := make(map[string]int)
+	for _, record := range records {
+		if record.Key != nil {
+			keyDistribution[string(record.Key.RecognizedEncoding)]++
+		}
+		if record.Value != nil {
+			valueDistribution[string(record.Value.RecognizedEncoding)]++
+		}
+	}
+
+	return &TopicEncodingDetection{
+		SampledRecords: len(records),
+		Key:            newPayloadEncodingDetection(keyDistribution, len(records)),
+		Value:          newPayloadEncodingDetection(valueDistribution, len(records)),
+	}
+}
+
+func newPayloadEncodingDetection(distribution map[string]int, total int) PayloadEncodingDetection {
+	// Sort encodings so that ties are always resolved the same way
+	encodings := make([]string, 0, len(distribution))
+	for encoding := range distribution {
+		encodings = append(encodings, encoding)
+	}
+	sort.Strings(encodings)
+
+	mostLikely := ""
+	for _, encoding := range encodings {
+		if mostLikely == "" || distribution[encoding] > distribution[mostLikely] {
+			mostLikely = encoding
+		}
+	}
+
+	confidence := 0.0
+	if total > 0 {
+		confidence = float64(distribution[mostLikely]) / float64(total)
+	}
+
+	return PayloadEncodingDetection{
+		Encoding:     mostLikely,
+		Confidence:   confidence,
+		Distribution: distribution,
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestSummarizeEncodings(t *testing.T) {
+	d := deserializer{}
+	sample := []*kgo.Record{
+		{Topic: "orders", Key: []byte("order-1"), Value: []byte(`{"id":1}`)},
+		{Topic: "orders", Key: []byte("order-2"), Value: []byte(`{"id":2}`)},
+		{Topic: "orders", Key: []byte("order-3"), Value: []byte(`{"id":3}`)},
+		{Topic: "orders", Key: []byte("order-4"), Value: []byte("<order><id>4</id></order>")},
+	}
+
+	records := make([]*deserializedRecord, len(sample))
+	for i, record := range sample {
+		records[i] = d.DeserializeRecord(record, DeserializationOptions{})
+	}
+	detection := summarizeEncodings(records)
+
+	assert.Equal(t, 4, detection.SampledRecords)
+	assert.Equal(t, PayloadEncodingDetection{
+		Encoding:     "text",
+		Confidence:   1,
+		Distribution: map[string]int{"text": 4},
+	}, detection.Key)
+	assert.Equal(t, PayloadEncodingDetection{
+		Encoding:     "json",
+		Confidence:   0.75,
+		Distribution: map[string]int{"json": 3, "xml": 1},
+	}, detection.Value)
+}
+
+func TestSummarizeEncodings_Empty(t *testing.T) {
+	detection := summarizeEncodings(nil)
+	assert.Equal(t, 0, detection.SampledRecords)
+	assert.Equal(t, "", detection.Value.Encoding)
+	assert.Equal(t, 0.0, detection.Value.Confidence)
+}
@@ -0,0 +1,652 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/decimal128"
+	"github.com/apache/arrow/go/v13/arrow/ipc"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/hamba/avro/v2"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// WriteRecordsAsArrowIPC decodes the given records and writes them as a single record batch
+// in the Arrow IPC stream format to w, so that they can be loaded into tools such as pandas.
+//
+// Each row has the columns partition, offset, timestamp, key and value. The type of the value
+// column is derived from the registry schema if the values are Avro encoded, otherwise it is
+// inferred from the decoded values. Avro logical types are mapped to the corresponding Arrow
+// types, e.g. timestamp-millis to a millisecond timestamp. Tombstones are represented as null
+// values.
+func (s *Service) WriteRecordsAsArrowIPC(ctx context.Context, w io.Writer, records []*kgo.Record, opts DeserializationOptions) error {
+	// Avro values are exported with their underlying values, which are the physical
+	// representations of the Arrow types that logical and fixed types are mapped to
+	opts.AvroJSONEncoding = false
+	opts.AvroRawLogicalTypes = true
+	opts.AvroFixedAsHex = false
+	opts = opts.withParsedPatterns()
+
+	deserialized := make([]*deserializedRecord, len(records))
+	for i, record := range records {
+		deserialized[i] = s.Deserializer.DeserializeRecord(record, opts)
+	}
+
+	valueSchema, err := s.Deserializer.arrowValueSchema(ctx, deserialized)
+	if err != nil {
+		return err
+	}
+	return writeArrowIPC(w, records, deserialized, valueSchema)
+}
+
+// arrowValueColumn describes how decoded values are converted to the value column.
+type arrowValueColumn struct {
+	Type arrow.DataType
+	// AvroSchema is set if the value column has been derived from an Avro schema. It's
+	// used to normalize decoded union values.
+	AvroSchema avro.Schema
+}
+
+// arrowValueSchema returns the value column for the given records. If the values are Avro
+// encoded with a registry schema, all non-null values must use the same schema, as a record
+// batch has a single schema.
+func (d *deserializer) arrowValueSchema(ctx context.Context, records []*deserializedRecord) (arrowValueColumn, error) {
+	schemaID := uint32(0)
+	for _, record := range records {
+		if record.Value.RecognizedEncoding == messageEncodingAvro && record.Value.SchemaID != 0 && d.SchemaService != nil {
+			schemaID = record.Value.SchemaID
+			break
+		}
+	}
+	if schemaID != 0 {
+		for i, record := range records {
+			switch {
+			case record.Value.IsPayloadNull:
+			case record.Value.RecognizedEncoding != messageEncodingAvro:
+				return arrowValueColumn{}, fmt.Errorf("values use differing schemas: value of record %d is not avro encoded but others use schema %d",
+					i, schemaID)
+			case record.Value.SchemaID != schemaID:
+				return arrowValueColumn{}, fmt.Errorf("values use differing schemas: value of record %d uses schema %d but others use schema %d",
+					i, record.Value.SchemaID, schemaID)
+			}
+		}
+		sch, err := d.SchemaService.GetAvroSchemaByID(ctx, schemaID)
+		if err != nil {
+			return arrowValueColumn{}, fmt.Errorf("failed to get avro schema: %w", err)
+		}
+		return arrowValueColumn{Type: avroToArrowType(sch), AvroSchema: sch}, nil
+	}
+
+	values := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		if !record.Value.IsPayloadNull {
+			values = append(values, arrowJSONValue(record.Value))
+		}
+	}
+	return arrowValueColumn{Type: inferArrowType(values)}, nil
+}
+
+func writeArrowIPC(w io.Writer, records []*kgo.Record, deserialized []*deserializedRecord, valueColumn arrowValueColumn) error {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "partition", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "offset", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ms},
+		{Name: "key", Type: arrow.BinaryTypes.Binary, Nullable: true},
+		{Name: "value", Type: valueColumn.Type, Nullable: true},
+	}, nil)
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	for i, record := range records {
+		builder.Field(0).(*array.Int32Builder).Append(record.Partition)
+		builder.Field(1).(*array.Int64Builder).Append(record.Offset)
+		builder.Field(2).(*array.TimestampBuilder).Append(arrow.Timestamp(record.Timestamp.UnixMilli()))
+		if record.Key == nil {
+			builder.Field(3).AppendNull()
+		} else {
+			builder.Field(3).(*array.BinaryBuilder).Append(record.Key)
+		}
+
+		value := deserialized[i].Value
+		if value.IsPayloadNull {
+			builder.Field(4).AppendNull()
+			continue
+		}
+		if valueColumn.AvroSchema != nil && value.RecognizedEncoding == messageEncodingAvro {
+			appendArrowValue(builder.Field(4), normalizeAvroValue(valueColumn.AvroSchema, value.Object))
+			continue
+		}
+		appendArrowValue(builder.Field(4), arrowJSONValue(value))
+	}
+
+	rec := builder.NewRecord()
+	defer rec.Release()
+
+	writer := ipc.NewWriter(w, ipc.WithSchema(schema))
+	if err := writer.Write(rec); err != nil {
+		return fmt.Errorf("failed to write arrow record batch: %w", err)
+	}
+	return writer.Close()
+}
+
+// arrowJSONValue returns the decoded payload as generic JSON value. Payloads that could not
+// be decoded into JSON (e.g. text or binary) are returned as string.
+func arrowJSONValue(dp *deserializedPayload) interface{} {
+	if !isStructuredEncoding(dp.Payload.RecognizedEncoding) {
+		normalized, err := dp.Payload.MarshalJSON()
+		if err != nil {
+			return nil
+		}
+		var str string
+		if err := json.Unmarshal(normalized, &str); err != nil {
+			return string(normalized)
+		}
+		return str
+	}
+
+	// Numbers are kept as json.Number so that integers beyond the float64 range stay exact
+	dec := json.NewDecoder(bytes.NewReader(dp.Payload.Payload))
+	dec.UseNumber()
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return string(dp.Payload.Payload)
+	}
+	return obj
+}
+
+// avroToArrowType maps an Avro schema to the corresponding Arrow type. Unions of a single
+// type and null become nullable columns, other unions are represented as JSON strings.
+func avroToArrowType(sch avro.Schema) arrow.DataType {
+	if logical, ok := sch.(avro.LogicalTypeSchema); ok && logical.Logical() != nil {
+		if t := avroLogicalToArrowType(logical.Logical()); t != nil {
+			return t
+		}
+	}
+
+	switch s := sch.(type) {
+	case *avro.RecordSchema:
+		fields := make([]arrow.Field, len(s.Fields()))
+		for i, field := range s.Fields() {
+			fields[i] = arrow.Field{Name: field.Name(), Type: avroToArrowType(field.Type()), Nullable: true}
+		}
+		return arrow.StructOf(fields...)
+	case *avro.RefSchema:
+		return avroToArrowType(s.Schema())
+	case *avro.ArraySchema:
+		return arrow.ListOf(avroToArrowType(s.Items()))
+	case *avro.MapSchema:
+		return arrow.MapOf(arrow.BinaryTypes.String, avroToArrowType(s.Values()))
+	case *avro.UnionSchema:
+		if nonNull := avroNullableUnionType(s); nonNull != nil {
+			return avroToArrowType(nonNull)
+		}
+		return arrow.BinaryTypes.String
+	case *avro.FixedSchema:
+		return arrow.BinaryTypes.Binary
+	}
+
+	switch sch.Type() {
+	case avro.Boolean:
+		return arrow.FixedWidthTypes.Boolean
+	case avro.Int:
+		return arrow.PrimitiveTypes.Int32
+	case avro.Long:
+		return arrow.PrimitiveTypes.Int64
+	case avro.Float:
+		return arrow.PrimitiveTypes.Float32
+	case avro.Double:
+		return arrow.PrimitiveTypes.Float64
+	case avro.Bytes:
+		return arrow.BinaryTypes.Binary
+	case avro.Null:
+		return arrow.Null
+	default:
+		// Strings, enums and everything else
+		return arrow.BinaryTypes.String
+	}
+}
+
+// avroLogicalToArrowType maps an Avro logical type to the corresponding Arrow type, or
+// returns nil if the underlying type should be used.
+func avroLogicalToArrowType(logical avro.LogicalSchema) arrow.DataType {
+	switch logical.Type() {
+	case avro.TimestampMillis:
+		return arrow.FixedWidthTypes.Timestamp_ms
+	case avro.TimestampMicros:
+		return arrow.FixedWidthTypes.Timestamp_us
+	case avro.Date:
+		return arrow.FixedWidthTypes.Date32
+	case avro.TimeMillis:
+		return arrow.FixedWidthTypes.Time32ms
+	case avro.TimeMicros:
+		return arrow.FixedWidthTypes.Time64us
+	case avro.Decimal:
+		decimal, ok := logical.(*avro.DecimalLogicalSchema)
+		if !ok || decimal.Precision() > 38 {
+			// Decimal128 holds at most 38 digits, larger decimals are written as strings
+			return arrow.BinaryTypes.String
+		}
+		return &arrow.Decimal128Type{Precision: int32(decimal.Precision()), Scale: int32(decimal.Scale())}
+	default:
+		return nil
+	}
+}
+
+// avroNullableUnionType returns the non-null type of a union of null and a single other type.
+func avroNullableUnionType(s *avro.UnionSchema) avro.Schema {
+	types := s.Types()
+	if len(types) != 2 || !s.Nullable() {
+		return nil
+	}
+	if types[0].Type() == avro.Null {
+		return types[1]
+	}
+	return types[0]
+}
+
+// normalizeAvroValue unwraps decoded union values, which are represented as single-key maps.
+func normalizeAvroValue(sch avro.Schema, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch s := sch.(type) {
+	case *avro.RecordSchema:
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		normalized := make(map[string]interface{}, len(record))
+		for _, field := range s.Fields() {
+			normalized[field.Name()] = normalizeAvroValue(field.Type(), record[field.Name()])
+		}
+		return normalized
+	case *avro.RefSchema:
+		return normalizeAvroValue(s.Schema(), value)
+	case *avro.ArraySchema:
+		items, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		normalized := make([]interface{}, len(items))
+		for i, item := range items {
+			normalized[i] = normalizeAvroValue(s.Items(), item)
+		}
+		return normalized
+	case *avro.MapSchema:
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		normalized := make(map[string]interface{}, len(values))
+		for key, v := range values {
+			normalized[key] = normalizeAvroValue(s.Values(), v)
+		}
+		return normalized
+	case *avro.UnionSchema:
+		nonNull := avroNullableUnionType(s)
+		if wrapped, ok := value.(map[string]interface{}); ok && len(wrapped) == 1 {
+			for _, inner := range wrapped {
+				value = inner
+			}
+		}
+		if nonNull == nil {
+			// Arbitrary unions are written as JSON strings
+			return value
+		}
+		return normalizeAvroValue(nonNull, value)
+	default:
+		return value
+	}
+}
+
+// inferArrowType infers an Arrow type that can represent all given JSON values. Objects become
+// structs with all observed keys. Values of mixed types are represented as JSON strings.
+func inferArrowType(values []interface{}) arrow.DataType {
+	var (
+		hasBool, hasNumber, hasFraction, hasString, hasObject, hasArray bool
+		objects                                                         []map[string]interface{}
+		items                                                           []interface{}
+	)
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case bool:
+			hasBool = true
+		case float64:
+			hasNumber = true
+			if v != math.Trunc(v) {
+				hasFraction = true
+			}
+		case json.Number:
+			hasNumber = true
+			if _, err := v.Int64(); err != nil {
+				hasFraction = true
+			}
+		case string:
+			hasString = true
+		case map[string]interface{}:
+			hasObject = true
+			objects = append(objects, v)
+		case []interface{}:
+			hasArray = true
+			items = append(items, v...)
+		default:
+			hasString = true
+		}
+	}
+
+	kinds := 0
+	for _, has := range []bool{hasBool, hasNumber, hasString, hasObject, hasArray} {
+		if has {
+			kinds++
+		}
+	}
+	switch {
+	case kinds != 1:
+		return arrow.BinaryTypes.String
+	case hasBool:
+		return arrow.FixedWidthTypes.Boolean
+	case hasNumber && hasFraction:
+		return arrow.PrimitiveTypes.Float64
+	case hasNumber:
+		return arrow.PrimitiveTypes.Int64
+	case hasArray:
+		return arrow.ListOf(inferArrowType(items))
+	case hasObject:
+		keySet := make(map[string]struct{})
+		for _, obj := range objects {
+			for key := range obj {
+				keySet[key] = struct{}{}
+			}
+		}
+		keys := make([]string, 0, len(keySet))
+		for key := range keySet {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fields := make([]arrow.Field, len(keys))
+		for i, key := range keys {
+			fieldValues := make([]interface{}, 0, len(objects))
+			for _, obj := range objects {
+				fieldValues = append(fieldValues, obj[key])
+			}
+			fields[i] = arrow.Field{Name: key, Type: inferArrowType(fieldValues), Nullable: true}
+		}
+		return arrow.StructOf(fields...)
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+// appendArrowValue appends a decoded value to the given builder, converting it to the
+// builder's type where necessary.
+//
+//nolint:gocyclo,cyclop // A single switch over all supported builder types is easiest to follow
+func appendArrowValue(b array.Builder, value interface{}) {
+	if value == nil {
+		b.AppendNull()
+		return
+	}
+
+	switch builder := b.(type) {
+	case *array.StructBuilder:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(true)
+		structType := builder.Type().(*arrow.StructType)
+		for i, field := range structType.Fields() {
+			appendArrowValue(builder.FieldBuilder(i), obj[field.Name])
+		}
+	case *array.ListBuilder:
+		items, ok := value.([]interface{})
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(true)
+		for _, item := range items {
+			appendArrowValue(builder.ValueBuilder(), item)
+		}
+	case *array.MapBuilder:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		builder.Append(true)
+		for _, key := range keys {
+			builder.KeyBuilder().(*array.StringBuilder).Append(key)
+			appendArrowValue(builder.ItemBuilder(), obj[key])
+		}
+	case *array.StringBuilder:
+		switch v := value.(type) {
+		case string:
+			builder.Append(v)
+		default:
+			jsonBytes, err := json.Marshal(v)
+			if err != nil {
+				builder.AppendNull()
+				return
+			}
+			builder.Append(string(jsonBytes))
+		}
+	case *array.BinaryBuilder:
+		switch v := value.(type) {
+		case []byte:
+			builder.Append(v)
+		case string:
+			builder.Append([]byte(v))
+		default:
+			builder.AppendNull()
+		}
+	case *array.BooleanBuilder:
+		v, ok := value.(bool)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(v)
+	case *array.Int32Builder:
+		v, ok := arrowInt(value)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(int32(v))
+	case *array.Int64Builder:
+		v, ok := arrowInt(value)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(v)
+	case *array.Float32Builder:
+		v, ok := arrowNumber(value)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(float32(v))
+	case *array.Float64Builder:
+		v, ok := arrowNumber(value)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(v)
+	case *array.TimestampBuilder:
+		unit := builder.Type().(*arrow.TimestampType).Unit
+		if t, ok := value.(time.Time); ok {
+			ts, err := arrow.TimestampFromTime(t, unit)
+			if err != nil {
+				builder.AppendNull()
+				return
+			}
+			builder.Append(ts)
+			return
+		}
+		v, ok := arrowInt(value)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(arrow.Timestamp(v))
+	case *array.Date32Builder:
+		if t, ok := value.(time.Time); ok {
+			builder.Append(arrow.Date32FromTime(t))
+			return
+		}
+		v, ok := arrowInt(value)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(arrow.Date32(v))
+	case *array.Time32Builder:
+		unit := builder.Type().(*arrow.Time32Type).Unit
+		if d, ok := value.(time.Duration); ok {
+			builder.Append(arrow.Time32(d / unit.Multiplier()))
+			return
+		}
+		v, ok := arrowInt(value)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(arrow.Time32(v))
+	case *array.Time64Builder:
+		unit := builder.Type().(*arrow.Time64Type).Unit
+		if d, ok := value.(time.Duration); ok {
+			builder.Append(arrow.Time64(d / unit.Multiplier()))
+			return
+		}
+		v, ok := arrowInt(value)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(arrow.Time64(v))
+	case *array.Decimal128Builder:
+		v, ok := arrowDecimal(value, builder.Type().(*arrow.Decimal128Type).Scale)
+		if !ok {
+			builder.AppendNull()
+			return
+		}
+		builder.Append(v)
+	default:
+		b.AppendNull()
+	}
+}
+
+// arrowInt converts the integer types returned by the Avro and JSON decoders to int64
+// without going through float64, which can't represent all int64 values.
+func arrowInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// arrowDecimal converts a decimal to its unscaled Decimal128 value. Decimals are decoded from
+// Avro as *big.Rat or as unscaled big endian two's complement bytes if logical types are
+// raw, and from JSON as numbers or decimal strings.
+func arrowDecimal(value interface{}, scale int32) (decimal128.Num, bool) {
+	var rat *big.Rat
+	switch v := value.(type) {
+	case *big.Rat:
+		rat = v
+	case []byte:
+		unscaled := new(big.Int).SetBytes(v)
+		if len(v) > 0 && v[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(v))))
+		}
+		if unscaled.BitLen() > 127 {
+			return decimal128.Num{}, false
+		}
+		return decimal128.FromBigInt(unscaled), true
+	case string:
+		r, ok := new(big.Rat).SetString(v)
+		if !ok {
+			return decimal128.Num{}, false
+		}
+		rat = r
+	case json.Number:
+		r, ok := new(big.Rat).SetString(v.String())
+		if !ok {
+			return decimal128.Num{}, false
+		}
+		rat = r
+	default:
+		return decimal128.Num{}, false
+	}
+
+	unscaled := new(big.Int).Mul(rat.Num(), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+	unscaled.Quo(unscaled, rat.Denom())
+	if unscaled.BitLen() > 127 {
+		return decimal128.Num{}, false
+	}
+	return decimal128.FromBigInt(unscaled), true
+}
+
+// arrowNumber converts the numeric types returned by the Avro and JSON decoders to float64.
+func arrowNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/decimal128"
+	"github.com/apache/arrow/go/v13/arrow/ipc"
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func readArrowIPC(t *testing.T, buf *bytes.Buffer) arrow.Record {
+	t.Helper()
+
+	reader, err := ipc.NewReader(buf)
+	require.NoError(t, err)
+	t.Cleanup(reader.Release)
+
+	require.True(t, reader.Next())
+	rec := reader.Record()
+	rec.Retain()
+	t.Cleanup(rec.Release)
+	require.False(t, reader.Next())
+	return rec
+}
+
+func TestService_WriteRecordsAsArrowIPC_Avro(t *testing.T) {
+	const orderSchema = `{"type": "record", "name": "order", "fields": [
+		{"name": "id", "type": "string"},
+		{"name": "quantity", "type": "int"},
+		{"name": "note", "type": ["null", "string"], "default": null},
+		{"name": "tags", "type": {"type": "array", "items": "string"}}
+	]}`
+	s := &Service{Deserializer: deserializer{SchemaService: newTestSchemaService(t, map[int]string{1: orderSchema})}}
+
+	encode := func(value map[string]interface{}) []byte {
+		body, err := avro.Marshal(avro.MustParse(orderSchema), value)
+		require.NoError(t, err)
+		header := []byte{0x0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(header[1:], 1)
+		return append(header, body...)
+	}
+	ts := time.UnixMilli(1700000000000)
+	records := []*kgo.Record{
+		{Partition: 1, Offset: 10, Timestamp: ts, Key: []byte("a"), Value: encode(map[string]interface{}{"id": "a", "quantity": 3, "note": "fragile", "tags": []string{"x"}})},
+		{Partition: 1, Offset: 11, Timestamp: ts, Value: encode(map[string]interface{}{"id": "b", "quantity": 1, "note": nil, "tags": []string{}})},
+		{Partition: 2, Offset: 12, Timestamp: ts, Key: []byte("c")},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteRecordsAsArrowIPC(context.Background(), &buf, records, DeserializationOptions{}))
+	rec := readArrowIPC(t, &buf)
+
+	require.EqualValues(t, 3, rec.NumRows())
+	assert.Equal(t, []int32{1, 1, 2}, rec.Column(0).(*array.Int32).Int32Values())
+	assert.Equal(t, []int64{10, 11, 12}, rec.Column(1).(*array.Int64).Int64Values())
+	assert.Equal(t, arrow.Timestamp(1700000000000), rec.Column(2).(*array.Timestamp).Value(0))
+	assert.True(t, rec.Column(3).IsNull(1))
+
+	value := rec.Column(4).(*array.Struct)
+	structType := value.DataType().(*arrow.StructType)
+	assert.Equal(t, arrow.BinaryTypes.String, structType.Field(0).Type)
+	assert.Equal(t, arrow.PrimitiveTypes.Int32, structType.Field(1).Type)
+	assert.Equal(t, arrow.BinaryTypes.String, structType.Field(2).Type)
+	assert.True(t, value.IsNull(2))
+
+	assert.Equal(t, "b", value.Field(0).(*array.String).Value(1))
+	assert.Equal(t, int32(3), value.Field(1).(*array.Int32).Value(0))
+	assert.Equal(t, "fragile", value.Field(2).(*array.String).Value(0))
+	assert.True(t, value.Field(2).IsNull(1))
+	tags := value.Field(3).(*array.List)
+	assert.Equal(t, "x", tags.ListValues().(*array.String).Value(0))
+}
+
+func TestService_WriteRecordsAsArrowIPC_AvroLogicalTypes(t *testing.T) {
+	const eventSchema = `{"type": "record", "name": "event", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "createdAt", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "updatedAt", "type": ["null", {"type": "long", "logicalType": "timestamp-micros"}]},
+		{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "startsAt", "type": {"type": "int", "logicalType": "time-millis"}},
+		{"name": "endsAt", "type": {"type": "long", "logicalType": "time-micros"}},
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+		{"name": "total", "type": {"type": "fixed", "name": "total", "size": 8, "logicalType": "decimal", "precision": 12, "scale": 2}}
+	]}`
+	s := &Service{Deserializer: deserializer{SchemaService: newTestSchemaService(t, map[int]string{1: eventSchema})}}
+
+	createdAt := time.UnixMilli(1700000000123).UTC()
+	updatedAt := time.UnixMicro(1700000000123456).UTC()
+	day := time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)
+	body, err := avro.Marshal(avro.MustParse(eventSchema), map[string]interface{}{
+		"id":        int64(9007199254740993),
+		"createdAt": createdAt,
+		"updatedAt": updatedAt,
+		"day":       day,
+		"startsAt":  90*time.Minute + 250*time.Millisecond,
+		"endsAt":    2*time.Hour + 15*time.Microsecond,
+		"price":     big.NewRat(-1234, 100),
+		"total":     big.NewRat(567890, 100),
+	})
+	require.NoError(t, err)
+	records := []*kgo.Record{{Value: append([]byte{0x0, 0, 0, 0, 1}, body...)}}
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteRecordsAsArrowIPC(context.Background(), &buf, records, DeserializationOptions{}))
+	rec := readArrowIPC(t, &buf)
+
+	value := rec.Column(4).(*array.Struct)
+	structType := value.DataType().(*arrow.StructType)
+	assert.Equal(t, arrow.PrimitiveTypes.Int64, structType.Field(0).Type)
+	assert.Equal(t, arrow.FixedWidthTypes.Timestamp_ms, structType.Field(1).Type)
+	assert.Equal(t, arrow.FixedWidthTypes.Timestamp_us, structType.Field(2).Type)
+	assert.Equal(t, arrow.FixedWidthTypes.Date32, structType.Field(3).Type)
+	assert.Equal(t, arrow.FixedWidthTypes.Time32ms, structType.Field(4).Type)
+	assert.Equal(t, arrow.FixedWidthTypes.Time64us, structType.Field(5).Type)
+	assert.Equal(t, &arrow.Decimal128Type{Precision: 10, Scale: 2}, structType.Field(6).Type)
+	assert.Equal(t, &arrow.Decimal128Type{Precision: 12, Scale: 2}, structType.Field(7).Type)
+
+	assert.Equal(t, int64(9007199254740993), value.Field(0).(*array.Int64).Value(0))
+	assert.Equal(t, arrow.Timestamp(createdAt.UnixMilli()), value.Field(1).(*array.Timestamp).Value(0))
+	assert.Equal(t, arrow.Timestamp(updatedAt.UnixMicro()), value.Field(2).(*array.Timestamp).Value(0))
+	assert.Equal(t, arrow.Date32FromTime(day), value.Field(3).(*array.Date32).Value(0))
+	assert.Equal(t, arrow.Time32(5400250), value.Field(4).(*array.Time32).Value(0))
+	assert.Equal(t, arrow.Time64(7200000015), value.Field(5).(*array.Time64).Value(0))
+	assert.Equal(t, decimal128.FromI64(-1234), value.Field(6).(*array.Decimal128).Value(0))
+	assert.Equal(t, decimal128.FromI64(567890), value.Field(7).(*array.Decimal128).Value(0))
+}
+
+func TestService_WriteRecordsAsArrowIPC_DifferingSchemas(t *testing.T) {
+	s := &Service{Deserializer: deserializer{SchemaService: newTestSchemaService(t, map[int]string{
+		1: `{"type": "record", "name": "a", "fields": [{"name": "id", "type": "string"}]}`,
+		2: `{"type": "record", "name": "b", "fields": [{"name": "count", "type": "int"}]}`,
+	})}}
+	records := []*kgo.Record{
+		{Value: []byte{0x0, 0, 0, 0, 1, 0x2, 'a'}},
+		{Value: []byte{0x0, 0, 0, 0, 2, 0x2}},
+	}
+
+	var buf bytes.Buffer
+	err := s.WriteRecordsAsArrowIPC(context.Background(), &buf, records, DeserializationOptions{})
+	assert.ErrorContains(t, err, "differing schemas")
+}
+
+func TestService_WriteRecordsAsArrowIPC_InferredJSON(t *testing.T) {
+	s := &Service{}
+	records := []*kgo.Record{
+		{Offset: 0, Value: []byte(`{"id": "a", "quantity": 3, "price": 1.5, "address": {"city": "Berlin"}}`)},
+		{Offset: 1, Value: []byte(`{"id": "b", "quantity": 1, "price": 2, "extra": true}`)},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteRecordsAsArrowIPC(context.Background(), &buf, records, DeserializationOptions{}))
+	rec := readArrowIPC(t, &buf)
+
+	value := rec.Column(4).(*array.Struct)
+	structType := value.DataType().(*arrow.StructType)
+	fieldTypes := make(map[string]arrow.DataType)
+	for _, field := range structType.Fields() {
+		fieldTypes[field.Name] = field.Type
+	}
+	assert.Equal(t, arrow.BinaryTypes.String, fieldTypes["id"])
+	assert.Equal(t, arrow.PrimitiveTypes.Int64, fieldTypes["quantity"])
+	assert.Equal(t, arrow.PrimitiveTypes.Float64, fieldTypes["price"])
+	assert.Equal(t, arrow.FixedWidthTypes.Boolean, fieldTypes["extra"])
+	assert.IsType(t, &arrow.StructType{}, fieldTypes["address"])
+
+	idx, _ := structType.FieldIdx("extra")
+	assert.True(t, value.Field(idx).IsNull(0))
+	assert.True(t, value.Field(idx).(*array.Boolean).Value(1))
+}
+
+func TestService_WriteRecordsAsArrowIPC_LargeJSONIntegers(t *testing.T) {
+	s := &Service{}
+	records := []*kgo.Record{{Value: []byte(`{"id": 9007199254740993}`)}}
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteRecordsAsArrowIPC(context.Background(), &buf, records, DeserializationOptions{}))
+	rec := readArrowIPC(t, &buf)
+
+	value := rec.Column(4).(*array.Struct)
+	assert.Equal(t, int64(9007199254740993), value.Field(0).(*array.Int64).Value(0))
+}
+
+func TestService_WriteRecordsAsArrowIPC_Text(t *testing.T) {
+	s := &Service{}
+	records := []*kgo.Record{{Value: []byte("hello world")}}
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteRecordsAsArrowIPC(context.Background(), &buf, records, DeserializationOptions{}))
+	rec := readArrowIPC(t, &buf)
+
+	assert.Equal(t, "hello world", rec.Column(4).(*array.String).Value(0))
+}
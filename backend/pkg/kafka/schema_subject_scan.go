@@ -0,0 +1,65 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/redpanda-data/console/backend/pkg/promutil"
+	"github.com/redpanda-data/console/backend/pkg/schema"
+)
+
+// newBrokenSubjectsGauge creates the gauge that reports the number of schema subjects that
+// failed to load during the last subject scan, and registers it with the given registerer.
+// A gauge that has already been registered by another service instance is reused.
+func newBrokenSubjectsGauge(reg prometheus.Registerer, metricsNamespace string) (prometheus.Gauge, error) {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "schema_registry",
+		Name:      "broken_subjects",
+		Help:      "Number of schema subjects whose latest schema failed to load during the last subject scan",
+	})
+	gauge, err := promutil.RegisterOrReuse(reg, gauge)
+	if err != nil {
+		return nil, err
+	}
+	return gauge, nil
+}
+
+// scanSchemaSubjects loads all schema subjects and reports the broken ones via logs and
+// metrics. Broken subjects do not fail the startup. The scan is bounded by the configured
+// scan timeout.
+func (s *Service) scanSchemaSubjects(ctx context.Context, brokenSubjects prometheus.Gauge) *schema.SubjectScanResult {
+	ctx, cancel := context.WithTimeout(ctx, s.Config.Kafka.Schema.ScanSubjectsTimeout)
+	defer cancel()
+
+	result, err := s.SchemaService.ScanSubjects(ctx)
+	if err != nil {
+		s.Logger.Warn("failed to scan schema subjects", zap.Error(err))
+		return nil
+	}
+
+	broken := result.BrokenSubjects()
+	for _, status := range broken {
+		s.Logger.Warn("failed to load schema subject",
+			zap.String("subject", status.Subject),
+			zap.String("error", status.Error))
+	}
+	if brokenSubjects != nil {
+		brokenSubjects.Set(float64(len(broken)))
+	}
+	s.Logger.Info("scanned schema subjects",
+		zap.Int("subjects", len(result.Subjects)),
+		zap.Int("broken_subjects", len(broken)))
+	return result
+}
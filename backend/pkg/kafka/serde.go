@@ -0,0 +1,166 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/redpanda-data/console/backend/pkg/config"
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+// Serde is a custom decoder that can be added to the chain of decoders that Console tries
+// for each record key, value and header. Implementations must follow these rules:
+//
+//   - Detection is idempotent: Deserialize returns the same result for the same input and
+//     must not keep state across calls. It may be called concurrently.
+//   - Deserialize returns an error if the payload is not in the serde's format, so that the
+//     next decoder is tried. It must not panic; panics are recovered and treated as errors.
+//   - Deserialize honors the context, which is cancelled once the decode budget of the
+//     record has been exceeded.
+type Serde interface {
+	// Name identifies the serde in the decoder chain, metrics and troubleshooting reports. It
+	// is reported as the encoding of decoded payloads and must therefore be unique.
+	Name() string
+
+	// Deserialize decodes the payload of the given topic. isKey is set for record keys.
+	Deserialize(ctx context.Context, payload []byte, topicName string, isKey bool) (*SerdeResult, error)
+}
+
+// SerdeResult is the result of a custom serde.
+type SerdeResult struct {
+	// Object is the decoded payload. It must be serializable to JSON.
+	Object interface{}
+	// SchemaID is the ID of the schema that has been used to decode the payload, if any.
+	SchemaID uint32
+}
+
+// SerdeRegistration registers a custom serde at a position in the decoder chain.
+type SerdeRegistration struct {
+	Serde Serde
+	// Before is the name of the decoder (e.g. "utf8") before which the serde is tried. The
+	// serde is tried after all built-in decoders if it's empty.
+	Before string
+}
+
+// NewServiceWithSerdes creates a new Kafka service like NewService and registers the given
+// custom serdes in order.
+func NewServiceWithSerdes(cfg *config.Config, logger *zap.Logger, metricsNamespace string, serdes []SerdeRegistration) (*Service, error) {
+	svc, err := newService(cfg, logger, metricsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, registration := range serdes {
+		if err := svc.RegisterSerde(registration.Serde, registration.Before); err != nil {
+			return nil, err
+		}
+	}
+	// Topic decoders may refer to the registered serdes
+	if err := svc.Deserializer.validateTopicDecoders(); err != nil {
+		return nil, fmt.Errorf("failed to create topic decoders: %w", err)
+	}
+	return svc, nil
+}
+
+// RegisterSerde adds a custom serde to the decoder chain before the decoder with the given
+// name, or after all decoders if it's empty. It must be called before records are consumed,
+// as it's not safe for concurrent use.
+func (s *Service) RegisterSerde(serde Serde, before string) error {
+	return s.Deserializer.registerSerde(SerdeRegistration{Serde: serde, Before: before})
+}
+
+func (d *deserializer) registerSerde(registration SerdeRegistration) error {
+	if registration.Serde == nil || registration.Serde.Name() == "" {
+		return fmt.Errorf("serde must have a name")
+	}
+	name := registration.Serde.Name()
+	beforeExists := registration.Before == ""
+	for _, decoder := range d.allPayloadDecoders() {
+		if decoder.Name == name {
+			return fmt.Errorf("a decoder with name %q is already registered", name)
+		}
+		if decoder.Name == registration.Before {
+			beforeExists = true
+		}
+	}
+	if !beforeExists {
+		return fmt.Errorf("cannot register serde %q before unknown decoder %q", name, registration.Before)
+	}
+	d.customSerdes = append(d.customSerdes, registration)
+	return nil
+}
+
+// withCustomSerdes inserts the registered custom serdes into the chain of decoders.
+func (d *deserializer) withCustomSerdes(decoders []payloadDecoder) []payloadDecoder {
+	for _, registration := range d.customSerdes {
+		decoder := payloadDecoder{Name: registration.Serde.Name(), Decode: customSerdeDecoder(registration.Serde)}
+		idx := len(decoders)
+		for i, existing := range decoders {
+			if existing.Name == registration.Before {
+				idx = i
+				break
+			}
+		}
+		decoders = append(decoders[:idx], append([]payloadDecoder{decoder}, decoders[idx:]...)...)
+	}
+	return decoders
+}
+
+// customSerdeDecoder adapts a custom serde to a payload decoder.
+func customSerdeDecoder(serde Serde) func(in payloadDecoderInput) *deserializedPayload {
+	return func(in payloadDecoderInput) *deserializedPayload {
+		ctx := context.Background()
+		if !in.Opts.deadline.IsZero() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, in.Opts.deadline)
+			defer cancel()
+		}
+
+		res, err := deserializeWithSerde(ctx, serde, in)
+		if err != nil || res == nil {
+			return nil
+		}
+
+		jsonBytes, err := json.Marshal(res.Object)
+		if err != nil {
+			return nil
+		}
+		var native interface{}
+		if err := json.Unmarshal(jsonBytes, &native); err != nil {
+			return nil
+		}
+		encoding := messageEncoding(serde.Name())
+		return &deserializedPayload{
+			Payload: normalizedPayload{
+				Payload:            jsonBytes,
+				RecognizedEncoding: encoding,
+			},
+			IsPayloadNull:      in.Payload == nil,
+			Object:             native,
+			RecognizedEncoding: encoding,
+			SchemaID:           res.SchemaID,
+			Size:               len(in.Payload),
+		}
+	}
+}
+
+// deserializeWithSerde calls the custom serde and recovers from panics.
+func deserializeWithSerde(ctx context.Context, serde Serde, in payloadDecoderInput) (res *SerdeResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("serde %q panicked: %v", serde.Name(), r)
+		}
+	}()
+	return serde.Deserialize(ctx, in.Payload, in.TopicName, in.RecordType == proto.RecordKey)
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// csvSerde decodes payloads such as "csv:a,b,c" into a list of values.
+type csvSerde struct{}
+
+func (csvSerde) Name() string { return "csv" }
+
+func (csvSerde) Deserialize(_ context.Context, payload []byte, _ string, isKey bool) (*SerdeResult, error) {
+	if !bytes.HasPrefix(payload, []byte("csv:")) {
+		return nil, fmt.Errorf("payload is not csv")
+	}
+	return &SerdeResult{Object: map[string]interface{}{
+		"isKey":  isKey,
+		"values": strings.Split(string(payload[4:]), ","),
+	}}, nil
+}
+
+// namedSerde is a csvSerde with another name.
+type namedSerde struct {
+	csvSerde
+	name string
+}
+
+func (s namedSerde) Name() string { return s.name }
+
+// panicSerde panics for every payload.
+type panicSerde struct{}
+
+func (panicSerde) Name() string { return "panic" }
+
+func (panicSerde) Deserialize(context.Context, []byte, string, bool) (*SerdeResult, error) {
+	panic("boom")
+}
+
+func TestService_RegisterSerde(t *testing.T) {
+	s := &Service{}
+	require.NoError(t, s.RegisterSerde(panicSerde{}, "json"))
+	require.NoError(t, s.RegisterSerde(csvSerde{}, "utf8"))
+
+	names := make([]string, 0)
+	for _, decoder := range s.Deserializer.payloadDecoders() {
+		names = append(names, decoder.Name)
+	}
+	assert.Equal(t, []string{"compositeKey", "schemaless", "panic", "json"}, names[:4])
+	assert.Equal(t, []string{"csv", "utf8", "uint"}, names[len(names)-3:])
+
+	rec := s.Deserializer.DeserializeRecord(&kgo.Record{
+		Topic: "orders",
+		Key:   []byte("csv:a"),
+		Value: []byte("csv:a,b,c"),
+	}, DeserializationOptions{})
+	assert.Equal(t, messageEncoding("csv"), rec.Key.RecognizedEncoding)
+	assert.Equal(t, map[string]interface{}{"isKey": true, "values": []interface{}{"a"}}, rec.Key.Object)
+	assert.Equal(t, messageEncoding("csv"), rec.Value.RecognizedEncoding)
+	assert.JSONEq(t, `{"isKey":false,"values":["a","b","c"]}`, string(rec.Value.Payload.Payload))
+
+	// Other payloads are still decoded by the built-in decoders
+	rec = s.Deserializer.DeserializeRecord(&kgo.Record{Topic: "orders", Value: []byte(`{"id":1}`)}, DeserializationOptions{})
+	assert.Equal(t, messageEncodingJSON, rec.Value.RecognizedEncoding)
+
+	// Registered serdes can be forced by name
+	require.NoError(t, s.Deserializer.validateForcedEncodings(DeserializationOptions{ValueEncoding: "csv"}))
+	rec = s.Deserializer.DeserializeRecord(&kgo.Record{Topic: "orders", Value: []byte(`{"id":1}`)}, DeserializationOptions{ValueEncoding: "csv"})
+	assert.Equal(t, messageEncodingBinary, rec.Value.RecognizedEncoding)
+	s.Deserializer.topicDecoders = []topicDecoder{{topicNames: []*regexp.Regexp{regexp.MustCompile("^orders$")}, decoder: "csv"}}
+	assert.NoError(t, s.Deserializer.validateTopicDecoders())
+
+	assert.Error(t, s.RegisterSerde(csvSerde{}, ""), "duplicate name")
+	assert.Error(t, s.RegisterSerde(namedSerde{name: "json"}, ""), "duplicate name of built-in decoder")
+	assert.Error(t, (&Service{}).RegisterSerde(csvSerde{}, "yaml"), "unknown position")
+}
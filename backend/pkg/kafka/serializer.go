@@ -0,0 +1,200 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Encodings that record keys and values can be serialized with.
+const (
+	serializeEncodingNone   = "none"
+	serializeEncodingAvro   = "avro"
+	serializeEncodingCBOR   = "cbor"
+	serializeEncodingJSON   = "json"
+	serializeEncodingText   = "text"
+	serializeEncodingBinary = "binary"
+)
+
+// RecordPayloadInput is the key or value of a record that shall be serialized.
+type RecordPayloadInput struct {
+	// Value is a Go value or JSON text given as []byte or json.RawMessage for avro and cbor,
+	// JSON text for json, a string or []byte for text and []byte for binary. It's ignored for none,
+	// which serializes to a null payload.
+	Value interface{}
+	// Encoding is one of none, avro, cbor, json, text and binary.
+	Encoding string
+	// Options select the schema that avro payloads are serialized with.
+	Options []SerializeOption
+}
+
+// SerializeInput is the key and value of a record that shall be serialized.
+type SerializeInput struct {
+	Key   RecordPayloadInput
+	Value RecordPayloadInput
+
+	// IncludeInfo adds a troubleshooting entry to each result that summarizes the applied
+	// serde and options, even if serializing succeeded. This helps to investigate why a
+	// produced record looks the way it does.
+	IncludeInfo bool
+}
+
+// SerializeOutput is the serialized key and value of a record.
+type SerializeOutput struct {
+	Key   *SerializeResult `json:"key"`
+	Value *SerializeResult `json:"value"`
+}
+
+// SerializeResult is a serialized record key or value.
+type SerializeResult struct {
+	Payload  []byte `json:"payload"`
+	Encoding string `json:"encoding"`
+	// SchemaID is the ID of the schema that the payload has been serialized with, if any.
+	SchemaID uint32 `json:"schemaId,omitempty"`
+	// Troubleshooting explains why serializing failed. On success it's only populated if
+	// requested by SerializeInput.IncludeInfo.
+	Troubleshooting []troubleshootingReport `json:"troubleshooting,omitempty"`
+}
+
+// SerializeRecord serializes the key and value of a record, so that it can be produced.
+//
+// The returned output always contains the results of both the key and the value, even if
+// an error is returned. If only one side fails, the other side's payload is still present,
+// and the failed side carries the error in its troubleshooting. Callers must therefore expect
+// a partially populated output along with a non-nil error.
+func (s *Service) SerializeRecord(ctx context.Context, input SerializeInput) (*SerializeOutput, error) {
+	key, keyErr := s.serializePayload(ctx, input.Key, input.IncludeInfo)
+	value, valueErr := s.serializePayload(ctx, input.Value, input.IncludeInfo)
+	out := &SerializeOutput{Key: key, Value: value}
+
+	switch {
+	case keyErr != nil && valueErr != nil:
+		return out, fmt.Errorf("failed to serialize record key: %v, and value: %w", keyErr, valueErr)
+	case keyErr != nil:
+		return out, fmt.Errorf("failed to serialize record key: %w", keyErr)
+	case valueErr != nil:
+		return out, fmt.Errorf("failed to serialize record value: %w", valueErr)
+	}
+	return out, nil
+}
+
+// serializePayload serializes a record key or value with the requested encoding. The result
+// is never nil, it carries the error in its troubleshooting if serializing failed.
+func (s *Service) serializePayload(ctx context.Context, input RecordPayloadInput, includeInfo bool) (*SerializeResult, error) {
+	payload, info, err := s.serializePayloadWithInfo(ctx, input)
+	if err != nil {
+		return &SerializeResult{
+			Encoding:        input.Encoding,
+			Troubleshooting: []troubleshootingReport{{SerdeName: input.Encoding, Message: err.Error()}},
+		}, err
+	}
+
+	res := &SerializeResult{Payload: payload, Encoding: input.Encoding}
+	if input.Encoding == serializeEncodingAvro {
+		res.SchemaID = binary.BigEndian.Uint32(payload[1:5])
+	}
+	if includeInfo {
+		res.Troubleshooting = []troubleshootingReport{{SerdeName: input.Encoding, Message: info}}
+	}
+	return res, nil
+}
+
+// serializePayloadWithInfo serializes a record key or value and describes how it has been
+// serialized.
+func (s *Service) serializePayloadWithInfo(ctx context.Context, input RecordPayloadInput) ([]byte, string, error) {
+	var payload []byte
+	var err error
+	var info string
+	switch input.Encoding {
+	case serializeEncodingNone:
+		info = "serialized as null payload"
+	case serializeEncodingAvro:
+		payload, err = s.SerializeAvro(ctx, input.Value, input.Options...)
+		if err == nil {
+			info = fmt.Sprintf("serialized %d bytes with avro schema %d%s",
+				len(payload), binary.BigEndian.Uint32(payload[1:5]), describeSerializeOptions(input.Options))
+		}
+	case serializeEncodingCBOR:
+		payload, err = s.SerializeCBOR(input.Value)
+		if err == nil {
+			info = fmt.Sprintf("serialized %d bytes as cbor, prefixed with the self-described cbor tag", len(payload))
+		}
+	case serializeEncodingJSON:
+		payload, err = serializeJSON(input.Value)
+		if err == nil {
+			info = fmt.Sprintf("serialized %d bytes as json, whitespaces have been removed", len(payload))
+		}
+	case serializeEncodingText:
+		switch v := input.Value.(type) {
+		case string:
+			payload = []byte(v)
+		case []byte:
+			if !utf8.Valid(v) {
+				return nil, "", fmt.Errorf("text is not valid utf-8")
+			}
+			payload = v
+		default:
+			return nil, "", fmt.Errorf("text must be given as string, but got %T", input.Value)
+		}
+		info = fmt.Sprintf("serialized %d bytes as utf-8 text", len(payload))
+	case serializeEncodingBinary:
+		b, ok := input.Value.([]byte)
+		if !ok {
+			return nil, "", fmt.Errorf("binary must be given as []byte, but got %T", input.Value)
+		}
+		payload = b
+		info = fmt.Sprintf("serialized %d bytes as is", len(payload))
+	default:
+		return nil, "", fmt.Errorf("unknown encoding %q", input.Encoding)
+	}
+	return payload, info, err
+}
+
+// serializeJSON validates the JSON text and removes insignificant whitespaces.
+func serializeJSON(value interface{}) ([]byte, error) {
+	var jsonText []byte
+	switch v := value.(type) {
+	case json.RawMessage:
+		jsonText = v
+	case []byte:
+		jsonText = v
+	case string:
+		jsonText = []byte(v)
+	default:
+		return nil, fmt.Errorf("json must be given as text, but got %T", value)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, jsonText); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// describeSerializeOptions describes how the schema has been selected by the options.
+func describeSerializeOptions(opts []SerializeOption) string {
+	var cfg serializeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.subject == "" {
+		return ""
+	}
+	version := cfg.version
+	if version == "" {
+		version = "latest"
+	}
+	return fmt.Sprintf(" (subject %q, version %s)", cfg.subject, version)
+}
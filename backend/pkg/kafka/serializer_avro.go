@@ -0,0 +1,460 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/hamba/avro/v2"
+
+	"github.com/redpanda-data/console/backend/pkg/schema"
+)
+
+// SerializeOption selects the writer schema that a value is serialized with.
+type SerializeOption func(*serializeConfig)
+
+type serializeConfig struct {
+	schemaID    uint32
+	hasSchemaID bool
+	subject     string
+	version     string
+}
+
+// WithSchemaID serializes the value with the schema of the given ID.
+func WithSchemaID(schemaID uint32) SerializeOption {
+	return func(cfg *serializeConfig) {
+		cfg.schemaID = schemaID
+		cfg.hasSchemaID = true
+	}
+}
+
+// WithSubjectVersion serializes the value with the schema of the given subject version. The
+// version may be "latest".
+func WithSubjectVersion(subject, version string) SerializeOption {
+	return func(cfg *serializeConfig) {
+		cfg.subject = subject
+		cfg.version = version
+	}
+}
+
+// SerializeAvro encodes the given value with an Avro schema of the schema registry and frames
+// it with the magic byte and schema ID, so that it can be produced as record key or value.
+// The value is either a Go value that can be marshalled with the schema, or JSON text given
+// as []byte or json.RawMessage. JSON text is expected in the same form that Console returns
+// decoded Avro payloads in, e.g. bytes as base64 and non-null union values as object with
+// the type name as key.
+func (s *Service) SerializeAvro(ctx context.Context, value interface{}, opts ...SerializeOption) ([]byte, error) {
+	return serializeAvro(ctx, s.SchemaService, value, opts...)
+}
+
+func serializeAvro(ctx context.Context, schemaSvc *schema.Service, value interface{}, opts ...SerializeOption) ([]byte, error) {
+	if schemaSvc == nil {
+		return nil, fmt.Errorf("no schema registry configured")
+	}
+
+	var cfg serializeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	schemaID, err := resolveSerializeSchemaID(ctx, schemaSvc, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := schemaSvc.GetAvroSchemaByID(ctx, schemaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get avro schema with id %d: %w", schemaID, err)
+	}
+
+	var jsonText []byte
+	switch v := value.(type) {
+	case json.RawMessage:
+		jsonText = v
+	case []byte:
+		jsonText = v
+	}
+	if jsonText != nil {
+		value, err = avroNativeFromJSONText(sch, jsonText)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := avro.Marshal(sch, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize value with avro schema %d: %w", schemaID, err)
+	}
+
+	payload := make([]byte, 5, 5+len(body))
+	binary.BigEndian.PutUint32(payload[1:], schemaID)
+	return append(payload, body...), nil
+}
+
+// resolveSerializeSchemaID returns the ID of the schema that has been selected by the options.
+func resolveSerializeSchemaID(ctx context.Context, schemaSvc *schema.Service, cfg serializeConfig) (uint32, error) {
+	switch {
+	case cfg.hasSchemaID && cfg.subject != "":
+		return 0, fmt.Errorf("either a schema id or a subject must be given, but not both")
+	case cfg.hasSchemaID:
+		return cfg.schemaID, nil
+	case cfg.subject == "":
+		return 0, fmt.Errorf("a schema id or a subject must be given")
+	}
+
+	version := cfg.version
+	if version == "" {
+		version = "latest"
+	}
+	res, err := schemaSvc.GetSchemaBySubject(ctx, cfg.subject, version, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema of subject %q version %q: %w", cfg.subject, version, err)
+	}
+	if res.Type != schema.TypeAvro {
+		return 0, fmt.Errorf("schema of subject %q version %q is not an avro schema but %s", cfg.subject, version, res.Type)
+	}
+	return uint32(res.SchemaID), nil
+}
+
+// avroNativeFromJSONText parses the JSON text and converts it to the Go values that the avro
+// encoder expects for the given schema.
+func avroNativeFromJSONText(sch avro.Schema, jsonText []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(jsonText))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+	native, err := avroNativeFromJSON(sch, value, "$")
+	if err != nil {
+		return nil, fmt.Errorf("value does not match avro schema: %w", err)
+	}
+	return native, nil
+}
+
+// avroNativeFromJSON converts a parsed JSON value to the Go value that the avro encoder expects
+// for the given schema. Path is the location of the value, which is reported in errors.
+//
+//nolint:gocognit,cyclop // One case per Avro type
+func avroNativeFromJSON(sch avro.Schema, value interface{}, path string) (interface{}, error) {
+	mismatch := func() error {
+		return fmt.Errorf("%s: cannot use %T as %s", path, value, sch.Type())
+	}
+
+	switch s := sch.(type) {
+	case *avro.RefSchema:
+		return avroNativeFromJSON(s.Schema(), value, path)
+
+	case *avro.NullSchema:
+		if value != nil {
+			return nil, mismatch()
+		}
+		return nil, nil
+
+	case *avro.UnionSchema:
+		return avroUnionFromJSON(s, value, path)
+
+	case *avro.RecordSchema:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		// Missing fields are filled with their defaults by the encoder
+		record := make(map[string]interface{}, len(obj))
+		for _, field := range s.Fields() {
+			fieldValue, exists := obj[field.Name()]
+			if !exists {
+				continue
+			}
+			native, err := avroNativeFromJSON(field.Type(), fieldValue, path+"."+field.Name())
+			if err != nil {
+				return nil, err
+			}
+			record[field.Name()] = native
+		}
+		return record, nil
+
+	case *avro.ArraySchema:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		native := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if native[i], err = avroNativeFromJSON(s.Items(), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+		return native, nil
+
+	case *avro.MapSchema:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		native := make(map[string]interface{}, len(obj))
+		for key, item := range obj {
+			var err error
+			if native[key], err = avroNativeFromJSON(s.Values(), item, path+"."+key); err != nil {
+				return nil, err
+			}
+		}
+		return native, nil
+
+	case *avro.EnumSchema:
+		symbol, ok := value.(string)
+		if !ok {
+			return nil, mismatch()
+		}
+		return symbol, nil
+
+	case *avro.FixedSchema:
+		var b []byte
+		var err error
+		switch {
+		case s.Logical() != nil && s.Logical().Type() == avro.Decimal:
+			if decimal, ok := avroDecimalFromJSON(value); ok {
+				return decimal, nil
+			}
+			b, err = avroBytesFromJSON(value, path)
+		case s.Logical() != nil && s.Logical().Type() == avro.Duration:
+			b, err = avroDurationFromJSON(value, path)
+		default:
+			b, err = avroBytesFromJSON(value, path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != s.Size() {
+			return nil, fmt.Errorf("%s: fixed value must have %d bytes, but has %d", path, s.Size(), len(b))
+		}
+		fixed := reflect.New(reflect.ArrayOf(s.Size(), reflect.TypeOf(byte(0)))).Elem()
+		reflect.Copy(fixed, reflect.ValueOf(b))
+		return fixed.Interface(), nil
+
+	case *avro.PrimitiveSchema:
+		return avroPrimitiveFromJSON(s, value, path)
+	}
+
+	return nil, fmt.Errorf("%s: unsupported avro type %s", path, sch.Type())
+}
+
+// avroUnionFromJSON converts a union value, which is either null, an object with the name of
+// the union type as only key (the Avro JSON encoding), or a value of one of the union types.
+func avroUnionFromJSON(union *avro.UnionSchema, value interface{}, path string) (interface{}, error) {
+	if value == nil {
+		if _, pos := union.Types().Get(string(avro.Null)); pos < 0 {
+			return nil, fmt.Errorf("%s: null is not allowed by union", path)
+		}
+		return nil, nil
+	}
+
+	if obj, ok := value.(map[string]interface{}); ok && len(obj) == 1 {
+		for name, wrapped := range obj {
+			if typ, _ := union.Types().Get(name); typ != nil {
+				native, err := avroNativeFromJSON(typ, wrapped, path)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{name: native}, nil
+			}
+		}
+	}
+
+	// Use the first type that the unwrapped value can be converted to
+	for _, typ := range union.Types() {
+		if typ.Type() == avro.Null {
+			continue
+		}
+		native, err := avroNativeFromJSON(typ, value, path)
+		if err != nil {
+			continue
+		}
+		return map[string]interface{}{avroUnionTypeName(typ): native}, nil
+	}
+	return nil, fmt.Errorf("%s: value matches none of the union types", path)
+}
+
+// avroUnionTypeName returns the name that identifies the type within a union.
+func avroUnionTypeName(sch avro.Schema) string {
+	switch s := sch.(type) {
+	case avro.NamedSchema:
+		return s.FullName()
+	case *avro.RefSchema:
+		return avroUnionTypeName(s.Schema())
+	case avro.LogicalTypeSchema:
+		// Primitive types with logical type are named like "long.timestamp-millis"
+		if s.Logical() != nil {
+			return string(sch.Type()) + "." + string(s.Logical().Type())
+		}
+	}
+	return string(sch.Type())
+}
+
+func avroPrimitiveFromJSON(sch *avro.PrimitiveSchema, value interface{}, path string) (interface{}, error) {
+	mismatch := fmt.Errorf("%s: cannot use %T as %s", path, value, sch.Type())
+
+	var logicalType avro.LogicalType
+	if sch.Logical() != nil {
+		logicalType = sch.Logical().Type()
+	}
+
+	switch sch.Type() {
+	case avro.Boolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+
+	case avro.String:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+
+	case avro.Bytes:
+		if logicalType == avro.Decimal {
+			if decimal, ok := avroDecimalFromJSON(value); ok {
+				return decimal, nil
+			}
+		}
+		return avroBytesFromJSON(value, path)
+
+	case avro.Int, avro.Long:
+		// Dates, times and timestamps are returned as strings by the deserializer, unless raw
+		// logical types have been requested
+		if s, ok := value.(string); ok && logicalType != "" {
+			return avroTimeFromJSON(logicalType, s, path)
+		}
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, mismatch
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an integer", path, n)
+		}
+		// The encoder expects times of day as duration since midnight
+		switch {
+		case logicalType == avro.TimeMillis:
+			return time.Duration(i) * time.Millisecond, nil
+		case logicalType == avro.TimeMicros:
+			return time.Duration(i) * time.Microsecond, nil
+		case sch.Type() == avro.Long:
+			return i, nil
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("%s: %d overflows int", path, i)
+		}
+		return int32(i), nil
+
+	case avro.Float, avro.Double:
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, mismatch
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if sch.Type() == avro.Float {
+			return float32(f), nil
+		}
+		return f, nil
+
+	default:
+		return nil, fmt.Errorf("%s: unsupported avro type %s", path, sch.Type())
+	}
+	return nil, mismatch
+}
+
+// avroBytesFromJSON decodes bytes, which are returned as base64 string by the deserializer.
+func avroBytesFromJSON(value interface{}, path string) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: cannot use %T as bytes, expected base64 string", path, value)
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: bytes are not base64 encoded: %w", path, err)
+	}
+	return b, nil
+}
+
+// avroDecimalFromJSON parses a decimal, which is returned as string by the deserializer.
+// Raw decimals, which are returned as base64 encoded bytes, are not parsed.
+func avroDecimalFromJSON(value interface{}) (*big.Rat, bool) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// avroDurationFromJSON encodes a duration, which is returned as object with its three
+// components by the deserializer, or as base64 encoded bytes if raw.
+func avroDurationFromJSON(value interface{}, path string) ([]byte, error) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return avroBytesFromJSON(value, path)
+	}
+	b := make([]byte, 0, 12)
+	for _, component := range []string{"months", "days", "milliseconds"} {
+		n, ok := obj[component].(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("%s: duration must have numeric %s", path, component)
+		}
+		i, err := strconv.ParseUint(n.String(), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid duration %s: %w", path, component, err)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(i))
+	}
+	return b, nil
+}
+
+// avroTimeFromJSON parses dates, times of day and timestamps in the forms that are returned
+// by the deserializer.
+func avroTimeFromJSON(logicalType avro.LogicalType, s string, path string) (interface{}, error) {
+	switch logicalType {
+	case avro.Date:
+		if t, err := time.Parse(avroDateLayout, s); err == nil {
+			return t, nil
+		}
+	case avro.TimeMillis, avro.TimeMicros:
+		t, err := time.Parse("15:04:05.999999999", s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)), nil
+	case avro.TimestampMillis, avro.TimestampMicros:
+	default:
+		return nil, fmt.Errorf("%s: cannot use string as %s", path, logicalType)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/console/backend/pkg/proto"
+)
+
+const testAvroSerializeSchema = `{
+	"type": "record", "name": "order", "namespace": "com.shop",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "quantity", "type": "int"},
+		{"name": "total", "type": "long"},
+		{"name": "weight", "type": "float"},
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+		{"name": "status", "type": {"type": "enum", "name": "status", "symbols": ["PENDING", "SHIPPED"]}},
+		{"name": "checksum", "type": {"type": "fixed", "name": "checksum", "size": 4}},
+		{"name": "created_at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attributes", "type": {"type": "map", "values": "long"}},
+		{"name": "note", "type": ["null", "string"], "default": null},
+		{"name": "customer", "type": ["null", {"type": "record", "name": "customer", "fields": [
+			{"name": "email", "type": "string"}
+		]}]}
+	]
+}`
+
+func TestSerializeAvro_RoundTrip(t *testing.T) {
+	schemaSvc := newTestSchemaService(t, map[int]string{7: testAvroSerializeSchema})
+	d := deserializer{SchemaService: schemaSvc}
+
+	input := `{
+		"id": "o-1",
+		"quantity": 3,
+		"total": 9007199254740993,
+		"weight": 1.5,
+		"price": "19.99",
+		"status": "SHIPPED",
+		"checksum": "3q2+7w==",
+		"created_at": "2023-06-01T12:30:00Z",
+		"tags": ["gift", "express"],
+		"attributes": {"priority": 2},
+		"note": "leave at door",
+		"customer": {"com.shop.customer": {"email": "jane@example.com"}}
+	}`
+	payload, err := serializeAvro(context.Background(), schemaSvc, json.RawMessage(input), WithSchemaID(7))
+	require.NoError(t, err)
+	assert.Equal(t, byte(0), payload[0])
+	assert.Equal(t, uint32(7), binary.BigEndian.Uint32(payload[1:5]))
+
+	dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+	require.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+	assert.Equal(t, uint32(7), dp.SchemaID)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(dp.Payload.Payload, &decoded))
+	assert.Equal(t, "o-1", decoded["id"])
+	assert.Equal(t, "SHIPPED", decoded["status"])
+	assert.Equal(t, map[string]interface{}{"string": "leave at door"}, decoded["note"])
+	assert.Equal(t, []interface{}{"gift", "express"}, decoded["tags"])
+
+	// The deserialized payload serializes to the same bytes again
+	again, err := serializeAvro(context.Background(), schemaSvc, json.RawMessage(dp.Payload.Payload), WithSchemaID(7))
+	require.NoError(t, err)
+	assert.Equal(t, payload, again)
+}
+
+func TestSerializeAvro_GoValueWithSubject(t *testing.T) {
+	schemaSvc := newTestSchemaService(t, map[int]string{3: testAvroOCFSchema})
+	d := deserializer{SchemaService: schemaSvc}
+
+	payload, err := serializeAvro(context.Background(), schemaSvc,
+		map[string]interface{}{"id": "a", "quantity": 1}, WithSubjectVersion("subject-3", "latest"))
+	require.NoError(t, err)
+
+	dp := d.deserializePayload(payload, "orders", proto.RecordValue, DeserializationOptions{})
+	require.Equal(t, messageEncodingAvro, dp.RecognizedEncoding)
+	assert.Equal(t, uint32(3), dp.SchemaID)
+	assert.JSONEq(t, `{"id": "a", "quantity": 1}`, string(dp.Payload.Payload))
+}
+
+func TestSerializeAvro_Errors(t *testing.T) {
+	schemaSvc := newTestSchemaService(t, map[int]string{7: testAvroSerializeSchema})
+	ctx := context.Background()
+
+	_, err := serializeAvro(ctx, nil, json.RawMessage(`{}`), WithSchemaID(7))
+	assert.ErrorContains(t, err, "no schema registry configured")
+
+	_, err = serializeAvro(ctx, schemaSvc, json.RawMessage(`{}`))
+	assert.ErrorContains(t, err, "a schema id or a subject must be given")
+
+	_, err = serializeAvro(ctx, schemaSvc, json.RawMessage(`{}`), WithSchemaID(7), WithSubjectVersion("subject-7", "1"))
+	assert.ErrorContains(t, err, "not both")
+
+	_, err = serializeAvro(ctx, schemaSvc, json.RawMessage(`{"id": "o-1", "quantity": "three"}`), WithSchemaID(7))
+	assert.ErrorContains(t, err, "$.quantity: cannot use string as int")
+
+	_, err = serializeAvro(ctx, schemaSvc, json.RawMessage(`{"id": "o-1", "quantity": 3}`), WithSchemaID(7))
+	assert.ErrorContains(t, err, "failed to serialize value with avro schema 7")
+}
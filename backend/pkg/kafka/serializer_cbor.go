@@ -0,0 +1,98 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var cborEncMode = func() cbor.EncMode {
+	em, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return em
+}()
+
+// SerializeCBOR encodes the given value as CBOR, so that it can be produced as record key or
+// value. The value is either a Go value or JSON text given as []byte or json.RawMessage.
+// Values are prefixed with the self-described CBOR tag, so that Console recognizes them as
+// CBOR when they are consumed, regardless of the configured CBOR topics.
+func (*Service) SerializeCBOR(value interface{}) ([]byte, error) {
+	return serializeCBOR(value)
+}
+
+func serializeCBOR(value interface{}) ([]byte, error) {
+	var jsonText []byte
+	switch v := value.(type) {
+	case json.RawMessage:
+		jsonText = v
+	case []byte:
+		jsonText = v
+	}
+	if jsonText != nil {
+		var err error
+		value, err = cborNativeFromJSONText(jsonText)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := cborEncMode.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize value as cbor: %w", err)
+	}
+	return cborEncMode.Marshal(cbor.Tag{Number: cborSelfDescribeTag, Content: cbor.RawMessage(payload)})
+}
+
+// cborNativeFromJSONText decodes JSON text into Go values. Integral numbers are decoded as
+// integers so that they are encoded as CBOR integers rather than floats.
+func cborNativeFromJSONText(jsonText []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(jsonText))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode json value: %w", err)
+	}
+	return cborNativeFromJSON(value), nil
+}
+
+func cborNativeFromJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = cborNativeFromJSON(val)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = cborNativeFromJSON(item)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_SerializeRecord(t *testing.T) {
+	s := &Service{SchemaService: newTestSchemaService(t, map[int]string{3: testAvroOCFSchema})}
+	input := SerializeInput{
+		Key: RecordPayloadInput{Value: "o-1", Encoding: serializeEncodingText},
+		Value: RecordPayloadInput{
+			Value:    []byte(`{"id": "o-1", "quantity": 2}`),
+			Encoding: serializeEncodingAvro,
+			Options:  []SerializeOption{WithSubjectVersion("subject-3", "latest")},
+		},
+	}
+
+	t.Run("without info", func(t *testing.T) {
+		out, err := s.SerializeRecord(context.Background(), input)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("o-1"), out.Key.Payload)
+		assert.Equal(t, uint32(3), out.Value.SchemaID)
+		assert.Empty(t, out.Key.Troubleshooting)
+		assert.Empty(t, out.Value.Troubleshooting)
+	})
+
+	t.Run("info on success", func(t *testing.T) {
+		withInfo := input
+		withInfo.IncludeInfo = true
+		out, err := s.SerializeRecord(context.Background(), withInfo)
+		require.NoError(t, err)
+
+		assert.Equal(t, []troubleshootingReport{{SerdeName: "text", Message: "serialized 3 bytes as utf-8 text"}}, out.Key.Troubleshooting)
+		require.Len(t, out.Value.Troubleshooting, 1)
+		assert.Equal(t, "avro", out.Value.Troubleshooting[0].SerdeName)
+		assert.Contains(t, out.Value.Troubleshooting[0].Message, `avro schema 3 (subject "subject-3", version latest)`)
+	})
+
+	t.Run("json is compacted", func(t *testing.T) {
+		out, err := s.SerializeRecord(context.Background(), SerializeInput{
+			Key:         RecordPayloadInput{Encoding: serializeEncodingNone},
+			Value:       RecordPayloadInput{Value: "{ \"id\": 1 }", Encoding: serializeEncodingJSON},
+			IncludeInfo: true,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, out.Key.Payload)
+		assert.Equal(t, `{"id":1}`, string(out.Value.Payload))
+		assert.Contains(t, out.Value.Troubleshooting[0].Message, "whitespaces have been removed")
+	})
+
+	t.Run("unknown encoding", func(t *testing.T) {
+		_, err := s.SerializeRecord(context.Background(), SerializeInput{
+			Key:   RecordPayloadInput{Encoding: "xml"},
+			Value: RecordPayloadInput{Encoding: serializeEncodingNone},
+		})
+		assert.ErrorContains(t, err, `unknown encoding "xml"`)
+	})
+
+	t.Run("key succeeds and value fails", func(t *testing.T) {
+		out, err := s.SerializeRecord(context.Background(), SerializeInput{
+			Key:   RecordPayloadInput{Value: "o-1", Encoding: serializeEncodingText},
+			Value: RecordPayloadInput{Value: []byte(`{"id": "o-1", "quantity": "two"}`), Encoding: serializeEncodingAvro, Options: []SerializeOption{WithSchemaID(3)}},
+		})
+		require.ErrorContains(t, err, "failed to serialize record value")
+		require.NotNil(t, out)
+
+		assert.Equal(t, []byte("o-1"), out.Key.Payload)
+		assert.Empty(t, out.Key.Troubleshooting)
+
+		assert.Nil(t, out.Value.Payload)
+		require.Len(t, out.Value.Troubleshooting, 1)
+		assert.Equal(t, "avro", out.Value.Troubleshooting[0].SerdeName)
+		assert.Contains(t, out.Value.Troubleshooting[0].Message, "$.quantity: cannot use string as int")
+	})
+
+	t.Run("both sides fail", func(t *testing.T) {
+		out, err := s.SerializeRecord(context.Background(), SerializeInput{
+			Key:   RecordPayloadInput{Value: 1, Encoding: serializeEncodingText},
+			Value: RecordPayloadInput{Value: "{", Encoding: serializeEncodingJSON},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key: text must be given as string")
+		assert.Contains(t, err.Error(), "value: invalid json")
+		assert.NotEmpty(t, out.Key.Troubleshooting)
+		assert.NotEmpty(t, out.Value.Troubleshooting)
+	})
+}
@@ -12,13 +12,17 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"regexp"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/franz-go/pkg/kversion"
+	"github.com/twmb/go-cache/cache"
 	"go.uber.org/zap"
 
 	"github.com/redpanda-data/console/backend/pkg/config"
@@ -39,11 +43,27 @@ type Service struct {
 	ProtoService     *proto.Service
 	Deserializer     deserializer
 	MetricsNamespace string
+
+	// encodingDetectionByTopic caches the sampled key/value encodings of a topic per sample size.
+	encodingDetectionByTopic *cache.Cache[encodingDetectionKey, *TopicEncodingDetection]
 }
 
 // NewService creates a new Kafka service and immediately checks connectivity to all components. If any of these external
 // dependencies fail an error wil be returned.
 func NewService(cfg *config.Config, logger *zap.Logger, metricsNamespace string) (*Service, error) {
+	svc, err := newService(cfg, logger, metricsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.Deserializer.validateTopicDecoders(); err != nil {
+		return nil, fmt.Errorf("failed to create topic decoders: %w", err)
+	}
+	return svc, nil
+}
+
+// newService creates a new Kafka service like NewService, but doesn't validate the topic
+// decoders, as they may refer to custom serdes that are registered afterwards.
+func newService(cfg *config.Config, logger *zap.Logger, metricsNamespace string) (*Service, error) {
 	kgoHooks := newClientHooks(logger.Named("kafka_client_hooks"), metricsNamespace)
 
 	logger.Debug("creating new kafka client", zap.Any("config", cfg.Kafka.RedactedConfig()))
@@ -88,7 +108,7 @@ func NewService(cfg *config.Config, logger *zap.Logger, metricsNamespace string)
 	var schemaSvc *schema.Service
 	if cfg.Kafka.Schema.Enabled {
 		logger.Info("creating schema registry client and testing connectivity")
-		schemaSvc, err = schema.NewService(cfg.Kafka.Schema, logger)
+		schemaSvc, err = schema.NewService(cfg.Kafka.Schema, logger, prometheus.DefaultRegisterer, metricsNamespace)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create schema service: %w", err)
 		}
@@ -97,7 +117,21 @@ func NewService(cfg *config.Config, logger *zap.Logger, metricsNamespace string)
 		if err != nil {
 			return nil, fmt.Errorf("failed to verify connectivity to schema registry: %w", err)
 		}
-		logger.Info("successfully tested schema registry connectivity")
+		logger.Info("successfully tested schema registry connectivity",
+			zap.Int("max_get_request_attempts", schemaSvc.MaxRequestAttempts(http.MethodGet)))
+
+		// Capabilities are only logged, as registries that restrict access to the detection
+		// endpoints work nonetheless
+		info, err := schemaSvc.GetRegistryInfo(context.Background())
+		if err != nil {
+			logger.Warn("failed to detect schema registry version and capabilities", zap.Error(err))
+		} else {
+			logger.Info("detected schema registry version and capabilities",
+				zap.String("version", info.ServerInfo.Version),
+				zap.String("flavor", string(info.Flavor)),
+				zap.Strings("supported_types", info.SupportedTypes),
+				zap.Bool("supports_bulk_listing", info.SupportsBulkListing))
+		}
 	}
 
 	// Proto Service
@@ -119,6 +153,40 @@ func NewService(cfg *config.Config, logger *zap.Logger, metricsNamespace string)
 		}
 	}
 
+	// Untagged CBOR is only decoded for allowed topics
+	var cborTopics []*regexp.Regexp
+	if cfg.Kafka.CBOR.Enabled {
+		cborTopics, err = config.CompileRegexes(cfg.Kafka.CBOR.TopicNames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile cbor topic names: %w", err)
+		}
+	}
+
+	topicDecoders, err := newTopicDecoders(cfg.Kafka.TopicDecoders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topic decoders: %w", err)
+	}
+
+	decoderChain, err := newDecoderChain(cfg.Kafka.Decoders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create decoder chain: %w", err)
+	}
+
+	compositeKeys, err := newCompositeKeyLayouts(cfg.Kafka.CompositeKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key layouts: %w", err)
+	}
+
+	// Serde metrics are only recorded if enabled, as they are observed for every decode attempt
+	var serdeMetricsRegisterer prometheus.Registerer
+	if cfg.Kafka.SerdeMetrics {
+		serdeMetricsRegisterer = prometheus.DefaultRegisterer
+	}
+	serdeMetrics, err := newSerdeMetrics(serdeMetricsRegisterer, metricsNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register serde metrics: %w", err)
+	}
+
 	return &Service{
 		Config:           cfg,
 		Logger:           logger,
@@ -131,14 +199,29 @@ func NewService(cfg *config.Config, logger *zap.Logger, metricsNamespace string)
 			SchemaService:  schemaSvc,
 			ProtoService:   protoSvc,
 			MsgPackService: msgPackSvc,
+			cborTopics:     cborTopics,
+			metrics:        serdeMetrics,
+			decoderChain:   decoderChain,
+			topicDecoders:  topicDecoders,
+			compositeKeys:  compositeKeys,
 		},
 		MetricsNamespace: metricsNamespace,
+
+		encodingDetectionByTopic: cache.New[encodingDetectionKey, *TopicEncodingDetection](cache.MaxAge(5*time.Minute), cache.MaxErrorAge(time.Second)),
 	}, nil
 }
 
 // Start starts all the (background) tasks which are required for this service to work properly. If any of these
 // tasks can not be setup an error will be returned which will cause the application to exit.
 func (s *Service) Start() error {
+	if s.SchemaService != nil && s.Config.Kafka.Schema.ScanSubjectsOnStartup {
+		brokenSubjects, err := newBrokenSubjectsGauge(prometheus.DefaultRegisterer, s.MetricsNamespace)
+		if err != nil {
+			return fmt.Errorf("failed to register schema registry metrics: %w", err)
+		}
+		go s.scanSchemaSubjects(context.Background(), brokenSubjects)
+	}
+
 	if s.ProtoService == nil {
 		return nil
 	}
@@ -0,0 +1,121 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package proto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// UnmarshalOptions control how protobuf messages are rendered as JSON.
+type UnmarshalOptions struct {
+	// EnumsAsNameAndNumber renders enum values as object with both the number and the
+	// name (e.g. {"value": 2, "name": "ACTIVE"}) instead of the name only. The name of
+	// numbers that are not defined in the enum is empty.
+	EnumsAsNameAndNumber bool
+}
+
+// enumsAsNameAndNumber replaces all enum values of the JSON encoded message with an
+// object that carries both the number and the name of the enum value.
+func enumsAsNameAndNumber(jsonBytes []byte, md *desc.MessageDescriptor) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(jsonBytes))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to decode protobuf JSON: %w", err)
+	}
+	expandEnums(obj, md)
+	return json.Marshal(obj)
+}
+
+func expandEnums(obj map[string]interface{}, md *desc.MessageDescriptor) {
+	for _, fd := range md.GetFields() {
+		val, ok := obj[fd.GetJSONName()]
+		if !ok || val == nil {
+			continue
+		}
+
+		if fd.IsMap() {
+			entries, ok := val.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for key, entry := range entries {
+				entries[key] = expandEnumField(entry, fd.GetMapValueType())
+			}
+			continue
+		}
+
+		if fd.IsRepeated() {
+			items, ok := val.([]interface{})
+			if !ok {
+				continue
+			}
+			for i, item := range items {
+				items[i] = expandEnumField(item, fd)
+			}
+			continue
+		}
+
+		obj[fd.GetJSONName()] = expandEnumField(val, fd)
+	}
+}
+
+// expandEnumField returns the JSON value of a single (non repeated) field with all
+// enums expanded.
+func expandEnumField(val interface{}, fd *desc.FieldDescriptor) interface{} {
+	switch fd.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		return expandEnumValue(val, fd.GetEnumType())
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		// Well known types such as timestamps or Any have their own JSON representation
+		md := fd.GetMessageType()
+		if strings.HasPrefix(md.GetFullyQualifiedName(), "google.protobuf.") {
+			return val
+		}
+		if nested, ok := val.(map[string]interface{}); ok {
+			expandEnums(nested, md)
+		}
+	}
+	return val
+}
+
+func expandEnumValue(val interface{}, ed *desc.EnumDescriptor) interface{} {
+	if ed.GetFullyQualifiedName() == "google.protobuf.NullValue" {
+		return val
+	}
+
+	switch v := val.(type) {
+	case string:
+		vd := ed.FindValueByName(v)
+		if vd == nil {
+			return val
+		}
+		return map[string]interface{}{"value": vd.GetNumber(), "name": vd.GetName()}
+	case json.Number:
+		// Numbers that are not defined in the enum are rendered as number by jsonpb
+		number, err := strconv.ParseInt(v.String(), 10, 32)
+		if err != nil {
+			return val
+		}
+		name := ""
+		if vd := ed.FindValueByNumber(int32(number)); vd != nil {
+			name = vd.GetName()
+		}
+		return map[string]interface{}{"value": int32(number), "name": name}
+	}
+	return val
+}
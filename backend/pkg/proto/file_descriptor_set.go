@@ -0,0 +1,64 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package proto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/redpanda-data/console/backend/pkg/config"
+)
+
+// loadFileDescriptorSets reads the configured FileDescriptorSets and returns the file
+// descriptors of all files they contain. Each set must include its imports.
+func loadFileDescriptorSets(sets []config.ProtoFileDescriptorSet) ([]*desc.FileDescriptor, error) {
+	var descriptors []*desc.FileDescriptor
+	for _, set := range sets {
+		fds, err := readFileDescriptorSet(set)
+		if err != nil {
+			return nil, err
+		}
+		descriptorsByName, err := desc.CreateFileDescriptorsFromSet(fds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file descriptors from set: %w", err)
+		}
+		for _, descriptor := range descriptorsByName {
+			descriptors = append(descriptors, descriptor)
+		}
+	}
+	return descriptors, nil
+}
+
+func readFileDescriptorSet(set config.ProtoFileDescriptorSet) (*descriptorpb.FileDescriptorSet, error) {
+	var serialized []byte
+	var err error
+	if set.Path != "" {
+		serialized, err = os.ReadFile(set.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file descriptor set: %w", err)
+		}
+	} else {
+		serialized, err = base64.StdEncoding.DecodeString(set.Inline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode inline file descriptor set: %w", err)
+		}
+	}
+
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(serialized, fds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal file descriptor set: %w", err)
+	}
+	return fds, nil
+}
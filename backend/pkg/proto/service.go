@@ -12,8 +12,11 @@ package proto
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -63,6 +66,9 @@ type Service struct {
 
 	registryMutex sync.RWMutex
 	registry      *msgregistry.MessageRegistry
+	// anyResolver resolves Any types against the registry and the schema registry schemas.
+	// It's rebuilt along with the registry, so that it's not built for every message.
+	anyResolver *anyResolver
 }
 
 // NewService creates a new proto.Service.
@@ -159,7 +165,7 @@ func (s *Service) Start() error {
 	return nil
 }
 
-func (s *Service) unmarshalConfluentMessage(payload []byte, topicName string) ([]byte, int, error) {
+func (s *Service) unmarshalConfluentMessage(payload []byte, topicName string, opts UnmarshalOptions) ([]byte, int, error) {
 	// 1. If schema registry for protobuf is enabled, let's check if this message has been serialized utilizing
 	// Confluent's KafakProtobuf serialization format.
 	wrapper, err := s.decodeConfluentBinaryWrapper(payload)
@@ -173,7 +179,7 @@ func (s *Service) unmarshalConfluentMessage(payload []byte, topicName string) ([
 		return nil, schemaID, err
 	}
 
-	jsonBytes, err := s.deserializeProtobufMessageToJSON(cleanPayload, md)
+	jsonBytes, err := s.deserializeProtobufMessageToJSON(cleanPayload, md, opts)
 	if err != nil {
 		return nil, schemaID, err
 	}
@@ -181,30 +187,39 @@ func (s *Service) unmarshalConfluentMessage(payload []byte, topicName string) ([
 	return jsonBytes, schemaID, nil
 }
 
-func (s *Service) deserializeProtobufMessageToJSON(payload []byte, md *desc.MessageDescriptor) ([]byte, error) {
+func (s *Service) deserializeProtobufMessageToJSON(payload []byte, md *desc.MessageDescriptor, opts UnmarshalOptions) ([]byte, error) {
 	msg := dynamic.NewMessage(md)
 	err := msg.Unmarshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal payload into protobuf message: %w", err)
 	}
 
+	return s.protobufMessageToJSON(msg, md, opts)
+}
+
+// protobufMessageToJSON marshals an unmarshalled message of the given type to JSON.
+func (s *Service) protobufMessageToJSON(msg *dynamic.Message, md *desc.MessageDescriptor, opts UnmarshalOptions) ([]byte, error) {
 	jsonBytes, err := msg.MarshalJSONPB(&jsonpb.Marshaler{
-		AnyResolver:  &anyResolver{s.registry},
+		AnyResolver:  s.anyResolverForMessage(md),
 		EmitDefaults: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal protobuf message to JSON: %w", err)
 	}
 
+	if opts.EnumsAsNameAndNumber {
+		return enumsAsNameAndNumber(jsonBytes, md)
+	}
+
 	return jsonBytes, nil
 }
 
 // UnmarshalPayload tries to deserialize a protobuf encoded payload to a JSON message,
 // so that it's human-readable in the Console frontend.
-func (s *Service) UnmarshalPayload(payload []byte, topicName string, property RecordPropertyType) ([]byte, int, error) {
+func (s *Service) UnmarshalPayload(payload []byte, topicName string, property RecordPropertyType, opts UnmarshalOptions) ([]byte, int, error) {
 	// 1. First let's try if we can deserialize this message with schema registry (if configured)
 	if s.cfg.SchemaRegistry.Enabled {
-		jsonBytes, schemaID, err := s.unmarshalConfluentMessage(payload, topicName)
+		jsonBytes, schemaID, err := s.unmarshalConfluentMessage(payload, topicName, opts)
 		if err == nil {
 			return jsonBytes, schemaID, nil
 		}
@@ -216,7 +231,7 @@ func (s *Service) UnmarshalPayload(payload []byte, topicName string, property Re
 		return nil, 0, fmt.Errorf("failed to get message descriptor for payload: %w", err)
 	}
 
-	jsonBytes, err := s.deserializeProtobufMessageToJSON(payload, messageDescriptor)
+	jsonBytes, err := s.deserializeProtobufMessageToJSON(payload, messageDescriptor, opts)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -224,6 +239,70 @@ func (s *Service) UnmarshalPayload(payload []byte, topicName string, property Re
 	return jsonBytes, 0, nil
 }
 
+// UnmarshalPayloadWithSchemaID deserializes a protobuf encoded payload that is not framed with
+// the schema ID, using the registry schema with the given ID. The message type is looked up by
+// its fully qualified name. If no name is given, the schema's first message type is used.
+// Payloads with fields that the message type doesn't define are rejected.
+func (s *Service) UnmarshalPayloadWithSchemaID(payload []byte, schemaID int, messageName string, opts UnmarshalOptions) ([]byte, error) {
+	fd, exists := s.getFileDescriptorBySchemaID(schemaID)
+	if !exists {
+		return nil, fmt.Errorf("could not find a file descriptor that matches the schema id '%v'", schemaID)
+	}
+
+	var md *desc.MessageDescriptor
+	if messageName == "" {
+		if messageTypes := fd.GetMessageTypes(); len(messageTypes) > 0 {
+			md = messageTypes[0]
+		}
+	} else {
+		md = fd.FindMessage(messageName)
+	}
+	if md == nil {
+		return nil, fmt.Errorf("could not find message type '%v' in schema id '%v'", messageName, schemaID)
+	}
+
+	// Protobuf decodes arbitrary bytes as unknown fields, so the payload must consist of the
+	// message type's fields only to tell it apart from payloads of other types
+	msg := dynamic.NewMessage(md)
+	if err := msg.Unmarshal(payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload into protobuf message: %w", err)
+	}
+	if unknown := msg.GetUnknownFields(); len(unknown) > 0 {
+		return nil, fmt.Errorf("payload has fields %v that are not defined in message type '%v'", unknown, md.GetFullyQualifiedName())
+	}
+	return s.protobufMessageToJSON(msg, md, opts)
+}
+
+// UnmarshalPayloadWithMessageType deserializes a protobuf encoded payload that is not framed
+// with a schema ID, using the message type with the given fully qualified name. The type is
+// looked up among the types from proto files and FileDescriptorSets, regardless of the topic
+// mappings. Payloads with fields that the message type doesn't define are rejected.
+func (s *Service) UnmarshalPayloadWithMessageType(payload []byte, messageName string, opts UnmarshalOptions) ([]byte, error) {
+	s.registryMutex.RLock()
+	registry := s.registry
+	s.registryMutex.RUnlock()
+	if registry == nil {
+		return nil, fmt.Errorf("proto registry has not been created yet")
+	}
+
+	md, err := registry.FindMessageTypeByUrl(messageName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message type '%v': %w", messageName, err)
+	}
+	if md == nil {
+		return nil, fmt.Errorf("could not find message type '%v'", messageName)
+	}
+
+	msg := dynamic.NewMessage(md)
+	if err := msg.Unmarshal(payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload into protobuf message: %w", err)
+	}
+	if unknown := msg.GetUnknownFields(); len(unknown) > 0 {
+		return nil, fmt.Errorf("payload has fields %v that are not defined in message type '%v'", unknown, md.GetFullyQualifiedName())
+	}
+	return s.protobufMessageToJSON(msg, md, opts)
+}
+
 // getMessageDescriptorFromConfluentMessage try to find the right message descriptor of a message that has been serialized
 // according to Confluent's ProtobufSerializer. If successful it will return the found message descriptor along with
 // the protobuf payload (without the bytes that carry the metadata such as schema id), so that this can be used
@@ -398,9 +477,16 @@ func (s *Service) createProtoRegistry(ctx context.Context) error {
 		return fmt.Errorf("failed to compile proto files to descriptors: %w", err)
 	}
 
+	setDescriptors, err := loadFileDescriptorSets(s.cfg.FileDescriptorSets)
+	if err != nil {
+		return fmt.Errorf("failed to load file descriptor sets: %w", err)
+	}
+	fileDescriptors = append(fileDescriptors, setDescriptors...)
+
 	// Merge proto descriptors from schema registry into the existing proto descriptors
+	var descriptors map[int]*desc.FileDescriptor
 	if s.schemaSvc != nil {
-		descriptors, err := s.schemaSvc.GetProtoDescriptors(ctx)
+		descriptors, err = s.schemaSvc.GetProtoDescriptors(ctx)
 		if err != nil {
 			s.logger.Error("failed to get proto descriptors from schema registry", zap.Error(err))
 		}
@@ -418,6 +504,7 @@ func (s *Service) createProtoRegistry(ctx context.Context) error {
 	s.registryMutex.Lock()
 	defer s.registryMutex.Unlock()
 	s.registry = registry
+	s.anyResolver = newAnyResolver(registry, descriptors)
 
 	// Let's compare the registry items against the mapping and let the user know if there are missing/mismatched proto types
 	foundTypes := 0
@@ -556,11 +643,52 @@ func (s *Service) getFileDescriptorBySchemaID(schemaID int) (*desc.FileDescripto
 // more detail as part of the pull request that addresses the
 // deserialization issue with the any types:
 // https://github.com/redpanda-data/console/pull/425
+//
+// Types are looked up in the local proto registry first, then in the file of
+// the deserialized message including its imports (e.g. schema references) and
+// finally in all schemas of the schema registry. Types that can't be resolved
+// are returned as unresolvedAny, so that the Any's raw value is kept.
 type anyResolver struct {
 	mr *msgregistry.MessageRegistry
+	// schemaFiles resolves types in all schemas of the schema registry
+	schemaFiles jsonpb.AnyResolver
+}
+
+// newAnyResolver returns the resolver for the given registry and schema registry schemas.
+// It's built once per registry refresh.
+func newAnyResolver(mr *msgregistry.MessageRegistry, descriptorsBySchemaID map[int]*desc.FileDescriptor) *anyResolver {
+	schemaIDs := make([]int, 0, len(descriptorsBySchemaID))
+	for schemaID := range descriptorsBySchemaID {
+		schemaIDs = append(schemaIDs, schemaID)
+	}
+	sort.Ints(schemaIDs)
+	files := make([]*desc.FileDescriptor, len(schemaIDs))
+	for i, schemaID := range schemaIDs {
+		files[i] = descriptorsBySchemaID[schemaID]
+	}
+
+	return &anyResolver{mr: mr, schemaFiles: dynamic.AnyResolver(nil, files...)}
+}
+
+// messageAnyResolver resolves Any types within messages of a single type, whose file is
+// searched before the schemas of the schema registry.
+type messageAnyResolver struct {
+	*anyResolver
+	file *desc.FileDescriptor
+}
+
+// anyResolverForMessage returns the resolver for Any types within messages of the given type.
+func (s *Service) anyResolverForMessage(md *desc.MessageDescriptor) *messageAnyResolver {
+	s.registryMutex.RLock()
+	resolver := s.anyResolver
+	s.registryMutex.RUnlock()
+	if resolver == nil {
+		resolver = newAnyResolver(nil, nil)
+	}
+	return &messageAnyResolver{anyResolver: resolver, file: md.GetFile()}
 }
 
-func (r *anyResolver) Resolve(typeURL string) (protoiface.MessageV1, error) {
+func (r *messageAnyResolver) Resolve(typeURL string) (protoiface.MessageV1, error) {
 	// Protoreflect registers the type by stripping the contents before the last
 	// slash. Therefore we need to mimic this behaviour in order to resolve
 	// the type by it's given type url.
@@ -569,5 +697,44 @@ func (r *anyResolver) Resolve(typeURL string) (protoiface.MessageV1, error) {
 		mname = mname[slash+1:]
 	}
 
-	return r.mr.Resolve(mname)
+	if r.mr != nil {
+		if msg, err := r.mr.Resolve(mname); err == nil {
+			return msg, nil
+		}
+	}
+	if msg, err := dynamic.AnyResolver(nil, r.file).Resolve(typeURL); err == nil {
+		return msg, nil
+	}
+	if msg, err := r.schemaFiles.Resolve(typeURL); err == nil {
+		return msg, nil
+	}
+	return &unresolvedAny{}, nil
+}
+
+// unresolvedAny is the message of an Any whose type could not be resolved. It keeps the
+// raw value, which is rendered as base64 along with the type URL.
+type unresolvedAny struct {
+	value []byte
+}
+
+func (*unresolvedAny) ProtoMessage() {}
+
+func (m *unresolvedAny) Reset() { m.value = nil }
+
+func (m *unresolvedAny) String() string { return base64.StdEncoding.EncodeToString(m.value) }
+
+// Unmarshal keeps the Any's value as is.
+func (m *unresolvedAny) Unmarshal(b []byte) error {
+	m.value = append([]byte(nil), b...)
+	return nil
+}
+
+// Marshal returns the Any's value as is.
+func (m *unresolvedAny) Marshal() ([]byte, error) {
+	return m.value, nil
+}
+
+// MarshalJSONPB renders the raw value, to which jsonpb adds the type URL.
+func (m *unresolvedAny) MarshalJSONPB(*jsonpb.Marshaler) ([]byte, error) {
+	return json.Marshal(map[string][]byte{"value": m.value})
 }
@@ -11,10 +11,22 @@ package proto
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/binary"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/redpanda-data/console/backend/pkg/config"
 )
 
 func Test_decodeConfluentBinaryWrapper(t *testing.T) {
@@ -35,3 +47,190 @@ func Test_decodeConfluentBinaryWrapper(t *testing.T) {
 	_, err := svc.decodeConfluentBinaryWrapper(buf.Bytes())
 	assert.Error(t, err)
 }
+
+func TestService_FileDescriptorSet(t *testing.T) {
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{
+			"shop/order.proto": `syntax = "proto3";
+package shop;
+import "google/protobuf/timestamp.proto";
+message Order {
+  string id = 1;
+  int32 quantity = 2;
+  google.protobuf.Timestamp created_at = 3;
+}`,
+		}),
+	}
+	fds, err := parser.ParseFiles("shop/order.proto")
+	require.NoError(t, err)
+	serialized, err := proto.Marshal(desc.ToFileDescriptorSet(fds...))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "types.pb")
+	require.NoError(t, os.WriteFile(path, serialized, 0o600))
+
+	msg := dynamic.NewMessage(fds[0].FindMessage("shop.Order"))
+	msg.SetFieldByName("id", "order-1")
+	msg.SetFieldByName("quantity", int32(3))
+	payload, err := msg.Marshal()
+	require.NoError(t, err)
+
+	sets := map[string]config.ProtoFileDescriptorSet{
+		"path":   {Path: path},
+		"inline": {Inline: base64.StdEncoding.EncodeToString(serialized)},
+	}
+	for name, set := range sets {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.Proto{
+				Enabled:            true,
+				FileDescriptorSets: []config.ProtoFileDescriptorSet{set},
+				Mappings:           []config.ProtoTopicMapping{{TopicName: "orders", ValueProtoType: "shop.Order"}},
+			}
+			require.NoError(t, cfg.Validate())
+
+			svc, err := NewService(cfg, zap.NewNop(), nil)
+			require.NoError(t, err)
+			require.NoError(t, svc.Start())
+
+			jsonBytes, schemaID, err := svc.UnmarshalPayload(payload, "orders", RecordValue, UnmarshalOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, 0, schemaID)
+			assert.JSONEq(t, `{"id":"order-1","quantity":3,"createdAt":null}`, string(jsonBytes))
+
+			_, _, err = svc.UnmarshalPayload(payload, "orders", RecordKey, UnmarshalOptions{})
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("message type without mappings", func(t *testing.T) {
+		cfg := config.Proto{
+			Enabled:            true,
+			FileDescriptorSets: []config.ProtoFileDescriptorSet{{Path: path}},
+		}
+		require.NoError(t, cfg.Validate())
+
+		svc, err := NewService(cfg, zap.NewNop(), nil)
+		require.NoError(t, err)
+		require.NoError(t, svc.Start())
+
+		jsonBytes, err := svc.UnmarshalPayloadWithMessageType(payload, "shop.Order", UnmarshalOptions{})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"order-1","quantity":3,"createdAt":null}`, string(jsonBytes))
+
+		_, err = svc.UnmarshalPayloadWithMessageType(payload, "shop.Unknown", UnmarshalOptions{})
+		assert.Error(t, err)
+		_, _, err = svc.UnmarshalPayload(payload, "orders", RecordValue, UnmarshalOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestService_AnyResolution(t *testing.T) {
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{
+			"shop/envelope.proto": `syntax = "proto3";
+package shop;
+import "google/protobuf/any.proto";
+message Envelope {
+  string id = 1;
+  google.protobuf.Any payload = 2;
+}`,
+			"shop/payment.proto": `syntax = "proto3";
+package shop;
+message Payment {
+  int32 amount = 1;
+}`,
+		}),
+	}
+	fds, err := parser.ParseFiles("shop/envelope.proto", "shop/payment.proto")
+	require.NoError(t, err)
+
+	// The payment type is not imported by the envelope, but part of another registry schema
+	descriptors := map[int]*desc.FileDescriptor{1: fds[0], 2: fds[1]}
+	svc := &Service{fileDescriptorsBySchemaID: descriptors, anyResolver: newAnyResolver(nil, descriptors)}
+
+	payment := dynamic.NewMessage(fds[1].FindMessage("shop.Payment"))
+	payment.SetFieldByName("amount", int32(5))
+	paymentBytes, err := payment.Marshal()
+	require.NoError(t, err)
+
+	envelopeWith := func(typeURL string) []byte {
+		envelope := dynamic.NewMessage(fds[0].FindMessage("shop.Envelope"))
+		envelope.SetFieldByName("id", "e-1")
+		envelope.SetFieldByName("payload", &anypb.Any{TypeUrl: typeURL, Value: paymentBytes})
+		payload, err := envelope.Marshal()
+		require.NoError(t, err)
+		return payload
+	}
+
+	t.Run("resolvable type", func(t *testing.T) {
+		jsonBytes, err := svc.UnmarshalPayloadWithSchemaID(envelopeWith("type.googleapis.com/shop.Payment"), 1, "shop.Envelope", UnmarshalOptions{})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"e-1","payload":{"@type":"type.googleapis.com/shop.Payment","amount":5}}`, string(jsonBytes))
+	})
+
+	t.Run("unresolvable type", func(t *testing.T) {
+		jsonBytes, err := svc.UnmarshalPayloadWithSchemaID(envelopeWith("type.googleapis.com/shop.Unknown"), 1, "shop.Envelope", UnmarshalOptions{})
+		require.NoError(t, err)
+		expected := `{"id":"e-1","payload":{"@type":"type.googleapis.com/shop.Unknown","value":"` +
+			base64.StdEncoding.EncodeToString(paymentBytes) + `"}}`
+		assert.JSONEq(t, expected, string(jsonBytes))
+	})
+}
+
+func TestService_EnumsAsNameAndNumber(t *testing.T) {
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{
+			"shop/account.proto": `syntax = "proto3";
+package shop;
+enum Status {
+  UNKNOWN = 0;
+  PENDING = 1;
+  ACTIVE = 2;
+}
+message Owner {
+  Status status = 1;
+}
+message Account {
+  string id = 1;
+  Status status = 2;
+  repeated Status history = 3;
+  map<string, Status> status_by_region = 4;
+  Owner owner = 5;
+}`,
+		}),
+	}
+	fds, err := parser.ParseFiles("shop/account.proto")
+	require.NoError(t, err)
+	svc := &Service{fileDescriptorsBySchemaID: map[int]*desc.FileDescriptor{1: fds[0]}}
+
+	md := fds[0].FindMessage("shop.Account")
+	owner := dynamic.NewMessage(fds[0].FindMessage("shop.Owner"))
+	owner.SetFieldByName("status", int32(1))
+	account := dynamic.NewMessage(md)
+	account.SetFieldByName("id", "a-1")
+	account.SetFieldByName("status", int32(2))
+	account.SetFieldByName("history", []int32{1, 7})
+	account.SetFieldByName("status_by_region", map[string]int32{"eu": 2, "us": 9})
+	account.SetFieldByName("owner", owner)
+	payload, err := account.Marshal()
+	require.NoError(t, err)
+
+	t.Run("names only", func(t *testing.T) {
+		jsonBytes, err := svc.UnmarshalPayloadWithSchemaID(payload, 1, "shop.Account", UnmarshalOptions{})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"a-1","status":"ACTIVE","history":["PENDING",7],`+
+			`"statusByRegion":{"eu":"ACTIVE","us":9},"owner":{"status":"PENDING"}}`, string(jsonBytes))
+	})
+
+	t.Run("name and number", func(t *testing.T) {
+		jsonBytes, err := svc.UnmarshalPayloadWithSchemaID(payload, 1, "shop.Account", UnmarshalOptions{EnumsAsNameAndNumber: true})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"id": "a-1",
+			"status": {"value": 2, "name": "ACTIVE"},
+			"history": [{"value": 1, "name": "PENDING"}, {"value": 7, "name": ""}],
+			"statusByRegion": {"eu": {"value": 2, "name": "ACTIVE"}, "us": {"value": 9, "name": ""}},
+			"owner": {"status": {"value": 1, "name": "PENDING"}}
+		}`, string(jsonBytes))
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"github.com/hamba/avro/v2"
+)
+
+// encryptRuleType is the data contract rule type that is used for client-side field
+// level encryption (CSFLE).
+const encryptRuleType = "ENCRYPT"
+
+// confluentTagsProp is the Avro field property that carries the tags of a field.
+const confluentTagsProp = "confluent:tags"
+
+// EncryptedAvroFieldName returns the name under which an encrypted field is reported by
+// GetAvroEncryptedFieldsByID.
+func EncryptedAvroFieldName(record *avro.RecordSchema, field *avro.Field) string {
+	return record.FullName() + "." + field.Name()
+}
+
+// encryptedAvroFields returns the set of fields that are encrypted via client-side field level
+// encryption. A field is encrypted if it carries a tag, either inline in the schema or via the
+// schema's metadata, that is targeted by an enabled ENCRYPT domain rule.
+func encryptedAvroFields(sch avro.Schema, metadata *SchemaMetadata, ruleSet *SchemaRuleSet) map[string]struct{} {
+	if ruleSet == nil {
+		return nil
+	}
+	encryptedTags := make(map[string]struct{})
+	for _, rule := range ruleSet.DomainRules {
+		if rule.Type != encryptRuleType || rule.Disabled {
+			continue
+		}
+		for _, tag := range rule.Tags {
+			encryptedTags[tag] = struct{}{}
+		}
+	}
+	if len(encryptedTags) == 0 {
+		return nil
+	}
+
+	isEncrypted := func(record *avro.RecordSchema, field *avro.Field) bool {
+		var tags []string
+		if inlineTags, ok := field.Prop(confluentTagsProp).([]any); ok {
+			for _, tag := range inlineTags {
+				if tagStr, ok := tag.(string); ok {
+					tags = append(tags, tagStr)
+				}
+			}
+		}
+		if metadata != nil {
+			tags = append(tags, metadata.Tags[EncryptedAvroFieldName(record, field)]...)
+		}
+		for _, tag := range tags {
+			if _, exists := encryptedTags[tag]; exists {
+				return true
+			}
+		}
+		return false
+	}
+
+	fields := make(map[string]struct{})
+	visited := make(map[string]struct{})
+	var walk func(sch avro.Schema)
+	walk = func(sch avro.Schema) {
+		switch s := sch.(type) {
+		case *avro.RecordSchema:
+			if _, exists := visited[s.FullName()]; exists {
+				return
+			}
+			visited[s.FullName()] = struct{}{}
+			for _, field := range s.Fields() {
+				if isEncrypted(s, field) {
+					fields[EncryptedAvroFieldName(s, field)] = struct{}{}
+				}
+				walk(field.Type())
+			}
+		case *avro.RefSchema:
+			walk(s.Schema())
+		case *avro.ArraySchema:
+			walk(s.Items())
+		case *avro.MapSchema:
+			walk(s.Values())
+		case *avro.UnionSchema:
+			for _, t := range s.Types() {
+				walk(t)
+			}
+		}
+	}
+	walk(sch)
+
+	return fields
+}
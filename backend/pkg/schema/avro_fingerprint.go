@@ -0,0 +1,99 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/pkg/crc64"
+	"go.uber.org/zap"
+)
+
+// AvroFingerprint returns the CRC-64-AVRO fingerprint of the schema's parsing canonical form,
+// as used by Avro's single object encoding.
+func AvroFingerprint(sch avro.Schema) uint64 {
+	// The hasher is created per call, because the fingerprinters of the avro package
+	// share a single hasher and must not be used concurrently.
+	h := crc64.New()
+	_, _ = h.Write([]byte(sch.String()))
+	return h.Sum64()
+}
+
+// AvroFingerprintIndex maps the CRC-64-AVRO fingerprints of all registered Avro schemas
+// to their schema IDs.
+type AvroFingerprintIndex struct {
+	SchemaIDsByFingerprint map[uint64]uint32
+	BuiltAt                time.Time
+}
+
+// Lookup returns the ID of the schema with the given fingerprint, or false if there is none.
+func (i *AvroFingerprintIndex) Lookup(fingerprint uint64) (uint32, bool) {
+	schemaID, ok := i.SchemaIDsByFingerprint[fingerprint]
+	return schemaID, ok
+}
+
+// GetAvroFingerprintIndex returns the cached fingerprint index or builds it if it's not
+// cached yet.
+func (s *Service) GetAvroFingerprintIndex(ctx context.Context) (*AvroFingerprintIndex, error) {
+	index, err := cachedGet(ctx, s.avroFingerprintIndex, struct{}{}, func() (*AvroFingerprintIndex, error) {
+		return s.buildAvroFingerprintIndex(ctx)
+	})
+	return index, err
+}
+
+// GetAvroSchemaIDByFingerprint returns the ID of the registered Avro schema with the given
+// CRC-64-AVRO fingerprint.
+func (s *Service) GetAvroSchemaIDByFingerprint(ctx context.Context, fingerprint uint64) (uint32, error) {
+	index, err := s.GetAvroFingerprintIndex(ctx)
+	if err != nil {
+		return 0, err
+	}
+	schemaID, ok := index.Lookup(fingerprint)
+	if !ok {
+		return 0, fmt.Errorf("no avro schema with fingerprint %016x", fingerprint)
+	}
+	return schemaID, nil
+}
+
+// buildAvroFingerprintIndex parses all registered Avro schemas along with their references
+// and indexes them by fingerprint. If multiple schema IDs share a fingerprint, the lowest ID
+// is indexed. Schemas that can't be parsed are skipped.
+func (s *Service) buildAvroFingerprintIndex(ctx context.Context) (*AvroFingerprintIndex, error) {
+	schemas, err := s.registryClient.GetSchemas(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schemas: %w", err)
+	}
+
+	schemaIDsByFingerprint := make(map[uint64]uint32)
+	for _, schemaRes := range schemas {
+		if schemaRes.Type != TypeAvro {
+			continue
+		}
+		schemaID := uint32(schemaRes.SchemaID)
+		sch, err := s.GetAvroSchemaByID(ctx, schemaID)
+		if err != nil {
+			s.logger.Debug("skipping avro schema that can't be parsed for the fingerprint index",
+				zap.Uint32("schema_id", schemaID), zap.Error(err))
+			continue
+		}
+		fingerprint := AvroFingerprint(sch)
+		if existing, ok := schemaIDsByFingerprint[fingerprint]; !ok || schemaID < existing {
+			schemaIDsByFingerprint[fingerprint] = schemaID
+		}
+	}
+
+	return &AvroFingerprintIndex{
+		SchemaIDsByFingerprint: schemaIDsByFingerprint,
+		BuiltAt:                time.Now(),
+	}, nil
+}
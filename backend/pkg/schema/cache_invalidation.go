@@ -0,0 +1,67 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"strings"
+
+	"github.com/twmb/go-cache/cache"
+)
+
+// InvalidateCache purges all cached schemas, so that they are fetched from the schema
+// registry again on next access. This is useful after schemas have been changed directly
+// in the schema registry, which otherwise are only picked up once the cache entries expire.
+func (s *Service) InvalidateCache() {
+	purgeCache(s.schemaBySubjectVersion, func(string) bool { return true })
+	purgeCache(s.avroSchemaByID, func(uint32) bool { return true })
+	purgeCache(s.schemaIDIndex, func(bool) bool { return true })
+	purgeCache(s.subjectVersionsByID, func(uint32) bool { return true })
+	purgeCache(s.avroFingerprintIndex, func(struct{}) bool { return true })
+	purgeCache(s.referenceGraphs, func(string) bool { return true })
+	s.registryClient.InvalidateSchemasByID()
+}
+
+// InvalidateSchemaID purges the cached schema with the given ID. The reverse index of
+// schema IDs and the reference graphs are purged as well, as they may refer to the schema.
+func (s *Service) InvalidateSchemaID(schemaID uint32) {
+	s.avroSchemaByID.Delete(schemaID)
+	s.subjectVersionsByID.Delete(schemaID)
+	s.registryClient.InvalidateSchemaByID(schemaID)
+	purgeCache(s.schemaIDIndex, func(bool) bool { return true })
+	purgeCache(s.referenceGraphs, func(string) bool { return true })
+}
+
+// InvalidateSubject purges all cached versions of the given subject. The reverse index of
+// schema IDs and the reference graphs are purged as well, as they may refer to the subject
+// versions.
+func (s *Service) InvalidateSubject(subject string) {
+	purgeCache(s.schemaBySubjectVersion, func(key string) bool {
+		// Keys are formatted as <subject>v<version>, and versions never contain a "v"
+		idx := strings.LastIndexByte(key, 'v')
+		return idx != -1 && key[:idx] == subject
+	})
+	purgeCache(s.schemaIDIndex, func(bool) bool { return true })
+	purgeCache(s.subjectVersionsByID, func(uint32) bool { return true })
+	purgeCache(s.referenceGraphs, func(string) bool { return true })
+}
+
+// purgeCache deletes all cache entries whose key matches.
+func purgeCache[K comparable, V any](c *cache.Cache[K, V], matches func(K) bool) {
+	var keys []K
+	c.Range(func(k K, _ V, _ error) bool {
+		if matches(k) {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	for _, k := range keys {
+		c.Delete(k)
+	}
+}
@@ -11,16 +11,26 @@ package schema
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/pem"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/redpanda-data/console/backend/pkg/config"
 )
@@ -29,6 +39,19 @@ import (
 type Client struct {
 	cfg    config.Schema
 	client *resty.Client
+
+	// serverInfo is cached once the registry reported its version.
+	serverInfo      *ServerInfo
+	serverInfoMutex sync.Mutex
+
+	// registryInfo is cached after the first successful call of GetRegistryInfo.
+	registryInfo      *RegistryInfo
+	registryInfoMutex sync.Mutex
+	// bulkListingUnsupported is set once the registry responded with 404 to GET /schemas.
+	bulkListingUnsupported atomic.Bool
+
+	// schemaByID caches the responses of GetSchemaByID. It's nil if caching is disabled.
+	schemaByID *schemaIDCache
 }
 
 // RestError represents the schema of the generic REST error that is returned
@@ -42,9 +65,52 @@ func (e RestError) Error() string {
 	return fmt.Sprintf("schema registry request failed: %d - %s", e.ErrorCode, e.Message)
 }
 
-func newClient(cfg config.Schema) (*Client, error) {
-	// TODO: Add support to fallback to other registry urls if provided
-	registryURL := cfg.URLs[0] // Array length is checked in config validate()
+// Is reports whether the error matches the given sentinel error, so that errors.Is can
+// be used to check for well-known error codes.
+func (e RestError) Is(target error) bool {
+	code, ok := errorCodesBySentinel[target]
+	return ok && e.ErrorCode == code
+}
+
+// normalizeRegistryURL validates the registry URL and removes trailing slashes, so that
+// registries that are served under a path prefix (e.g. behind a gateway) can be configured
+// with or without trailing slash. All request paths are appended to the path prefix.
+func normalizeRegistryURL(registryURL string) (string, error) {
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse schema registry url %q: %w", registryURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("schema registry url %q must be absolute, e.g. https://host:8081", registryURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("schema registry url %q must not contain a query or fragment", registryURL)
+	}
+	if u.Path != "" {
+		u.Path = strings.TrimRight(path.Clean(u.Path), "/")
+		u.RawPath = ""
+	}
+	return u.String(), nil
+}
+
+const (
+	// defaultRequestTimeout is the timeout of requests to the registry, if none is configured.
+	defaultRequestTimeout = 5 * time.Second
+	// defaultRetryBackoff is the wait time before the first retry, if none is configured.
+	defaultRetryBackoff = 100 * time.Millisecond
+)
+
+func newClient(cfg config.Schema, logger *zap.Logger, metrics *clientMetrics) (*Client, error) {
+	// Array length is checked in config validate()
+	registryURLs := make([]string, len(cfg.URLs))
+	for i, u := range cfg.URLs {
+		normalized, err := normalizeRegistryURL(u)
+		if err != nil {
+			return nil, err
+		}
+		registryURLs[i] = normalized
+	}
+	registryURL := registryURLs[0]
 
 	client := resty.New().
 		SetBaseURL(registryURL).
@@ -52,13 +118,39 @@ func newClient(cfg config.Schema) (*Client, error) {
 		SetHeader("Accept", "application/vnd.schemaregistry.v1+json").
 		SetHeader("Content-Type", "application/vnd.schemaregistry.v1+json").
 		SetError(&RestError{}).
-		SetTimeout(5 * time.Second)
+		SetTimeout(defaultRequestTimeout).
+		OnAfterResponse(checkResponseBody).
+		SetPreRequestHook(applyContextCredentials)
+	client.JSONUnmarshal = unmarshalJSONWithSnippet
+
+	if cfg.RequestTimeout > 0 {
+		client.SetTimeout(cfg.RequestTimeout)
+	}
+
+	// Retry GET requests only, as other requests modify the registry and are not
+	// necessarily idempotent
+	if cfg.RetryAttempts > 0 {
+		retryBackoff := cfg.RetryBackoff
+		if retryBackoff <= 0 {
+			retryBackoff = defaultRetryBackoff
+		}
+		client.SetRetryCount(cfg.RetryAttempts).
+			SetRetryWaitTime(retryBackoff).
+			SetRetryMaxWaitTime(retryBackoff << cfg.RetryAttempts).
+			AddRetryCondition(func(res *resty.Response, err error) bool {
+				if res == nil || res.Request == nil || res.Request.Method != http.MethodGet {
+					return false
+				}
+				// 501 is returned for endpoints that aren't implemented, which is not transient
+				return err != nil || (res.StatusCode() >= http.StatusInternalServerError && res.StatusCode() != http.StatusNotImplemented)
+			})
+	}
 
 	// Configure credentials
 	if cfg.Username != "" {
 		client = client.SetBasicAuth(cfg.Username, cfg.Password)
 	}
-	if cfg.BearerToken != "" {
+	if cfg.BearerToken != "" && !cfg.OAuth.Enabled() {
 		client = client.SetAuthToken(cfg.BearerToken)
 	}
 
@@ -77,44 +169,128 @@ func newClient(cfg config.Schema) (*Client, error) {
 			}
 		}
 
-		// If configured load TLS cert & key - Mutual TLS
-		var certificates []tls.Certificate
+		tlsCfg := &tls.Config{
+			//nolint:gosec // InsecureSkipVerify may be true upon user's responsibility.
+			InsecureSkipVerify: cfg.TLS.InsecureSkipTLSVerify,
+			RootCAs:            caCertPool,
+		}
+
+		// If configured load TLS cert & key - Mutual TLS. The certificate is reloaded once
+		// the files have been rotated.
 		if cfg.TLS.CertFilepath != "" && cfg.TLS.KeyFilepath != "" {
-			cert, err := os.ReadFile(cfg.TLS.CertFilepath)
+			reloader, err := newClientCertReloader(cfg.TLS.CertFilepath, cfg.TLS.KeyFilepath)
 			if err != nil {
-				return nil, fmt.Errorf("failed to read cert file for schema registry client: %w", err)
+				return nil, err
 			}
+			tlsCfg.GetClientCertificate = reloader.GetClientCertificate
+		}
 
-			privateKey, err := os.ReadFile(cfg.TLS.KeyFilepath)
+		// If certificate fingerprints are pinned we replace the chain verification with our
+		// own check, so that self-signed certificates can be used without skipping verification.
+		if len(cfg.TLS.PinnedCertFingerprints) > 0 {
+			verifyPeerCertificate, err := newPinnedCertVerifier(cfg.TLS.PinnedCertFingerprints)
 			if err != nil {
-				return nil, fmt.Errorf("failed to read key file for schema registry client: %w", err)
+				return nil, err
 			}
+			tlsCfg.InsecureSkipVerify = true
+			tlsCfg.VerifyPeerCertificate = verifyPeerCertificate
+		}
 
-			pemBlock, _ := pem.Decode(privateKey)
-			if pemBlock == nil {
-				return nil, fmt.Errorf("no valid private key found")
-			}
+		transport := &http.Transport{TLSClientConfig: tlsCfg}
 
-			tlsCert, err := tls.X509KeyPair(cert, privateKey)
-			if err != nil {
-				return nil, fmt.Errorf("failed to load certificate pair for schema registry client: %w", err)
-			}
-			certificates = []tls.Certificate{tlsCert}
-		}
+		client.SetTransport(transport)
+	}
 
-		transport := &http.Transport{TLSClientConfig: &tls.Config{
-			//nolint:gosec // InsecureSkipVerify may be true upon user's responsibility.
-			InsecureSkipVerify: cfg.TLS.InsecureSkipTLSVerify,
-			Certificates:       certificates,
-			RootCAs:            caCertPool,
-		}}
+	// Route requests through the configured proxy. The custom TLS transport would not honor
+	// the proxy env vars otherwise, so that these are set explicitly as fallback.
+	proxy, err := newProxyFunc(cfg.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	if transport, ok := client.GetClient().Transport.(*http.Transport); ok {
+		transport.Proxy = proxy
+	}
 
+	// Tokens are requested through the same transport, so that the identity provider is
+	// reached with the configured TLS settings and proxy
+	if cfg.OAuth.Enabled() {
+		tokenClient := &http.Client{Transport: client.GetClient().Transport, Timeout: client.GetClient().Timeout}
+		client = client.OnBeforeRequest(newOAuthMiddleware(cfg.OAuth, tokenClient))
+	}
+
+	metrics.instrument(client)
+	logRequests(client, logger)
+
+	// Fail over to the other registry urls, if multiple are configured
+	if len(registryURLs) > 1 {
+		next := client.GetClient().Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		transport, err := newFailoverTransport(next, registryURLs, logger)
+		if err != nil {
+			return nil, err
+		}
 		client.SetTransport(transport)
 	}
 
 	return &Client{
-		cfg:    cfg,
-		client: client,
+		cfg:        cfg,
+		client:     client,
+		schemaByID: newSchemaIDCache(cfg.SchemaIDCacheSize, cfg.SchemaIDCacheTTL),
+	}, nil
+}
+
+// MaxRequestAttempts returns how many times a request with the given method is sent at most.
+// Each attempt of resty fails over to all configured registry URLs, and only GET requests are
+// retried by resty, hence GET requests are sent up to (retry attempts + 1) × URLs times.
+func (c *Client) MaxRequestAttempts(method string) int {
+	attempts := len(c.cfg.URLs)
+	if attempts == 0 {
+		attempts = 1
+	}
+	if method == http.MethodGet {
+		attempts *= c.cfg.RetryAttempts + 1
+	}
+	return attempts
+}
+
+// newProxyFunc returns the proxy function for http.Transport that sends all requests through
+// the given proxy URL, or the proxy selected by the environment variables if it is empty.
+func newProxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy url %q: %w", proxyURL, err)
+	}
+	return http.ProxyURL(parsed), nil
+}
+
+// newPinnedCertVerifier returns a callback for tls.Config.VerifyPeerCertificate that accepts
+// the connection only if the leaf certificate has one of the given SHA-256 fingerprints.
+// Other certificates of the chain are ignored, as anyone can present the public pinned
+// certificate as an intermediate of their own chain.
+func newPinnedCertVerifier(fingerprints []string) (func([][]byte, [][]*x509.Certificate) error, error) {
+	pinned := make(map[[sha256.Size]byte]struct{}, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		decoded, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("pinned certificate fingerprint %q is not a hex encoded SHA-256 hash", fingerprint)
+		}
+		var key [sha256.Size]byte
+		copy(key[:], decoded)
+		pinned[key] = struct{}{}
+	}
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) > 0 {
+			if _, exists := pinned[sha256.Sum256(rawCerts[0])]; exists {
+				return nil
+			}
+		}
+		return fmt.Errorf("schema registry presented no leaf certificate matching any of the pinned fingerprints")
 	}, nil
 }
 
@@ -123,19 +299,66 @@ func newClient(cfg config.Schema) (*Client, error) {
 //
 //nolint:revive // This is stuttering when calling this with the pkg name, but without that the
 type SchemaResponse struct {
+	// Type is the schema's type. The registry omits it for Avro schemas.
+	Type       SchemaType        `json:"schemaType"`
 	Schema     string            `json:"schema"`
 	References []SchemaReference `json:"references,omitempty"`
+	// GUID is a globally unique identifier of the schema, which is only returned by some
+	// registries in addition to the ID.
+	GUID string `json:"guid,omitempty"`
+
+	// Metadata and RuleSet are part of Confluent's data contracts. They are only
+	// returned by registries that support data contracts.
+	Metadata *SchemaMetadata `json:"metadata,omitempty"`
+	RuleSet  *SchemaRuleSet  `json:"ruleSet,omitempty"`
 }
 
 // GetSchemaByID returns the schema string identified by the input ID.
 // id (int) – the globally unique identifier of the schema
+//
+// Responses are cached if a schema ID cache size is configured. Concurrent requests for the
+// same uncached ID are then coalesced into a single request.
 func (c *Client) GetSchemaByID(ctx context.Context, id uint32) (*SchemaResponse, error) {
-	url := fmt.Sprintf("/schemas/ids/%d", id)
+	if _, hasCredentials := credentialsFromContext(ctx); c.schemaByID == nil || hasCredentials {
+		return c.fetchSchemaByID(ctx, id)
+	}
+	return c.schemaByID.getOrFetch(id, func() (*SchemaResponse, error) {
+		return c.fetchSchemaByID(ctx, id)
+	})
+}
+
+// cachedSchemaByID returns the schema with the given ID if it's cached by GetSchemaByID.
+// Requests with credentials of the context are never served from the cache.
+func (c *Client) cachedSchemaByID(ctx context.Context, id uint32) (*SchemaResponse, bool) {
+	if _, hasCredentials := credentialsFromContext(ctx); c.schemaByID == nil || hasCredentials {
+		return nil, false
+	}
+	return c.schemaByID.get(id)
+}
+
+// InvalidateSchemaByID removes the schema with the given ID from the cache of GetSchemaByID,
+// so that it's fetched from the registry again on next access.
+func (c *Client) InvalidateSchemaByID(id uint32) {
+	if c.schemaByID != nil {
+		c.schemaByID.invalidate(id)
+	}
+}
+
+// InvalidateSchemasByID removes all schemas from the cache of GetSchemaByID.
+func (c *Client) InvalidateSchemasByID() {
+	if c.schemaByID != nil {
+		c.schemaByID.invalidateAll()
+	}
+}
+
+func (c *Client) fetchSchemaByID(ctx context.Context, id uint32) (*SchemaResponse, error) {
 	req := c.client.R().
 		SetContext(ctx).
-		SetResult(&SchemaResponse{})
+		SetResult(&SchemaResponse{}).
+		SetPathParam("id", strconv.FormatUint(uint64(id), 10))
+	c.setContextQueryParam(req)
 
-	res, err := req.Get(url)
+	res, err := req.Get("/schemas/ids/{id}")
 	if err != nil {
 		return nil, fmt.Errorf("get schema by id request failed: %w", err)
 	}
@@ -167,6 +390,35 @@ type SchemaVersionedResponse struct {
 	Schema     string            `json:"schema"`
 	Type       SchemaType        `json:"schemaType"`
 	References []SchemaReference `json:"references"`
+
+	// Metadata and RuleSet are part of Confluent's data contracts. They are only
+	// returned by registries that support data contracts.
+	Metadata *SchemaMetadata `json:"metadata,omitempty"`
+	RuleSet  *SchemaRuleSet  `json:"ruleSet,omitempty"`
+}
+
+// SchemaRuleSet is a set of data contract rules that is attached to a schema version.
+type SchemaRuleSet struct {
+	// MigrationRules transform data between schema versions (e.g. after breaking changes).
+	MigrationRules []SchemaRule `json:"migrationRules,omitempty"`
+	// DomainRules are applied to data that is written or read with this schema version.
+	DomainRules []SchemaRule `json:"domainRules,omitempty"`
+}
+
+// SchemaRule is a single data contract rule, such as a data quality condition
+// or a field transformation.
+type SchemaRule struct {
+	Name      string            `json:"name"`
+	Doc       string            `json:"doc,omitempty"`
+	Kind      string            `json:"kind"` // TRANSFORM or CONDITION
+	Mode      string            `json:"mode"` // UPGRADE, DOWNGRADE, UPDOWN, WRITE, READ or WRITEREAD
+	Type      string            `json:"type"`
+	Tags      []string          `json:"tags,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	Expr      string            `json:"expr,omitempty"`
+	OnSuccess string            `json:"onSuccess,omitempty"`
+	OnFailure string            `json:"onFailure,omitempty"`
+	Disabled  bool              `json:"disabled,omitempty"`
 }
 
 // GetSchemaBySubject returns the schema for the specified version of this subject. The unescaped schema only is returned.
@@ -180,7 +432,7 @@ func (c *Client) GetSchemaBySubject(ctx context.Context, subject, version string
 		SetContext(ctx).
 		SetResult(&SchemaVersionedResponse{}).
 		SetPathParams(map[string]string{
-			"subjects": subject,
+			"subjects": c.contextSubject(subject),
 			"version":  version,
 		})
 	if showSoftDeleted {
@@ -232,8 +484,72 @@ type SubjectsResponse struct {
 	Subjects []string // Subject names
 }
 
-// GetSubjects returns a list of registered subjects.
+// GetSubjects returns a list of registered subjects. If a schema context is configured,
+// only the subjects of that context are returned, with their qualified names.
 func (c *Client) GetSubjects(ctx context.Context, showSoftDeleted bool) (*SubjectsResponse, error) {
+	prefix := ""
+	if c.cfg.Context != "" {
+		prefix = ContextSubjectPrefix(c.cfg.Context)
+	}
+	return c.GetSubjectsByPrefix(ctx, prefix, showSoftDeleted)
+}
+
+// contextSubject prefixes the subject name with the configured schema context, unless no
+// context is configured or the subject is qualified with a context already.
+func (c *Client) contextSubject(subject string) string {
+	if c.cfg.Context == "" || strings.HasPrefix(subject, ":.") {
+		return subject
+	}
+	return ContextSubjectPrefix(c.cfg.Context) + subject
+}
+
+// setContextQueryParam scopes a lookup by schema ID to the configured schema context, as
+// schema IDs are only unique within a context.
+func (c *Client) setContextQueryParam(req *resty.Request) {
+	if c.cfg.Context != "" {
+		req.SetQueryParam("subject", ContextSubjectPrefix(c.cfg.Context))
+	}
+}
+
+// GetContexts returns the names of all schema contexts (e.g. ".tenant-a"). The default
+// context is returned as ".".
+func (c *Client) GetContexts(ctx context.Context) ([]string, error) {
+	var contexts []string
+	res, err := c.client.R().
+		SetContext(ctx).
+		SetResult(&contexts).
+		Get("/contexts")
+	if err != nil {
+		return nil, fmt.Errorf("get contexts failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("get contexts failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	return contexts, nil
+}
+
+// allContextsSubjectPrefix is the subject prefix that matches the subjects of all schema
+// contexts.
+const allContextsSubjectPrefix = ":*:"
+
+// ContextSubjectPrefix returns the subject prefix that matches all subjects of the given
+// schema context, e.g. ":.tenant-a:" for the context "tenant-a".
+func ContextSubjectPrefix(contextName string) string {
+	return ":." + strings.TrimPrefix(contextName, ".") + ":"
+}
+
+// GetSubjectsByPrefix returns a list of registered subjects that start with the given prefix.
+// Subjects of schema contexts can be listed with a prefix such as ":.tenant-a:" (see
+// ContextSubjectPrefix), or ":*:" for all contexts. An empty prefix returns all subjects of
+// the default context. Registries that don't support the prefix filter return all subjects,
+// which are then filtered by the client.
+func (c *Client) GetSubjectsByPrefix(ctx context.Context, prefix string, showSoftDeleted bool) (*SubjectsResponse, error) {
 	req := c.client.R().
 		SetContext(ctx).
 		SetResult([]string{})
@@ -241,6 +557,9 @@ func (c *Client) GetSubjects(ctx context.Context, showSoftDeleted bool) (*Subjec
 	if showSoftDeleted {
 		req.SetQueryParam("deleted", "true")
 	}
+	if prefix != "" {
+		req.SetQueryParam("subjectPrefix", prefix)
+	}
 
 	res, err := req.Get("/subjects")
 	if err != nil {
@@ -253,8 +572,19 @@ func (c *Client) GetSubjects(ctx context.Context, showSoftDeleted bool) (*Subjec
 		return nil, fmt.Errorf("failed to parse subjects response")
 	}
 
+	subjects := *parsed
+	if prefix != "" && prefix != allContextsSubjectPrefix {
+		filtered := make([]string, 0, len(subjects))
+		for _, subject := range subjects {
+			if strings.HasPrefix(subject, prefix) {
+				filtered = append(filtered, subject)
+			}
+		}
+		subjects = filtered
+	}
+
 	return &SubjectsResponse{
-		Subjects: *parsed,
+		Subjects: subjects,
 	}, nil
 }
 
@@ -269,7 +599,7 @@ func (c *Client) GetSubjectVersions(ctx context.Context, subject string, showSof
 	req := c.client.R().
 		SetContext(ctx).
 		SetResult([]int{}).
-		SetPathParam("subject", subject)
+		SetPathParam("subject", c.contextSubject(subject))
 
 	if showSoftDeleted {
 		req.SetQueryParam("deleted", "true")
@@ -330,11 +660,106 @@ func (c *Client) GetMode(ctx context.Context) (*ModeResponse, error) {
 	return parsed, nil
 }
 
+// validateMode returns an error if the given mode is not one of IMPORT, READONLY or READWRITE.
+func validateMode(mode string) error {
+	switch mode {
+	case "IMPORT", "READONLY", "READWRITE":
+		return nil
+	default:
+		return fmt.Errorf("invalid mode %q, must be one of IMPORT, READONLY or READWRITE", mode)
+	}
+}
+
+// SetMode sets the mode for Schema Registry at a global level.
+func (c *Client) SetMode(ctx context.Context, mode string) (*ModeResponse, error) {
+	if err := validateMode(mode); err != nil {
+		return nil, err
+	}
+
+	res, err := c.client.R().
+		SetContext(ctx).
+		SetResult(&ModeResponse{}).
+		SetBody(&ModeResponse{Mode: mode}).
+		Put("/mode")
+	if err != nil {
+		return nil, fmt.Errorf("set mode request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("set mode request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*ModeResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse mode response")
+	}
+
+	return parsed, nil
+}
+
+// SetSubjectMode sets the mode for a given subject, overriding the global mode.
+func (c *Client) SetSubjectMode(ctx context.Context, subject string, mode string) (*ModeResponse, error) {
+	if err := validateMode(mode); err != nil {
+		return nil, err
+	}
+
+	res, err := c.client.R().
+		SetContext(ctx).
+		SetResult(&ModeResponse{}).
+		SetBody(&ModeResponse{Mode: mode}).
+		SetPathParam("subject", c.contextSubject(subject)).
+		Put("/mode/{subject}")
+	if err != nil {
+		return nil, fmt.Errorf("set subject mode request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("set subject mode request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*ModeResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse mode response")
+	}
+
+	return parsed, nil
+}
+
 // ConfigResponse is the response schema for the schema registry's /config endpoint.
 type ConfigResponse struct {
 	// Global compatibility level. Will be one of:
 	// BACKWARD, BACKWARD_TRANSITIVE, FORWARD, FORWARD_TRANSITIVE, FULL, FULL_TRANSITIVE, NONE, DEFAULT (only for subject configs)
 	Compatibility CompatibilityLevel `json:"compatibilityLevel"`
+
+	// The fields below are only returned by newer schema registries and are
+	// therefore empty if the registry does not support them.
+
+	// CompatibilityGroup is the name of a metadata property. Compatibility is only
+	// checked against schemas that have the same value for this property.
+	CompatibilityGroup string `json:"compatibilityGroup,omitempty"`
+	// DefaultMetadata is the metadata that is applied to new schemas by default.
+	DefaultMetadata *SchemaMetadata `json:"defaultMetadata,omitempty"`
+	// Normalize indicates whether schemas are normalized when registered or looked up.
+	Normalize *bool `json:"normalize,omitempty"`
+	// Alias is the name of another subject this subject refers to. Lookups of this
+	// subject are resolved to the aliased subject.
+	Alias string `json:"alias,omitempty"`
+}
+
+// SchemaMetadata is the metadata that can be attached to schemas or configured as default
+// in the schema registry config.
+type SchemaMetadata struct {
+	Tags       map[string][]string `json:"tags,omitempty"`
+	Properties map[string]string   `json:"properties,omitempty"`
+	Sensitive  []string            `json:"sensitive,omitempty"`
 }
 
 // GetConfig gets global compatibility level.
@@ -368,6 +793,8 @@ func (c *Client) GetConfig(ctx context.Context) (*ConfigResponse, error) {
 type PutConfigResponse struct {
 	// Compatibility after setting the compat level.
 	Compatibility CompatibilityLevel `json:"compatibility"`
+	// Alias after setting the subject alias.
+	Alias string `json:"alias,omitempty"`
 }
 
 // PutConfig sets the global compatibility level.
@@ -410,7 +837,7 @@ func (c *Client) GetSubjectConfig(ctx context.Context, subject string) (*ConfigR
 	res, err := c.client.R().
 		SetContext(ctx).
 		SetResult(&ConfigResponse{}).
-		SetPathParam("subject", subject).
+		SetPathParam("subject", c.contextSubject(subject)).
 		SetPathParam("defaultToGlobal", "true").
 		Get("/config/{subject}")
 	if err != nil {
@@ -423,7 +850,7 @@ func (c *Client) GetSubjectConfig(ctx context.Context, subject string) (*ConfigR
 			return nil, fmt.Errorf("get config for subject failed: Status code %d", res.StatusCode())
 		}
 
-		if restErr.ErrorCode == CodeSubjectNotFound || restErr.ErrorCode == CodeSubjectCompatibilityNotConfigured {
+		if errors.Is(restErr, ErrSubjectNotFound) || errors.Is(restErr, ErrSubjectCompatibilityNotConfigured) {
 			return &ConfigResponse{
 				Compatibility: CompatDefault,
 			}, nil
@@ -439,6 +866,45 @@ func (c *Client) GetSubjectConfig(ctx context.Context, subject string) (*ConfigR
 	return parsed, nil
 }
 
+// SubjectConfigResponse is the config of a subject along with the compatibility level that
+// effectively applies to it.
+type SubjectConfigResponse struct {
+	ConfigResponse
+
+	// EffectiveCompatibility is the subject's compatibility level, or the global compatibility
+	// level if the subject has none configured. It's empty if the global level is unknown.
+	EffectiveCompatibility CompatibilityLevel `json:"effectiveCompatibilityLevel,omitempty"`
+	// IsInherited is true if the subject has no compatibility level configured, so that the
+	// effective level is inherited from the global config.
+	IsInherited bool `json:"isInherited"`
+}
+
+// GetEffectiveSubjectConfig gets the config for a given subject like GetSubjectConfig, but
+// additionally resolves the compatibility level that applies to the subject. If the subject
+// has no compatibility level of its own, the global config is consulted. If the global
+// config can't be retrieved, the subject config is returned without an effective level.
+func (c *Client) GetEffectiveSubjectConfig(ctx context.Context, subject string) (*SubjectConfigResponse, error) {
+	subjectConfig, err := c.GetSubjectConfig(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SubjectConfigResponse{ConfigResponse: *subjectConfig}
+	if subjectConfig.Compatibility != 0 && subjectConfig.Compatibility != CompatDefault {
+		res.EffectiveCompatibility = subjectConfig.Compatibility
+		return res, nil
+	}
+
+	res.IsInherited = true
+	globalConfig, err := c.GetConfig(ctx)
+	if err != nil {
+		// The subject config is still worth showing, even if the inherited level is unknown
+		return res, nil
+	}
+	res.EffectiveCompatibility = globalConfig.Compatibility
+	return res, nil
+}
+
 // PutSubjectConfig sets compatibility level for a given subject.
 // If the subject you ask about does not have a subject-specific compatibility level set, this command returns an
 // error code.
@@ -452,7 +918,7 @@ func (c *Client) PutSubjectConfig(ctx context.Context, subject string, compatLev
 		SetContext(ctx).
 		SetResult(&PutConfigResponse{}).
 		SetBody(&payload).
-		SetPathParam("subject", subject).
+		SetPathParam("subject", c.contextSubject(subject)).
 		Put("/config/{subject}")
 	if err != nil {
 		return nil, fmt.Errorf("put config for subject failed: %w", err)
@@ -475,14 +941,92 @@ func (c *Client) PutSubjectConfig(ctx context.Context, subject string, compatLev
 	return parsed, nil
 }
 
-// DeleteSubjectConfig deletes compatibility level for a given subject.
-// If the subject you ask about does not have a subject-specific compatibility level set, this command returns an
-// error code.
+// parseSettableCompatibilityLevel parses the given compatibility level. DEFAULT is rejected,
+// as it can't be set explicitly.
+func parseSettableCompatibilityLevel(compatibility string) (CompatibilityLevel, error) {
+	var compatLevel CompatibilityLevel
+	if err := compatLevel.UnmarshalText([]byte(compatibility)); err != nil || compatLevel == CompatDefault {
+		return 0, fmt.Errorf("invalid compatibility level %q, must be one of BACKWARD, BACKWARD_TRANSITIVE, "+
+			"FORWARD, FORWARD_TRANSITIVE, FULL, FULL_TRANSITIVE or NONE", compatibility)
+	}
+	return compatLevel, nil
+}
+
+// SetGlobalConfig sets the global compatibility level and returns the new level.
+func (c *Client) SetGlobalConfig(ctx context.Context, compatibility string) (*ConfigResponse, error) {
+	compatLevel, err := parseSettableCompatibilityLevel(compatibility)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.PutConfig(ctx, compatLevel)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigResponse{Compatibility: res.Compatibility}, nil
+}
+
+// SetSubjectConfig sets the compatibility level for a given subject and returns the new level.
+// Like in GetSubjectConfig, an unknown subject results in the DEFAULT compatibility level.
+func (c *Client) SetSubjectConfig(ctx context.Context, subject, compatibility string) (*ConfigResponse, error) {
+	compatLevel, err := parseSettableCompatibilityLevel(compatibility)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.PutSubjectConfig(ctx, subject, compatLevel)
+	if err != nil {
+		if errors.Is(err, ErrSubjectNotFound) {
+			return &ConfigResponse{Compatibility: CompatDefault}, nil
+		}
+		return nil, err
+	}
+	return &ConfigResponse{Compatibility: res.Compatibility}, nil
+}
+
+// PutSubjectAlias sets the alias of a given subject, so that the subject refers to the aliased
+// subject. An empty alias removes the alias.
+func (c *Client) PutSubjectAlias(ctx context.Context, subject, alias string) (*PutConfigResponse, error) {
+	type requestPayload struct {
+		Alias string `json:"alias"`
+	}
+	payload := requestPayload{Alias: alias}
+
+	res, err := c.client.R().
+		SetContext(ctx).
+		SetResult(&PutConfigResponse{}).
+		SetBody(&payload).
+		SetPathParam("subject", c.contextSubject(subject)).
+		Put("/config/{subject}")
+	if err != nil {
+		return nil, fmt.Errorf("put alias for subject failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("put alias for subject failed: Status code %d", res.StatusCode())
+		}
+
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*PutConfigResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse alias for subject response")
+	}
+
+	return parsed, nil
+}
+
+// DeleteSubjectConfig deletes compatibility level for a given subject, so that the subject falls back to the
+// global compatibility level. The removed compatibility level is returned. If the subject does not have a
+// subject-specific compatibility level set, the returned error matches ErrSubjectNotFound.
 func (c *Client) DeleteSubjectConfig(ctx context.Context, subject string) (*ConfigResponse, error) {
 	res, err := c.client.R().
 		SetContext(ctx).
 		SetResult(&ConfigResponse{}).
-		SetPathParam("subject", subject).
+		SetPathParam("subject", c.contextSubject(subject)).
 		Delete("/config/{subject}")
 	if err != nil {
 		return nil, fmt.Errorf("delete config for subject failed: %w", err)
@@ -519,7 +1063,7 @@ func (c *Client) DeleteSubject(ctx context.Context, subject string, deletePerman
 	res, err := c.client.R().
 		SetContext(ctx).
 		SetResult(&deletedVersions).
-		SetPathParam("subject", subject).
+		SetPathParam("subject", c.contextSubject(subject)).
 		SetQueryParam("permanent", strconv.FormatBool(deletePermanently)).
 		Delete("/subjects/{subject}")
 	if err != nil {
@@ -550,7 +1094,7 @@ func (c *Client) DeleteSubjectVersion(ctx context.Context, subject, version stri
 	res, err := c.client.R().
 		SetContext(ctx).
 		SetResult(&deletedVersion).
-		SetPathParam("subject", subject).
+		SetPathParam("subject", c.contextSubject(subject)).
 		SetPathParam("version", version).
 		SetQueryParam("permanent", strconv.FormatBool(deletePermanently)).
 		Delete("/subjects/{subject}/versions/{version}")
@@ -594,6 +1138,15 @@ func (c *Client) GetSchemaTypes(ctx context.Context) ([]string, error) {
 
 // GetSchemas retrieves all stored schemas from a schema registry.
 func (c *Client) GetSchemas(ctx context.Context, showSoftDeleted bool) ([]SchemaVersionedResponse, error) {
+	// The /schemas endpoint has been introduced with v6.0.0, hence we can skip the request
+	// if the registry reported an older version or did not serve it before.
+	if c.bulkListingUnsupported.Load() {
+		return c.GetSchemasIndividually(ctx, showSoftDeleted)
+	}
+	if info, err := c.GetServerInfo(ctx); err == nil && info.IsOlderThan(6, 0) {
+		return c.GetSchemasIndividually(ctx, showSoftDeleted)
+	}
+
 	var schemas []SchemaVersionedResponse
 	req := c.client.R().
 		SetContext(ctx).
@@ -608,9 +1161,10 @@ func (c *Client) GetSchemas(ctx context.Context, showSoftDeleted bool) ([]Schema
 		return nil, fmt.Errorf("get schemas failed: %w", err)
 	}
 
-	if res.StatusCode() == http.StatusNotFound {
+	if isBulkListingUnsupportedStatus(res.StatusCode()) {
 		// The /schemas endpoint has been introduced with v6.0.0, so instead we could achieve the same by querying
 		// every subject one by one
+		c.setBulkListingUnsupported()
 		return c.GetSchemasIndividually(ctx, showSoftDeleted)
 	}
 
@@ -625,6 +1179,18 @@ func (c *Client) GetSchemas(ctx context.Context, showSoftDeleted bool) ([]Schema
 	return schemas, nil
 }
 
+// isBulkListingUnsupportedStatus returns true if the status code of a GET /schemas request
+// indicates that the endpoint is not available. Besides registries that don't implement it,
+// some proxies in front of registries respond with 405 or 501 rather than 404.
+func isBulkListingUnsupportedStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
 // Schema is the object form of a schema for the HTTP API.
 type Schema struct {
 	// Schema is the actual unescaped text of a schema.
@@ -636,6 +1202,12 @@ type Schema struct {
 	// References declares other schemas this schema references. See the
 	// docs on SchemaReference for more details.
 	References []SchemaReference `json:"references,omitempty"`
+
+	// Metadata and RuleSet are part of Confluent's data contracts. If the subject
+	// has a compatibility group configured, compatibility is only checked against
+	// schemas with the same value for the group's metadata property.
+	Metadata *SchemaMetadata `json:"metadata,omitempty"`
+	RuleSet  *SchemaRuleSet  `json:"ruleSet,omitempty"`
 }
 
 // SchemaReference is a way for a one schema to reference another. The details
@@ -650,7 +1222,56 @@ type Schema struct {
 type SchemaReference struct {
 	Name    string `json:"name"`
 	Subject string `json:"subject"`
-	Version int    `json:"version"`
+	// Version is the referenced version of the subject, or LatestVersion if the reference is
+	// not pinned to a version.
+	Version int `json:"version"`
+}
+
+// LatestVersion is the version of references that point to the latest version of a subject.
+const LatestVersion = -1
+
+// UnmarshalJSON unmarshals the reference. References to "latest" are unmarshalled with
+// LatestVersion, as some registries return the version as string in this case.
+func (r *SchemaReference) UnmarshalJSON(data []byte) error {
+	type schemaReference SchemaReference // Avoid recursion
+	var raw struct {
+		schemaReference
+		Version json.RawMessage `json:"version"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = SchemaReference(raw.schemaReference)
+
+	version := strings.Trim(string(raw.Version), `"`)
+	switch version {
+	case "", "null":
+		return nil
+	case "latest":
+		r.Version = LatestVersion
+		return nil
+	}
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q of reference %q: %w", version, r.Name, err)
+	}
+	r.Version = v
+	return nil
+}
+
+// IsLatest returns true if the reference points to the latest version of the subject rather
+// than to a pinned version.
+func (r SchemaReference) IsLatest() bool {
+	return r.Version == LatestVersion
+}
+
+// VersionString returns the version as expected by the registry API, i.e. "latest" for
+// unpinned references.
+func (r SchemaReference) VersionString() string {
+	if r.IsLatest() {
+		return "latest"
+	}
+	return strconv.Itoa(r.Version)
 }
 
 // CreateSchemaResponse is the response to creating a schema.
@@ -658,14 +1279,16 @@ type CreateSchemaResponse struct {
 	ID int `json:"id"`
 }
 
-// CreateSchema registers a new schema under the specified subject.
-func (c *Client) CreateSchema(ctx context.Context, subjectName string, schema Schema) (*CreateSchemaResponse, error) {
+// CreateSchema registers a new schema under the specified subject. If normalize is set, the
+// registry stores the schema in its normalized form, otherwise the schema is stored verbatim.
+// The schema type must be set for Protobuf and JSON schemas, as the registry assumes Avro.
+func (c *Client) CreateSchema(ctx context.Context, subjectName string, schema Schema, normalize bool) (*CreateSchemaResponse, error) {
 	var createSchemaRes CreateSchemaResponse
 	res, err := c.client.R().
 		SetContext(ctx).
 		SetResult(&createSchemaRes).
-		SetPathParam("subject", subjectName).
-		SetQueryParam("normalize", "true").
+		SetPathParam("subject", c.contextSubject(subjectName)).
+		SetQueryParam("normalize", strconv.FormatBool(normalize)).
 		SetBody(&schema).
 		Post("/subjects/{subject}/versions")
 	if err != nil {
@@ -683,6 +1306,72 @@ func (c *Client) CreateSchema(ctx context.Context, subjectName string, schema Sc
 	return &createSchemaRes, nil
 }
 
+// importSchemaRequest is the request body for registering a schema with a given ID and version.
+type importSchemaRequest struct {
+	Schema
+	ID      int `json:"id"`
+	Version int `json:"version"`
+}
+
+// ImportSchema registers a schema under the specified subject with the given ID and version,
+// so that identifiers are preserved when schemas are migrated from another registry. The
+// registry only accepts this while the subject (or the whole registry) is in IMPORT mode.
+// The schema is stored verbatim.
+func (c *Client) ImportSchema(ctx context.Context, subjectName string, schema Schema, id, version int) (*CreateSchemaResponse, error) {
+	if id <= 0 || version <= 0 {
+		return nil, fmt.Errorf("schema id and version must be positive, got id %d and version %d", id, version)
+	}
+
+	var createSchemaRes CreateSchemaResponse
+	res, err := c.client.R().
+		SetContext(ctx).
+		SetResult(&createSchemaRes).
+		SetPathParam("subject", c.contextSubject(subjectName)).
+		SetBody(&importSchemaRequest{Schema: schema, ID: id, Version: version}).
+		Post("/subjects/{subject}/versions")
+	if err != nil {
+		return nil, fmt.Errorf("import schema failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("import schema failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	return &createSchemaRes, nil
+}
+
+// LookupSchema checks whether the given schema is already registered under the subject and
+// returns the registered version along with its ID. If the schema is not registered, an
+// error matching ErrSchemaNotFound is returned. Unknown subjects result in an error
+// matching ErrSubjectNotFound.
+func (c *Client) LookupSchema(ctx context.Context, subject string, schema Schema) (*SchemaVersionedResponse, error) {
+	var schemaRes SchemaVersionedResponse
+	res, err := c.client.R().
+		SetContext(ctx).
+		SetResult(&schemaRes).
+		SetPathParam("subject", c.contextSubject(subject)).
+		SetQueryParam("normalize", "true").
+		SetBody(&schema).
+		Post("/subjects/{subject}")
+	if err != nil {
+		return nil, fmt.Errorf("lookup schema failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("lookup schema failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	return &schemaRes, nil
+}
+
 // GetSchemasIndividually returns all schemas by describing all schemas one by one. This may be used against
 // schema registry that don't support the /schemas endpoint that returns a list of all registered schemas.
 func (c *Client) GetSchemasIndividually(ctx context.Context, showSoftDeleted bool) ([]SchemaVersionedResponse, error) {
@@ -691,30 +1380,31 @@ func (c *Client) GetSchemasIndividually(ctx context.Context, showSoftDeleted boo
 		return nil, fmt.Errorf("failed to get subjects to fetch schemas for: %w", err)
 	}
 
-	type chRes struct {
-		schemaRes []SchemaVersionedResponse
-		err       error
-	}
-	ch := make(chan chRes, len(subjectsRes.Subjects))
-
-	// Describe all subjects concurrently one by one
-	for _, subject := range subjectsRes.Subjects {
-		go func(s string) {
-			srRes, err := c.GetSchemasBySubject(ctx, s, showSoftDeleted)
-			ch <- chRes{
-				schemaRes: srRes,
-				err:       err,
+	// Describe all subjects concurrently one by one. The first error cancels the context of
+	// all other requests, so that no request outlives this function.
+	schemasBySubject := make([][]SchemaVersionedResponse, len(subjectsRes.Subjects))
+	grp, grpCtx := errgroup.WithContext(ctx)
+	for i, subject := range subjectsRes.Subjects {
+		i, subject := i, subject
+		grp.Go(func() error {
+			if err := grpCtx.Err(); err != nil {
+				return err
 			}
-		}(subject)
+			srRes, err := c.GetSchemasBySubject(grpCtx, subject, showSoftDeleted)
+			if err != nil {
+				return err
+			}
+			schemasBySubject[i] = srRes
+			return nil
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to fetch at least one schema: %w", err)
 	}
 
 	schemas := make([]SchemaVersionedResponse, 0)
-	for i := 0; i < cap(ch); i++ {
-		res := <-ch
-		if res.err != nil {
-			return nil, fmt.Errorf("failed to fetch at least one schema: %w", res.err)
-		}
-		schemas = append(schemas, res.schemaRes...)
+	for _, subjectSchemas := range schemasBySubject {
+		schemas = append(schemas, subjectSchemas...)
 	}
 
 	return schemas, nil
@@ -728,12 +1418,25 @@ type GetSchemaReferencesResponse struct {
 // GetSchemaReferences returns all schema ids that references the input
 // subject-version. You can use -1 or 'latest' to check the latest version.
 func (c *Client) GetSchemaReferences(ctx context.Context, subject, version string) (*GetSchemaReferencesResponse, error) {
+	schemaIDs, err := c.GetSchemaReferencedBy(ctx, subject, version)
+	if err != nil {
+		return nil, err
+	}
+	return &GetSchemaReferencesResponse{SchemaIDs: schemaIDs}, nil
+}
+
+// GetSchemaReferencedBy returns the IDs of all schemas that reference the given
+// subject-version, so that dependents can be discovered before the version is deleted.
+// Soft-deleted versions are included, as they may still be deleted permanently. The
+// returned slice is empty, but not nil, if the version is not referenced.
+func (c *Client) GetSchemaReferencedBy(ctx context.Context, subject, version string) ([]int, error) {
 	var schemaIDs []int
 	res, err := c.client.R().
 		SetContext(ctx).
 		SetResult(&schemaIDs).
-		SetPathParam("subject", subject).
+		SetPathParam("subject", c.contextSubject(subject)).
 		SetPathParam("version", version).
+		SetQueryParam("deleted", "true").
 		Get("/subjects/{subject}/versions/{version}/referencedby")
 	if err != nil {
 		return nil, fmt.Errorf("get schema references failed: %w", err)
@@ -747,22 +1450,30 @@ func (c *Client) GetSchemaReferences(ctx context.Context, subject, version strin
 		return nil, restErr
 	}
 
-	return &GetSchemaReferencesResponse{SchemaIDs: schemaIDs}, nil
+	if schemaIDs == nil {
+		schemaIDs = []int{}
+	}
+	return schemaIDs, nil
 }
 
 // CheckCompatibilityResponse is the response to a compatibility check for a schema.
 type CheckCompatibilityResponse struct {
 	IsCompatible bool `json:"is_compatible"`
+	// Messages explain why a schema is incompatible. They are only returned by
+	// registries that support verbose compatibility checks.
+	Messages []string `json:"messages,omitempty"`
 }
 
 // CheckCompatibility checks if a schema is compatible with the given version
 // that exists. You can use 'latest' to check compatibility with the latest version.
+// The schema's metadata is sent along, so that registries with a compatibility group
+// only check compatibility against versions within the same group.
 func (c *Client) CheckCompatibility(ctx context.Context, subject string, version string, schema Schema) (*CheckCompatibilityResponse, error) {
 	var checkCompatRes CheckCompatibilityResponse
 	res, err := c.client.R().
 		SetContext(ctx).
 		SetResult(&checkCompatRes).
-		SetPathParam("subject", subject).
+		SetPathParam("subject", c.contextSubject(subject)).
 		SetPathParam("version", version).
 		SetQueryParam("verbose", "true").
 		SetBody(&schema).
@@ -782,6 +1493,57 @@ func (c *Client) CheckCompatibility(ctx context.Context, subject string, version
 	return &checkCompatRes, nil
 }
 
+// RegisterVersionsError is returned by RegisterVersions if a schema could not be registered.
+type RegisterVersionsError struct {
+	// Index of the schema that could not be registered. All schemas before it have been registered.
+	Index int
+	// Incompatible is true if the schema is incompatible according to the subject's
+	// compatibility policy.
+	Incompatible bool
+	Err          error
+}
+
+// Error implements the error interface.
+func (e *RegisterVersionsError) Error() string {
+	if e.Incompatible {
+		return fmt.Sprintf("schema at index %d is incompatible with the previous version: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("failed to register schema at index %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RegisterVersionsError) Unwrap() error {
+	return e.Err
+}
+
+// RegisterVersions registers the given schemas as subsequent versions of the subject, e.g. to
+// replay the version history of a subject in order. Each schema is checked against the latest
+// version according to the subject's compatibility policy before it's registered. Registration
+// stops at the first schema that is incompatible or can't be registered. The IDs of all schemas
+// that have been registered until then are returned along with a *RegisterVersionsError.
+func (c *Client) RegisterVersions(ctx context.Context, subject string, schemas []Schema) ([]int, error) {
+	ids := make([]int, 0, len(schemas))
+	for i, schema := range schemas {
+		compatRes, err := c.CheckCompatibility(ctx, subject, "latest", schema)
+		if err != nil {
+			// There's nothing to be compatible with if the subject doesn't exist yet
+			if !errors.Is(err, ErrSubjectNotFound) && !errors.Is(err, ErrVersionNotFound) {
+				return ids, &RegisterVersionsError{Index: i, Err: err}
+			}
+		} else if !compatRes.IsCompatible {
+			return ids, &RegisterVersionsError{Index: i, Incompatible: true, Err: errors.New("compatibility check failed")}
+		}
+
+		createRes, err := c.CreateSchema(ctx, subject, schema, true)
+		if err != nil {
+			incompatible := errors.Is(err, ErrIncompatibleSchema)
+			return ids, &RegisterVersionsError{Index: i, Incompatible: incompatible, Err: err}
+		}
+		ids = append(ids, createRes.ID)
+	}
+	return ids, nil
+}
+
 // SubjectVersion is a schema's subject name and version.
 type SubjectVersion struct {
 	Subject string `json:"subject"`
@@ -791,12 +1553,21 @@ type SubjectVersion struct {
 // GetSchemaUsagesByID returns all usages of a given schema ID. A single schema
 // can be reused in multiple subject versions.
 func (c *Client) GetSchemaUsagesByID(ctx context.Context, schemaID int) ([]SubjectVersion, error) {
+	return c.GetSchemaVersionsByID(ctx, uint32(schemaID))
+}
+
+// GetSchemaVersionsByID returns the subject versions that the schema with the given ID is
+// registered under, so that decoded records can be linked back to their subject. If the ID
+// exists but all of its subject versions have been deleted, an empty slice is returned.
+func (c *Client) GetSchemaVersionsByID(ctx context.Context, id uint32) ([]SubjectVersion, error) {
 	var subjectVersions []SubjectVersion
-	res, err := c.client.R().
+	req := c.client.R().
 		SetContext(ctx).
 		SetResult(&subjectVersions).
-		SetPathParam("id", strconv.Itoa(schemaID)).
-		Get("/schemas/ids/{id}/versions")
+		SetPathParam("id", strconv.FormatUint(uint64(id), 10))
+	c.setContextQueryParam(req)
+
+	res, err := req.Get("/schemas/ids/{id}/versions")
 	if err != nil {
 		return nil, fmt.Errorf("get schema usages failed: %w", err)
 	}
@@ -809,9 +1580,47 @@ func (c *Client) GetSchemaUsagesByID(ctx context.Context, schemaID int) ([]Subje
 		return nil, restErr
 	}
 
+	if subjectVersions == nil {
+		subjectVersions = []SubjectVersion{}
+	}
 	return subjectVersions, nil
 }
 
+// GetSubjectsByID returns the names of the subjects that the schema with the given ID is
+// registered under. Subjects whose versions of the schema are all soft-deleted are only
+// included if showSoftDeleted is set, so that an empty slice is returned for IDs that are
+// only used by soft-deleted versions otherwise.
+func (c *Client) GetSubjectsByID(ctx context.Context, id uint32, showSoftDeleted bool) ([]string, error) {
+	var subjects []string
+	req := c.client.R().
+		SetContext(ctx).
+		SetResult(&subjects).
+		SetPathParam("id", strconv.FormatUint(uint64(id), 10))
+	c.setContextQueryParam(req)
+
+	if showSoftDeleted {
+		req.SetQueryParam("deleted", "true")
+	}
+
+	res, err := req.Get("/schemas/ids/{id}/subjects")
+	if err != nil {
+		return nil, fmt.Errorf("get subjects by schema id request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("get subjects by schema id request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	if subjects == nil {
+		subjects = []string{}
+	}
+	return subjects, nil
+}
+
 // CheckConnectivity checks whether the schema registry can be access by GETing the /subjects
 func (c *Client) CheckConnectivity(ctx context.Context) error {
 	url := "subjects"
@@ -0,0 +1,98 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// clientCertReloader provides the client certificate for mutual TLS and reads it from disk
+// again once the cert or key file has been modified, so that rotated certificates (e.g. by
+// cert-manager) are picked up without a restart.
+type clientCertReloader struct {
+	certFilepath string
+	keyFilepath  string
+
+	mutex       sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// newClientCertReloader loads the certificate once, so that invalid files are reported
+// right away.
+func newClientCertReloader(certFilepath, keyFilepath string) (*clientCertReloader, error) {
+	r := &clientCertReloader{certFilepath: certFilepath, keyFilepath: keyFilepath}
+	if _, err := r.getCertificate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate. If a rotated certificate
+// can't be loaded, the previous certificate is used, as the files may be half-written.
+func (r *clientCertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.getCertificate()
+}
+
+func (r *clientCertReloader) getCertificate() (*tls.Certificate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	certInfo, certErr := os.Stat(r.certFilepath)
+	keyInfo, keyErr := os.Stat(r.keyFilepath)
+	if certErr == nil && keyErr == nil && r.cert != nil &&
+		certInfo.ModTime().Equal(r.certModTime) && keyInfo.ModTime().Equal(r.keyModTime) {
+		return r.cert, nil
+	}
+
+	cert, err := loadClientCertificate(r.certFilepath, r.keyFilepath)
+	if err != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, err
+	}
+	r.cert = cert
+	if certErr == nil && keyErr == nil {
+		r.certModTime = certInfo.ModTime()
+		r.keyModTime = keyInfo.ModTime()
+	}
+	return r.cert, nil
+}
+
+// loadClientCertificate reads the PEM encoded certificate and private key from disk.
+func loadClientCertificate(certFilepath, keyFilepath string) (*tls.Certificate, error) {
+	cert, err := os.ReadFile(certFilepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cert file for schema registry client: %w", err)
+	}
+
+	privateKey, err := os.ReadFile(keyFilepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file for schema registry client: %w", err)
+	}
+
+	pemBlock, _ := pem.Decode(privateKey)
+	if pemBlock == nil {
+		return nil, fmt.Errorf("no valid private key found")
+	}
+
+	tlsCert, err := tls.X509KeyPair(cert, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate pair for schema registry client: %w", err)
+	}
+	return &tlsCert, nil
+}
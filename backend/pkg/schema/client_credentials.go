@@ -0,0 +1,75 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/twmb/go-cache/cache"
+)
+
+// credentialsCtxKey is the context key of per-request credentials.
+var credentialsCtxKey = &struct{ name string }{"SchemaRegistryCredentials"}
+
+// Credentials authenticate requests against the schema registry, either with basic auth or
+// with a bearer token. The bearer token takes precedence if both are set.
+type Credentials struct {
+	Username    string
+	Password    string
+	BearerToken string
+}
+
+// ContextWithCredentials returns a context that makes the client authenticate all requests
+// that are made with it using the given credentials rather than the configured ones, so that
+// Console can act on behalf of the calling user's registry identity. Empty credentials send
+// requests unauthenticated rather than falling back to the configured credentials.
+//
+// Requests with such a context bypass all caches, as responses depend on the identity and
+// must neither be served from nor added to the caches that are shared across callers.
+func ContextWithCredentials(ctx context.Context, creds Credentials) context.Context {
+	return context.WithValue(ctx, credentialsCtxKey, creds)
+}
+
+// credentialsFromContext returns the per-request credentials of the given context, if any.
+func credentialsFromContext(ctx context.Context) (Credentials, bool) {
+	creds, ok := ctx.Value(credentialsCtxKey).(Credentials)
+	return creds, ok
+}
+
+// cachedGet returns the cached value of the key, or fetches and caches it if it's missing.
+// Requests with per-request credentials always fetch the value and don't cache it.
+func cachedGet[K comparable, V any](ctx context.Context, c *cache.Cache[K, V], key K, fetch func() (V, error)) (V, error) {
+	if _, ok := credentialsFromContext(ctx); ok {
+		return fetch()
+	}
+	v, err, _ := c.Get(key, fetch)
+	return v, err
+}
+
+// applyContextCredentials is a pre-request hook that replaces the configured credentials with
+// the credentials of the request's context. It runs after resty has set the configured
+// credentials, right before the request is sent.
+func applyContextCredentials(_ *resty.Client, req *http.Request) error {
+	creds, ok := credentialsFromContext(req.Context())
+	if !ok {
+		return nil
+	}
+
+	req.Header.Del("Authorization")
+	switch {
+	case creds.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+creds.BearerToken)
+	case creds.Username != "":
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	return nil
+}
@@ -9,6 +9,42 @@
 
 package schema
 
+import "errors"
+
+// The sentinel errors below can be matched with errors.Is against errors returned by the
+// client, if the registry responded with the corresponding error code. The RestError with
+// the numeric code and message remains accessible via errors.As.
+var (
+	// ErrSubjectNotFound matches CodeSubjectNotFound.
+	ErrSubjectNotFound = errors.New("subject not found")
+	// ErrVersionNotFound matches CodeVersionNotFound.
+	ErrVersionNotFound = errors.New("version not found")
+	// ErrSchemaNotFound matches CodeSchemaNotFound.
+	ErrSchemaNotFound = errors.New("schema not found")
+	// ErrSubjectCompatibilityNotConfigured matches CodeSubjectCompatibilityNotConfigured.
+	ErrSubjectCompatibilityNotConfigured = errors.New("subject compatibility level not configured")
+	// ErrIncompatibleSchema matches CodeIncompatibleSchema.
+	ErrIncompatibleSchema = errors.New("incompatible schema")
+	// ErrInvalidSchema matches CodeInvalidSchema.
+	ErrInvalidSchema = errors.New("invalid schema")
+	// ErrInvalidVersion matches CodeInvalidVersion.
+	ErrInvalidVersion = errors.New("invalid version")
+	// ErrInvalidCompatibilityLevel matches CodeInvalidCompatibilityLevel.
+	ErrInvalidCompatibilityLevel = errors.New("invalid compatibility level")
+)
+
+// errorCodesBySentinel maps the sentinel errors to the registry error code they match.
+var errorCodesBySentinel = map[error]int{
+	ErrSubjectNotFound:                   CodeSubjectNotFound,
+	ErrVersionNotFound:                   CodeVersionNotFound,
+	ErrSchemaNotFound:                    CodeSchemaNotFound,
+	ErrSubjectCompatibilityNotConfigured: CodeSubjectCompatibilityNotConfigured,
+	ErrIncompatibleSchema:                CodeIncompatibleSchema,
+	ErrInvalidSchema:                     CodeInvalidSchema,
+	ErrInvalidVersion:                    CodeInvalidVersion,
+	ErrInvalidCompatibilityLevel:         CodeInvalidCompatibilityLevel,
+}
+
 const (
 	// CodeSubjectNotFound is the returned error code when the requested subject
 	// does not exist.
@@ -25,4 +61,19 @@ const (
 	// CodeSubjectCompatibilityNotConfigured is returned when retrieving a subject's
 	// compatibility level, and it's not set.
 	CodeSubjectCompatibilityNotConfigured = 40408
+
+	// CodeIncompatibleSchema is returned when registering a schema that is incompatible
+	// with the subject's previous versions.
+	CodeIncompatibleSchema = 409
+
+	// CodeInvalidSchema is returned when registering or checking a schema that can't
+	// be parsed.
+	CodeInvalidSchema = 42201
+
+	// CodeInvalidVersion is returned when the requested version is neither a positive
+	// integer nor "latest".
+	CodeInvalidVersion = 42202
+
+	// CodeInvalidCompatibilityLevel is returned when setting an unknown compatibility level.
+	CodeInvalidCompatibilityLevel = 42203
 )
@@ -0,0 +1,148 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// failoverTransport sends requests to the registry URL that responded last and fails over
+// to the next configured URL if a GET or HEAD request fails with a connection error or a 5xx
+// status. Requests that modify the registry only fail over if no connection could be
+// established, as the node may have applied them otherwise. Each URL is tried at most once
+// per request, hence a request is attempted as many times as URLs are configured. This is
+// multiplied by the retries of GET requests, see Client.MaxRequestAttempts. Requests that
+// fail with a 4xx status are not retried, as the other nodes of the registry cluster would
+// respond the same.
+type failoverTransport struct {
+	next   http.RoundTripper
+	logger *zap.Logger
+
+	// registryURLs are the normalized registry URLs. Requests are built against the first URL
+	// by resty and are rewritten to the current URL.
+	registryURLs []*url.URL
+	// current is the index of the registry URL that is tried first.
+	current atomic.Int32
+}
+
+func newFailoverTransport(next http.RoundTripper, registryURLs []string, logger *zap.Logger) (*failoverTransport, error) {
+	parsed := make([]*url.URL, len(registryURLs))
+	for i, registryURL := range registryURLs {
+		u, err := url.Parse(registryURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema registry url %q: %w", registryURL, err)
+		}
+		parsed[i] = u
+	}
+	return &failoverTransport{
+		next:         next,
+		logger:       logger,
+		registryURLs: parsed,
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The escaped path is used, so that escaped path params (e.g. subjects with a slash) are retained
+	requestPath := strings.TrimPrefix(req.URL.EscapedPath(), t.registryURLs[0].EscapedPath())
+	attempts := len(t.registryURLs)
+	start := int(t.current.Load())
+
+	for attempt := 1; ; attempt++ {
+		index := (start + attempt - 1) % attempts
+		attemptReq, err := t.requestFor(req, t.registryURLs[index], requestPath, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := t.next.RoundTrip(attemptReq)
+		if !shouldFailOver(req, res, err) {
+			if err != nil {
+				return nil, err
+			}
+			t.current.Store(int32(index))
+			return res, nil
+		}
+		if attempt == attempts {
+			if err != nil {
+				return nil, fmt.Errorf("request failed on all %d schema registry urls: %w", attempts, err)
+			}
+			return res, nil
+		}
+
+		failure := zap.Error(err)
+		if err == nil {
+			failure = zap.Int("status_code", res.StatusCode)
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		t.logger.Warn("schema registry request failed, failing over to next url",
+			zap.String("failed_url", t.registryURLs[index].Redacted()),
+			zap.String("next_url", t.registryURLs[(index+1)%attempts].Redacted()),
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", attempts),
+			failure)
+	}
+}
+
+// shouldFailOver returns true if the request shall be sent to the next registry URL.
+func shouldFailOver(req *http.Request, res *http.Response, err error) bool {
+	isSafe := req.Method == http.MethodGet || req.Method == http.MethodHead
+	if err != nil {
+		// Requests that have been cancelled by the caller would fail on all URLs
+		if req.Context().Err() != nil {
+			return false
+		}
+		return isSafe || isDialError(err)
+	}
+	return isSafe && res.StatusCode >= http.StatusInternalServerError
+}
+
+// isDialError returns true if the connection to the registry could not be established, so
+// that the request has not been sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// requestFor returns a copy of the request that is sent to the given registry URL. The body
+// is recreated for all but the first attempt, as it has been consumed by previous attempts.
+func (*failoverTransport) requestFor(req *http.Request, registryURL *url.URL, requestPath string, attempt int) (*http.Request, error) {
+	target, err := url.Parse(registryURL.String() + requestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schema registry request url: %w", err)
+	}
+	target.RawQuery = req.URL.RawQuery
+
+	attemptReq := req.Clone(req.Context())
+	attemptReq.URL = target
+	attemptReq.Host = ""
+
+	if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("failed to retry schema registry request, because its body can't be recreated")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to recreate body of schema registry request: %w", err)
+		}
+		attemptReq.Body = body
+	}
+	return attemptReq, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// redactedHeaders are the request headers whose values are never logged.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization"}
+
+// logRequests adds the hooks to the resty client that log each request at debug level and
+// each retry at warn level, so that intermittent registry failures can be diagnosed.
+func logRequests(client *resty.Client, logger *zap.Logger) {
+	client.OnAfterResponse(func(_ *resty.Client, res *resty.Response) error {
+		if ce := logger.Check(zap.DebugLevel, "schema registry request completed"); ce != nil {
+			ce.Write(append(requestFields(res.Request),
+				zap.Int("status_code", res.StatusCode()),
+				zap.Duration("latency", res.Time()))...)
+		}
+		return nil
+	})
+	client.OnError(func(req *resty.Request, err error) {
+		if ce := logger.Check(zap.DebugLevel, "schema registry request failed"); ce != nil {
+			fields := requestFields(req)
+			var resErr *resty.ResponseError
+			if errors.As(err, &resErr) && resErr.Response != nil && resErr.Response.RawResponse != nil {
+				fields = append(fields, zap.Int("status_code", resErr.Response.StatusCode()))
+			}
+			if !req.Time.IsZero() {
+				fields = append(fields, zap.Duration("latency", time.Since(req.Time)))
+			}
+			ce.Write(append(fields, zap.Error(err))...)
+		}
+	})
+	client.AddRetryHook(func(res *resty.Response, err error) {
+		if res == nil || res.Request == nil {
+			logger.Warn("retrying schema registry request", zap.Error(err))
+			return
+		}
+		fields := requestFields(res.Request)
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		} else {
+			fields = append(fields, zap.Int("status_code", res.StatusCode()))
+		}
+		logger.Warn("retrying schema registry request", fields...)
+	})
+}
+
+// requestFields returns the log fields that describe the request. Credentials in the URL and
+// headers are redacted.
+func requestFields(req *resty.Request) []zap.Field {
+	fields := []zap.Field{
+		zap.String("method", req.Method),
+		zap.Int("attempt", req.Attempt),
+	}
+	if req.RawRequest == nil {
+		return append(fields, zap.String("url", req.URL))
+	}
+
+	headers := req.RawRequest.Header.Clone()
+	for _, name := range redactedHeaders {
+		if headers.Get(name) != "" {
+			headers.Set(name, "[REDACTED]")
+		}
+	}
+	return append(fields,
+		zap.String("url", req.RawRequest.URL.Redacted()),
+		zap.Any("headers", headers))
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/redpanda-data/console/backend/pkg/promutil"
+)
+
+// clientMetrics records the requests to the schema registry per endpoint, so that slow or
+// failing registries can be alerted on. A nil *clientMetrics is valid and does not record
+// anything.
+type clientMetrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// newClientMetrics creates the client metrics and registers them with the given registerer.
+// If the registerer is nil, nil is returned so that no metrics are recorded. Metrics that
+// have already been registered by another client are reused.
+func newClientMetrics(reg prometheus.Registerer, metricsNamespace string) (*clientMetrics, error) {
+	if reg == nil {
+		return nil, nil
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "schema_registry",
+		Name:      "requests_total",
+		Help:      "Number of requests to the schema registry per method, path and status code",
+	}, []string{"method", "path", "status"})
+	errs := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "schema_registry",
+		Name:      "request_errors_total",
+		Help:      "Number of requests to the schema registry per method and path that failed or returned an error status code",
+	}, []string{"method", "path"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "schema_registry",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of requests to the schema registry per method and path",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "path"})
+
+	var err error
+	if requests, err = promutil.RegisterOrReuse(reg, requests); err != nil {
+		return nil, err
+	}
+	if errs, err = promutil.RegisterOrReuse(reg, errs); err != nil {
+		return nil, err
+	}
+	if duration, err = promutil.RegisterOrReuse(reg, duration); err != nil {
+		return nil, err
+	}
+	return &clientMetrics{requests: requests, errors: errs, duration: duration}, nil
+}
+
+type requestObservationKey struct{}
+
+// requestObservation is attached to the context of each request attempt, so that the hooks
+// that run after the request know its path template and whether it has been recorded.
+type requestObservation struct {
+	pathTemplate string
+	observed     bool
+}
+
+// instrument adds the hooks to the resty client that record each request.
+func (m *clientMetrics) instrument(client *resty.Client) {
+	if m == nil {
+		return
+	}
+
+	// The path parameters are not substituted yet, so that the path template (e.g.
+	// "/subjects/{subject}/versions") is used as label rather than the actual path.
+	client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		req.SetContext(context.WithValue(req.Context(), requestObservationKey{}, &requestObservation{pathTemplate: req.URL}))
+		return nil
+	})
+	client.OnAfterResponse(func(_ *resty.Client, res *resty.Response) error {
+		m.observe(res.Request, strconv.Itoa(res.StatusCode()), res.IsError(), res.Time())
+		return nil
+	})
+	// Requests that failed without response, or whose response was rejected by a previous
+	// after response hook, are recorded once all retries have failed.
+	client.OnError(func(req *resty.Request, err error) {
+		status := "error"
+		var resErr *resty.ResponseError
+		if errors.As(err, &resErr) && resErr.Response != nil && resErr.Response.RawResponse != nil {
+			status = strconv.Itoa(resErr.Response.StatusCode())
+		}
+		var elapsed time.Duration
+		if !req.Time.IsZero() {
+			elapsed = time.Since(req.Time)
+		}
+		m.observe(req, status, true, elapsed)
+	})
+}
+
+// observe records a single request to the registry, unless it has been recorded already.
+func (m *clientMetrics) observe(req *resty.Request, status string, failed bool, elapsed time.Duration) {
+	obs, ok := req.Context().Value(requestObservationKey{}).(*requestObservation)
+	if !ok || obs.observed {
+		return
+	}
+	obs.observed = true
+
+	m.requests.WithLabelValues(req.Method, obs.pathTemplate, status).Inc()
+	if failed {
+		m.errors.WithLabelValues(req.Method, obs.pathTemplate).Inc()
+	}
+	m.duration.WithLabelValues(req.Method, obs.pathTemplate).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/redpanda-data/console/backend/pkg/config"
+)
+
+// oauthTokenSource acquires access tokens of the client credentials grant with the given
+// HTTP client, so that token requests use the same TLS, proxy and timeout settings as the
+// requests to the registry. Tokens are cached and acquired again shortly before they expire.
+type oauthTokenSource struct {
+	cfg        *clientcredentials.Config
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns the cached token, or acquires a new one within the given context.
+func (s *oauthTokenSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+	token, err := s.cfg.Token(context.WithValue(ctx, oauth2.HTTPClient, s.httpClient))
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// newOAuthMiddleware returns a request middleware that authenticates requests with an access
+// token of the client credentials grant. Tokens are requested with the given HTTP client and
+// within the context of the request that needs them. Requests with per-request credentials
+// don't need a token.
+func newOAuthMiddleware(cfg config.SchemaOAuth, httpClient *http.Client) resty.RequestMiddleware {
+	tokenSource := &oauthTokenSource{
+		cfg: &clientcredentials.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			TokenURL:     cfg.TokenURL,
+			Scopes:       cfg.Scopes,
+		},
+		httpClient: httpClient,
+	}
+
+	return func(_ *resty.Client, req *resty.Request) error {
+		if _, ok := credentialsFromContext(req.Context()); ok {
+			return nil
+		}
+		token, err := tokenSource.Token(req.Context())
+		if err != nil {
+			return fmt.Errorf("failed to acquire oauth token for schema registry: %w", err)
+		}
+		req.SetAuthToken(token.AccessToken)
+		return nil
+	}
+}
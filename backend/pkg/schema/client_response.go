@@ -0,0 +1,88 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// maxResponseSnippetLength is the max number of bytes of a response body that are included
+// in errors about unexpected responses.
+const maxResponseSnippetLength = 256
+
+// responseSnippet returns the beginning of the response body, so that unexpected responses
+// (e.g. an HTML error page of a proxy) can be told apart in errors.
+func responseSnippet(body []byte) string {
+	snippet := body
+	truncated := len(snippet) > maxResponseSnippetLength
+	if truncated {
+		snippet = snippet[:maxResponseSnippetLength]
+	}
+	s := strings.Join(strings.Fields(strings.ToValidUTF8(string(snippet), "")), " ")
+	if truncated {
+		s += "..."
+	}
+	if s == "" {
+		return "<empty body>"
+	}
+	return s
+}
+
+// checkResponseBody is a response middleware that turns responses that are no schema
+// registry responses into diagnosable errors. Successful responses must have a JSON body if
+// a result is expected. Error responses without registry error (e.g. a 502 of a gateway)
+// are returned as RestError with the HTTP status as error code and a body snippet as message.
+func checkResponseBody(_ *resty.Client, res *resty.Response) error {
+	if res.StatusCode() == http.StatusNoContent {
+		return nil
+	}
+	contentType := res.Header().Get("Content-Type")
+
+	if res.IsSuccess() {
+		if res.Request.Result != nil && !resty.IsJSONType(contentType) {
+			return fmt.Errorf("unexpected response with status %d and content type %q: %s",
+				res.StatusCode(), contentType, responseSnippet(res.Body()))
+		}
+		return nil
+	}
+
+	if !res.IsError() {
+		return nil
+	}
+	restErr, ok := res.Request.Error.(*RestError)
+	if res.Request.Error != nil && !ok {
+		return nil
+	}
+	if restErr == nil {
+		restErr = &RestError{}
+		res.Request.Error = restErr
+	}
+	if restErr.ErrorCode == 0 {
+		restErr.ErrorCode = res.StatusCode()
+	}
+	if restErr.Message == "" {
+		restErr.Message = fmt.Sprintf("unexpected response with content type %q: %s", contentType, responseSnippet(res.Body()))
+	}
+	return nil
+}
+
+// unmarshalJSONWithSnippet unmarshals JSON responses and adds a snippet of the body to
+// errors, as responses with JSON content type may still have a different body.
+func unmarshalJSONWithSnippet(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse response %q: %w", responseSnippet(data), err)
+	}
+	return nil
+}
@@ -11,11 +11,38 @@ package schema
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/redpanda-data/console/backend/pkg/config"
 )
@@ -29,7 +56,7 @@ func TestClient_GetSchemaByID(t *testing.T) {
 	c, _ := newClient(config.Schema{
 		Enabled: true,
 		URLs:    []string{baseURL},
-	})
+	}, zap.NewNop(), nil)
 
 	httpClient := c.client.GetClient()
 	httpmock.ActivateNonDefault(httpClient)
@@ -47,6 +74,23 @@ func TestClient_GetSchemaByID(t *testing.T) {
 	actual, err := c.GetSchemaByID(context.Background(), 1000)
 	assert.NoError(t, err, "expected no error when fetching schema by id")
 	assert.Equal(t, expected, actual)
+	assert.Equal(t, TypeAvro, actual.Type, "the type defaults to Avro if it's absent")
+
+	protoSchemaStr := "syntax = \"proto3\";\nmessage Order {}"
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1001",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]string{
+			"schemaType": "PROTOBUF",
+			"schema":     protoSchemaStr,
+			"guid":       "4f3c1a2e-8b5d-4c6e-9f7a-1b2c3d4e5f60",
+		}))
+
+	actual, err = c.GetSchemaByID(context.Background(), 1001)
+	require.NoError(t, err)
+	assert.Equal(t, &SchemaResponse{
+		Type:   TypeProtobuf,
+		Schema: protoSchemaStr,
+		GUID:   "4f3c1a2e-8b5d-4c6e-9f7a-1b2c3d4e5f60",
+	}, actual)
 }
 
 func TestClient_GetSubjects(t *testing.T) {
@@ -54,7 +98,7 @@ func TestClient_GetSubjects(t *testing.T) {
 	c, _ := newClient(config.Schema{
 		Enabled: true,
 		URLs:    []string{baseURL},
-	})
+	}, zap.NewNop(), nil)
 
 	httpClient := c.client.GetClient()
 	httpmock.ActivateNonDefault(httpClient)
@@ -77,7 +121,7 @@ func TestClient_GetSubjectVersions(t *testing.T) {
 	c, _ := newClient(config.Schema{
 		Enabled: true,
 		URLs:    []string{baseURL},
-	})
+	}, zap.NewNop(), nil)
 	httpClient := c.client.GetClient()
 	httpmock.ActivateNonDefault(httpClient)
 	defer httpmock.DeactivateAndReset()
@@ -93,3 +137,2134 @@ func TestClient_GetSubjectVersions(t *testing.T) {
 	assert.NoError(t, err, "expected no error when fetching subject versions")
 	assert.Equal(t, expected, actual)
 }
+
+func TestClient_PinnedCertFingerprints(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`["orders-value"]`))
+	}))
+	defer srv.Close()
+
+	fingerprint := sha256.Sum256(srv.Certificate().Raw)
+
+	t.Run("pinned cert", func(t *testing.T) {
+		// Use upper case and colon separators like openssl prints fingerprints
+		hexFingerprint := strings.ToUpper(hex.EncodeToString(fingerprint[:]))
+		var colonSeparated []string
+		for i := 0; i < len(hexFingerprint); i += 2 {
+			colonSeparated = append(colonSeparated, hexFingerprint[i:i+2])
+		}
+
+		c, err := newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{srv.URL},
+			TLS: config.SchemaTLS{
+				Enabled:                true,
+				PinnedCertFingerprints: []string{strings.Join(colonSeparated, ":")},
+			},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		res, err := c.GetSubjects(context.Background(), false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders-value"}, res.Subjects)
+	})
+
+	t.Run("mismatched cert", func(t *testing.T) {
+		otherFingerprint := sha256.Sum256([]byte("some other certificate"))
+		c, err := newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{srv.URL},
+			TLS: config.SchemaTLS{
+				Enabled:                true,
+				PinnedCertFingerprints: []string{hex.EncodeToString(otherFingerprint[:])},
+			},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		_, err = c.GetSubjects(context.Background(), false)
+		assert.ErrorContains(t, err, "no leaf certificate matching any of the pinned fingerprints")
+	})
+
+	t.Run("pinned cert appended to another chain", func(t *testing.T) {
+		verify, err := newPinnedCertVerifier([]string{hex.EncodeToString(fingerprint[:])})
+		require.NoError(t, err)
+
+		assert.NoError(t, verify([][]byte{srv.Certificate().Raw}, nil))
+		assert.Error(t, verify([][]byte{[]byte("attacker certificate"), srv.Certificate().Raw}, nil))
+		assert.Error(t, verify(nil, nil))
+	})
+
+	t.Run("invalid fingerprint", func(t *testing.T) {
+		_, err := newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{srv.URL},
+			TLS: config.SchemaTLS{
+				Enabled:                true,
+				PinnedCertFingerprints: []string{"not-a-fingerprint"},
+			},
+		}, zap.NewNop(), nil)
+		assert.ErrorContains(t, err, "is not a hex encoded SHA-256 hash")
+	})
+}
+
+func TestClient_Proxy(t *testing.T) {
+	// The proxy receives requests with the absolute URL of the registry
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`["orders-value"]`))
+	}))
+	defer proxy.Close()
+
+	t.Run("configured proxy", func(t *testing.T) {
+		c, err := newClient(config.Schema{
+			Enabled:  true,
+			URLs:     []string{"http://registry.invalid:8081"},
+			ProxyURL: proxy.URL,
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		res, err := c.GetSubjects(context.Background(), false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders-value"}, res.Subjects)
+		assert.Equal(t, "registry.invalid:8081", proxiedHost)
+	})
+
+	t.Run("tls transport", func(t *testing.T) {
+		c, err := newClient(config.Schema{
+			Enabled:  true,
+			URLs:     []string{"https://registry.invalid:8081"},
+			TLS:      config.SchemaTLS{Enabled: true},
+			ProxyURL: proxy.URL,
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		transport, ok := c.client.GetClient().Transport.(*http.Transport)
+		require.True(t, ok)
+		require.NotNil(t, transport.Proxy)
+		req := httptest.NewRequest(http.MethodGet, "https://registry.invalid:8081/subjects", http.NoBody)
+		proxyURL, err := transport.Proxy(req)
+		require.NoError(t, err)
+		assert.Equal(t, proxy.URL, proxyURL.String())
+	})
+
+	t.Run("proxy from environment", func(t *testing.T) {
+		c, err := newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{"https://registry.invalid:8081"},
+			TLS:     config.SchemaTLS{Enabled: true},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		transport, ok := c.client.GetClient().Transport.(*http.Transport)
+		require.True(t, ok)
+		assert.NotNil(t, transport.Proxy)
+	})
+}
+
+func TestClient_Metrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/subjects/missing/versions" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code": 40401, "message": "Subject 'missing' not found."}`))
+			return
+		}
+		w.Write([]byte(`[1]`))
+	}))
+
+	reg := prometheus.NewRegistry()
+	metrics, err := newClientMetrics(reg, "test")
+	require.NoError(t, err)
+	c, err := newClient(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), metrics)
+	require.NoError(t, err)
+
+	_, err = c.GetSubjectVersions(context.Background(), "orders-value", false)
+	require.NoError(t, err)
+	_, err = c.GetSubjectVersions(context.Background(), "customers-value", false)
+	require.NoError(t, err)
+	_, err = c.GetSubjectVersions(context.Background(), "missing", false)
+	require.Error(t, err)
+
+	// Requests are labelled with the path template rather than the subject
+	requests := func(path, status string) float64 {
+		return promtestutil.ToFloat64(metrics.requests.WithLabelValues(http.MethodGet, path, status))
+	}
+	assert.Equal(t, 2.0, requests("/subjects/{subject}/versions", "200"))
+	assert.Equal(t, 1.0, requests("/subjects/{subject}/versions", "404"))
+	assert.Equal(t, 1.0, promtestutil.ToFloat64(metrics.errors.WithLabelValues(http.MethodGet, "/subjects/{subject}/versions")))
+	assert.Equal(t, 1, promtestutil.CollectAndCount(metrics.duration))
+
+	// Requests that fail without response are recorded as error
+	srv.Close()
+	_, err = c.GetSubjectVersions(context.Background(), "orders-value", false)
+	require.Error(t, err)
+	assert.Equal(t, 1.0, requests("/subjects/{subject}/versions", "error"))
+	assert.Equal(t, 2.0, promtestutil.ToFloat64(metrics.errors.WithLabelValues(http.MethodGet, "/subjects/{subject}/versions")))
+
+	// Registering the metrics again reuses the existing collectors
+	again, err := newClientMetrics(reg, "test")
+	require.NoError(t, err)
+	assert.Same(t, metrics.requests, again.requests)
+
+	noop, err := newClientMetrics(nil, "test")
+	require.NoError(t, err)
+	assert.Nil(t, noop)
+}
+
+func TestClient_Logging(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error_code": 50301, "message": "Unavailable."}`))
+			return
+		}
+		w.Write([]byte(`["orders-value"]`))
+	}))
+	defer srv.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	c, err := newClient(config.Schema{
+		Enabled:       true,
+		URLs:          []string{srv.URL},
+		BearerToken:   "secret-token",
+		RetryAttempts: 1,
+		RetryBackoff:  time.Millisecond,
+	}, zap.New(core), nil)
+	require.NoError(t, err)
+
+	_, err = c.GetSubjects(context.Background(), false)
+	require.NoError(t, err)
+
+	retries := logs.FilterMessage("retrying schema registry request").All()
+	require.Len(t, retries, 1)
+	assert.Equal(t, zap.WarnLevel, retries[0].Level)
+	assert.EqualValues(t, http.StatusServiceUnavailable, retries[0].ContextMap()["status_code"])
+
+	completed := logs.FilterMessage("schema registry request completed").All()
+	require.Len(t, completed, 2)
+	fields := completed[1].ContextMap()
+	assert.Equal(t, http.MethodGet, fields["method"])
+	assert.Equal(t, srv.URL+"/subjects", fields["url"])
+	assert.EqualValues(t, http.StatusOK, fields["status_code"])
+	assert.Contains(t, fields, "latency")
+	headers, ok := fields["headers"].(http.Header)
+	require.True(t, ok)
+	assert.Equal(t, "[REDACTED]", headers.Get("Authorization"))
+	for _, entry := range logs.All() {
+		assert.NotContains(t, fmt.Sprint(entry.ContextMap()), "secret-token")
+	}
+}
+
+func TestClientCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFilepath := filepath.Join(dir, "tls.crt")
+	keyFilepath := filepath.Join(dir, "tls.key")
+
+	// writeKeyPair writes a new self-signed key pair and sets the given modification time
+	writeKeyPair := func(commonName string, modTime time.Time) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: commonName},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(certFilepath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+		require.NoError(t, os.WriteFile(keyFilepath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+		require.NoError(t, os.Chtimes(certFilepath, modTime, modTime))
+		require.NoError(t, os.Chtimes(keyFilepath, modTime, modTime))
+	}
+	commonName := func(cert *tls.Certificate) string {
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return parsed.Subject.CommonName
+	}
+
+	start := time.Now().Add(-time.Minute)
+	writeKeyPair("first", start)
+	reloader, err := newClientCertReloader(certFilepath, keyFilepath)
+	require.NoError(t, err)
+
+	cert, err := reloader.GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first", commonName(cert))
+
+	// Rotated files are picked up
+	writeKeyPair("second", start.Add(time.Second))
+	cert, err = reloader.GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second", commonName(cert))
+
+	// Half-written files don't replace the previous certificate
+	require.NoError(t, os.WriteFile(keyFilepath, []byte("garbage"), 0o600))
+	cert, err = reloader.GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second", commonName(cert))
+
+	_, err = newClientCertReloader(certFilepath, keyFilepath)
+	assert.ErrorContains(t, err, "no valid private key found")
+}
+
+func TestClient_GetConfig(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	t.Run("extended response", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/config",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"compatibilityLevel": "FULL",
+				"compatibilityGroup": "application.major.version",
+				"defaultMetadata": map[string]interface{}{
+					"properties": map[string]string{"owner": "team-a"},
+					"tags":       map[string][]string{"Order.ssn": {"PII"}},
+					"sensitive":  []string{"secret"},
+				},
+				"normalize": true,
+			}))
+
+		normalize := true
+		expected := &ConfigResponse{
+			Compatibility:      CompatFull,
+			CompatibilityGroup: "application.major.version",
+			DefaultMetadata: &SchemaMetadata{
+				Tags:       map[string][]string{"Order.ssn": {"PII"}},
+				Properties: map[string]string{"owner": "team-a"},
+				Sensitive:  []string{"secret"},
+			},
+			Normalize: &normalize,
+		}
+		actual, err := c.GetConfig(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("legacy response", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/config",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]string{"compatibilityLevel": "BACKWARD"}))
+
+		actual, err := c.GetConfig(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, &ConfigResponse{Compatibility: CompatBackward}, actual)
+	})
+}
+
+func TestClient_GetSchemaBySubject(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	schemaStr := `{"type":"record","name":"order","fields":[{"name":"ssn","type":"string"}]}`
+
+	t.Run("with data contract", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/2",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"subject":    "orders-value",
+				"id":         20,
+				"version":    2,
+				"schemaType": "AVRO",
+				"schema":     schemaStr,
+				"metadata": map[string]interface{}{
+					"properties": map[string]string{"owner": "team-a"},
+					"tags":       map[string][]string{"order.ssn": {"PII"}},
+				},
+				"ruleSet": map[string]interface{}{
+					"domainRules": []map[string]interface{}{{
+						"name":      "checkSsnLen",
+						"kind":      "CONDITION",
+						"mode":      "WRITE",
+						"type":      "CEL",
+						"expr":      "size(message.ssn) == 9",
+						"onFailure": "DLQ",
+						"params":    map[string]string{"dlq.topic": "bad-orders"},
+					}},
+					"migrationRules": []map[string]interface{}{{
+						"name": "upgradeOrder",
+						"kind": "TRANSFORM",
+						"mode": "UPGRADE",
+						"type": "JSONATA",
+						"expr": "$merge([$sift($, function($v, $k) {$k != 'ssn'})])",
+					}},
+				},
+			}))
+
+		expected := &SchemaVersionedResponse{
+			Subject:  "orders-value",
+			SchemaID: 20,
+			Version:  2,
+			Schema:   schemaStr,
+			Type:     TypeAvro,
+			Metadata: &SchemaMetadata{
+				Tags:       map[string][]string{"order.ssn": {"PII"}},
+				Properties: map[string]string{"owner": "team-a"},
+			},
+			RuleSet: &SchemaRuleSet{
+				MigrationRules: []SchemaRule{{
+					Name: "upgradeOrder",
+					Kind: "TRANSFORM",
+					Mode: "UPGRADE",
+					Type: "JSONATA",
+					Expr: "$merge([$sift($, function($v, $k) {$k != 'ssn'})])",
+				}},
+				DomainRules: []SchemaRule{{
+					Name:      "checkSsnLen",
+					Kind:      "CONDITION",
+					Mode:      "WRITE",
+					Type:      "CEL",
+					Params:    map[string]string{"dlq.topic": "bad-orders"},
+					Expr:      "size(message.ssn) == 9",
+					OnFailure: "DLQ",
+				}},
+			},
+		}
+		actual, err := c.GetSchemaBySubject(context.Background(), "orders-value", "2", false)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("without data contract", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/1",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"subject": "orders-value",
+				"id":      10,
+				"version": 1,
+				"schema":  schemaStr,
+			}))
+
+		actual, err := c.GetSchemaBySubject(context.Background(), "orders-value", "1", false)
+		require.NoError(t, err)
+		assert.Nil(t, actual.Metadata)
+		assert.Nil(t, actual.RuleSet)
+	})
+}
+
+func TestClient_SubjectAlias(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("PUT", baseURL+"/config/orders-value",
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+			}
+			assert.Equal(t, map[string]interface{}{"alias": "orders-v2-value"}, body)
+			return httpmock.NewJsonResponse(http.StatusOK, body)
+		})
+	httpmock.RegisterResponder("GET", baseURL+"/config/orders-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"compatibilityLevel": "BACKWARD",
+			"alias":              "orders-v2-value",
+		}))
+
+	putRes, err := c.PutSubjectAlias(context.Background(), "orders-value", "orders-v2-value")
+	require.NoError(t, err)
+	assert.Equal(t, "orders-v2-value", putRes.Alias)
+
+	config, err := c.GetSubjectConfig(context.Background(), "orders-value")
+	require.NoError(t, err)
+	assert.Equal(t, "orders-v2-value", config.Alias)
+	assert.Equal(t, CompatBackward, config.Compatibility)
+}
+
+func TestClient_SetMode(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	echoMode := func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+		}
+		return httpmock.NewJsonResponse(http.StatusOK, body)
+	}
+	httpmock.RegisterResponder("PUT", baseURL+"/mode", echoMode)
+	httpmock.RegisterResponder("PUT", baseURL+"/mode/orders-value", echoMode)
+	httpmock.RegisterResponder("PUT", baseURL+"/mode/payments-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error_code": 42205,
+			"message":    "Subject payments-value is not empty",
+		}))
+
+	res, err := c.SetMode(context.Background(), "READONLY")
+	require.NoError(t, err)
+	assert.Equal(t, "READONLY", res.Mode)
+
+	res, err = c.SetSubjectMode(context.Background(), "orders-value", "IMPORT")
+	require.NoError(t, err)
+	assert.Equal(t, "IMPORT", res.Mode)
+
+	_, err = c.SetSubjectMode(context.Background(), "payments-value", "IMPORT")
+	var restErr *RestError
+	require.ErrorAs(t, err, &restErr)
+	assert.Equal(t, 42205, restErr.ErrorCode)
+
+	callsBefore := httpmock.GetTotalCallCount()
+	_, err = c.SetMode(context.Background(), "readonly")
+	assert.Error(t, err)
+	_, err = c.SetSubjectMode(context.Background(), "orders-value", "")
+	assert.Error(t, err)
+	assert.Equal(t, callsBefore, httpmock.GetTotalCallCount(), "invalid modes must not be sent")
+}
+
+func TestClient_GetServerInfo(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	newMockedClient := func(t *testing.T) *Client {
+		c, err := newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{baseURL},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+		httpmock.ActivateNonDefault(c.client.GetClient())
+		t.Cleanup(httpmock.DeactivateAndReset)
+		return c
+	}
+
+	t.Run("version is parsed and cached", func(t *testing.T) {
+		c := newMockedClient(t)
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"version":  "7.5.1-ce",
+				"commitId": "3c4bbd5b26a8a7e1",
+			}))
+
+		info, err := c.GetServerInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "7.5.1-ce", info.Version)
+		assert.Equal(t, "3c4bbd5b26a8a7e1", info.CommitID)
+		assert.True(t, info.IsKnown())
+		assert.False(t, info.IsOlderThan(6, 0))
+		assert.False(t, info.IsOlderThan(7, 5))
+		assert.True(t, info.IsOlderThan(7, 6))
+
+		_, err = c.GetServerInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("registries without version endpoint", func(t *testing.T) {
+		c := newMockedClient(t)
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewStringResponder(http.StatusNotFound, ""))
+
+		info, err := c.GetServerInfo(context.Background())
+		require.NoError(t, err)
+		assert.False(t, info.IsKnown())
+		assert.False(t, info.IsOlderThan(6, 0))
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		c := newMockedClient(t)
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewJsonResponderOrPanic(http.StatusInternalServerError, map[string]interface{}{
+				"error_code": 50001,
+				"message":    "internal error",
+			}))
+
+		_, err := c.GetServerInfo(context.Background())
+		require.Error(t, err)
+
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"version": "7.5.1"}))
+		info, err := c.GetServerInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "7.5.1", info.Version)
+	})
+
+	t.Run("old registries are not asked for all schemas", func(t *testing.T) {
+		c := newMockedClient(t)
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"version": "5.5.3"}))
+		httpmock.RegisterResponder("GET", baseURL+"/subjects",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"orders-value"}))
+		httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, []int{1}))
+		httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/1",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"subject": "orders-value",
+				"version": 1,
+				"id":      1000,
+				"schema":  `"string"`,
+			}))
+
+		schemas, err := c.GetSchemas(context.Background(), false)
+		require.NoError(t, err)
+		require.Len(t, schemas, 1)
+		assert.Equal(t, 1000, schemas[0].SchemaID)
+		assert.Zero(t, httpmock.GetCallCountInfo()["GET "+baseURL+"/schemas"])
+	})
+}
+
+func TestClient_GetRegistryInfo(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	newMockedClient := func(t *testing.T) *Client {
+		c, err := newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{baseURL},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+		httpmock.ActivateNonDefault(c.client.GetClient())
+		t.Cleanup(httpmock.DeactivateAndReset)
+		return c
+	}
+
+	t.Run("redpanda registry is detected and cached", func(t *testing.T) {
+		c := newMockedClient(t)
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"version": "v23.2.1"}))
+		httpmock.RegisterResponder("GET", baseURL+"/schemas/types",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"AVRO", "PROTOBUF"}))
+
+		info, err := c.GetRegistryInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, &RegistryInfo{
+			ServerInfo:          ServerInfo{Version: "v23.2.1"},
+			Flavor:              RegistryFlavorRedpanda,
+			SupportedTypes:      []string{"AVRO", "PROTOBUF"},
+			SupportsBulkListing: true,
+		}, info)
+
+		_, err = c.GetRegistryInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("old confluent registry", func(t *testing.T) {
+		c := newMockedClient(t)
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"version": "5.4.2"}))
+		httpmock.RegisterResponder("GET", baseURL+"/schemas/types",
+			httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{"error_code": 404, "message": "HTTP 404 Not Found"}))
+
+		info, err := c.GetRegistryInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, RegistryFlavorConfluent, info.Flavor)
+		assert.Equal(t, []string{"AVRO"}, info.SupportedTypes)
+		assert.False(t, info.SupportsBulkListing)
+	})
+
+	t.Run("bulk listing is unsupported after the endpoint was not found", func(t *testing.T) {
+		c := newMockedClient(t)
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewStringResponder(http.StatusNotFound, ""))
+		httpmock.RegisterResponder("GET", baseURL+"/schemas/types",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"AVRO"}))
+		httpmock.RegisterResponder("GET", baseURL+"/schemas",
+			httpmock.NewStringResponder(http.StatusNotFound, ""))
+		httpmock.RegisterResponder("GET", baseURL+"/subjects",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{}))
+
+		info, err := c.GetRegistryInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, RegistryFlavorUnknown, info.Flavor)
+		assert.True(t, info.SupportsBulkListing, "unknown versions are assumed to support bulk listing")
+
+		_, err = c.GetSchemas(context.Background(), false)
+		require.NoError(t, err)
+		info, err = c.GetRegistryInfo(context.Background())
+		require.NoError(t, err)
+		assert.False(t, info.SupportsBulkListing)
+
+		// The fallback is used right away for further requests
+		_, err = c.GetSchemas(context.Background(), false)
+		require.NoError(t, err)
+		assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/schemas"])
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		c := newMockedClient(t)
+		httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"version": "7.5.1"}))
+		httpmock.RegisterResponder("GET", baseURL+"/schemas/types",
+			httpmock.NewJsonResponderOrPanic(http.StatusForbidden, map[string]interface{}{"error_code": 40301, "message": "forbidden"}))
+
+		_, err := c.GetRegistryInfo(context.Background())
+		require.Error(t, err)
+
+		httpmock.RegisterResponder("GET", baseURL+"/schemas/types",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"AVRO", "PROTOBUF", "JSON"}))
+		info, err := c.GetRegistryInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"AVRO", "PROTOBUF", "JSON"}, info.SupportedTypes)
+	})
+}
+
+func TestClient_GetSchemasFallback(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+
+	for _, tc := range []struct {
+		status   int
+		fallback bool
+	}{
+		{status: http.StatusNotFound, fallback: true},
+		{status: http.StatusMethodNotAllowed, fallback: true},
+		{status: http.StatusNotImplemented, fallback: true},
+		{status: http.StatusUnauthorized, fallback: false},
+		{status: http.StatusForbidden, fallback: false},
+		{status: http.StatusInternalServerError, fallback: false},
+	} {
+		t.Run(strconv.Itoa(tc.status), func(t *testing.T) {
+			c, err := newClient(config.Schema{
+				Enabled: true,
+				URLs:    []string{baseURL},
+			}, zap.NewNop(), nil)
+			require.NoError(t, err)
+			httpmock.ActivateNonDefault(c.client.GetClient())
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder("GET", baseURL+"/v1/metadata/version",
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"version": "7.5.1"}))
+			httpmock.RegisterResponder("GET", baseURL+"/schemas",
+				httpmock.NewStringResponder(tc.status, "<html>Error</html>"))
+			httpmock.RegisterResponder("GET", baseURL+"/subjects",
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"orders-value"}))
+			httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions",
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, []int{1}))
+			httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/1",
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, SchemaVersionedResponse{Subject: "orders-value", SchemaID: 1, Version: 1, Schema: `"string"`}))
+
+			schemas, err := c.GetSchemas(context.Background(), false)
+			if !tc.fallback {
+				require.Error(t, err)
+				assert.Zero(t, httpmock.GetCallCountInfo()["GET "+baseURL+"/subjects"], "real errors must not trigger the fallback")
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, schemas, 1)
+			assert.Equal(t, "orders-value", schemas[0].Subject)
+			assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/subjects"])
+		})
+	}
+}
+
+func TestClient_GetSchemasIndividuallyCancellation(t *testing.T) {
+	slowSubjects := []string{"slow-1", "slow-2", "slow-3"}
+	var cancelled atomic.Int32
+	var started sync.WaitGroup
+	started.Add(len(slowSubjects))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/subjects":
+			json.NewEncoder(w).Encode(append([]string{"broken"}, slowSubjects...))
+		case r.URL.Path == "/subjects/broken/versions":
+			// Fail only once all other requests are in flight, so that all of them must be cancelled
+			started.Wait()
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error_code":500,"message":"internal error"}`))
+		default:
+			// Slow subjects only respond once their request is cancelled
+			started.Done()
+			select {
+			case <-r.Context().Done():
+				cancelled.Add(1)
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newClient(config.Schema{Enabled: true, URLs: []string{ts.URL}}, zap.NewNop(), nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = c.GetSchemasIndividually(context.Background(), false)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second, "the first error must cancel all other requests")
+	assert.Eventually(t, func() bool { return cancelled.Load() == int32(len(slowSubjects)) },
+		time.Second, 10*time.Millisecond)
+}
+
+func TestParseMajorMinorVersion(t *testing.T) {
+	tt := []struct {
+		version string
+		major   int
+		minor   int
+		ok      bool
+	}{
+		{"7.5.1", 7, 5, true},
+		{"v23.2.1", 23, 2, true},
+		{"7.4-ce", 7, 4, true},
+		{"7", 0, 0, false},
+		{"latest", 0, 0, false},
+	}
+	for _, test := range tt {
+		major, minor, ok := parseMajorMinorVersion(test.version)
+		assert.Equal(t, test.ok, ok, test.version)
+		assert.Equal(t, test.major, major, test.version)
+		assert.Equal(t, test.minor, minor, test.version)
+	}
+}
+
+func TestClient_SetConfig(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	echoCompatibility := func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+		}
+		return httpmock.NewJsonResponse(http.StatusOK, body)
+	}
+	httpmock.RegisterResponder("PUT", baseURL+"/config", echoCompatibility)
+	httpmock.RegisterResponder("PUT", baseURL+"/config/orders-value", echoCompatibility)
+	httpmock.RegisterResponder("PUT", baseURL+"/config/unknown-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{
+			"error_code": CodeSubjectNotFound,
+			"message":    "Subject 'unknown-value' not found.",
+		}))
+
+	res, err := c.SetGlobalConfig(context.Background(), "FULL_TRANSITIVE")
+	require.NoError(t, err)
+	assert.Equal(t, CompatFullTransitive, res.Compatibility)
+
+	res, err = c.SetSubjectConfig(context.Background(), "orders-value", "BACKWARD")
+	require.NoError(t, err)
+	assert.Equal(t, CompatBackward, res.Compatibility)
+
+	res, err = c.SetSubjectConfig(context.Background(), "unknown-value", "NONE")
+	require.NoError(t, err)
+	assert.Equal(t, CompatDefault, res.Compatibility)
+
+	callsBefore := httpmock.GetTotalCallCount()
+	_, err = c.SetGlobalConfig(context.Background(), "DEFAULT")
+	assert.Error(t, err)
+	_, err = c.SetSubjectConfig(context.Background(), "orders-value", "SIDEWAYS")
+	assert.Error(t, err)
+	assert.Equal(t, callsBefore, httpmock.GetTotalCallCount(), "invalid levels must not be sent")
+}
+
+func TestClient_GetEffectiveSubjectConfig(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/config",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]string{"compatibilityLevel": "BACKWARD"}))
+	httpmock.RegisterResponder("GET", baseURL+"/config/orders-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]string{"compatibilityLevel": "FULL"}))
+	httpmock.RegisterResponder("GET", baseURL+"/config/payments-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, RestError{ErrorCode: CodeSubjectCompatibilityNotConfigured, Message: "Subject 'payments-value' does not have subject-level compatibility configured"}))
+
+	t.Run("subject with override", func(t *testing.T) {
+		httpmock.ZeroCallCounters()
+		actual, err := c.GetEffectiveSubjectConfig(context.Background(), "orders-value")
+		require.NoError(t, err)
+		assert.Equal(t, CompatFull, actual.Compatibility)
+		assert.Equal(t, CompatFull, actual.EffectiveCompatibility)
+		assert.False(t, actual.IsInherited)
+		assert.Zero(t, httpmock.GetCallCountInfo()["GET "+baseURL+"/config"], "global config is not needed")
+	})
+
+	t.Run("subject inheriting the global level", func(t *testing.T) {
+		actual, err := c.GetEffectiveSubjectConfig(context.Background(), "payments-value")
+		require.NoError(t, err)
+		assert.Equal(t, CompatDefault, actual.Compatibility)
+		assert.Equal(t, CompatBackward, actual.EffectiveCompatibility)
+		assert.True(t, actual.IsInherited)
+
+		jsonBytes, err := json.Marshal(actual)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"compatibilityLevel": "DEFAULT", "effectiveCompatibilityLevel": "BACKWARD", "isInherited": true}`, string(jsonBytes))
+	})
+
+	t.Run("global config unavailable", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/config",
+			httpmock.NewJsonResponderOrPanic(http.StatusForbidden, RestError{ErrorCode: 40301, Message: "forbidden"}))
+
+		actual, err := c.GetEffectiveSubjectConfig(context.Background(), "payments-value")
+		require.NoError(t, err)
+		assert.Equal(t, CompatDefault, actual.Compatibility)
+		assert.Zero(t, actual.EffectiveCompatibility)
+		assert.True(t, actual.IsInherited)
+
+		jsonBytes, err := json.Marshal(actual)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"compatibilityLevel": "DEFAULT", "isInherited": true}`, string(jsonBytes))
+
+		actual, err = c.GetEffectiveSubjectConfig(context.Background(), "orders-value")
+		require.NoError(t, err)
+		assert.Equal(t, CompatFull, actual.EffectiveCompatibility)
+	})
+}
+
+func TestClient_DeleteSubjectConfig(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("DELETE", baseURL+"/config/orders-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"compatibilityLevel": "FULL",
+		}))
+	httpmock.RegisterResponder("DELETE", baseURL+"/config/payments-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{
+			"error_code": CodeSubjectNotFound,
+			"message":    "Subject 'payments-value' not found.",
+		}))
+
+	res, err := c.DeleteSubjectConfig(context.Background(), "orders-value")
+	require.NoError(t, err)
+	assert.Equal(t, CompatFull, res.Compatibility)
+
+	_, err = c.DeleteSubjectConfig(context.Background(), "payments-value")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrSubjectNotFound)
+	var restErr *RestError
+	require.ErrorAs(t, err, &restErr, "the registry's error is still available")
+	assert.Equal(t, CodeSubjectNotFound, restErr.ErrorCode)
+
+	otherErr := RestError{ErrorCode: CodeVersionNotFound, Message: "Version not found."}
+	assert.NotErrorIs(t, otherErr, ErrSubjectNotFound)
+}
+
+func TestRestError_Is(t *testing.T) {
+	for sentinel, code := range errorCodesBySentinel {
+		restErr := &RestError{ErrorCode: code, Message: sentinel.Error()}
+		wrapped := fmt.Errorf("request failed: %w", restErr)
+		assert.ErrorIs(t, wrapped, sentinel)
+
+		var asRestErr *RestError
+		require.ErrorAs(t, wrapped, &asRestErr)
+		assert.Equal(t, code, asRestErr.ErrorCode)
+
+		for otherSentinel := range errorCodesBySentinel {
+			if otherSentinel != sentinel {
+				assert.NotErrorIs(t, wrapped, otherSentinel)
+			}
+		}
+	}
+
+	assert.NotErrorIs(t, &RestError{ErrorCode: 50001, Message: "Error in the backend data store"}, ErrSubjectNotFound)
+	assert.NotErrorIs(t, &RestError{ErrorCode: CodeSubjectNotFound}, errors.New("subject not found"), "only the sentinel errors match")
+}
+
+func TestClient_GetSubjectsByPrefix(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	// The registry ignores the prefix of schema contexts, so that the client has to filter them
+	subjects := []string{"orders-value", "payments-value", ":.tenant-a:orders-value", ":.tenant-b:orders-value"}
+	var prefixes []string
+	httpmock.RegisterResponder("GET", baseURL+"/subjects",
+		func(req *http.Request) (*http.Response, error) {
+			prefix := req.URL.Query().Get("subjectPrefix")
+			prefixes = append(prefixes, prefix)
+			if strings.HasPrefix(prefix, ":") {
+				return httpmock.NewJsonResponse(http.StatusOK, subjects)
+			}
+			var res []string
+			for _, subject := range subjects {
+				if strings.HasPrefix(subject, prefix) && !strings.HasPrefix(subject, ":") {
+					res = append(res, subject)
+				}
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, res)
+		})
+
+	res, err := c.GetSubjectsByPrefix(context.Background(), "orders", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders-value"}, res.Subjects)
+
+	res, err = c.GetSubjectsByPrefix(context.Background(), ContextSubjectPrefix("tenant-a"), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{":.tenant-a:orders-value"}, res.Subjects)
+
+	res, err = c.GetSubjectsByPrefix(context.Background(), ":*:", false)
+	require.NoError(t, err)
+	assert.Equal(t, subjects, res.Subjects)
+
+	res, err = c.GetSubjects(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders-value", "payments-value"}, res.Subjects)
+
+	assert.Equal(t, []string{"orders", ":.tenant-a:", ":*:", ""}, prefixes)
+	assert.Equal(t, ":.tenant-a:", ContextSubjectPrefix(".tenant-a"))
+}
+
+func TestClient_SchemaContext(t *testing.T) {
+	var requestedPaths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPaths = append(requestedPaths, r.URL.Path+"?"+r.URL.Query().Get("subjectPrefix"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/contexts":
+			_ = json.NewEncoder(w).Encode([]string{".", ".tenant-a"})
+		case "/subjects":
+			_ = json.NewEncoder(w).Encode([]string{"orders-value", ":.tenant-a:orders-value"})
+		case "/subjects/orders-value/versions/latest", "/subjects/:.tenant-a:orders-value/versions/latest", "/subjects/:.tenant-b:orders-value/versions/latest":
+			_ = json.NewEncoder(w).Encode(SchemaVersionedResponse{Subject: strings.Split(r.URL.Path, "/")[2], Version: 1, Schema: `"string"`})
+		case "/subjects/orders-value/versions", "/subjects/:.tenant-a:orders-value/versions":
+			_ = json.NewEncoder(w).Encode(CreateSchemaResponse{ID: 1})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("subjects are prefixed with the configured context", func(t *testing.T) {
+		requestedPaths = nil
+		c, err := newClient(config.Schema{Enabled: true, URLs: []string{srv.URL}, Context: "tenant-a"}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		contexts, err := c.GetContexts(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{".", ".tenant-a"}, contexts)
+
+		subjects, err := c.GetSubjects(context.Background(), false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{":.tenant-a:orders-value"}, subjects.Subjects)
+
+		schemaRes, err := c.GetSchemaBySubject(context.Background(), "orders-value", "latest", false)
+		require.NoError(t, err)
+		assert.Equal(t, ":.tenant-a:orders-value", schemaRes.Subject)
+
+		// Qualified subject names, as returned by GetSubjects, are not prefixed again
+		schemaRes, err = c.GetSchemaBySubject(context.Background(), ":.tenant-b:orders-value", "latest", false)
+		require.NoError(t, err)
+		assert.Equal(t, ":.tenant-b:orders-value", schemaRes.Subject)
+
+		_, err = c.CreateSchema(context.Background(), "orders-value", Schema{Schema: `"string"`}, true)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"/contexts?",
+			"/subjects?:.tenant-a:",
+			"/subjects/:.tenant-a:orders-value/versions/latest?",
+			"/subjects/:.tenant-b:orders-value/versions/latest?",
+			"/subjects/:.tenant-a:orders-value/versions?",
+		}, requestedPaths)
+	})
+
+	t.Run("all subject and id lookups are scoped to the context", func(t *testing.T) {
+		var requests []string
+		scopedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case strings.HasPrefix(r.URL.Path, "/schemas/ids/1/"):
+				_ = json.NewEncoder(w).Encode([]interface{}{})
+			case r.URL.Path == "/schemas/ids/1":
+				_ = json.NewEncoder(w).Encode(SchemaResponse{Schema: `"string"`})
+			case strings.HasPrefix(r.URL.Path, "/config/"), strings.HasPrefix(r.URL.Path, "/mode/"):
+				_ = json.NewEncoder(w).Encode(map[string]string{"compatibilityLevel": "BACKWARD", "mode": "READONLY"})
+			case r.Method == http.MethodDelete:
+				_ = json.NewEncoder(w).Encode(1)
+			default:
+				_ = json.NewEncoder(w).Encode([]int{1})
+			}
+		}))
+		defer scopedSrv.Close()
+
+		c, err := newClient(config.Schema{Enabled: true, URLs: []string{scopedSrv.URL}, Context: "tenant-a"}, zap.NewNop(), nil)
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		_, err = c.GetSubjectVersions(ctx, "orders-value", false)
+		require.NoError(t, err)
+		_, err = c.GetSubjectConfig(ctx, "orders-value")
+		require.NoError(t, err)
+		_, err = c.SetSubjectMode(ctx, "orders-value", "READONLY")
+		require.NoError(t, err)
+		_, err = c.DeleteSubjectVersion(ctx, "orders-value", "1", false)
+		require.NoError(t, err)
+		_, err = c.GetSchemaReferencedBy(ctx, "orders-value", "1")
+		require.NoError(t, err)
+		_, err = c.GetSchemaByID(ctx, 1)
+		require.NoError(t, err)
+		_, err = c.GetSchemaVersionsByID(ctx, 1)
+		require.NoError(t, err)
+		_, err = c.GetSubjectsByID(ctx, 1, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"GET /subjects/:.tenant-a:orders-value/versions?",
+			"GET /config/:.tenant-a:orders-value?",
+			"PUT /mode/:.tenant-a:orders-value?",
+			"DELETE /subjects/:.tenant-a:orders-value/versions/1?permanent=false",
+			"GET /subjects/:.tenant-a:orders-value/versions/1/referencedby?deleted=true",
+			"GET /schemas/ids/1?subject=%3A.tenant-a%3A",
+			"GET /schemas/ids/1/versions?subject=%3A.tenant-a%3A",
+			"GET /schemas/ids/1/subjects?subject=%3A.tenant-a%3A",
+		}, requests)
+	})
+
+	t.Run("empty context keeps subject names", func(t *testing.T) {
+		requestedPaths = nil
+		c, err := newClient(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		_, err = c.GetSubjects(context.Background(), false)
+		require.NoError(t, err)
+		_, err = c.GetSchemaBySubject(context.Background(), "orders-value", "latest", false)
+		require.NoError(t, err)
+		_, err = c.CreateSchema(context.Background(), "orders-value", Schema{Schema: `"string"`}, true)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"/subjects?",
+			"/subjects/orders-value/versions/latest?",
+			"/subjects/orders-value/versions?",
+		}, requestedPaths)
+	})
+}
+
+func TestClient_CreateSchema(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	var normalizeParams []string
+	var bodies []map[string]interface{}
+	httpmock.RegisterResponder("POST", baseURL+"/subjects/orders-value/versions",
+		func(req *http.Request) (*http.Response, error) {
+			normalizeParams = append(normalizeParams, req.URL.Query().Get("normalize"))
+			var body map[string]interface{}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return httpmock.NewStringResponse(http.StatusBadRequest, err.Error()), nil
+			}
+			bodies = append(bodies, body)
+			return httpmock.NewJsonResponse(http.StatusOK, CreateSchemaResponse{ID: len(bodies)})
+		})
+
+	res, err := c.CreateSchema(context.Background(), "orders-value", Schema{Schema: `"string"`}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.ID)
+
+	protoSchema := Schema{Schema: "syntax = \"proto3\";\nmessage Order {}", Type: TypeProtobuf}
+	_, err = c.CreateSchema(context.Background(), "orders-value", protoSchema, false)
+	require.NoError(t, err)
+
+	jsonSchema := Schema{Schema: `{"type": "object"}`, Type: TypeJSON}
+	_, err = c.CreateSchema(context.Background(), "orders-value", jsonSchema, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"true", "false", "false"}, normalizeParams)
+	require.Len(t, bodies, 3)
+	assert.NotContains(t, bodies[0], "schemaType", "the type defaults to Avro")
+	assert.Equal(t, "PROTOBUF", bodies[1]["schemaType"])
+	assert.Equal(t, protoSchema.Schema, bodies[1]["schema"])
+	assert.Equal(t, "JSON", bodies[2]["schemaType"])
+}
+
+func TestClient_ImportSchema(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	var body map[string]interface{}
+	httpmock.RegisterResponder("POST", baseURL+"/subjects/orders-value/versions",
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return httpmock.NewStringResponse(http.StatusBadRequest, err.Error()), nil
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, CreateSchemaResponse{ID: 42})
+		})
+	httpmock.RegisterResponder("POST", baseURL+"/subjects/payments-value/versions",
+		httpmock.NewJsonResponderOrPanic(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error_code": 42205,
+			"message":    "Subject payments-value is not in import mode",
+		}))
+
+	protoSchema := Schema{Schema: "syntax = \"proto3\";\nmessage Order {}", Type: TypeProtobuf}
+	res, err := c.ImportSchema(context.Background(), "orders-value", protoSchema, 42, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 42, res.ID)
+	assert.Equal(t, map[string]interface{}{
+		"schema":     protoSchema.Schema,
+		"schemaType": "PROTOBUF",
+		"id":         float64(42),
+		"version":    float64(3),
+	}, body)
+
+	_, err = c.ImportSchema(context.Background(), "payments-value", protoSchema, 42, 3)
+	var restErr *RestError
+	require.ErrorAs(t, err, &restErr)
+	assert.Equal(t, 42205, restErr.ErrorCode)
+
+	_, err = c.ImportSchema(context.Background(), "orders-value", protoSchema, 0, 3)
+	assert.Error(t, err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount(), "invalid IDs must not be sent")
+}
+
+func TestClient_LookupSchema(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	registered := `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`
+	httpmock.RegisterResponder("POST", baseURL+"/subjects/orders-value",
+		func(req *http.Request) (*http.Response, error) {
+			var sch Schema
+			if err := json.NewDecoder(req.Body).Decode(&sch); err != nil {
+				return nil, err
+			}
+			if sch.Schema != registered {
+				return httpmock.NewJsonResponse(http.StatusNotFound, map[string]interface{}{
+					"error_code": CodeSchemaNotFound,
+					"message":    "Schema not found",
+				})
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"subject": "orders-value",
+				"id":      7,
+				"version": 3,
+				"schema":  registered,
+			})
+		})
+	httpmock.RegisterResponder("POST", baseURL+"/subjects/payments-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{
+			"error_code": CodeSubjectNotFound,
+			"message":    "Subject 'payments-value' not found.",
+		}))
+
+	res, err := c.LookupSchema(context.Background(), "orders-value", Schema{Schema: registered})
+	require.NoError(t, err)
+	assert.Equal(t, "orders-value", res.Subject)
+	assert.Equal(t, 7, res.SchemaID)
+	assert.Equal(t, 3, res.Version)
+	assert.Equal(t, registered, res.Schema)
+
+	_, err = c.LookupSchema(context.Background(), "orders-value", Schema{Schema: `"string"`})
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+	assert.NotErrorIs(t, err, ErrSubjectNotFound)
+
+	_, err = c.LookupSchema(context.Background(), "payments-value", Schema{Schema: registered})
+	assert.ErrorIs(t, err, ErrSubjectNotFound)
+	assert.NotErrorIs(t, err, ErrSchemaNotFound)
+}
+
+func TestClient_GetSchemaReferencedBy(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/customer/versions/1/referencedby?deleted=true",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []int{7, 9}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/customer/versions/2/referencedby?deleted=true",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []int{}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/customer/versions/3/referencedby?deleted=true",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{
+			"error_code": CodeVersionNotFound,
+			"message":    "Version 3 not found.",
+		}))
+
+	ids, err := c.GetSchemaReferencedBy(context.Background(), "customer", "1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{7, 9}, ids)
+
+	ids, err = c.GetSchemaReferencedBy(context.Background(), "customer", "2")
+	require.NoError(t, err)
+	assert.NotNil(t, ids)
+	assert.Empty(t, ids)
+
+	_, err = c.GetSchemaReferencedBy(context.Background(), "customer", "3")
+	var restErr *RestError
+	require.ErrorAs(t, err, &restErr)
+	assert.Equal(t, CodeVersionNotFound, restErr.ErrorCode)
+
+	res, err := c.GetSchemaReferences(context.Background(), "customer", "1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{7, 9}, res.SchemaIDs)
+}
+
+func TestClient_GetSchemaVersionsByID(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1/versions",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []SubjectVersion{
+			{Subject: "orders-value", Version: 1},
+			{Subject: "orders-archive-value", Version: 3},
+		}))
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/2/versions",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []SubjectVersion{}))
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/3/versions",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, RestError{ErrorCode: CodeSchemaNotFound, Message: "Schema 3 not found"}))
+
+	versions, err := c.GetSchemaVersionsByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []SubjectVersion{
+		{Subject: "orders-value", Version: 1},
+		{Subject: "orders-archive-value", Version: 3},
+	}, versions)
+
+	// The ID exists, but all its subject versions have been deleted
+	versions, err = c.GetSchemaVersionsByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.NotNil(t, versions)
+	assert.Empty(t, versions)
+
+	_, err = c.GetSchemaVersionsByID(context.Background(), 3)
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+}
+
+func TestClient_GetSubjectsByID(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	// Schema 2 is only used by a soft-deleted version
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1/subjects",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"orders-value", "orders-archive-value"}))
+	httpmock.RegisterResponderWithQuery("GET", baseURL+"/schemas/ids/2/subjects", "deleted=true",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"payments-value"}))
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/2/subjects",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{}))
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/3/subjects",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, RestError{ErrorCode: CodeSchemaNotFound, Message: "Schema 3 not found"}))
+
+	subjects, err := c.GetSubjectsByID(context.Background(), 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders-value", "orders-archive-value"}, subjects)
+
+	subjects, err = c.GetSubjectsByID(context.Background(), 2, false)
+	require.NoError(t, err)
+	assert.NotNil(t, subjects)
+	assert.Empty(t, subjects)
+
+	subjects, err = c.GetSubjectsByID(context.Background(), 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments-value"}, subjects)
+
+	_, err = c.GetSubjectsByID(context.Background(), 3, false)
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+}
+
+func TestClient_SchemaIDCache(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/schemas/ids/1" {
+			<-release
+		}
+		if r.URL.Path == "/schemas/ids/404" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"error_code": CodeSchemaNotFound, "message": "Schema not found"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": `"string"`})
+	}))
+	defer srv.Close()
+
+	c, err := newClient(config.Schema{
+		Enabled:           true,
+		URLs:              []string{srv.URL},
+		SchemaIDCacheSize: 2,
+	}, zap.NewNop(), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("concurrent lookups are coalesced", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := c.GetSchemaByID(ctx, 1)
+				assert.NoError(t, err)
+				assert.Equal(t, `"string"`, res.Schema)
+			}()
+		}
+		// Give all goroutines a chance to wait for the in-flight request
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.EqualValues(t, 1, requests.Load())
+
+		_, err := c.GetSchemaByID(ctx, 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, requests.Load(), "cached schemas are not requested again")
+	})
+
+	t.Run("least recently used schemas are evicted", func(t *testing.T) {
+		requests.Store(0)
+		_, err := c.GetSchemaByID(ctx, 2)
+		require.NoError(t, err)
+		_, err = c.GetSchemaByID(ctx, 1) // 2 is now the least recently used schema
+		require.NoError(t, err)
+		_, err = c.GetSchemaByID(ctx, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 2, c.schemaByID.len())
+		assert.EqualValues(t, 2, requests.Load())
+
+		_, err = c.GetSchemaByID(ctx, 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, requests.Load())
+		_, err = c.GetSchemaByID(ctx, 2)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, requests.Load())
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		requests.Store(0)
+		for i := 0; i < 2; i++ {
+			_, err := c.GetSchemaByID(ctx, 404)
+			assert.ErrorIs(t, err, ErrSchemaNotFound)
+		}
+		assert.EqualValues(t, 2, requests.Load())
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		requests.Store(0)
+		c.InvalidateSchemaByID(2)
+		_, err := c.GetSchemaByID(ctx, 2)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, requests.Load())
+
+		c.InvalidateSchemasByID()
+		assert.Equal(t, 0, c.schemaByID.len())
+	})
+
+	t.Run("expired schemas are fetched again", func(t *testing.T) {
+		requests.Store(0)
+		ttlClient, err := newClient(config.Schema{
+			Enabled:           true,
+			URLs:              []string{srv.URL},
+			SchemaIDCacheSize: 10,
+			SchemaIDCacheTTL:  time.Millisecond,
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		_, err = ttlClient.GetSchemaByID(ctx, 2)
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		_, err = ttlClient.GetSchemaByID(ctx, 2)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, requests.Load())
+	})
+}
+
+func TestClient_URLFailover(t *testing.T) {
+	var healthyRequests []string
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		healthyRequests = append(healthyRequests, r.Method+" "+r.URL.EscapedPath()+" "+string(body))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.EscapedPath() {
+		case "/registry/subjects":
+			_ = json.NewEncoder(w).Encode([]string{"orders-value"})
+		case "/registry/config/orders%2Fv1-value":
+			_, _ = w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(RestError{ErrorCode: CodeSubjectNotFound, Message: "Subject not found."})
+		}
+	}))
+	defer healthy.Close()
+
+	var unavailableRequests int
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		unavailableRequests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	// A server that has been shut down refuses connections
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	c, err := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{down.URL, unavailable.URL + "/registry/", healthy.URL + "/registry"},
+	}, zap.NewNop(), nil)
+	require.NoError(t, err)
+
+	subjects, err := c.GetSubjects(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders-value"}, subjects.Subjects)
+	assert.Equal(t, 1, unavailableRequests)
+
+	t.Run("subsequent requests are sent to the healthy url", func(t *testing.T) {
+		res, err := c.PutSubjectConfig(context.Background(), "orders/v1-value", CompatFull)
+		require.NoError(t, err)
+		assert.Equal(t, CompatFull, res.Compatibility)
+		assert.Equal(t, 1, unavailableRequests)
+		assert.Equal(t, `PUT /registry/config/orders%2Fv1-value {"compatibility":"FULL"}`, healthyRequests[len(healthyRequests)-1])
+	})
+
+	t.Run("4xx responses are not retried", func(t *testing.T) {
+		requestsBefore := len(healthyRequests)
+		_, err := c.GetSubjectVersions(context.Background(), "unknown-value", false)
+		assert.ErrorIs(t, err, ErrSubjectNotFound)
+		assert.Equal(t, requestsBefore+1, len(healthyRequests))
+		assert.Equal(t, 1, unavailableRequests)
+	})
+
+	t.Run("request fails if all urls fail", func(t *testing.T) {
+		c, err := newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{down.URL, unavailable.URL},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		_, err = c.GetSchemaTypes(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, 2, unavailableRequests)
+
+		c, err = newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{unavailable.URL, down.URL},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		_, err = c.GetSchemaTypes(context.Background())
+		assert.ErrorContains(t, err, "request failed on all 2 schema registry urls")
+	})
+
+	t.Run("writes only fail over if the connection can't be established", func(t *testing.T) {
+		c, err := newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{unavailable.URL, healthy.URL + "/registry"},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		unavailableBefore, healthyBefore := unavailableRequests, len(healthyRequests)
+		_, err = c.PutSubjectConfig(context.Background(), "orders/v1-value", CompatFull)
+		assert.Error(t, err)
+		assert.Equal(t, unavailableBefore+1, unavailableRequests)
+		assert.Equal(t, healthyBefore, len(healthyRequests), "the write may have been applied by the failed node")
+
+		c, err = newClient(config.Schema{
+			Enabled: true,
+			URLs:    []string{down.URL, healthy.URL + "/registry"},
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+
+		res, err := c.PutSubjectConfig(context.Background(), "orders/v1-value", CompatFull)
+		require.NoError(t, err)
+		assert.Equal(t, CompatFull, res.Compatibility)
+	})
+
+	t.Run("max request attempts", func(t *testing.T) {
+		c, err := newClient(config.Schema{
+			Enabled:       true,
+			URLs:          []string{down.URL, unavailable.URL, healthy.URL},
+			RetryAttempts: 2,
+		}, zap.NewNop(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 9, c.MaxRequestAttempts(http.MethodGet))
+		assert.Equal(t, 3, c.MaxRequestAttempts(http.MethodPost))
+	})
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	requestsByMethod := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsByMethod[r.Method]++
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/subjects/orders-value/versions" && r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(RestError{ErrorCode: CodeSubjectNotFound, Message: "Subject not found."})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(RestError{ErrorCode: 50001, Message: "Internal error."})
+	}))
+	defer srv.Close()
+
+	c, err := newClient(config.Schema{
+		Enabled:       true,
+		URLs:          []string{srv.URL},
+		RetryAttempts: 3,
+		RetryBackoff:  time.Millisecond,
+	}, zap.NewNop(), nil)
+	require.NoError(t, err)
+
+	_, err = c.GetSchemaTypes(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 4, requestsByMethod[http.MethodGet], "the initial request and 3 retries")
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		requestsByMethod[http.MethodGet] = 0
+		_, err := c.GetSubjectVersions(context.Background(), "orders-value", false)
+		assert.ErrorIs(t, err, ErrSubjectNotFound)
+		assert.Equal(t, 1, requestsByMethod[http.MethodGet])
+	})
+
+	t.Run("requests other than GET are not retried", func(t *testing.T) {
+		_, err := c.PutConfig(context.Background(), CompatFull)
+		assert.Error(t, err)
+		assert.Equal(t, 1, requestsByMethod[http.MethodPut])
+	})
+
+	t.Run("no retries by default", func(t *testing.T) {
+		c, err := newClient(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, defaultRequestTimeout, c.client.GetClient().Timeout)
+
+		requestsByMethod[http.MethodGet] = 0
+		_, err = c.GetSchemaTypes(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, 1, requestsByMethod[http.MethodGet])
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		c, err := newClient(config.Schema{Enabled: true, URLs: []string{srv.URL}, RequestTimeout: time.Minute}, zap.NewNop(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, c.client.GetClient().Timeout)
+	})
+}
+
+func TestClient_RegisterVersions(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	// The registry only accepts schemas that add optional fields, so the last version of the
+	// history is incompatible.
+	var registered []string
+	isCompatible := func(schema string) bool { return !strings.Contains(schema, "required") }
+	decodeSchema := func(req *http.Request) Schema {
+		var schema Schema
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&schema))
+		return schema
+	}
+	httpmock.RegisterResponder("POST", baseURL+"/compatibility/subjects/orders-value/versions/latest",
+		func(req *http.Request) (*http.Response, error) {
+			if len(registered) == 0 {
+				return httpmock.NewJsonResponse(http.StatusNotFound, RestError{ErrorCode: CodeSubjectNotFound, Message: "Subject 'orders-value' not found."})
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, CheckCompatibilityResponse{IsCompatible: isCompatible(decodeSchema(req).Schema)})
+		})
+	httpmock.RegisterResponder("POST", baseURL+"/subjects/orders-value/versions",
+		func(req *http.Request) (*http.Response, error) {
+			registered = append(registered, decodeSchema(req).Schema)
+			return httpmock.NewJsonResponse(http.StatusOK, CreateSchemaResponse{ID: 100 + len(registered)})
+		})
+
+	history := []Schema{
+		{Schema: `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`},
+		{Schema: `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"note","type":["null","string"],"default":null}]}`},
+		{Schema: `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"required","type":"string"}]}`},
+		{Schema: `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`},
+	}
+
+	t.Run("compatible history", func(t *testing.T) {
+		registered = nil
+		ids, err := c.RegisterVersions(context.Background(), "orders-value", history[:2])
+		require.NoError(t, err)
+		assert.Equal(t, []int{101, 102}, ids)
+	})
+
+	t.Run("subject of the configured context", func(t *testing.T) {
+		contextClient, err := newClient(config.Schema{Enabled: true, URLs: []string{baseURL}, Context: "tenant-a"}, zap.NewNop(), nil)
+		require.NoError(t, err)
+		httpmock.ActivateNonDefault(contextClient.client.GetClient())
+
+		var contextRegistered []string
+		httpmock.RegisterResponder("POST", baseURL+"/compatibility/subjects/:.tenant-a:orders-value/versions/latest",
+			httpmock.NewJsonResponderOrPanic(http.StatusNotFound, RestError{ErrorCode: CodeSubjectNotFound, Message: "Subject not found."}))
+		httpmock.RegisterResponder("POST", baseURL+"/subjects/:.tenant-a:orders-value/versions",
+			func(req *http.Request) (*http.Response, error) {
+				contextRegistered = append(contextRegistered, decodeSchema(req).Schema)
+				return httpmock.NewJsonResponse(http.StatusOK, CreateSchemaResponse{ID: 200})
+			})
+
+		ids, err := contextClient.RegisterVersions(context.Background(), "orders-value", history[:1])
+		require.NoError(t, err)
+		assert.Equal(t, []int{200}, ids)
+		assert.Len(t, contextRegistered, 1)
+	})
+
+	t.Run("stops on first incompatible schema", func(t *testing.T) {
+		registered = nil
+		ids, err := c.RegisterVersions(context.Background(), "orders-value", history)
+		assert.Equal(t, []int{101, 102}, ids)
+		assert.Len(t, registered, 2)
+
+		var registerErr *RegisterVersionsError
+		require.ErrorAs(t, err, &registerErr)
+		assert.Equal(t, 2, registerErr.Index)
+		assert.True(t, registerErr.Incompatible)
+	})
+}
+
+func TestClient_OAuth(t *testing.T) {
+	var tokenRequests atomic.Int32
+	expiresIn := 3600
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := tokenRequests.Add(1)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "registry:read registry:write", r.PostForm.Get("scope"))
+		clientID, clientSecret, _ := r.BasicAuth()
+		assert.Equal(t, "console", clientID)
+		assert.Equal(t, "secret", clientSecret)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	defer tokenSrv.Close()
+
+	var authHeaders []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]string{"orders-value"})
+	}))
+	defer srv.Close()
+
+	newOAuthClient := func() *Client {
+		cfg := config.Schema{
+			Enabled:     true,
+			URLs:        []string{srv.URL},
+			BearerToken: "static-token",
+			OAuth: config.SchemaOAuth{
+				TokenURL:     tokenSrv.URL,
+				ClientID:     "console",
+				ClientSecret: "secret",
+				Scopes:       []string{"registry:read", "registry:write"},
+			},
+		}
+		require.NoError(t, cfg.Validate())
+		c, err := newClient(cfg, zap.NewNop(), nil)
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("token is reused until it expires", func(t *testing.T) {
+		authHeaders = nil
+		tokenRequests.Store(0)
+		c := newOAuthClient()
+		for i := 0; i < 2; i++ {
+			_, err := c.GetSubjects(context.Background(), false)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"Bearer token-1", "Bearer token-1"}, authHeaders)
+		assert.Equal(t, int32(1), tokenRequests.Load())
+	})
+
+	t.Run("expired token is refreshed", func(t *testing.T) {
+		// Tokens are refreshed shortly before they expire, which is immediately for this lifetime
+		expiresIn = 1
+		defer func() { expiresIn = 3600 }()
+		authHeaders = nil
+		tokenRequests.Store(0)
+		c := newOAuthClient()
+		for i := 0; i < 2; i++ {
+			_, err := c.GetSubjects(context.Background(), false)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, authHeaders)
+	})
+
+	t.Run("token errors fail the request", func(t *testing.T) {
+		closedSrv := httptest.NewServer(http.NotFoundHandler())
+		closedSrv.Close()
+		cfg := config.Schema{
+			Enabled: true,
+			URLs:    []string{srv.URL},
+			OAuth:   config.SchemaOAuth{TokenURL: closedSrv.URL, ClientID: "console", ClientSecret: "secret"},
+		}
+		c, err := newClient(cfg, zap.NewNop(), nil)
+		require.NoError(t, err)
+		_, err = c.GetSubjects(context.Background(), false)
+		assert.ErrorContains(t, err, "failed to acquire oauth token")
+	})
+
+	t.Run("token requests honour the request context", func(t *testing.T) {
+		c := newOAuthClient()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := c.GetSubjects(ctx, false)
+		assert.ErrorContains(t, err, "failed to acquire oauth token")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("token requests use the configured proxy", func(t *testing.T) {
+		var proxiedHosts []string
+		var mu sync.Mutex
+		proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			proxiedHosts = append(proxiedHosts, r.URL.Host)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodPost {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "proxied", "token_type": "bearer", "expires_in": 3600})
+				return
+			}
+			_ = json.NewEncoder(w).Encode([]string{"orders-value"})
+		}))
+		defer proxySrv.Close()
+
+		cfg := config.Schema{
+			Enabled:  true,
+			URLs:     []string{"http://registry.internal"},
+			ProxyURL: proxySrv.URL,
+			OAuth:    config.SchemaOAuth{TokenURL: "http://idp.internal/token", ClientID: "console", ClientSecret: "secret"},
+		}
+		c, err := newClient(cfg, zap.NewNop(), nil)
+		require.NoError(t, err)
+		_, err = c.GetSubjects(context.Background(), false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"idp.internal", "registry.internal"}, proxiedHosts)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		cfg := config.SchemaOAuth{TokenURL: tokenSrv.URL, ClientID: "console"}
+		assert.Error(t, cfg.Validate())
+	})
+}
+
+func TestClient_ContextCredentials(t *testing.T) {
+	var authHeaders []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]string{"orders-value"})
+	}))
+	defer srv.Close()
+
+	basic := func(username, password string) string {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, http.NoBody)
+		req.SetBasicAuth(username, password)
+		return req.Header.Get("Authorization")
+	}
+
+	for _, tc := range []struct {
+		name     string
+		cfg      config.Schema
+		ctx      context.Context
+		expected string
+	}{
+		{
+			name:     "configured basic auth",
+			cfg:      config.Schema{Username: "console", Password: "secret"},
+			ctx:      context.Background(),
+			expected: basic("console", "secret"),
+		},
+		{
+			name:     "basic auth overrides configured basic auth",
+			cfg:      config.Schema{Username: "console", Password: "secret"},
+			ctx:      ContextWithCredentials(context.Background(), Credentials{Username: "jane", Password: "pw"}),
+			expected: basic("jane", "pw"),
+		},
+		{
+			name:     "basic auth overrides configured bearer token",
+			cfg:      config.Schema{BearerToken: "console-token"},
+			ctx:      ContextWithCredentials(context.Background(), Credentials{Username: "jane", Password: "pw"}),
+			expected: basic("jane", "pw"),
+		},
+		{
+			name:     "bearer token overrides configured basic auth",
+			cfg:      config.Schema{Username: "console", Password: "secret"},
+			ctx:      ContextWithCredentials(context.Background(), Credentials{BearerToken: "jane-token"}),
+			expected: "Bearer jane-token",
+		},
+		{
+			name:     "empty credentials don't fall back to configured credentials",
+			cfg:      config.Schema{Username: "console", Password: "secret"},
+			ctx:      ContextWithCredentials(context.Background(), Credentials{}),
+			expected: "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			authHeaders = nil
+			cfg := tc.cfg
+			cfg.Enabled = true
+			cfg.URLs = []string{srv.URL}
+			c, err := newClient(cfg, zap.NewNop(), nil)
+			require.NoError(t, err)
+
+			_, err = c.GetSubjects(tc.ctx, false)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tc.expected}, authHeaders)
+		})
+	}
+}
+
+func TestClient_PathPrefix(t *testing.T) {
+	var requestedPaths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPaths = append(requestedPaths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/schema-registry/subjects":
+			_ = json.NewEncoder(w).Encode([]string{"orders-value"})
+		case "/schema-registry/subjects/orders-value/versions/latest":
+			_ = json.NewEncoder(w).Encode(SchemaVersionedResponse{Subject: "orders-value", Version: 1, Schema: `"string"`})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	for _, registryURL := range []string{
+		srv.URL + "/schema-registry",
+		srv.URL + "/schema-registry/",
+		srv.URL + "/schema-registry//",
+	} {
+		t.Run(registryURL, func(t *testing.T) {
+			requestedPaths = nil
+			c, err := newClient(config.Schema{Enabled: true, URLs: []string{registryURL}}, zap.NewNop(), nil)
+			require.NoError(t, err)
+
+			subjects, err := c.GetSubjects(context.Background(), false)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"orders-value"}, subjects.Subjects)
+
+			schemaRes, err := c.GetSchemaBySubject(context.Background(), "orders-value", "latest", false)
+			require.NoError(t, err)
+			assert.Equal(t, 1, schemaRes.Version)
+
+			assert.Equal(t, []string{
+				"/schema-registry/subjects",
+				"/schema-registry/subjects/orders-value/versions/latest",
+			}, requestedPaths)
+		})
+	}
+}
+
+func TestNormalizeRegistryURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		errMsg   string
+	}{
+		{input: "https://host:8081", expected: "https://host:8081"},
+		{input: "https://host:8081/", expected: "https://host:8081"},
+		{input: "https://host/schema-registry/", expected: "https://host/schema-registry"},
+		{input: "https://host/api//schema-registry//", expected: "https://host/api/schema-registry"},
+		{input: "host:8081", errMsg: "must be absolute"},
+		{input: "https://host/schema-registry?tenant=a", errMsg: "must not contain a query or fragment"},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			actual, err := normalizeRegistryURL(test.input)
+			if test.errMsg != "" {
+				assert.ErrorContains(t, err, test.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestClient_CheckCompatibilityGroup(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	c, _ := newClient(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil)
+
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	// The subject has the compatibility group "major_version" configured and its latest
+	// version is in group "1". Breaking changes are only accepted in a new group.
+	const group = "major_version"
+	httpmock.RegisterResponder("POST", baseURL+"/compatibility/subjects/orders-value/versions/latest",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "true", req.URL.Query().Get("verbose"))
+			var schema Schema
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&schema))
+			if schema.Metadata != nil && schema.Metadata.Properties[group] != "1" {
+				return httpmock.NewJsonResponse(http.StatusOK, CheckCompatibilityResponse{IsCompatible: true})
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, CheckCompatibilityResponse{
+				IsCompatible: false,
+				Messages:     []string{"{errorType:'READER_FIELD_MISSING_DEFAULT_VALUE', description:'The field 'note' at path '/fields/1' in the new schema has no default value'}"},
+			})
+		})
+
+	breaking := `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"note","type":"string"}]}`
+
+	t.Run("same group", func(t *testing.T) {
+		res, err := c.CheckCompatibility(context.Background(), "orders-value", "latest", Schema{
+			Schema:   breaking,
+			Metadata: &SchemaMetadata{Properties: map[string]string{group: "1"}},
+		})
+		require.NoError(t, err)
+		assert.False(t, res.IsCompatible)
+		require.Len(t, res.Messages, 1)
+		assert.Contains(t, res.Messages[0], "READER_FIELD_MISSING_DEFAULT_VALUE")
+	})
+
+	t.Run("new group", func(t *testing.T) {
+		res, err := c.CheckCompatibility(context.Background(), "orders-value", "latest", Schema{
+			Schema:   breaking,
+			Metadata: &SchemaMetadata{Properties: map[string]string{group: "2"}},
+		})
+		require.NoError(t, err)
+		assert.True(t, res.IsCompatible)
+		assert.Empty(t, res.Messages)
+	})
+}
+
+func TestClient_UnexpectedResponseBody(t *testing.T) {
+	htmlPage := "<html>\n<head><title>502 Bad Gateway</title></head>\n<body>" + strings.Repeat("x", 500) + "</body>\n</html>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schemas/ids/1":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(htmlPage))
+		case "/schemas/ids/2":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>login required</html>"))
+		case "/subjects":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("not json at all"))
+		case "/schemas/ids/3":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code":40403,"message":"Schema 3 not found"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := newClient(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil)
+	require.NoError(t, err)
+
+	t.Run("non-JSON error response", func(t *testing.T) {
+		_, err := c.GetSchemaByID(context.Background(), 1)
+		require.Error(t, err)
+		var restErr *RestError
+		require.ErrorAs(t, err, &restErr)
+		assert.Equal(t, http.StatusBadGateway, restErr.ErrorCode)
+		assert.Contains(t, err.Error(), "<html> <head><title>502 Bad Gateway</title></head>")
+		assert.Contains(t, err.Error(), `"text/html"`)
+		assert.True(t, strings.HasSuffix(err.Error(), "..."), "long bodies must be truncated")
+		assert.Less(t, len(err.Error()), len(htmlPage))
+	})
+
+	t.Run("non-JSON success response", func(t *testing.T) {
+		_, err := c.GetSchemaByID(context.Background(), 2)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 200")
+		assert.Contains(t, err.Error(), "<html>login required</html>")
+	})
+
+	t.Run("invalid JSON response", func(t *testing.T) {
+		_, err := c.GetSubjects(context.Background(), false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not json at all")
+	})
+
+	t.Run("registry error response", func(t *testing.T) {
+		_, err := c.GetSchemaByID(context.Background(), 3)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrSchemaNotFound)
+		assert.Equal(t, "schema registry request failed: 40403 - Schema 3 not found", err.Error())
+	})
+}
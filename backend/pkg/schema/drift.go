@@ -0,0 +1,114 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// SchemaDriftStatus describes how a local schema relates to the registered schema.
+//
+//nolint:revive // This is stuttering when calling this with the pkg name, but the name is clearer this way.
+type SchemaDriftStatus string
+
+const (
+	// SchemaDriftIdentical means the local and the latest registered schema are equal after
+	// normalization.
+	SchemaDriftIdentical SchemaDriftStatus = "identical"
+	// SchemaDriftDifferent means the local schema differs from the latest registered schema.
+	SchemaDriftDifferent SchemaDriftStatus = "different"
+	// SchemaDriftMissingInRegistry means there's a local schema, but no registered subject.
+	SchemaDriftMissingInRegistry SchemaDriftStatus = "missingInRegistry"
+	// SchemaDriftExtraInRegistry means there's a registered subject, but no local schema.
+	SchemaDriftExtraInRegistry SchemaDriftStatus = "extraInRegistry"
+)
+
+// SchemaDrift is the result of comparing the local schema of a single subject against the
+// registry.
+//
+//nolint:revive // This is stuttering when calling this with the pkg name, but the name is clearer this way.
+type SchemaDrift struct {
+	Subject string            `json:"subject"`
+	Status  SchemaDriftStatus `json:"status"`
+
+	// RegisteredVersion is the latest registered version of the subject, if it exists.
+	RegisteredVersion int `json:"registeredVersion,omitempty"`
+	// Diff is a unified diff from the registered to the local schema, if they are different.
+	Diff string `json:"diff,omitempty"`
+}
+
+// CompareSchemas compares the given local schemas, mapped by subject, against the latest
+// versions that are registered in the schema registry. Schemas are normalized before they are
+// compared, so that schemas that are semantically equal are not reported as different. All
+// registered subjects without local schema are reported as extra. Results are sorted by subject.
+func (s *Service) CompareSchemas(ctx context.Context, localSchemas map[string]string) ([]SchemaDrift, error) {
+	subjectsRes, err := s.registryClient.GetSubjects(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+
+	drifts := make([]SchemaDrift, 0, len(localSchemas))
+	for _, subject := range subjectsRes.Subjects {
+		if _, exists := localSchemas[subject]; !exists {
+			drifts = append(drifts, SchemaDrift{Subject: subject, Status: SchemaDriftExtraInRegistry})
+		}
+	}
+
+	for subject, localSchema := range localSchemas {
+		drift, err := s.compareSchema(ctx, subject, localSchema)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, drift)
+	}
+
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Subject < drifts[j].Subject })
+	return drifts, nil
+}
+
+func (s *Service) compareSchema(ctx context.Context, subject, localSchema string) (SchemaDrift, error) {
+	registered, err := s.registryClient.GetSchemaBySubject(ctx, subject, "latest", false)
+	if err != nil {
+		if errors.Is(err, ErrSubjectNotFound) {
+			return SchemaDrift{Subject: subject, Status: SchemaDriftMissingInRegistry}, nil
+		}
+		return SchemaDrift{}, fmt.Errorf("failed to get latest schema of subject %q: %w", subject, err)
+	}
+
+	drift := SchemaDrift{
+		Subject:           subject,
+		Status:            SchemaDriftIdentical,
+		RegisteredVersion: registered.Version,
+	}
+
+	// The local schema is normalized with the registered type, as it can't be told reliably
+	// from the schema text alone.
+	normalizedRegistered, err := NormalizeSchema(registered.Type, registered.Schema)
+	if err != nil {
+		return SchemaDrift{}, fmt.Errorf("failed to normalize registered schema of subject %q: %w", subject, err)
+	}
+	normalizedLocal, err := NormalizeSchema(registered.Type, localSchema)
+	if err != nil || normalizedLocal != normalizedRegistered {
+		drift.Status = SchemaDriftDifferent
+		drift.Diff, _ = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(registered.Schema),
+			B:        difflib.SplitLines(localSchema),
+			FromFile: "registry",
+			ToFile:   "local",
+			Context:  3,
+		})
+	}
+	return drift, nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// JSONSchemaValidationError is returned if a JSON document does not conform to a JSON schema.
+// It lists every violation along with the JSON pointer of the offending value.
+type JSONSchemaValidationError struct {
+	Violations []JSONSchemaViolation `json:"violations"`
+}
+
+// JSONSchemaViolation is a single violation of a JSON schema.
+type JSONSchemaViolation struct {
+	// Path is the JSON pointer to the value that violates the schema, e.g. "/address/city".
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *JSONSchemaValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Path + ": " + violation.Message
+	}
+	return "json document does not conform to schema: " + strings.Join(messages, "; ")
+}
+
+// ValidateJSONBySchemaID validates a JSON document against the JSON schema with the given ID,
+// so that records can be checked before they are produced. References to other registered
+// schemas are resolved recursively. A *JSONSchemaValidationError is returned if the document
+// does not conform to the schema.
+func (s *Service) ValidateJSONBySchemaID(ctx context.Context, schemaID uint32, document []byte) error {
+	compiled, err := s.compileJSONSchemaByID(ctx, schemaID)
+	if err != nil {
+		return err
+	}
+
+	var obj interface{}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	if err := decoder.Decode(&obj); err != nil {
+		return fmt.Errorf("failed to decode json document: %w", err)
+	}
+
+	err = compiled.Validate(obj)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return &JSONSchemaValidationError{Violations: jsonSchemaViolations(validationErr)}
+	}
+	return err
+}
+
+// compileJSONSchemaByID fetches the JSON schema with the given ID and compiles it along with
+// all its (transitive) references.
+func (s *Service) compileJSONSchemaByID(ctx context.Context, schemaID uint32) (*jsonschema.Schema, error) {
+	schemaRes, err := s.getSchemaByID(ctx, schemaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema from registry: %w", err)
+	}
+
+	compiler := newJSONSchemaCompiler()
+	// Only schemas from the registry may be referenced, never local files or remote URLs
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("referenced schema %q is not part of the schema references", url)
+	}
+
+	if _, err := jsonSchemaDialect(schemaRes.Schema); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", schemaID, err)
+	}
+	name := strconv.FormatUint(uint64(schemaID), 10) + ".json"
+	added := make(map[string]struct{})
+	if err := s.addJSONSchemaReferences(ctx, compiler, schemaRes.References, added, nil); err != nil {
+		return nil, err
+	}
+	if err := compiler.AddResource(name, strings.NewReader(schemaRes.Schema)); err != nil {
+		return nil, fmt.Errorf("failed to add schema %d: %w", schemaID, err)
+	}
+
+	compiled, err := compiler.Compile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %d: %w", schemaID, err)
+	}
+	return compiled, nil
+}
+
+// addJSONSchemaReferences adds the given references and their references to the compiler.
+// The path holds the subject versions that are currently being resolved, so that reference
+// cycles are detected. Each subject version is only added once.
+func (s *Service) addJSONSchemaReferences(ctx context.Context, compiler *jsonschema.Compiler, refs []SchemaReference, added map[string]struct{}, path []string) error {
+	for _, ref := range refs {
+		key := ref.Subject + "/" + ref.VersionString()
+		for _, resolving := range path {
+			if resolving == key {
+				return fmt.Errorf("reference cycle detected: %s -> %s", strings.Join(path, " -> "), key)
+			}
+		}
+		if _, exists := added[key]; exists {
+			continue
+		}
+
+		schemaRef, err := s.getReferencedSchema(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve reference %q: %w", ref.Subject, err)
+		}
+		if err := s.addJSONSchemaReferences(ctx, compiler, schemaRef.References, added, append(path, key)); err != nil {
+			return err
+		}
+
+		if _, err := jsonSchemaDialect(schemaRef.Schema); err != nil {
+			return fmt.Errorf("reference %q: %w", ref.Name, err)
+		}
+
+		// Prevent a panic by the schema compiler by checking the name before AddResource
+		if strings.IndexByte(ref.Name, '#') != -1 {
+			return fmt.Errorf("hashtags are not allowed as part of the reference name %q", ref.Name)
+		}
+		if err := compiler.AddResource(ref.Name, strings.NewReader(schemaRef.Schema)); err != nil {
+			return fmt.Errorf("failed to add reference %q: %w", ref.Name, err)
+		}
+		added[key] = struct{}{}
+	}
+	return nil
+}
+
+// defaultJSONSchemaDraft is the dialect of JSON schemas without a $schema keyword. Like the
+// Confluent schema registry we assume draft-07, which most registered schemas are written in.
+var defaultJSONSchemaDraft = jsonschema.Draft7
+
+// jsonSchemaDrafts are the supported JSON schema dialects by their $schema URI, without
+// scheme and empty fragment.
+var jsonSchemaDrafts = map[string]*jsonschema.Draft{
+	"json-schema.org/draft-04/schema":      jsonschema.Draft4,
+	"json-schema.org/draft-06/schema":      jsonschema.Draft6,
+	"json-schema.org/draft-07/schema":      jsonschema.Draft7,
+	"json-schema.org/draft/2019-09/schema": jsonschema.Draft2019,
+	"json-schema.org/draft/2020-12/schema": jsonschema.Draft2020,
+	"json-schema.org/schema":               jsonschema.Draft2020,
+}
+
+// newJSONSchemaCompiler returns a compiler that uses the default draft for schemas without
+// a $schema keyword. Schemas declaring their dialect are compiled with that dialect, so that
+// draft-specific keywords such as prefixItems (2020-12) or tuple items (draft-07) apply.
+func newJSONSchemaCompiler() *jsonschema.Compiler {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = defaultJSONSchemaDraft
+	return compiler
+}
+
+// jsonSchemaDialect returns the draft declared by the $schema keyword of the given schema,
+// or the default draft if it is absent. An error is returned for unsupported dialects.
+func jsonSchemaDialect(schema string) (*jsonschema.Draft, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &obj); err != nil {
+		// Boolean schemas and syntax errors are left to the compiler
+		return defaultJSONSchemaDraft, nil
+	}
+	rawDialect, exists := obj["$schema"]
+	if !exists {
+		return defaultJSONSchemaDraft, nil
+	}
+	dialect, ok := rawDialect.(string)
+	if !ok {
+		return nil, fmt.Errorf("$schema must be a string, but is %T", rawDialect)
+	}
+
+	key := strings.TrimPrefix(strings.TrimPrefix(dialect, "http://"), "https://")
+	key = strings.TrimSuffix(strings.TrimSuffix(key, "/"), "#")
+	draft, ok := jsonSchemaDrafts[key]
+	if !ok {
+		return nil, fmt.Errorf("unsupported JSON schema dialect %q", dialect)
+	}
+	return draft, nil
+}
+
+// jsonSchemaViolations flattens the validation error into its leaf causes, which carry the
+// actual violations rather than the keywords that contain them.
+func jsonSchemaViolations(err *jsonschema.ValidationError) []JSONSchemaViolation {
+	if len(err.Causes) == 0 {
+		path := err.InstanceLocation
+		if path == "" {
+			path = "/"
+		}
+		return []JSONSchemaViolation{{Path: path, Message: err.Message}}
+	}
+
+	var violations []JSONSchemaViolation
+	for _, cause := range err.Causes {
+		violations = append(violations, jsonSchemaViolations(cause)...)
+	}
+	return violations
+}
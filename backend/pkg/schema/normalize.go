@@ -0,0 +1,139 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// NormalizeSchema returns a normalized form of the given schema, so that schemas that only
+// differ in formatting (e.g. whitespaces, comments or the order of JSON keys) can be compared.
+// Avro schemas are compared as full JSON documents rather than their canonical form, because
+// the canonical form strips attributes such as defaults, docs and aliases. Only the two
+// notations of primitive types ("string" and {"type": "string"}) are considered equal.
+func NormalizeSchema(schemaType SchemaType, schema string) (string, error) {
+	switch schemaType {
+	case TypeAvro:
+		return normalizeJSON(schema, collapseAvroPrimitives)
+	case TypeJSON:
+		return normalizeJSON(schema, nil)
+	case TypeProtobuf:
+		return normalizeProtobuf(schema), nil
+	default:
+		return "", fmt.Errorf("unknown schema type %d", schemaType)
+	}
+}
+
+// normalizeJSON re-encodes the JSON document, which sorts object keys and removes whitespaces.
+// The optional rewrite function is applied to the decoded document before it's encoded.
+func normalizeJSON(schema string, rewrite func(interface{}) interface{}) (string, error) {
+	var obj interface{}
+	dec := json.NewDecoder(strings.NewReader(schema))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return "", fmt.Errorf("failed to parse schema as json: %w", err)
+	}
+	if rewrite != nil {
+		obj = rewrite(obj)
+	}
+	normalized, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(normalized), nil
+}
+
+// collapseAvroPrimitives replaces objects that only consist of a type name, such as
+// {"type": "string"}, with the type name itself. All other attributes are kept as is.
+func collapseAvroPrimitives(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 1 {
+			if typeName, ok := val["type"].(string); ok {
+				return typeName
+			}
+		}
+		for k, child := range val {
+			val[k] = collapseAvroPrimitives(child)
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = collapseAvroPrimitives(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// normalizeProtobuf removes comments from the proto file and collapses all whitespaces,
+// while keeping string literals as is.
+func normalizeProtobuf(schema string) string {
+	const punctuation = "{}()[]<>;=,"
+
+	var sb strings.Builder
+	pendingSpace := false
+	lastByte := byte(0)
+	// writeSpace writes a pending whitespace, unless it follows punctuation where it's insignificant
+	writeSpace := func() {
+		if pendingSpace && sb.Len() > 0 && strings.IndexByte(punctuation, lastByte) == -1 {
+			sb.WriteByte(' ')
+		}
+		pendingSpace = false
+	}
+
+	for i := 0; i < len(schema); i++ {
+		c := schema[i]
+		switch {
+		case c == '"' || c == '\'':
+			writeSpace()
+			end := i + 1
+			for end < len(schema) && schema[end] != c {
+				if schema[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(schema) {
+				end = len(schema) - 1
+			}
+			sb.WriteString(schema[i : end+1])
+			lastByte = c
+			i = end
+		case c == '/' && i+1 < len(schema) && schema[i+1] == '/':
+			for i < len(schema) && schema[i] != '\n' {
+				i++
+			}
+			pendingSpace = true
+		case c == '/' && i+1 < len(schema) && schema[i+1] == '*':
+			end := strings.Index(schema[i+2:], "*/")
+			if end == -1 {
+				i = len(schema)
+			} else {
+				i += end + 3
+			}
+			pendingSpace = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pendingSpace = true
+		case strings.IndexByte(punctuation, c) != -1:
+			pendingSpace = false
+			sb.WriteByte(c)
+			lastByte = c
+		default:
+			writeSpace()
+			sb.WriteByte(c)
+			lastByte = c
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,265 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ReferenceGraph is the directed graph of schema references, so that dependencies between
+// schemas can be visualized. Nodes are subject versions and edges point from a schema to the
+// schemas it references.
+type ReferenceGraph struct {
+	Nodes []ReferenceGraphNode `json:"nodes"`
+	Edges []ReferenceGraphEdge `json:"edges"`
+	// Cycles are the reference cycles in the graph, each given as the subject versions along
+	// the cycle. Registries usually reject cyclic references, but imported schemas may have them.
+	Cycles  [][]SubjectVersion `json:"cycles"`
+	BuiltAt time.Time          `json:"builtAt"`
+}
+
+// ReferenceGraphNode is a subject version in the reference graph.
+type ReferenceGraphNode struct {
+	Subject  string     `json:"subject"`
+	Version  int        `json:"version"`
+	SchemaID int        `json:"schemaId"`
+	Type     SchemaType `json:"schemaType"`
+}
+
+// ReferenceGraphEdge is a reference from one subject version to another. Name is the name
+// of the reference, e.g. the import path of a Protobuf schema.
+type ReferenceGraphEdge struct {
+	From SubjectVersion `json:"from"`
+	To   SubjectVersion `json:"to"`
+	Name string         `json:"name"`
+}
+
+// GetReferenceGraph returns the cached reference graph or builds it if it's not cached yet.
+// If a subject is given, the graph contains all versions of the subject along with all
+// schemas that they reference or that reference them, transitively. Otherwise the graph
+// contains all subject versions of the registry.
+func (s *Service) GetReferenceGraph(ctx context.Context, subject string) (*ReferenceGraph, error) {
+	graph, err := cachedGet(ctx, s.referenceGraphs, subject, func() (*ReferenceGraph, error) {
+		return s.buildReferenceGraph(ctx, subject)
+	})
+	return graph, err
+}
+
+// RefreshReferenceGraph drops the cached reference graph and builds it again.
+func (s *Service) RefreshReferenceGraph(ctx context.Context, subject string) (*ReferenceGraph, error) {
+	s.referenceGraphs.Delete(subject)
+	return s.GetReferenceGraph(ctx, subject)
+}
+
+// referenceGraphBuilder walks the references of the queued subject versions.
+type referenceGraphBuilder struct {
+	registryClient *Client
+
+	// followReferencedBy also queues the subject versions that reference a visited schema.
+	followReferencedBy bool
+
+	schemas        map[SubjectVersion]*SchemaVersionedResponse
+	latestVersions map[string]int
+	queue          []SubjectVersion
+	graph          *ReferenceGraph
+}
+
+func (s *Service) buildReferenceGraph(ctx context.Context, subject string) (*ReferenceGraph, error) {
+	b := &referenceGraphBuilder{
+		registryClient:     s.registryClient,
+		followReferencedBy: subject != "",
+		schemas:            make(map[SubjectVersion]*SchemaVersionedResponse),
+		latestVersions:     make(map[string]int),
+		graph:              &ReferenceGraph{Nodes: []ReferenceGraphNode{}, Edges: []ReferenceGraphEdge{}},
+	}
+
+	subjects := []string{subject}
+	if subject == "" {
+		subjectsRes, err := s.registryClient.GetSubjects(ctx, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get subjects: %w", err)
+		}
+		subjects = subjectsRes.Subjects
+	}
+	for _, subj := range subjects {
+		schemas, err := s.registryClient.GetSchemasBySubject(ctx, subj, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schemas of subject %q: %w", subj, err)
+		}
+		for i := range schemas {
+			sv := SubjectVersion{Subject: schemas[i].Subject, Version: schemas[i].Version}
+			b.schemas[sv] = &schemas[i]
+			b.queue = append(b.queue, sv)
+		}
+	}
+
+	if err := b.walk(ctx); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(b.graph.Nodes, func(i, j int) bool {
+		return lessSubjectVersion(
+			SubjectVersion{Subject: b.graph.Nodes[i].Subject, Version: b.graph.Nodes[i].Version},
+			SubjectVersion{Subject: b.graph.Nodes[j].Subject, Version: b.graph.Nodes[j].Version},
+		)
+	})
+	sort.Slice(b.graph.Edges, func(i, j int) bool {
+		if b.graph.Edges[i].From != b.graph.Edges[j].From {
+			return lessSubjectVersion(b.graph.Edges[i].From, b.graph.Edges[j].From)
+		}
+		return lessSubjectVersion(b.graph.Edges[i].To, b.graph.Edges[j].To)
+	})
+	b.graph.Cycles = findReferenceCycles(b.graph)
+	b.graph.BuiltAt = time.Now()
+	return b.graph, nil
+}
+
+// walk visits all queued subject versions and queues the ones they are connected to.
+func (b *referenceGraphBuilder) walk(ctx context.Context) error {
+	visited := make(map[SubjectVersion]bool)
+	for len(b.queue) > 0 {
+		sv := b.queue[0]
+		b.queue = b.queue[1:]
+		if visited[sv] {
+			continue
+		}
+		visited[sv] = true
+
+		schema, err := b.getSchema(ctx, sv)
+		if err != nil {
+			return err
+		}
+		b.graph.Nodes = append(b.graph.Nodes, ReferenceGraphNode{
+			Subject:  sv.Subject,
+			Version:  sv.Version,
+			SchemaID: schema.SchemaID,
+			Type:     schema.Type,
+		})
+
+		for _, ref := range schema.References {
+			version := ref.Version
+			if ref.IsLatest() {
+				if version, err = b.getLatestVersion(ctx, ref.Subject); err != nil {
+					return err
+				}
+			}
+			target := SubjectVersion{Subject: ref.Subject, Version: version}
+			b.graph.Edges = append(b.graph.Edges, ReferenceGraphEdge{From: sv, To: target, Name: ref.Name})
+			b.queue = append(b.queue, target)
+		}
+
+		if b.followReferencedBy {
+			if err := b.queueReferencedBy(ctx, sv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// queueReferencedBy queues all subject versions whose schemas reference the given one.
+func (b *referenceGraphBuilder) queueReferencedBy(ctx context.Context, sv SubjectVersion) error {
+	schemaIDs, err := b.registryClient.GetSchemaReferencedBy(ctx, sv.Subject, strconv.Itoa(sv.Version))
+	if err != nil {
+		return fmt.Errorf("failed to get schemas referencing %q version %d: %w", sv.Subject, sv.Version, err)
+	}
+	for _, schemaID := range schemaIDs {
+		subjectVersions, err := b.registryClient.GetSchemaVersionsByID(ctx, uint32(schemaID))
+		if err != nil {
+			return fmt.Errorf("failed to get subject versions of schema %d: %w", schemaID, err)
+		}
+		b.queue = append(b.queue, subjectVersions...)
+	}
+	return nil
+}
+
+func (b *referenceGraphBuilder) getSchema(ctx context.Context, sv SubjectVersion) (*SchemaVersionedResponse, error) {
+	if schema, exists := b.schemas[sv]; exists {
+		return schema, nil
+	}
+	// Referenced versions may be soft-deleted, which doesn't break the reference
+	schema, err := b.registryClient.GetSchemaBySubject(ctx, sv.Subject, strconv.Itoa(sv.Version), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema of %q version %d: %w", sv.Subject, sv.Version, err)
+	}
+	b.schemas[sv] = schema
+	return schema, nil
+}
+
+func (b *referenceGraphBuilder) getLatestVersion(ctx context.Context, subject string) (int, error) {
+	if version, exists := b.latestVersions[subject]; exists {
+		return version, nil
+	}
+	schema, err := b.registryClient.GetSchemaBySubject(ctx, subject, "latest", false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest schema of subject %q: %w", subject, err)
+	}
+	b.latestVersions[subject] = schema.Version
+	b.schemas[SubjectVersion{Subject: subject, Version: schema.Version}] = schema
+	return schema.Version, nil
+}
+
+// findReferenceCycles returns a cycle for each back edge that is found by a depth-first
+// search of the graph.
+func findReferenceCycles(graph *ReferenceGraph) [][]SubjectVersion {
+	edgesByNode := make(map[SubjectVersion][]SubjectVersion)
+	for _, edge := range graph.Edges {
+		edgesByNode[edge.From] = append(edgesByNode[edge.From], edge.To)
+	}
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[SubjectVersion]int)
+	var path []SubjectVersion
+	cycles := make([][]SubjectVersion, 0)
+
+	var visit func(sv SubjectVersion)
+	visit = func(sv SubjectVersion) {
+		state[sv] = inProgress
+		path = append(path, sv)
+		for _, target := range edgesByNode[sv] {
+			switch state[target] {
+			case unvisited:
+				visit(target)
+			case inProgress:
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == target {
+						cycles = append(cycles, append([]SubjectVersion{}, path[i:]...))
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[sv] = done
+	}
+
+	for _, node := range graph.Nodes {
+		sv := SubjectVersion{Subject: node.Subject, Version: node.Version}
+		if state[sv] == unvisited {
+			visit(sv)
+		}
+	}
+	return cycles
+}
+
+func lessSubjectVersion(a, b SubjectVersion) bool {
+	if a.Subject != b.Subject {
+		return a.Subject < b.Subject
+	}
+	return a.Version < b.Version
+}
@@ -0,0 +1,416 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemaFileRegistration is the result of registering a single schema file.
+//
+//nolint:revive // This is stuttering when calling this with the pkg name, but the name is clearer this way.
+type SchemaFileRegistration struct {
+	File     string     `json:"file"`
+	Subject  string     `json:"subject"`
+	Type     SchemaType `json:"type"`
+	SchemaID int        `json:"schemaId,omitempty"`
+	Version  int        `json:"version,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// schemaFile is a schema file that has been read and analyzed, but not yet registered.
+type schemaFile struct {
+	Path    string
+	Subject string
+	Type    SchemaType
+	Schema  string
+
+	// Dependencies are the reference names (e.g. Avro full names or proto import paths)
+	// mapped to the path of the file that defines them.
+	Dependencies map[string]string
+}
+
+var protoImportRegexp = regexp.MustCompile(`(?m)^\s*import\s+(?:public\s+|weak\s+)?"([^"]+)"\s*;`)
+
+// RegisterSchemaFiles registers all .avsc, .proto and .json schema files in the given file system.
+// The subject of each schema is the file name without extension. References between the files
+// are resolved by name (Avro full names, proto import paths and JSON schema $ref paths), so that
+// the schemas are registered in dependency order along with their references. Files whose
+// registration failed are reported in the returned results, rather than aborting all registrations.
+func (s *Service) RegisterSchemaFiles(ctx context.Context, fsys fs.FS) ([]SchemaFileRegistration, error) {
+	files, err := readSchemaFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered, cyclic := sortSchemaFilesByDependencies(files)
+
+	results := make([]SchemaFileRegistration, 0, len(files))
+	registeredByPath := make(map[string]SchemaFileRegistration)
+	for _, file := range ordered {
+		result := s.registerSchemaFile(ctx, file, files, registeredByPath)
+		registeredByPath[file.Path] = result
+		results = append(results, result)
+	}
+	for _, file := range cyclic {
+		results = append(results, SchemaFileRegistration{
+			File:    file.Path,
+			Subject: file.Subject,
+			Type:    file.Type,
+			Error:   "schema file is part of a reference cycle",
+		})
+	}
+
+	return results, nil
+}
+
+// RegisterSchemaFilesFromDirectory registers all schema files in the given directory and its
+// subdirectories. See RegisterSchemaFiles for details.
+func (s *Service) RegisterSchemaFilesFromDirectory(ctx context.Context, dir string) ([]SchemaFileRegistration, error) {
+	return s.RegisterSchemaFiles(ctx, os.DirFS(dir))
+}
+
+func (s *Service) registerSchemaFile(ctx context.Context, file *schemaFile, files map[string]*schemaFile, registeredByPath map[string]SchemaFileRegistration) SchemaFileRegistration {
+	result := SchemaFileRegistration{
+		File:    file.Path,
+		Subject: file.Subject,
+		Type:    file.Type,
+	}
+
+	referenceNames := make([]string, 0, len(file.Dependencies))
+	for name := range file.Dependencies {
+		referenceNames = append(referenceNames, name)
+	}
+	sort.Strings(referenceNames)
+
+	references := make([]SchemaReference, 0, len(referenceNames))
+	for _, name := range referenceNames {
+		dependency := registeredByPath[file.Dependencies[name]]
+		if dependency.Error != "" {
+			result.Error = fmt.Sprintf("referenced schema file %q could not be registered", dependency.File)
+			return result
+		}
+		references = append(references, SchemaReference{
+			Name:    name,
+			Subject: files[dependency.File].Subject,
+			Version: dependency.Version,
+		})
+	}
+
+	created, err := s.registryClient.CreateSchema(ctx, file.Subject, Schema{
+		Schema:     file.Schema,
+		Type:       file.Type,
+		References: references,
+	}, true)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.SchemaID = created.ID
+
+	// The version is required to reference this schema from other schemas. It's looked up
+	// by the schema itself rather than as latest version, because the schema may have been
+	// registered before and a newer version may exist or be registered concurrently.
+	registered, err := s.registryClient.LookupSchema(ctx, file.Subject, Schema{
+		Schema:     file.Schema,
+		Type:       file.Type,
+		References: references,
+	})
+	if err != nil {
+		result.Error = fmt.Sprintf("schema has been registered, but its version could not be retrieved: %v", err)
+		return result
+	}
+	result.Version = registered.Version
+
+	return result
+}
+
+// readSchemaFiles reads all schema files, infers their types and resolves the dependencies
+// between them. Files with other extensions are ignored.
+func readSchemaFiles(fsys fs.FS) (map[string]*schemaFile, error) {
+	files := make(map[string]*schemaFile)
+	err := fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ext := path.Ext(filePath)
+		switch ext {
+		case ".avsc", ".proto", ".json":
+		default:
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file %q: %w", filePath, err)
+		}
+		files[filePath] = &schemaFile{
+			Path:    filePath,
+			Subject: strings.TrimSuffix(path.Base(filePath), ext),
+			Type:    inferSchemaFileType(ext, content),
+			Schema:  string(content),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Index Avro named types by the file they are defined in
+	definedIn := make(map[string]string)
+	for _, file := range files {
+		if file.Type != TypeAvro {
+			continue
+		}
+		defined, _ := avroSchemaNames(file.Schema)
+		for _, name := range defined {
+			definedIn[name] = file.Path
+		}
+	}
+
+	for _, file := range files {
+		file.Dependencies = make(map[string]string)
+		switch file.Type {
+		case TypeAvro:
+			_, referenced := avroSchemaNames(file.Schema)
+			for _, name := range referenced {
+				if dependencyPath, exists := definedIn[name]; exists && dependencyPath != file.Path {
+					file.Dependencies[name] = dependencyPath
+				}
+			}
+		case TypeProtobuf:
+			for _, match := range protoImportRegexp.FindAllStringSubmatch(file.Schema, -1) {
+				if dependencyPath, exists := resolveSchemaFilePath(files, file.Path, match[1]); exists {
+					file.Dependencies[match[1]] = dependencyPath
+				}
+			}
+		case TypeJSON:
+			for _, ref := range jsonSchemaRefs(file.Schema) {
+				if dependencyPath, exists := resolveSchemaFilePath(files, file.Path, ref); exists {
+					file.Dependencies[ref] = dependencyPath
+				}
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// inferSchemaFileType infers the schema type by file extension. JSON files may either be
+// JSON schemas or Avro schemas, hence we look at the content for these.
+func inferSchemaFileType(ext string, content []byte) SchemaType {
+	switch ext {
+	case ".avsc":
+		return TypeAvro
+	case ".proto":
+		return TypeProtobuf
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(content, &obj); err == nil {
+		_, hasName := obj["name"].(string)
+		switch obj["type"] {
+		case "record", "enum", "fixed":
+			if hasName {
+				return TypeAvro
+			}
+		}
+	}
+	return TypeJSON
+}
+
+// resolveSchemaFilePath finds the schema file that is referenced by the given import or
+// $ref path, relative to the root first and relative to the referencing file second.
+func resolveSchemaFilePath(files map[string]*schemaFile, fromPath, ref string) (string, bool) {
+	ref = strings.TrimPrefix(ref, "./")
+	candidates := []string{ref, path.Join(path.Dir(fromPath), ref)}
+	for _, candidate := range candidates {
+		if _, exists := files[candidate]; exists && candidate != fromPath {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// avroSchemaNames returns the full names of all named types that are defined in the given
+// Avro schema, as well as the full names of all named types it uses but does not define.
+func avroSchemaNames(schemaStr string) (defined, referenced []string) {
+	var node interface{}
+	if err := json.Unmarshal([]byte(schemaStr), &node); err != nil {
+		return nil, nil
+	}
+
+	definedSet := make(map[string]struct{})
+	usedSet := make(map[string]struct{})
+	qualify := func(name, namespace string) string {
+		if strings.Contains(name, ".") || namespace == "" {
+			return name
+		}
+		return namespace + "." + name
+	}
+
+	var walk func(node interface{}, namespace string)
+	walk = func(node interface{}, namespace string) {
+		switch n := node.(type) {
+		case string:
+			if !isAvroPrimitiveType(n) {
+				usedSet[qualify(n, namespace)] = struct{}{}
+			}
+		case []interface{}:
+			for _, t := range n {
+				walk(t, namespace)
+			}
+		case map[string]interface{}:
+			typ, isString := n["type"].(string)
+			if !isString {
+				walk(n["type"], namespace)
+				return
+			}
+			switch typ {
+			case "record", "error", "enum", "fixed":
+				name, _ := n["name"].(string)
+				if ns, ok := n["namespace"].(string); ok {
+					namespace = ns
+				}
+				fullName := qualify(name, namespace)
+				definedSet[fullName] = struct{}{}
+				if idx := strings.LastIndex(fullName, "."); idx >= 0 {
+					namespace = fullName[:idx]
+				}
+				fields, _ := n["fields"].([]interface{})
+				for _, field := range fields {
+					if fieldMap, ok := field.(map[string]interface{}); ok {
+						walk(fieldMap["type"], namespace)
+					}
+				}
+			case "array":
+				walk(n["items"], namespace)
+			case "map":
+				walk(n["values"], namespace)
+			default:
+				walk(typ, namespace)
+			}
+		}
+	}
+	walk(node, "")
+
+	for name := range definedSet {
+		defined = append(defined, name)
+	}
+	for name := range usedSet {
+		if _, isDefined := definedSet[name]; !isDefined {
+			referenced = append(referenced, name)
+		}
+	}
+	sort.Strings(defined)
+	sort.Strings(referenced)
+	return defined, referenced
+}
+
+func isAvroPrimitiveType(typ string) bool {
+	switch typ {
+	case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+		return true
+	}
+	return false
+}
+
+// jsonSchemaRefs returns all $ref values of a JSON schema that point to other documents.
+func jsonSchemaRefs(schemaStr string) []string {
+	var node interface{}
+	if err := json.Unmarshal([]byte(schemaStr), &node); err != nil {
+		return nil
+	}
+
+	refSet := make(map[string]struct{})
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch n := node.(type) {
+		case []interface{}:
+			for _, child := range n {
+				walk(child)
+			}
+		case map[string]interface{}:
+			for key, child := range n {
+				if ref, ok := child.(string); ok && key == "$ref" {
+					ref, _, _ = strings.Cut(ref, "#")
+					if ref != "" {
+						refSet[ref] = struct{}{}
+					}
+					continue
+				}
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+
+	refs := make([]string, 0, len(refSet))
+	for ref := range refSet {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// sortSchemaFilesByDependencies sorts the schema files so that each file comes after all files
+// it depends on. Files are sorted by path where the dependencies don't dictate an order. Files
+// that are part of a dependency cycle can't be sorted and are returned separately.
+func sortSchemaFilesByDependencies(files map[string]*schemaFile) (ordered, cyclic []*schemaFile) {
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	added := make(map[string]bool)
+	for len(added) < len(files) {
+		progress := false
+		for _, filePath := range paths {
+			if added[filePath] {
+				continue
+			}
+			ready := true
+			for _, dependencyPath := range files[filePath].Dependencies {
+				if !added[dependencyPath] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				added[filePath] = true
+				ordered = append(ordered, files[filePath])
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+
+	for _, filePath := range paths {
+		if !added[filePath] {
+			cyclic = append(cyclic, files[filePath])
+		}
+	}
+	return ordered, cyclic
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RegistryFlavor is the implementation of the schema registry API.
+type RegistryFlavor string
+
+const (
+	// RegistryFlavorUnknown is reported if the registry doesn't expose its version.
+	RegistryFlavorUnknown RegistryFlavor = "unknown"
+	// RegistryFlavorConfluent is reported for the Confluent schema registry.
+	RegistryFlavorConfluent RegistryFlavor = "confluent"
+	// RegistryFlavorRedpanda is reported for Redpanda's built-in schema registry.
+	RegistryFlavorRedpanda RegistryFlavor = "redpanda"
+)
+
+// RegistryInfo describes the version and capabilities of the schema registry, so that
+// callers can decide which endpoints are available.
+type RegistryInfo struct {
+	ServerInfo ServerInfo     `json:"serverInfo"`
+	Flavor     RegistryFlavor `json:"flavor"`
+	// SupportedTypes are the supported schema types, e.g. AVRO and PROTOBUF.
+	SupportedTypes []string `json:"supportedTypes"`
+	// SupportsBulkListing is true if all schemas can be listed with a single request to the
+	// /schemas endpoint, rather than requesting the schemas of each subject.
+	SupportsBulkListing bool `json:"supportsBulkListing"`
+}
+
+// registryFlavorFromVersion guesses the registry implementation by its version format.
+// Redpanda reports versions such as "v23.2.1", whereas Confluent reports "7.5.1".
+func registryFlavorFromVersion(info *ServerInfo) RegistryFlavor {
+	switch {
+	case !info.IsKnown():
+		return RegistryFlavorUnknown
+	case strings.HasPrefix(info.Version, "v"):
+		return RegistryFlavorRedpanda
+	default:
+		return RegistryFlavorConfluent
+	}
+}
+
+// GetRegistryInfo returns the version and capabilities of the schema registry. The result
+// is cached on the client after the first successful call.
+func (c *Client) GetRegistryInfo(ctx context.Context) (*RegistryInfo, error) {
+	c.registryInfoMutex.Lock()
+	defer c.registryInfoMutex.Unlock()
+	if c.registryInfo != nil {
+		info := *c.registryInfo
+		return &info, nil
+	}
+
+	serverInfo, err := c.GetServerInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	supportedTypes, err := c.GetSchemaTypes(ctx)
+	if err != nil {
+		// The /schemas/types endpoint has been introduced along with Protobuf and JSON
+		// support, hence older registries only support Avro.
+		var restErr *RestError
+		if !errors.As(err, &restErr) || restErr.ErrorCode != http.StatusNotFound {
+			return nil, fmt.Errorf("failed to get supported schema types: %w", err)
+		}
+		supportedTypes = []string{TypeAvro.String()}
+	}
+
+	c.registryInfo = &RegistryInfo{
+		ServerInfo:     *serverInfo,
+		Flavor:         registryFlavorFromVersion(serverInfo),
+		SupportedTypes: supportedTypes,
+		// The /schemas endpoint has been introduced with v6.0.0. Registries with unknown
+		// versions are assumed to support it, until a request proves otherwise.
+		SupportsBulkListing: !serverInfo.IsOlderThan(6, 0) && !c.bulkListingUnsupported.Load(),
+	}
+	info := *c.registryInfo
+	return &info, nil
+}
+
+// setBulkListingUnsupported records that the registry doesn't serve the /schemas endpoint.
+func (c *Client) setBulkListingUnsupported() {
+	c.bulkListingUnsupported.Store(true)
+
+	c.registryInfoMutex.Lock()
+	defer c.registryInfoMutex.Unlock()
+	if c.registryInfo != nil {
+		c.registryInfo.SupportsBulkListing = false
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// schemaIDCache is a size bounded LRU cache of schemas by ID. Schemas of a given ID are
+// immutable, hence entries only expire to pick up permanently deleted schemas eventually.
+// Concurrent lookups of the same missing ID are coalesced into a single fetch. Errors are
+// not cached.
+type schemaIDCache struct {
+	maxEntries int
+	ttl        time.Duration
+
+	mutex   sync.Mutex
+	entries map[uint32]*list.Element
+	// lru holds the entries ordered from most to least recently used.
+	lru *list.List
+
+	fetchGroup singleflight.Group
+}
+
+type schemaIDCacheEntry struct {
+	schemaID  uint32
+	schema    *SchemaResponse
+	expiresAt time.Time
+}
+
+// newSchemaIDCache returns a cache that holds up to maxEntries schemas. Entries never
+// expire if ttl is zero. It returns nil if maxEntries is not positive, which disables
+// caching.
+func newSchemaIDCache(maxEntries int, ttl time.Duration) *schemaIDCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	return &schemaIDCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[uint32]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// getOrFetch returns the cached schema or fetches it if it's missing or expired.
+func (c *schemaIDCache) getOrFetch(schemaID uint32, fetch func() (*SchemaResponse, error)) (*SchemaResponse, error) {
+	if schema, ok := c.get(schemaID); ok {
+		return schema, nil
+	}
+
+	v, err, _ := c.fetchGroup.Do(strconv.FormatUint(uint64(schemaID), 10), func() (interface{}, error) {
+		// Another fetch may have completed while waiting
+		if schema, ok := c.get(schemaID); ok {
+			return schema, nil
+		}
+		schema, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		c.set(schemaID, schema)
+		return schema, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*SchemaResponse), nil
+}
+
+func (c *schemaIDCache) get(schemaID uint32) (*SchemaResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.entries[schemaID]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*schemaIDCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, schemaID)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.schema, true
+}
+
+func (c *schemaIDCache) set(schemaID uint32, schema *SchemaResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := &schemaIDCacheEntry{schemaID: schemaID, schema: schema}
+	if c.ttl > 0 {
+		entry.expiresAt = time.Now().Add(c.ttl)
+	}
+	if elem, ok := c.entries[schemaID]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[schemaID] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*schemaIDCacheEntry).schemaID)
+	}
+}
+
+// invalidate removes the schema with the given ID.
+func (c *schemaIDCache) invalidate(schemaID uint32) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[schemaID]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, schemaID)
+	}
+}
+
+// invalidateAll removes all schemas.
+func (c *schemaIDCache) invalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[uint32]*list.Element)
+	c.lru.Init()
+}
+
+// len returns the number of cached schemas, including expired ones that haven't been
+// looked up since.
+func (c *schemaIDCache) len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lru.Len()
+}
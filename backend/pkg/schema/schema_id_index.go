@@ -0,0 +1,126 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// schemaIDIndexConcurrency is the max number of subjects that are described concurrently
+// while building the index. It also bounds the number of schema responses held in memory.
+const schemaIDIndexConcurrency = 10
+
+// SchemaIDIndex is a reverse index that maps schema IDs to all subject versions that
+// use the respective schema.
+type SchemaIDIndex struct {
+	SubjectVersionsByID map[int][]SubjectVersion
+	LatestOnly          bool
+	BuiltAt             time.Time
+}
+
+// Lookup returns all subject versions that use the given schema ID.
+func (i *SchemaIDIndex) Lookup(schemaID int) []SubjectVersion {
+	return i.SubjectVersionsByID[schemaID]
+}
+
+// GetSchemaIDIndex returns the cached schema ID index or builds it if it's not cached yet.
+// If latestOnly is true, only the latest version of each subject is indexed.
+func (s *Service) GetSchemaIDIndex(ctx context.Context, latestOnly bool) (*SchemaIDIndex, error) {
+	index, err := cachedGet(ctx, s.schemaIDIndex, latestOnly, func() (*SchemaIDIndex, error) {
+		return s.buildSchemaIDIndex(ctx, latestOnly)
+	})
+	return index, err
+}
+
+// RefreshSchemaIDIndex drops the cached schema ID index and builds it again.
+func (s *Service) RefreshSchemaIDIndex(ctx context.Context, latestOnly bool) (*SchemaIDIndex, error) {
+	s.schemaIDIndex.Delete(latestOnly)
+	return s.GetSchemaIDIndex(ctx, latestOnly)
+}
+
+// LookupSubjectVersionsBySchemaID returns all subject versions that use the given schema ID
+// by consulting the (cached) schema ID index.
+func (s *Service) LookupSubjectVersionsBySchemaID(ctx context.Context, schemaID int, latestOnly bool) ([]SubjectVersion, error) {
+	index, err := s.GetSchemaIDIndex(ctx, latestOnly)
+	if err != nil {
+		return nil, err
+	}
+	return index.Lookup(schemaID), nil
+}
+
+// buildSchemaIDIndex walks all subjects and describes them one by one, rather than fetching
+// all schemas at once. Only the IDs, subjects and versions are kept, so that memory usage is
+// bounded even for large registries.
+func (s *Service) buildSchemaIDIndex(ctx context.Context, latestOnly bool) (*SchemaIDIndex, error) {
+	subjectsRes, err := s.registryClient.GetSubjects(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subjects: %w", err)
+	}
+
+	var mutex sync.Mutex
+	subjectVersionsByID := make(map[int][]SubjectVersion)
+	addSchemas := func(schemas ...SchemaVersionedResponse) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, schema := range schemas {
+			subjectVersionsByID[schema.SchemaID] = append(subjectVersionsByID[schema.SchemaID], SubjectVersion{
+				Subject: schema.Subject,
+				Version: schema.Version,
+			})
+		}
+	}
+
+	grp, grpCtx := errgroup.WithContext(ctx)
+	grp.SetLimit(schemaIDIndexConcurrency)
+	for _, subject := range subjectsRes.Subjects {
+		subject := subject
+		grp.Go(func() error {
+			if latestOnly {
+				schema, err := s.registryClient.GetSchemaBySubject(grpCtx, subject, "latest", false)
+				if err != nil {
+					return fmt.Errorf("failed to get latest schema of subject %q: %w", subject, err)
+				}
+				addSchemas(*schema)
+				return nil
+			}
+
+			schemas, err := s.registryClient.GetSchemasBySubject(grpCtx, subject, false)
+			if err != nil {
+				return fmt.Errorf("failed to get schemas of subject %q: %w", subject, err)
+			}
+			addSchemas(schemas...)
+			return nil
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, subjectVersions := range subjectVersionsByID {
+		sort.Slice(subjectVersions, func(i, j int) bool {
+			if subjectVersions[i].Subject != subjectVersions[j].Subject {
+				return subjectVersions[i].Subject < subjectVersions[j].Subject
+			}
+			return subjectVersions[i].Version < subjectVersions[j].Version
+		})
+	}
+
+	return &SchemaIDIndex{
+		SubjectVersionsByID: subjectVersionsByID,
+		LatestOnly:          latestOnly,
+		BuiltAt:             time.Now(),
+	}, nil
+}
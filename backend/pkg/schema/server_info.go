@@ -0,0 +1,105 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ServerInfo is the response schema of the GET /v1/metadata/version endpoint. Both fields
+// are empty if the registry does not expose its version.
+type ServerInfo struct {
+	Version  string `json:"version"`
+	CommitID string `json:"commitId"`
+}
+
+// IsKnown returns true if the registry reported its version.
+func (i *ServerInfo) IsKnown() bool {
+	return i != nil && i.Version != ""
+}
+
+// IsOlderThan returns true only if the registry reported a version that is older than the
+// given major and minor version. Unknown or unparsable versions are never considered older,
+// so that the code paths for current registries are used by default.
+func (i *ServerInfo) IsOlderThan(major, minor int) bool {
+	if !i.IsKnown() {
+		return false
+	}
+	actualMajor, actualMinor, ok := parseMajorMinorVersion(i.Version)
+	if !ok {
+		return false
+	}
+	if actualMajor != major {
+		return actualMajor < major
+	}
+	return actualMinor < minor
+}
+
+// parseMajorMinorVersion parses the major and minor version of versions such as "7.5.1",
+// "v23.2.1" or "7.4.0-ce".
+func parseMajorMinorVersion(version string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// GetServerInfo returns the version and commit of the schema registry. The result is
+// cached once the registry responded. Registries that don't expose their version return
+// an empty ServerInfo rather than an error.
+func (c *Client) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+	c.serverInfoMutex.Lock()
+	defer c.serverInfoMutex.Unlock()
+	if c.serverInfo != nil {
+		return c.serverInfo, nil
+	}
+
+	res, err := c.client.R().
+		SetContext(ctx).
+		SetResult(&ServerInfo{}).
+		Get("/v1/metadata/version")
+	if err != nil {
+		return nil, fmt.Errorf("get server info request failed: %w", err)
+	}
+
+	if res.StatusCode() == http.StatusNotFound {
+		c.serverInfo = &ServerInfo{}
+		return c.serverInfo, nil
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("get server info request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*ServerInfo)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse server info response")
+	}
+	c.serverInfo = parsed
+
+	return parsed, nil
+}
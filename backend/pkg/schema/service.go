@@ -11,17 +11,20 @@ package schema
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hamba/avro/v2"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/twmb/go-cache/cache"
 	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/redpanda-data/console/backend/pkg/config"
@@ -40,26 +43,113 @@ type Service struct {
 	// schemaBySubjectVersion caches schema response by subject and version. Caching schemas
 	// by subjects is needed to lookup references in avro schemas.
 	schemaBySubjectVersion *cache.Cache[string, *SchemaVersionedResponse]
-	avroSchemaByID         *cache.Cache[uint32, avro.Schema]
+	avroSchemaByID         *cache.Cache[uint32, *avroSchemaEntry]
+
+	// schemaIDIndex caches the reverse index of schema IDs to subject versions. The key
+	// indicates whether only the latest subject versions have been indexed.
+	schemaIDIndex *cache.Cache[bool, *SchemaIDIndex]
+
+	// avroFingerprintIndex caches the index of Avro schema fingerprints to schema IDs.
+	avroFingerprintIndex *cache.Cache[struct{}, *AvroFingerprintIndex]
+
+	// subjectVersionsByID caches the subject versions that use a schema ID.
+	subjectVersionsByID *cache.Cache[uint32, []SubjectVersion]
+
+	// referenceGraphs caches the reference graphs by subject. The empty subject is the graph
+	// of the whole registry.
+	referenceGraphs *cache.Cache[string, *ReferenceGraph]
+
+	// fetchSemaphore limits the number of concurrent schema fetches while decoding records.
+	// It's nil if the number is not limited.
+	fetchSemaphore *semaphore.Weighted
+
+	// subjectScan is the result of the last subject scan, see ScanSubjects.
+	subjectScan      *SubjectScanResult
+	subjectScanMutex sync.RWMutex
 }
 
 // NewService to access schema registry. Returns an error if connection can't be established.
-func NewService(cfg config.Schema, logger *zap.Logger) (*Service, error) {
-	client, err := newClient(cfg)
+// Metrics of the registry requests are registered with reg, unless it is nil.
+func NewService(cfg config.Schema, logger *zap.Logger, reg prometheus.Registerer, metricsNamespace string) (*Service, error) {
+	metrics, err := newClientMetrics(reg, metricsNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schema registry client metrics: %w", err)
+	}
+
+	client, err := newClient(cfg, logger, metrics)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create schema registry client: %w", err)
 	}
 
+	var fetchSemaphore *semaphore.Weighted
+	if cfg.MaxConcurrentFetches > 0 {
+		fetchSemaphore = semaphore.NewWeighted(int64(cfg.MaxConcurrentFetches))
+	}
+
 	return &Service{
 		cfg:                    cfg,
 		logger:                 logger,
 		requestGroup:           singleflight.Group{},
 		registryClient:         client,
-		avroSchemaByID:         cache.New[uint32, avro.Schema](cache.MaxAge(5*time.Minute), cache.MaxErrorAge(time.Second)),
+		avroSchemaByID:         cache.New[uint32, *avroSchemaEntry](cache.MaxAge(5*time.Minute), cache.MaxErrorAge(time.Second)),
 		schemaBySubjectVersion: cache.New[string, *SchemaVersionedResponse](cache.MaxAge(5*time.Minute), cache.MaxErrorAge(time.Second)),
+		schemaIDIndex:          cache.New[bool, *SchemaIDIndex](cache.MaxAge(5*time.Minute), cache.MaxErrorAge(time.Second)),
+		subjectVersionsByID:    cache.New[uint32, []SubjectVersion](cache.MaxAge(5*time.Minute), cache.MaxErrorAge(time.Second)),
+		avroFingerprintIndex:   cache.New[struct{}, *AvroFingerprintIndex](cache.MaxAge(5*time.Minute), cache.MaxErrorAge(time.Second)),
+		referenceGraphs:        cache.New[string, *ReferenceGraph](cache.MaxAge(5*time.Minute), cache.MaxErrorAge(time.Second)),
+		fetchSemaphore:         fetchSemaphore,
 	}, nil
 }
 
+// acquireFetch blocks until another schema may be fetched from the registry. The returned
+// release func must be called as soon as the request has completed. It must not be held
+// while fetching references, as this could deadlock once all fetches wait for references.
+func (s *Service) acquireFetch(ctx context.Context) (func(), error) {
+	if s.fetchSemaphore == nil {
+		return func() {}, nil
+	}
+	if err := s.fetchSemaphore.Acquire(ctx, 1); err != nil {
+		return nil, fmt.Errorf("failed to wait for schema fetch: %w", err)
+	}
+	return func() { s.fetchSemaphore.Release(1) }, nil
+}
+
+// getSchemaByID returns the schema with the given ID from the client's schema ID cache, or
+// fetches it respecting the limit of concurrent fetches.
+func (s *Service) getSchemaByID(ctx context.Context, schemaID uint32) (*SchemaResponse, error) {
+	if schemaRes, ok := s.registryClient.cachedSchemaByID(ctx, schemaID); ok {
+		return schemaRes, nil
+	}
+
+	release, err := s.acquireFetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.registryClient.GetSchemaByID(ctx, schemaID)
+}
+
+// GetSchemaTypeByID returns the type of the schema with the given ID, so that decoders can
+// check whether a schema matches their format before decoding a record with it.
+func (s *Service) GetSchemaTypeByID(ctx context.Context, schemaID uint32) (SchemaType, error) {
+	schemaRes, err := s.getSchemaByID(ctx, schemaID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema from registry: %w", err)
+	}
+	return schemaRes.Type, nil
+}
+
+// GetRegistryInfo returns the version and capabilities of the schema registry.
+func (s *Service) GetRegistryInfo(ctx context.Context) (*RegistryInfo, error) {
+	return s.registryClient.GetRegistryInfo(ctx)
+}
+
+// MaxRequestAttempts returns how many times a registry request with the given method is sent
+// at most, counting retries and failovers to other registry URLs.
+func (s *Service) MaxRequestAttempts(method string) int {
+	return s.registryClient.MaxRequestAttempts(method)
+}
+
 // CheckConnectivity to schema registry. Returns no error if connectivity is fine.
 func (s *Service) CheckConnectivity(ctx context.Context) error {
 	return s.registryClient.CheckConnectivity(ctx)
@@ -71,7 +161,7 @@ func (s *Service) GetProtoDescriptors(ctx context.Context) (map[int]*desc.FileDe
 	// Singleflight makes sure to not run the function body if there are concurrent requests. We use this to avoid
 	// duplicate requests against the schema registry
 	key := "get-proto-descriptors"
-	v, err, _ := s.requestGroup.Do(key, func() (interface{}, error) {
+	fetch := func() (interface{}, error) {
 		schemasRes, err := s.registryClient.GetSchemas(ctx, false)
 		if err != nil {
 			// If schema registry returns an error we want to retry it next time, so let's forget the key
@@ -112,7 +202,16 @@ func (s *Service) GetProtoDescriptors(ctx context.Context) (map[int]*desc.FileDe
 		}
 
 		return fdBySchemaID, nil
-	})
+	}
+
+	// Requests with per-request credentials must not share the result with other callers
+	var v interface{}
+	var err error
+	if _, hasCredentials := credentialsFromContext(ctx); hasCredentials {
+		v, err = fetch()
+	} else {
+		v, err, _ = s.requestGroup.Do(key, fetch)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -128,9 +227,18 @@ func (s *Service) addReferences(schema SchemaVersionedResponse, schemaRepository
 		if !exists {
 			return fmt.Errorf("failed to resolve reference. Reference with subject '%s' does not exist", ref.Subject)
 		}
-		refSchema, exists := refSubject[ref.Version]
+		version := ref.Version
+		if ref.IsLatest() {
+			s.warnUnpinnedReference(ref)
+			for v := range refSubject {
+				if v > version {
+					version = v
+				}
+			}
+		}
+		refSchema, exists := refSubject[version]
 		if !exists {
-			return fmt.Errorf("failed to resolve reference. Reference with subject '%s', version '%d' does not exist", ref.Subject, ref.Version)
+			return fmt.Errorf("failed to resolve reference. Reference with subject '%s', version '%s' does not exist", ref.Subject, ref.VersionString())
 		}
 		// The reference name is the name that has been used for the import in the proto schema (e.g. 'customer.proto')
 		schemasByPath[ref.Name] = refSchema.Schema
@@ -192,25 +300,60 @@ func (s *Service) compileProtoSchemas(schema SchemaVersionedResponse, schemaRepo
 	return descriptors[0], nil
 }
 
+// avroSchemaEntry is a parsed Avro schema along with information derived from the schema
+// registry's response that is needed to decode records.
+type avroSchemaEntry struct {
+	schema          avro.Schema
+	encryptedFields map[string]struct{}
+}
+
 // GetAvroSchemaByID loads the schema by the given schemaID and tries to parse the schema
 // contents to an avro.Schema, so that it can be used for decoding Avro encoded messages.
 func (s *Service) GetAvroSchemaByID(ctx context.Context, schemaID uint32) (avro.Schema, error) {
-	codecCached, err, _ := s.avroSchemaByID.Get(schemaID, func() (avro.Schema, error) {
-		schemaRes, err := s.registryClient.GetSchemaByID(ctx, schemaID)
+	entry, err := s.getAvroSchemaEntry(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+	return entry.schema, nil
+}
+
+// GetAvroEncryptedFieldsByID returns the set of fields that are encrypted using client-side
+// field level encryption in the Avro schema with the given ID. Fields are named as returned by
+// EncryptedAvroFieldName.
+func (s *Service) GetAvroEncryptedFieldsByID(ctx context.Context, schemaID uint32) (map[string]struct{}, error) {
+	entry, err := s.getAvroSchemaEntry(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+	return entry.encryptedFields, nil
+}
+
+func (s *Service) getAvroSchemaEntry(ctx context.Context, schemaID uint32) (*avroSchemaEntry, error) {
+	entryCached, err := cachedGet(ctx, s.avroSchemaByID, schemaID, func() (*avroSchemaEntry, error) {
+		schemaRes, err := s.getSchemaByID(ctx, schemaID)
 		if err != nil {
 			s.logger.Warn("failed to fetch avro schema", zap.Uint32("schema_id", schemaID), zap.Error(err))
 			return nil, fmt.Errorf("failed to get schema from registry: %w", err)
 		}
+		if schemaRes.Type != TypeAvro {
+			return nil, fmt.Errorf("schema %d is %s, not %s", schemaID, schemaRes.Type, TypeAvro)
+		}
 
-		codec, err := s.ParseAvroSchemaWithReferences(ctx, schemaRes, avro.DefaultSchemaCache)
+		// Each schema is parsed with its own cache of named types, so that equally named
+		// types of unrelated schemas (or of other versions of a referenced subject) can't
+		// resolve to each other. The resolved schema is cached by ID instead.
+		codec, err := s.ParseAvroSchemaWithReferences(ctx, schemaRes, &avro.SchemaCache{})
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse schema: %w", err)
 		}
 
-		return codec, nil
+		return &avroSchemaEntry{
+			schema:          codec,
+			encryptedFields: encryptedAvroFields(codec, schemaRes.Metadata, schemaRes.RuleSet),
+		}, nil
 	})
 
-	return codecCached, err
+	return entryCached, err
 }
 
 // GetSubjects returns a list of all deployed schemas.
@@ -218,6 +361,17 @@ func (s *Service) GetSubjects(ctx context.Context, showSoftDeleted bool) (*Subje
 	return s.registryClient.GetSubjects(ctx, showSoftDeleted)
 }
 
+// GetSubjectsByPrefix returns a list of all deployed schemas whose subject starts with the
+// given prefix. See Client.GetSubjectsByPrefix for the prefixes of schema contexts.
+func (s *Service) GetSubjectsByPrefix(ctx context.Context, prefix string, showSoftDeleted bool) (*SubjectsResponse, error) {
+	return s.registryClient.GetSubjectsByPrefix(ctx, prefix, showSoftDeleted)
+}
+
+// GetContexts returns the names of all schema contexts.
+func (s *Service) GetContexts(ctx context.Context) ([]string, error) {
+	return s.registryClient.GetContexts(ctx)
+}
+
 // GetSchemaTypes returns supported types (AVRO, PROTOBUF, JSON)
 func (s *Service) GetSchemaTypes(ctx context.Context) ([]string, error) {
 	return s.registryClient.GetSchemaTypes(ctx)
@@ -229,8 +383,56 @@ func (s *Service) GetSubjectVersions(ctx context.Context, subject string, showSo
 }
 
 // GetSchemaBySubject returns the schema for the specified version of this subject.
+// If following subject aliases is enabled, subjects that don't exist are resolved via their alias.
 func (s *Service) GetSchemaBySubject(ctx context.Context, subject, version string, showSoftDeleted bool) (*SchemaVersionedResponse, error) {
-	return s.registryClient.GetSchemaBySubject(ctx, subject, version, showSoftDeleted)
+	return s.getSchemaBySubject(ctx, subject, version, showSoftDeleted)
+}
+
+func (s *Service) getSchemaBySubject(ctx context.Context, subject, version string, showSoftDeleted bool) (*SchemaVersionedResponse, error) {
+	schema, err := s.registryClient.GetSchemaBySubject(ctx, subject, version, showSoftDeleted)
+	if err == nil || !s.cfg.FollowSubjectAliases {
+		return schema, err
+	}
+
+	if !errors.Is(err, ErrSubjectNotFound) {
+		return nil, err
+	}
+	resolved, resolveErr := s.ResolveSubjectAlias(ctx, subject)
+	if resolveErr != nil || resolved == subject {
+		return nil, err
+	}
+	return s.registryClient.GetSchemaBySubject(ctx, resolved, version, showSoftDeleted)
+}
+
+// maxSubjectAliasDepth is the maximum number of aliases that are followed when resolving a subject.
+const maxSubjectAliasDepth = 10
+
+// ResolveSubjectAlias follows the alias of the given subject, and the aliases of the aliased
+// subjects, until a subject without an alias is found. The subject is returned as is if it
+// is not an alias.
+func (s *Service) ResolveSubjectAlias(ctx context.Context, subject string) (string, error) {
+	visited := map[string]struct{}{subject: {}}
+	for i := 0; i < maxSubjectAliasDepth; i++ {
+		config, err := s.registryClient.GetSubjectConfig(ctx, subject)
+		if err != nil {
+			return "", fmt.Errorf("failed to get config of subject %q: %w", subject, err)
+		}
+		if config.Alias == "" {
+			return subject, nil
+		}
+		if _, exists := visited[config.Alias]; exists {
+			return "", fmt.Errorf("subject alias cycle detected at subject %q", config.Alias)
+		}
+		visited[config.Alias] = struct{}{}
+		subject = config.Alias
+	}
+	return "", fmt.Errorf("subject aliases are nested deeper than %d levels", maxSubjectAliasDepth)
+}
+
+// GetServerInfo returns the version of the schema registry. The version is empty if the
+// registry does not expose it.
+func (s *Service) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+	return s.registryClient.GetServerInfo(ctx)
 }
 
 // GetMode returns the current mode for Schema Registry at a global level.
@@ -238,6 +440,16 @@ func (s *Service) GetMode(ctx context.Context) (*ModeResponse, error) {
 	return s.registryClient.GetMode(ctx)
 }
 
+// SetMode sets the mode for Schema Registry at a global level.
+func (s *Service) SetMode(ctx context.Context, mode string) (*ModeResponse, error) {
+	return s.registryClient.SetMode(ctx, mode)
+}
+
+// SetSubjectMode sets the mode for a given subject, overriding the global mode.
+func (s *Service) SetSubjectMode(ctx context.Context, subject string, mode string) (*ModeResponse, error) {
+	return s.registryClient.SetSubjectMode(ctx, subject, mode)
+}
+
 // GetConfig gets global compatibility level.
 func (s *Service) GetConfig(ctx context.Context) (*ConfigResponse, error) {
 	return s.registryClient.GetConfig(ctx)
@@ -253,11 +465,22 @@ func (s *Service) GetSubjectConfig(ctx context.Context, subject string) (*Config
 	return s.registryClient.GetSubjectConfig(ctx, subject)
 }
 
+// GetEffectiveSubjectConfig gets the config for a given subject along with the compatibility
+// level that applies to it, which may be inherited from the global config.
+func (s *Service) GetEffectiveSubjectConfig(ctx context.Context, subject string) (*SubjectConfigResponse, error) {
+	return s.registryClient.GetEffectiveSubjectConfig(ctx, subject)
+}
+
 // PutSubjectConfig puts compatibility level for a given subject.
 func (s *Service) PutSubjectConfig(ctx context.Context, subject string, compatLevel CompatibilityLevel) (*PutConfigResponse, error) {
 	return s.registryClient.PutSubjectConfig(ctx, subject, compatLevel)
 }
 
+// PutSubjectAlias sets the alias of a given subject. An empty alias removes the alias.
+func (s *Service) PutSubjectAlias(ctx context.Context, subject, alias string) (*PutConfigResponse, error) {
+	return s.registryClient.PutSubjectAlias(ctx, subject, alias)
+}
+
 // DeleteSubjectConfig puts compatibility level for a given subject.
 func (s *Service) DeleteSubjectConfig(ctx context.Context, subject string) (*ConfigResponse, error) {
 	return s.registryClient.DeleteSubjectConfig(ctx, subject)
@@ -279,6 +502,11 @@ func (s *Service) GetSchemaReferences(ctx context.Context, subject, version stri
 	return s.registryClient.GetSchemaReferences(ctx, subject, version)
 }
 
+// GetSchemaReferencedBy returns the IDs of all schemas that reference the given subject-version.
+func (s *Service) GetSchemaReferencedBy(ctx context.Context, subject, version string) ([]int, error) {
+	return s.registryClient.GetSchemaReferencedBy(ctx, subject, version)
+}
+
 // CheckCompatibility checks if a schema is compatible with the given version
 // that exists. You can use 'latest' to check compatibility with the latest version.
 func (s *Service) CheckCompatibility(ctx context.Context, subject string, version string, schema Schema) (*CheckCompatibilityResponse, error) {
@@ -287,28 +515,43 @@ func (s *Service) CheckCompatibility(ctx context.Context, subject string, versio
 
 // ParseAvroSchemaWithReferences parses an avro schema that potentially has references
 // to other schemas. References will be resolved by requesting and parsing them
-// recursively. If any of the referenced schemas can't be fetched or parsed an
-// error will be returned.
+// recursively into the given cache of named types. If any of the referenced schemas
+// can't be fetched or parsed an error will be returned.
 func (s *Service) ParseAvroSchemaWithReferences(ctx context.Context, schema *SchemaResponse, schemaCache *avro.SchemaCache) (avro.Schema, error) {
-	if len(schema.References) == 0 {
-		return avro.Parse(schema.Schema)
-	}
+	return s.parseAvroSchemaWithReferences(ctx, schema, schemaCache, make(map[string]struct{}))
+}
 
+// parseAvroSchemaWithReferences parses the schema after all of its references that are not
+// in the set of parsed references yet. Subject versions that are referenced multiple times
+// are therefore fetched and parsed only once.
+func (s *Service) parseAvroSchemaWithReferences(
+	ctx context.Context,
+	schema *SchemaResponse,
+	schemaCache *avro.SchemaCache,
+	parsedReferences map[string]struct{},
+) (avro.Schema, error) {
 	// Fetch and parse all schema references recursively. All schemas that have
-	// been parsed successfully will be cached by the avro library.
+	// been parsed successfully will be cached in the schema cache.
 	for _, reference := range schema.References {
-		schemaRef, err := s.GetSchemaBySubjectAndVersion(ctx, reference.Subject, strconv.Itoa(reference.Version))
+		referenceKey := reference.Subject + "/" + reference.VersionString()
+		if _, parsed := parsedReferences[referenceKey]; parsed {
+			continue
+		}
+		parsedReferences[referenceKey] = struct{}{}
+
+		schemaRef, err := s.getReferencedSchema(ctx, reference)
 		if err != nil {
 			return nil, err
 		}
 
-		if _, err := s.ParseAvroSchemaWithReferences(
+		if _, err := s.parseAvroSchemaWithReferences(
 			ctx,
 			&SchemaResponse{
 				Schema:     schemaRef.Schema,
 				References: schemaRef.References,
 			},
 			schemaCache,
+			parsedReferences,
 		); err != nil {
 			return nil, fmt.Errorf(
 				"failed to parse schema reference (subject: %q, version %q): %w",
@@ -318,7 +561,7 @@ func (s *Service) ParseAvroSchemaWithReferences(ctx context.Context, schema *Sch
 	}
 
 	// Parse the main schema in the end after solving all references
-	return avro.Parse(schema.Schema)
+	return avro.ParseWithCache(schema.Schema, "", schemaCache)
 }
 
 // ValidateAvroSchema tries to parse the given avro schema with the avro library.
@@ -334,11 +577,11 @@ func (s *Service) ValidateAvroSchema(ctx context.Context, sch Schema) error {
 // ValidateJSONSchema validates a JSON schema for syntax issues.
 func (s *Service) ValidateJSONSchema(ctx context.Context, name string, sch Schema, schemaCompiler *jsonschema.Compiler) error {
 	if schemaCompiler == nil {
-		schemaCompiler = jsonschema.NewCompiler()
+		schemaCompiler = newJSONSchemaCompiler()
 	}
 
 	for _, ref := range sch.References {
-		schemaRefRes, err := s.GetSchemaBySubjectAndVersion(ctx, ref.Subject, strconv.Itoa(ref.Version))
+		schemaRefRes, err := s.getReferencedSchema(ctx, ref)
 		if err != nil {
 			return fmt.Errorf("failed to retrieve reference %q: %w", ref.Subject, err)
 		}
@@ -352,6 +595,10 @@ func (s *Service) ValidateJSONSchema(ctx context.Context, name string, sch Schem
 		}
 	}
 
+	if _, err := jsonSchemaDialect(sch.Schema); err != nil {
+		return fmt.Errorf("failed to validate schema %q: %w", name, err)
+	}
+
 	// Prevent a panic by the schema compiler by checking the name before AddResource
 	if strings.IndexByte(name, '#') != -1 {
 		return fmt.Errorf("hashtags are not allowed as part of the schema name")
@@ -361,7 +608,11 @@ func (s *Service) ValidateJSONSchema(ctx context.Context, name string, sch Schem
 		return fmt.Errorf("failed to add resource for %q", name)
 	}
 
-	_, err = jsonschema.CompileString(name, sch.Schema)
+	compiler := newJSONSchemaCompiler()
+	if err := compiler.AddResource(name, strings.NewReader(sch.Schema)); err != nil {
+		return fmt.Errorf("failed to add resource for %q", name)
+	}
+	_, err = compiler.Compile(name)
 	if err != nil {
 		return fmt.Errorf("failed to validate schema %q: %w", name, err)
 	}
@@ -375,7 +626,7 @@ func (s *Service) ValidateProtobufSchema(ctx context.Context, name string, sch S
 	schemasByPath[name] = sch.Schema
 
 	for _, ref := range sch.References {
-		schemaRefRes, err := s.GetSchemaBySubjectAndVersion(ctx, ref.Subject, strconv.Itoa(ref.Version))
+		schemaRefRes, err := s.getReferencedSchema(ctx, ref)
 		if err != nil {
 			return fmt.Errorf("failed to retrieve reference %q: %w", ref.Subject, err)
 		}
@@ -395,8 +646,13 @@ func (s *Service) ValidateProtobufSchema(ctx context.Context, name string, sch S
 // by a given <subject, version> tuple.
 func (s *Service) GetSchemaBySubjectAndVersion(ctx context.Context, subject string, version string) (*SchemaVersionedResponse, error) {
 	cacheKey := subject + "v" + version
-	cachedSchema, err, _ := s.schemaBySubjectVersion.Get(cacheKey, func() (*SchemaVersionedResponse, error) {
-		schema, err := s.registryClient.GetSchemaBySubject(ctx, subject, version, false)
+	cachedSchema, err := cachedGet(ctx, s.schemaBySubjectVersion, cacheKey, func() (*SchemaVersionedResponse, error) {
+		release, err := s.acquireFetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := s.getSchemaBySubject(ctx, subject, version, false)
+		release()
 		if err != nil {
 			return nil, fmt.Errorf("get schema by subject failed: %w", err)
 		}
@@ -407,9 +663,45 @@ func (s *Service) GetSchemaBySubjectAndVersion(ctx context.Context, subject stri
 	return cachedSchema, err
 }
 
-// CreateSchema registers a new schema for the given subject.
-func (s *Service) CreateSchema(ctx context.Context, subject string, schema Schema) (*CreateSchemaResponse, error) {
-	return s.registryClient.CreateSchema(ctx, subject, schema)
+// getReferencedSchema returns the schema of the given reference. References to the latest
+// version are resolved to the currently latest version, which is cached like pinned versions.
+func (s *Service) getReferencedSchema(ctx context.Context, ref SchemaReference) (*SchemaVersionedResponse, error) {
+	if ref.IsLatest() {
+		s.warnUnpinnedReference(ref)
+	}
+	return s.GetSchemaBySubjectAndVersion(ctx, ref.Subject, ref.VersionString())
+}
+
+// warnUnpinnedReference logs that a reference points to the latest version of a subject.
+// Such references are fragile, because registering a new version of the referenced subject
+// changes the referencing schema.
+func (s *Service) warnUnpinnedReference(ref SchemaReference) {
+	s.logger.Warn("schema reference is not pinned to a version, the latest version will be used",
+		zap.String("reference_name", ref.Name),
+		zap.String("reference_subject", ref.Subject))
+}
+
+// CreateSchema registers a new schema for the given subject, in its normalized form if
+// normalize is set.
+func (s *Service) CreateSchema(ctx context.Context, subject string, schema Schema, normalize bool) (*CreateSchemaResponse, error) {
+	return s.registryClient.CreateSchema(ctx, subject, schema, normalize)
+}
+
+// ImportSchema registers a schema with the given ID and version. The subject must be in
+// IMPORT mode.
+func (s *Service) ImportSchema(ctx context.Context, subject string, schema Schema, id, version int) (*CreateSchemaResponse, error) {
+	return s.registryClient.ImportSchema(ctx, subject, schema, id, version)
+}
+
+// LookupSchema returns the version of the subject that the given schema is registered as.
+func (s *Service) LookupSchema(ctx context.Context, subject string, schema Schema) (*SchemaVersionedResponse, error) {
+	return s.registryClient.LookupSchema(ctx, subject, schema)
+}
+
+// RegisterVersions registers the given schemas as subsequent versions of the subject. See
+// Client.RegisterVersions for details.
+func (s *Service) RegisterVersions(ctx context.Context, subject string, schemas []Schema) ([]int, error) {
+	return s.registryClient.RegisterVersions(ctx, subject, schemas)
 }
 
 // GetSchemaUsagesByID returns all usages of a given schema ID. A single schema
@@ -417,3 +709,29 @@ func (s *Service) CreateSchema(ctx context.Context, subject string, schema Schem
 func (s *Service) GetSchemaUsagesByID(ctx context.Context, schemaID int) ([]SubjectVersion, error) {
 	return s.registryClient.GetSchemaUsagesByID(ctx, schemaID)
 }
+
+// GetSchemaVersionsByID returns the subject versions that the schema with the given ID is
+// registered under.
+func (s *Service) GetSchemaVersionsByID(ctx context.Context, id uint32) ([]SubjectVersion, error) {
+	return s.registryClient.GetSchemaVersionsByID(ctx, id)
+}
+
+// GetSubjectsByID returns the names of the subjects that the schema with the given ID is
+// registered under.
+func (s *Service) GetSubjectsByID(ctx context.Context, id uint32, showSoftDeleted bool) ([]string, error) {
+	return s.registryClient.GetSubjectsByID(ctx, id, showSoftDeleted)
+}
+
+// GetCachedSchemaUsagesByID returns all usages of a given schema ID like GetSchemaUsagesByID,
+// but caches the usages, so that it can be used while decoding records.
+func (s *Service) GetCachedSchemaUsagesByID(ctx context.Context, schemaID uint32) ([]SubjectVersion, error) {
+	usages, err := cachedGet(ctx, s.subjectVersionsByID, schemaID, func() ([]SubjectVersion, error) {
+		release, err := s.acquireFetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return s.registryClient.GetSchemaUsagesByID(ctx, int(schemaID))
+	})
+	return usages, err
+}
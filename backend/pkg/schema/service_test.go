@@ -11,12 +11,27 @@ package schema
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/hamba/avro/v2"
 	"github.com/jarcoal/httpmock"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
 
 	"github.com/redpanda-data/console/backend/pkg/config"
 )
@@ -27,7 +42,7 @@ func TestService_GetAvroSchemaByID(t *testing.T) {
 	s, _ := NewService(config.Schema{
 		Enabled: true,
 		URLs:    []string{baseURL},
-	}, logger)
+	}, logger, nil, "")
 
 	httpClient := (*s.registryClient.client).GetClient()
 	httpmock.ActivateNonDefault(httpClient)
@@ -66,3 +81,1009 @@ func TestService_GetAvroSchemaByID(t *testing.T) {
 	assert.NoError(t, err, "expected no error when fetching avro schema by id")
 	assert.Equal(t, actual.String(), expectedSchemaString)
 }
+
+func TestService_ContextCredentialsBypassCaches(t *testing.T) {
+	const orderSchema = `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if username, _, _ := r.BasicAuth(); username != "alice" && username != "console" {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(RestError{ErrorCode: 40301, Message: "User is denied operation"})
+			return
+		}
+		switch r.URL.Path {
+		case "/schemas/ids/1":
+			_ = json.NewEncoder(w).Encode(map[string]string{"schema": orderSchema})
+		case "/subjects/orders-value/versions/1":
+			_ = json.NewEncoder(w).Encode(SchemaVersionedResponse{Subject: "orders-value", SchemaID: 1, Version: 1, Schema: orderSchema})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Schema{Enabled: true, URLs: []string{srv.URL}, Username: "console", Password: "secret"}
+	cfg.SetDefaults()
+	svc, err := NewService(cfg, zap.NewNop(), nil, "")
+	require.NoError(t, err)
+
+	alice := ContextWithCredentials(context.Background(), Credentials{Username: "alice", Password: "pw"})
+	bob := ContextWithCredentials(context.Background(), Credentials{Username: "bob", Password: "pw"})
+
+	// Alice and Console itself fetch the schemas, so that they are cached if shared
+	_, err = svc.GetAvroSchemaByID(alice, 1)
+	require.NoError(t, err)
+	_, err = svc.GetSchemaBySubjectAndVersion(alice, "orders-value", "1")
+	require.NoError(t, err)
+	_, err = svc.GetAvroSchemaByID(context.Background(), 1)
+	require.NoError(t, err)
+	_, err = svc.GetSchemaBySubjectAndVersion(context.Background(), "orders-value", "1")
+	require.NoError(t, err)
+
+	_, err = svc.GetAvroSchemaByID(bob, 1)
+	assert.ErrorContains(t, err, "User is denied operation")
+	_, err = svc.GetSchemaTypeByID(bob, 1)
+	assert.ErrorContains(t, err, "User is denied operation")
+	_, err = svc.GetSchemaBySubjectAndVersion(bob, "orders-value", "1")
+	assert.ErrorContains(t, err, "User is denied operation")
+	_, err = svc.registryClient.GetSchemaByID(bob, 1)
+	assert.ErrorContains(t, err, "User is denied operation")
+
+	// Requests without per-request credentials are still served from the caches
+	before := requests.Load()
+	_, err = svc.GetAvroSchemaByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, before, requests.Load())
+}
+
+func TestService_GetAvroSchemaByIDWithNestedReferences(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	schemaResponder := func(schemaStr string, references ...map[string]interface{}) httpmock.Responder {
+		return httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"schema":     schemaStr,
+			"references": references,
+			"schemaType": "AVRO",
+		})
+	}
+	addressRef := map[string]interface{}{"name": "com.shop.address", "subject": "address-value", "version": 1}
+	customerRef := map[string]interface{}{"name": "com.shop.customer", "subject": "customer-value", "version": 1}
+
+	// The order references the address both directly and through the customer
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1", schemaResponder(`{
+		"type": "record", "name": "order", "namespace": "com.shop",
+		"fields": [{"name": "customer", "type": "customer"}, {"name": "shipping", "type": "address"}]
+	}`, customerRef, addressRef))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/customer-value/versions/1", schemaResponder(`{
+		"type": "record", "name": "customer", "namespace": "com.shop",
+		"fields": [{"name": "email", "type": "string"}, {"name": "billing", "type": "address"}]
+	}`, addressRef))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/address-value/versions/1", schemaResponder(`{
+		"type": "record", "name": "address", "namespace": "com.shop",
+		"fields": [{"name": "city", "type": "string"}]
+	}`))
+	// A schema that uses the address type without referencing it
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/2", schemaResponder(`{
+		"type": "record", "name": "shipment", "namespace": "com.shop",
+		"fields": [{"name": "to", "type": "address"}]
+	}`))
+
+	order, err := s.GetAvroSchemaByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+baseURL+"/subjects/address-value/versions/1"],
+		"references that are used multiple times are fetched once")
+
+	payload, err := avro.Marshal(order, map[string]interface{}{
+		"customer": map[string]interface{}{"email": "jane@example.com", "billing": map[string]interface{}{"city": "Berlin"}},
+		"shipping": map[string]interface{}{"city": "Hamburg"},
+	})
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, avro.Unmarshal(order, payload, &decoded))
+	assert.Equal(t, map[string]interface{}{"city": "Hamburg"}, decoded["shipping"])
+
+	// Named types of one schema don't leak into other schemas
+	_, err = s.GetAvroSchemaByID(context.Background(), 2)
+	assert.ErrorContains(t, err, "unknown type")
+}
+
+func TestService_SchemaIDIndex(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/subjects",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"orders-value", "payments-value"}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []int{1, 2}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/payments-value/versions",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []int{1}))
+
+	schemaVersion := func(subject string, version, id int) httpmock.Responder {
+		return httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"subject": subject,
+			"version": version,
+			"id":      id,
+			"schema":  `"string"`,
+		})
+	}
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/1", schemaVersion("orders-value", 1, 1))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/2", schemaVersion("orders-value", 2, 2))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/latest", schemaVersion("orders-value", 2, 2))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/payments-value/versions/1", schemaVersion("payments-value", 1, 1))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/payments-value/versions/latest", schemaVersion("payments-value", 1, 1))
+
+	ctx := context.Background()
+
+	usages, err := s.LookupSubjectVersionsBySchemaID(ctx, 1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []SubjectVersion{{Subject: "orders-value", Version: 1}, {Subject: "payments-value", Version: 1}}, usages)
+
+	usages, err = s.LookupSubjectVersionsBySchemaID(ctx, 1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []SubjectVersion{{Subject: "payments-value", Version: 1}}, usages)
+
+	// Second lookup must be served from the cache, refresh must fetch again
+	callsBefore := httpmock.GetTotalCallCount()
+	usages, err = s.LookupSubjectVersionsBySchemaID(ctx, 2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []SubjectVersion{{Subject: "orders-value", Version: 2}}, usages)
+	assert.Equal(t, callsBefore, httpmock.GetTotalCallCount())
+
+	index, err := s.RefreshSchemaIDIndex(ctx, false)
+	assert.NoError(t, err)
+	assert.Len(t, index.SubjectVersionsByID, 2)
+	assert.Greater(t, httpmock.GetTotalCallCount(), callsBefore)
+}
+
+func TestService_ReferenceGraph(t *testing.T) {
+	// a-value and b-value reference each other, which has been imported from another registry
+	schemas := []SchemaVersionedResponse{
+		{Subject: "customer-value", Version: 1, SchemaID: 1},
+		{Subject: "address-value", Version: 1, SchemaID: 2},
+		{Subject: "address-value", Version: 2, SchemaID: 3},
+		{Subject: "orders-value", Version: 1, SchemaID: 4, References: []SchemaReference{
+			{Name: "customer.proto", Subject: "customer-value", Version: 1},
+			{Name: "address.proto", Subject: "address-value", Version: LatestVersion},
+		}},
+		{Subject: "a-value", Version: 1, SchemaID: 5, References: []SchemaReference{{Name: "b", Subject: "b-value", Version: 1}}},
+		{Subject: "b-value", Version: 1, SchemaID: 6, References: []SchemaReference{{Name: "a", Subject: "a-value", Version: 1}}},
+	}
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		var res interface{}
+		switch {
+		case len(parts) == 1 && parts[0] == "subjects":
+			subjects := []string{}
+			for _, schema := range schemas {
+				if !slices.Contains(subjects, schema.Subject) {
+					subjects = append(subjects, schema.Subject)
+				}
+			}
+			res = subjects
+		case len(parts) == 3 && parts[0] == "subjects":
+			versions := []int{}
+			for _, schema := range schemas {
+				if schema.Subject == parts[1] {
+					versions = append(versions, schema.Version)
+				}
+			}
+			res = versions
+		case len(parts) >= 4 && parts[0] == "subjects":
+			var found *SchemaVersionedResponse
+			for i, schema := range schemas {
+				if schema.Subject == parts[1] && (strconv.Itoa(schema.Version) == parts[3] || parts[3] == "latest") {
+					found = &schemas[i]
+				}
+			}
+			if found == nil {
+				w.WriteHeader(http.StatusNotFound)
+				res = RestError{ErrorCode: CodeSubjectNotFound, Message: "not found"}
+				break
+			}
+			res = found
+			if len(parts) == 5 && parts[4] == "referencedby" {
+				ids := []int{}
+				for _, schema := range schemas {
+					for _, ref := range schema.References {
+						if ref.Subject == found.Subject && (ref.Version == found.Version || ref.IsLatest()) {
+							ids = append(ids, schema.SchemaID)
+						}
+					}
+				}
+				res = ids
+			}
+		case len(parts) == 4 && parts[0] == "schemas" && parts[3] == "versions":
+			versions := []SubjectVersion{}
+			for _, schema := range schemas {
+				if strconv.Itoa(schema.SchemaID) == parts[2] {
+					versions = append(versions, SubjectVersion{Subject: schema.Subject, Version: schema.Version})
+				}
+			}
+			res = versions
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			res = RestError{ErrorCode: http.StatusNotFound, Message: "not found"}
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	s, err := NewService(config.Schema{Enabled: true, URLs: []string{srv.URL}}, zap.NewNop(), nil, "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("subject graph", func(t *testing.T) {
+		graph, err := s.GetReferenceGraph(ctx, "customer-value")
+		require.NoError(t, err)
+
+		nodes := make([]SubjectVersion, 0, len(graph.Nodes))
+		for _, node := range graph.Nodes {
+			nodes = append(nodes, SubjectVersion{Subject: node.Subject, Version: node.Version})
+		}
+		// Unrelated subjects and address version 1, which is not referenced, are not part of it
+		assert.Equal(t, []SubjectVersion{
+			{Subject: "address-value", Version: 2},
+			{Subject: "customer-value", Version: 1},
+			{Subject: "orders-value", Version: 1},
+		}, nodes)
+		assert.Equal(t, []ReferenceGraphEdge{
+			{From: SubjectVersion{Subject: "orders-value", Version: 1}, To: SubjectVersion{Subject: "address-value", Version: 2}, Name: "address.proto"},
+			{From: SubjectVersion{Subject: "orders-value", Version: 1}, To: SubjectVersion{Subject: "customer-value", Version: 1}, Name: "customer.proto"},
+		}, graph.Edges)
+		assert.Empty(t, graph.Cycles)
+
+		// The graph is cached until it's refreshed or the cache is invalidated
+		requestsBefore := requests.Load()
+		_, err = s.GetReferenceGraph(ctx, "customer-value")
+		require.NoError(t, err)
+		assert.Equal(t, requestsBefore, requests.Load())
+
+		s.InvalidateSubject("customer-value")
+		_, err = s.GetReferenceGraph(ctx, "customer-value")
+		require.NoError(t, err)
+		assert.Greater(t, requests.Load(), requestsBefore)
+	})
+
+	t.Run("registry graph with cycle", func(t *testing.T) {
+		graph, err := s.GetReferenceGraph(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, graph.Nodes, len(schemas))
+		assert.Len(t, graph.Edges, 4)
+		assert.Equal(t, [][]SubjectVersion{
+			{{Subject: "a-value", Version: 1}, {Subject: "b-value", Version: 1}},
+		}, graph.Cycles)
+	})
+}
+
+func TestService_GetAvroEncryptedFieldsByID(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	// ssn is tagged inline, address.street is tagged via metadata
+	schemaStr := `{"type": "record", "name": "customer", "namespace": "com.shop", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "ssn", "type": "string", "confluent:tags": ["PII"]},
+		{"name": "address", "type": {"type": "record", "name": "address", "fields": [{"name": "street", "type": "string"}]}}
+	]}`
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/2000",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"schema":     schemaStr,
+			"schemaType": "AVRO",
+			"metadata": map[string]interface{}{
+				"tags": map[string][]string{"com.shop.address.street": {"PII"}},
+			},
+			"ruleSet": map[string]interface{}{
+				"domainRules": []map[string]interface{}{{
+					"name":   "encryptPII",
+					"kind":   "TRANSFORM",
+					"mode":   "WRITEREAD",
+					"type":   "ENCRYPT",
+					"tags":   []string{"PII"},
+					"params": map[string]string{"encrypt.kek.name": "kek"},
+				}},
+			},
+		}))
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/2001",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"schema":     schemaStr,
+			"schemaType": "AVRO",
+		}))
+
+	fields, err := s.GetAvroEncryptedFieldsByID(context.Background(), 2000)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"com.shop.customer.ssn":   {},
+		"com.shop.address.street": {},
+	}, fields)
+
+	fields, err = s.GetAvroEncryptedFieldsByID(context.Background(), 2001)
+	assert.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestService_RegisterSchemaFiles(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	fsys := fstest.MapFS{
+		"avro/a_customer.avsc": {Data: []byte(`{"type": "record", "name": "customer", "namespace": "com.shop", "fields": [
+			{"name": "address", "type": "address"}
+		]}`)},
+		"avro/address.avsc": {Data: []byte(`{"type": "record", "name": "address", "namespace": "com.shop", "fields": [
+			{"name": "street", "type": "string"}
+		]}`)},
+		"proto/order.proto": {Data: []byte(`syntax = "proto3";
+import "proto/money.proto";
+import "google/protobuf/timestamp.proto";
+message Order { Money total = 1; }`)},
+		"proto/money.proto": {Data: []byte(`syntax = "proto3";
+message Money { int64 units = 1; }`)},
+		"json/invoice.json": {Data: []byte(`{"type": "object", "properties": {"total": {"$ref": "amount.json#/definitions/amount"}}}`)},
+		"json/amount.json":  {Data: []byte(`{"definitions": {"amount": {"type": "number"}}}`)},
+		"json/broken.json":  {Data: []byte(`{"type": "object"}`)},
+		"README.md":         {Data: []byte(`not a schema`)},
+	}
+
+	registeredBySubject := make(map[string]Schema)
+	httpmock.RegisterRegexpResponder("POST", regexp.MustCompile(`/subjects/(.+)/versions`),
+		func(req *http.Request) (*http.Response, error) {
+			subject := httpmock.MustGetSubmatch(req, 1)
+			if subject == "broken" {
+				return httpmock.NewJsonResponse(http.StatusUnprocessableEntity, map[string]interface{}{
+					"error_code": 42201,
+					"message":    "Invalid schema",
+				})
+			}
+			var sch Schema
+			if err := json.NewDecoder(req.Body).Decode(&sch); err != nil {
+				return nil, err
+			}
+			registeredBySubject[subject] = sch
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]int{"id": len(registeredBySubject)})
+		})
+	// The version of the registered schema must be looked up, the latest version may differ
+	httpmock.RegisterRegexpResponder("POST", regexp.MustCompile(`/subjects/([^/]+)$`),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"subject": httpmock.MustGetSubmatch(req, 1),
+				"version": 1,
+			})
+		})
+	httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(`/subjects/(.+)/versions/latest`),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"subject": httpmock.MustGetSubmatch(req, 1),
+				"version": 7,
+			})
+		})
+
+	results, err := s.RegisterSchemaFiles(context.Background(), fsys)
+	require.NoError(t, err)
+
+	files := make([]string, len(results))
+	for i, res := range results {
+		files[i] = res.File
+	}
+	// Referenced files must be registered first
+	assert.Equal(t, []string{
+		"avro/address.avsc", "json/amount.json", "json/broken.json", "json/invoice.json",
+		"proto/money.proto", "proto/order.proto", "avro/a_customer.avsc",
+	}, files)
+
+	for _, res := range results {
+		if res.Subject == "broken" {
+			assert.Contains(t, res.Error, "Invalid schema")
+			continue
+		}
+		assert.Empty(t, res.Error, res.File)
+		assert.Equal(t, 1, res.Version)
+	}
+
+	assert.Equal(t, TypeAvro, registeredBySubject["a_customer"].Type)
+	assert.Equal(t, []SchemaReference{{Name: "com.shop.address", Subject: "address", Version: 1}}, registeredBySubject["a_customer"].References)
+	assert.Equal(t, TypeProtobuf, registeredBySubject["order"].Type)
+	assert.Equal(t, []SchemaReference{{Name: "proto/money.proto", Subject: "money", Version: 1}}, registeredBySubject["order"].References)
+	assert.Equal(t, TypeJSON, registeredBySubject["invoice"].Type)
+	assert.Equal(t, []SchemaReference{{Name: "amount.json", Subject: "amount", Version: 1}}, registeredBySubject["invoice"].References)
+}
+
+func TestService_RegisterSchemaFilesCycle(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, logger, nil, "")
+
+	fsys := fstest.MapFS{
+		"a.proto": {Data: []byte("syntax = \"proto3\";\nimport \"b.proto\";")},
+		"b.proto": {Data: []byte("syntax = \"proto3\";\nimport \"a.proto\";")},
+	}
+	results, err := s.RegisterSchemaFiles(context.Background(), fsys)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, res := range results {
+		assert.Equal(t, "schema file is part of a reference cycle", res.Error)
+	}
+}
+
+func TestService_ValidateJSONBySchemaID(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	parentSchema := `{"type": "object", "properties": {"address": {"$ref": "address.json"}}, "required": ["address"]}`
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"schema":     parentSchema,
+			"schemaType": "JSON",
+			"references": []map[string]interface{}{{"name": "address.json", "subject": "address", "version": 1}},
+		}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/address/versions/1",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"subject":    "address",
+			"version":    1,
+			"id":         2,
+			"schemaType": "JSON",
+			"schema":     `{"type": "object", "properties": {"city": {"type": "string"}, "zip": {"type": "integer"}}, "required": ["city"]}`,
+		}))
+
+	err := s.ValidateJSONBySchemaID(context.Background(), 1, []byte(`{"address": {"city": "Berlin", "zip": 10115}}`))
+	assert.NoError(t, err)
+
+	err = s.ValidateJSONBySchemaID(context.Background(), 1, []byte(`{"address": {"zip": "10115"}}`))
+	var validationErr *JSONSchemaValidationError
+	require.ErrorAs(t, err, &validationErr)
+	paths := make([]string, 0, len(validationErr.Violations))
+	for _, violation := range validationErr.Violations {
+		paths = append(paths, violation.Path)
+	}
+	assert.ElementsMatch(t, []string{"/address", "/address/zip"}, paths)
+
+	t.Run("reference cycle", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/3",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"schema":     `{"$ref": "a.json"}`,
+				"schemaType": "JSON",
+				"references": []map[string]interface{}{{"name": "a.json", "subject": "a", "version": 1}},
+			}))
+		httpmock.RegisterResponder("GET", baseURL+"/subjects/a/versions/1",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"subject": "a", "version": 1, "id": 4, "schemaType": "JSON", "schema": `{"$ref": "b.json"}`,
+				"references": []map[string]interface{}{{"name": "b.json", "subject": "b", "version": 1}},
+			}))
+		httpmock.RegisterResponder("GET", baseURL+"/subjects/b/versions/1",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"subject": "b", "version": 1, "id": 5, "schemaType": "JSON", "schema": `{"$ref": "a.json"}`,
+				"references": []map[string]interface{}{{"name": "a.json", "subject": "a", "version": 1}},
+			}))
+
+		err := s.ValidateJSONBySchemaID(context.Background(), 3, []byte(`{}`))
+		assert.ErrorContains(t, err, "reference cycle detected: a/1 -> b/1 -> a/1")
+	})
+}
+
+func TestService_ValidateJSONBySchemaIDDialects(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	schemas := map[int]string{
+		// Tuples are declared via items and additionalItems up to draft 2019-09
+		10: `{"$schema": "http://json-schema.org/draft-07/schema#", "type": "array",
+			"items": [{"type": "integer"}, {"type": "string"}], "additionalItems": false}`,
+		// Tuples are declared via prefixItems and items since draft 2020-12
+		11: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "array",
+			"prefixItems": [{"type": "integer"}, {"type": "string"}], "items": false}`,
+		// Schemas without $schema are treated as draft-07
+		12: `{"type": "array", "items": [{"type": "integer"}, {"type": "string"}], "additionalItems": false}`,
+	}
+	for id, schema := range schemas {
+		httpmock.RegisterResponder("GET", fmt.Sprintf("%s/schemas/ids/%d", baseURL, id),
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"schema":     schema,
+				"schemaType": "JSON",
+			}))
+	}
+
+	for id := range schemas {
+		t.Run(strconv.Itoa(id), func(t *testing.T) {
+			err := s.ValidateJSONBySchemaID(context.Background(), uint32(id), []byte(`[1, "a"]`))
+			assert.NoError(t, err)
+
+			err = s.ValidateJSONBySchemaID(context.Background(), uint32(id), []byte(`["a", 1]`))
+			var validationErr *JSONSchemaValidationError
+			assert.ErrorAs(t, err, &validationErr)
+
+			err = s.ValidateJSONBySchemaID(context.Background(), uint32(id), []byte(`[1, "a", true]`))
+			assert.ErrorAs(t, err, &validationErr)
+		})
+	}
+
+	t.Run("unsupported dialect", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/13",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"schema":     `{"$schema": "https://json-schema.org/draft-03/schema#", "type": "array"}`,
+				"schemaType": "JSON",
+			}))
+
+		err := s.ValidateJSONBySchemaID(context.Background(), 13, []byte(`[]`))
+		assert.ErrorContains(t, err, `unsupported JSON schema dialect "https://json-schema.org/draft-03/schema#"`)
+	})
+}
+
+func TestJSONSchemaDialect(t *testing.T) {
+	tests := []struct {
+		schema   string
+		expected *jsonschema.Draft
+	}{
+		{schema: `{"type": "object"}`, expected: jsonschema.Draft7},
+		{schema: `true`, expected: jsonschema.Draft7},
+		{schema: `{"$schema": "http://json-schema.org/draft-04/schema#"}`, expected: jsonschema.Draft4},
+		{schema: `{"$schema": "http://json-schema.org/draft-07/schema"}`, expected: jsonschema.Draft7},
+		{schema: `{"$schema": "https://json-schema.org/draft/2019-09/schema"}`, expected: jsonschema.Draft2019},
+		{schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema#"}`, expected: jsonschema.Draft2020},
+	}
+	for _, test := range tests {
+		draft, err := jsonSchemaDialect(test.schema)
+		require.NoError(t, err, test.schema)
+		assert.Equal(t, test.expected, draft, test.schema)
+	}
+
+	_, err := jsonSchemaDialect(`{"$schema": 7}`)
+	assert.Error(t, err)
+}
+
+func TestService_FollowSubjectAliases(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled:              true,
+		URLs:                 []string{baseURL},
+		FollowSubjectAliases: true,
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	subjectNotFound := httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{
+		"error_code": CodeSubjectNotFound,
+		"message":    "Subject not found.",
+	})
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/latest", subjectNotFound)
+	httpmock.RegisterResponder("GET", baseURL+"/config/orders-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"alias": "orders-v2-value"}))
+	httpmock.RegisterResponder("GET", baseURL+"/config/orders-v2-value",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"compatibilityLevel": "BACKWARD"}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-v2-value/versions/latest",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"subject": "orders-v2-value",
+			"version": 3,
+			"id":      7,
+			"schema":  `{"type": "string"}`,
+		}))
+
+	resolved, err := s.ResolveSubjectAlias(context.Background(), "orders-value")
+	require.NoError(t, err)
+	assert.Equal(t, "orders-v2-value", resolved)
+
+	schema, err := s.GetSchemaBySubject(context.Background(), "orders-value", "latest", false)
+	require.NoError(t, err)
+	assert.Equal(t, "orders-v2-value", schema.Subject)
+	assert.Equal(t, 7, schema.SchemaID)
+
+	t.Run("alias cycle", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/config/a",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"alias": "b"}))
+		httpmock.RegisterResponder("GET", baseURL+"/config/b",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{"alias": "a"}))
+
+		_, err := s.ResolveSubjectAlias(context.Background(), "a")
+		assert.ErrorContains(t, err, "cycle")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s.cfg.FollowSubjectAliases = false
+		defer func() { s.cfg.FollowSubjectAliases = true }()
+
+		_, err := s.GetSchemaBySubject(context.Background(), "orders-value", "latest", false)
+		assert.Error(t, err)
+	})
+}
+
+func TestNormalizeSchema(t *testing.T) {
+	a, err := NormalizeSchema(TypeProtobuf, "syntax = \"proto3\";\n\n// An order\nmessage Order {\n  string id = 1; /* unique */\n}\n")
+	require.NoError(t, err)
+	b, err := NormalizeSchema(TypeProtobuf, `syntax="proto3"; message Order { string id=1; }`)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	a, err = NormalizeSchema(TypeJSON, `{"type": "object", "properties": {"id": {"type": "string"}}}`)
+	require.NoError(t, err)
+	b, err = NormalizeSchema(TypeJSON, `{"properties":{"id":{"type":"string"}},"type":"object"}`)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	a, err = NormalizeSchema(TypeAvro, `{"type": "record", "name": "Order", "fields": [{"name": "qty", "type": "int", "default": 1}]}`)
+	require.NoError(t, err)
+	b, err = NormalizeSchema(TypeAvro, `{"fields":[{"default":1,"name":"qty","type":"int"}],"name":"Order","type":"record"}`)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	// Defaults are stripped from the canonical form, but changing them is a drift
+	b, err = NormalizeSchema(TypeAvro, `{"type": "record", "name": "Order", "fields": [{"name": "qty", "type": "int", "default": 2}]}`)
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+}
+
+func TestService_CompareSchemas(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/subjects",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"customers-value", "orders-value", "payments-value"}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/latest",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"subject": "orders-value",
+			"version": 2,
+			"id":      1,
+			"schema":  `{"type":"record","name":"order","fields":[{"name":"id","type":"string"}]}`,
+		}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/customers-value/versions/latest",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+			"subject":    "customers-value",
+			"version":    1,
+			"id":         2,
+			"schemaType": "JSON",
+			"schema":     `{"type": "object", "properties": {"id": {"type": "string"}}}`,
+		}))
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/shipments-value/versions/latest",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{
+			"error_code": CodeSubjectNotFound,
+			"message":    "Subject not found.",
+		}))
+
+	drifts, err := s.CompareSchemas(context.Background(), map[string]string{
+		// Same schema, but formatted differently
+		"orders-value": `{
+			"name": "order",
+			"type": "record",
+			"fields": [{"name": "id", "type": {"type": "string"}}]
+		}`,
+		"customers-value": `{"type": "object", "properties": {"id": {"type": "integer"}}}`,
+		"shipments-value": `{"type": "string"}`,
+	})
+	require.NoError(t, err)
+	require.Len(t, drifts, 4)
+
+	assert.Equal(t, "customers-value", drifts[0].Subject)
+	assert.Equal(t, SchemaDriftDifferent, drifts[0].Status)
+	assert.Contains(t, drifts[0].Diff, `+{"type": "object", "properties": {"id": {"type": "integer"}}}`)
+
+	assert.Equal(t, SchemaDrift{Subject: "orders-value", Status: SchemaDriftIdentical, RegisteredVersion: 2}, drifts[1])
+	assert.Equal(t, SchemaDrift{Subject: "payments-value", Status: SchemaDriftExtraInRegistry}, drifts[2])
+	assert.Equal(t, SchemaDrift{Subject: "shipments-value", Status: SchemaDriftMissingInRegistry}, drifts[3])
+}
+
+func TestService_MaxConcurrentFetches(t *testing.T) {
+	const maxConcurrentFetches = 3
+
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": `{"type": "string"}`})
+	}))
+	defer srv.Close()
+
+	s, err := NewService(config.Schema{
+		Enabled:              true,
+		URLs:                 []string{srv.URL},
+		MaxConcurrentFetches: maxConcurrentFetches,
+	}, zap.NewNop(), nil, "")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(schemaID uint32) {
+			defer wg.Done()
+			_, err := s.GetAvroSchemaByID(context.Background(), schemaID)
+			assert.NoError(t, err)
+		}(uint32(i))
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(maxConcurrentFetches))
+	assert.Greater(t, maxInFlight.Load(), int32(1))
+}
+
+func TestSubjectNameStrategy_SubjectName(t *testing.T) {
+	tests := []struct {
+		strategy   SubjectNameStrategy
+		isKey      bool
+		recordName string
+		expected   string
+		errMsg     string
+	}{
+		{strategy: TopicNameStrategy, expected: "orders-value"},
+		{strategy: TopicNameStrategy, isKey: true, expected: "orders-key"},
+		{strategy: RecordNameStrategy, recordName: "shop.Order", expected: "shop.Order"},
+		{strategy: RecordNameStrategy, errMsg: "record name is required for the RecordName strategy"},
+		{strategy: TopicRecordNameStrategy, recordName: "shop.Order", expected: "orders-shop.Order"},
+		{strategy: TopicRecordNameStrategy, isKey: true, recordName: "shop.OrderKey", expected: "orders-shop.OrderKey"},
+		{strategy: "", errMsg: "subject name strategy is required"},
+		{strategy: "Custom", errMsg: `unknown subject name strategy "Custom"`},
+	}
+	for _, test := range tests {
+		actual, err := test.strategy.SubjectName("orders", test.isKey, test.recordName)
+		if test.errMsg != "" {
+			assert.EqualError(t, err, test.errMsg)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, actual)
+	}
+}
+
+func TestService_LatestReference(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, zap.NewNop(), nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	// The reference version is returned as "latest" by some registries and as -1 by others
+	for _, version := range []interface{}{"latest", -1} {
+		httpmock.Reset()
+		httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"schema": `{"type":"record","name":"Order","namespace":"shop","fields":[{"name":"customer","type":"shop.Customer"}]}`,
+				"references": []map[string]interface{}{
+					{"name": "shop.Customer", "subject": "customer", "version": version},
+				},
+			}))
+		httpmock.RegisterResponder("GET", baseURL+"/subjects/customer/versions/latest",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"subject": "customer",
+				"version": 3,
+				"id":      2,
+				"schema":  `{"type":"record","name":"Customer","namespace":"shop","fields":[{"name":"name","type":"string"}]}`,
+			}))
+
+		s.avroSchemaByID.Delete(1)
+		schemaRes, err := s.registryClient.GetSchemaByID(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, schemaRes.References, 1)
+		assert.True(t, schemaRes.References[0].IsLatest())
+		assert.Equal(t, "latest", schemaRes.References[0].VersionString())
+
+		schema, err := s.GetAvroSchemaByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Contains(t, schema.String(), "shop.Customer")
+	}
+
+	// Unresolvable latest references are reported as such rather than by their sentinel version
+	err := s.addReferences(SchemaVersionedResponse{
+		References: []SchemaReference{{Name: "customer.proto", Subject: "customer", Version: -1}},
+	}, map[string]map[int]SchemaVersionedResponse{"customer": {}}, map[string]string{})
+	assert.EqualError(t, err, "failed to resolve reference. Reference with subject 'customer', version 'latest' does not exist")
+
+	var pinned SchemaReference
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"a","subject":"a","version":2}`), &pinned))
+	assert.Equal(t, SchemaReference{Name: "a", Subject: "a", Version: 2}, pinned)
+	assert.Equal(t, "2", pinned.VersionString())
+}
+
+func TestService_InvalidateCache(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled:           true,
+		URLs:              []string{baseURL},
+		SchemaIDCacheSize: 10,
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	for _, id := range []int{1, 2} {
+		httpmock.RegisterResponder("GET", fmt.Sprintf("%s/schemas/ids/%d", baseURL, id),
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"schema":     `{"type": "string"}`,
+				"schemaType": "JSON",
+			}))
+	}
+	for _, subject := range []string{"orders", "ordersv"} {
+		httpmock.RegisterResponder("GET", fmt.Sprintf("%s/subjects/%s/versions/1", baseURL, subject),
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"subject": subject, "version": 1, "id": 1, "schemaType": "JSON", "schema": `{"type": "string"}`,
+			}))
+	}
+
+	// fetchAll accesses all schemas and returns the number of requests per schema
+	fetchAll := func() []int {
+		for _, id := range []uint32{1, 2} {
+			_, err := s.GetSchemaTypeByID(context.Background(), id)
+			require.NoError(t, err)
+		}
+		for _, subject := range []string{"orders", "ordersv"} {
+			_, err := s.GetSchemaBySubjectAndVersion(context.Background(), subject, "1")
+			require.NoError(t, err)
+		}
+		calls := httpmock.GetCallCountInfo()
+		return []int{
+			calls["GET "+baseURL+"/schemas/ids/1"],
+			calls["GET "+baseURL+"/schemas/ids/2"],
+			calls["GET "+baseURL+"/subjects/orders/versions/1"],
+			calls["GET "+baseURL+"/subjects/ordersv/versions/1"],
+		}
+	}
+
+	assert.Equal(t, []int{1, 1, 1, 1}, fetchAll())
+	assert.Equal(t, []int{1, 1, 1, 1}, fetchAll(), "cached schemas must not be fetched again")
+
+	s.InvalidateSchemaID(2)
+	assert.Equal(t, []int{1, 2, 1, 1}, fetchAll())
+
+	s.InvalidateSubject("orders")
+	assert.Equal(t, []int{1, 2, 2, 1}, fetchAll())
+
+	s.InvalidateCache()
+	assert.Equal(t, []int{2, 3, 3, 2}, fetchAll())
+}
+
+func TestService_ScanSubjects(t *testing.T) {
+	baseURL := testSchemaRegistryBaseURL
+	logger, _ := zap.NewProduction()
+	s, _ := NewService(config.Schema{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	}, logger, nil, "")
+
+	httpClient := (*s.registryClient.client).GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/subjects",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, []string{"orders-value", "payments-value", "customers-value", "events-value"}))
+	latest := map[string]map[string]interface{}{
+		"orders-value": {
+			"schema": `{"type": "record", "name": "order", "fields": [{"name": "id", "type": "string"}]}`,
+		},
+		// References a schema that has been deleted
+		"payments-value": {
+			"schema":     `{"type": "record", "name": "payment", "fields": [{"name": "currency", "type": "currency"}]}`,
+			"references": []map[string]interface{}{{"name": "currency", "subject": "currency", "version": 1}},
+		},
+		// Is syntactically broken
+		"customers-value": {
+			"schema": `{"type": "record", "name": "customer", "fields": [{"name": "id"`,
+		},
+		"events-value": {
+			"schemaType": "JSON",
+			"schema":     `{"type": "object", "properties": {"id": {"type": "string"}}}`,
+		},
+	}
+	for subject, res := range latest {
+		res["subject"] = subject
+		res["version"] = 3
+		httpmock.RegisterResponder("GET", baseURL+"/subjects/"+subject+"/versions/latest",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, res))
+	}
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/currency/versions/1",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{"error_code": 40401, "message": "Subject 'currency' not found."}))
+
+	assert.Nil(t, s.LastSubjectScan())
+
+	result, err := s.ScanSubjects(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, result, s.LastSubjectScan())
+	require.Len(t, result.Subjects, 4)
+
+	loaded := make(map[string]bool)
+	for _, status := range result.Subjects {
+		loaded[status.Subject] = status.Loaded
+	}
+	assert.Equal(t, map[string]bool{
+		"customers-value": false,
+		"events-value":    true,
+		"orders-value":    true,
+		"payments-value":  false,
+	}, loaded)
+
+	broken := result.BrokenSubjects()
+	require.Len(t, broken, 2)
+	assert.Equal(t, "customers-value", broken[0].Subject)
+	assert.Contains(t, broken[0].Error, "failed to parse schema version 3")
+	assert.Equal(t, "payments-value", broken[1].Subject)
+	assert.Contains(t, broken[1].Error, "currency")
+
+	t.Run("subjects can't be listed", func(t *testing.T) {
+		httpmock.RegisterResponder("GET", baseURL+"/subjects",
+			httpmock.NewErrorResponder(errors.New("connection refused")))
+		_, err := s.ScanSubjects(context.Background())
+		assert.Error(t, err)
+		assert.Same(t, result, s.LastSubjectScan(), "the last successful scan is kept")
+	})
+}
+
+func TestAvroFingerprint(t *testing.T) {
+	// Test vectors of the Avro specification's reference implementation
+	assert.Equal(t, uint64(0x7275d51a3f395c8f), AvroFingerprint(avro.MustParse(`"int"`)))
+}
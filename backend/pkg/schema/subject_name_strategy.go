@@ -0,0 +1,54 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SubjectNameStrategy determines the subject under which the schema of a record is
+// registered, as done by the serializers when they register or look up schemas.
+type SubjectNameStrategy string
+
+const (
+	// TopicNameStrategy uses the topic name with a "-key" or "-value" suffix as subject.
+	TopicNameStrategy SubjectNameStrategy = "TopicName"
+	// RecordNameStrategy uses the fully qualified record name as subject.
+	RecordNameStrategy SubjectNameStrategy = "RecordName"
+	// TopicRecordNameStrategy uses the topic name and the fully qualified record name,
+	// separated by a hyphen, as subject.
+	TopicRecordNameStrategy SubjectNameStrategy = "TopicRecordName"
+)
+
+// SubjectName returns the subject of the given topic's key or value schema according to
+// the strategy. The record name is the fully qualified name of the record (Avro) or message
+// (Protobuf) type and is required for all strategies but TopicNameStrategy.
+func (s SubjectNameStrategy) SubjectName(topicName string, isKey bool, recordName string) (string, error) {
+	switch s {
+	case TopicNameStrategy:
+		if isKey {
+			return topicName + "-key", nil
+		}
+		return topicName + "-value", nil
+	case RecordNameStrategy, TopicRecordNameStrategy:
+		if recordName == "" {
+			return "", fmt.Errorf("record name is required for the %s strategy", s)
+		}
+		if s == RecordNameStrategy {
+			return recordName, nil
+		}
+		return topicName + "-" + recordName, nil
+	case "":
+		return "", errors.New("subject name strategy is required")
+	default:
+		return "", fmt.Errorf("unknown subject name strategy %q", s)
+	}
+}
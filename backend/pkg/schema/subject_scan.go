@@ -0,0 +1,105 @@
+// Copyright 2023 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SubjectLoadStatus is the result of loading the latest schema of a single subject.
+type SubjectLoadStatus struct {
+	Subject string `json:"subject"`
+	Loaded  bool   `json:"loaded"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SubjectScanResult is the load status of all subjects at the time of the scan.
+type SubjectScanResult struct {
+	ScannedAt time.Time           `json:"scannedAt"`
+	Subjects  []SubjectLoadStatus `json:"subjects"`
+}
+
+// BrokenSubjects returns the status of all subjects that could not be loaded.
+func (r *SubjectScanResult) BrokenSubjects() []SubjectLoadStatus {
+	if r == nil {
+		return nil
+	}
+	var broken []SubjectLoadStatus
+	for _, status := range r.Subjects {
+		if !status.Loaded {
+			broken = append(broken, status)
+		}
+	}
+	return broken
+}
+
+// ScanSubjects loads and parses the latest schema of each subject, including its references,
+// so that broken subjects (e.g. referencing a deleted schema) are reported individually rather
+// than failing all at once. An error is only returned if the subjects can't be listed. The
+// result is kept and can be retrieved via LastSubjectScan.
+func (s *Service) ScanSubjects(ctx context.Context) (*SubjectScanResult, error) {
+	subjectsRes, err := s.GetSubjects(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subjects: %w", err)
+	}
+	subjects := append([]string(nil), subjectsRes.Subjects...)
+	sort.Strings(subjects)
+
+	result := &SubjectScanResult{
+		ScannedAt: time.Now(),
+		Subjects:  make([]SubjectLoadStatus, 0, len(subjects)),
+	}
+	for _, subject := range subjects {
+		status := SubjectLoadStatus{Subject: subject, Loaded: true}
+		if err := s.loadLatestSchema(ctx, subject); err != nil {
+			status.Loaded = false
+			status.Error = err.Error()
+		}
+		result.Subjects = append(result.Subjects, status)
+	}
+
+	s.subjectScanMutex.Lock()
+	s.subjectScan = result
+	s.subjectScanMutex.Unlock()
+	return result, nil
+}
+
+// LastSubjectScan returns the result of the last subject scan, or nil if no scan completed yet.
+func (s *Service) LastSubjectScan() *SubjectScanResult {
+	s.subjectScanMutex.RLock()
+	defer s.subjectScanMutex.RUnlock()
+	return s.subjectScan
+}
+
+// loadLatestSchema fetches the latest schema of the subject and parses it along with its
+// references.
+func (s *Service) loadLatestSchema(ctx context.Context, subject string) error {
+	schemaRes, err := s.getSchemaBySubject(ctx, subject, "latest", false)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest schema: %w", err)
+	}
+
+	sch := Schema{Schema: schemaRes.Schema, Type: schemaRes.Type, References: schemaRes.References}
+	switch sch.Type {
+	case TypeJSON:
+		err = s.ValidateJSONSchema(ctx, subject, sch, nil)
+	case TypeProtobuf:
+		err = s.ValidateProtobufSchema(ctx, subject, sch)
+	default:
+		err = s.ValidateAvroSchema(ctx, sch)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse schema version %d: %w", schemaRes.Version, err)
+	}
+	return nil
+}